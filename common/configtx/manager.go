@@ -99,6 +99,18 @@ func validateChainID(chainID string) error {
 	return nil
 }
 
+// ValidateChainID checks that the supplied chain ID complies with the
+// restrictions placed on the names of channels
+func ValidateChainID(chainID string) error {
+	return validateChainID(chainID)
+}
+
+// ComputeSequence returns the sequence of the supplied config, that is the
+// highest version of its values
+func ComputeSequence(configGroup *cb.ConfigGroup) uint64 {
+	return computeSequence(configGroup)
+}
+
 func NewManagerImpl(configEnv *cb.ConfigEnvelope, initializer api.Initializer, callOnUpdate []func(api.Manager)) (api.Manager, error) {
 	if configEnv == nil {
 		return nil, fmt.Errorf("Nil config envelope")
@@ -17,6 +17,8 @@ limitations under the License.
 package txvalidator
 
 import (
+	"bytes"
+	"compress/gzip"
 	"testing"
 
 	"github.com/golang/protobuf/proto"
@@ -26,6 +28,7 @@ import (
 	"github.com/hyperledger/fabric/core/ledger/util"
 	mocktxvalidator "github.com/hyperledger/fabric/core/mocks/txvalidator"
 	"github.com/hyperledger/fabric/core/mocks/validator"
+	mspmgmt "github.com/hyperledger/fabric/msp/mgmt"
 	"github.com/hyperledger/fabric/protos/common"
 	"github.com/hyperledger/fabric/protos/utils"
 	"github.com/spf13/viper"
@@ -124,3 +127,45 @@ func TestNewTxValidator_DuplicateTransactions(t *testing.T) {
 
 	assert.True(t, txsfltr.IsSet(0))
 }
+
+func TestTxValidator_CompressedPayload(t *testing.T) {
+	viper.Set("peer.fileSystemPath", "/tmp/fabric/txvalidatortest")
+	ledgermgmt.InitializeTestEnv()
+	defer ledgermgmt.CleanupTestEnv()
+	ledger, _ := ledgermgmt.CreateLedger("TestLedger")
+	defer ledger.Close()
+
+	validator := &txValidator{&mocktxvalidator.Support{LedgerVal: ledger}, &validator.MockVsccValidator{}}
+
+	tx, _, err := testutil.ConstructTransaction(t, []byte("simulation_result"), true)
+	assert.NoError(t, err)
+
+	// Compress the payload of another transaction and sign it again
+	other, _, err := testutil.ConstructTransaction(t, []byte("simulation_result"), true)
+	assert.NoError(t, err)
+
+	var buf bytes.Buffer
+	w := gzip.NewWriter(&buf)
+	_, err = w.Write(other.Payload)
+	assert.NoError(t, err)
+	assert.NoError(t, w.Close())
+
+	signer, err := mspmgmt.GetLocalMSP().GetDefaultSigningIdentity()
+	assert.NoError(t, err)
+	sig, err := signer.Sign(buf.Bytes())
+	assert.NoError(t, err)
+	compressed := &common.Envelope{Payload: buf.Bytes(), Signature: sig}
+
+	block := common.NewBlock(1, []byte("previous_hash"))
+	block.Data.Data = [][]byte{utils.MarshalOrPanic(tx), utils.MarshalOrPanic(compressed)}
+	block.Header.DataHash = block.Data.Hash()
+
+	// The envelopes are committed as they are, so the compressed one
+	// should be invalidated rather than passed on to the ledger
+	validator.Validate(block)
+
+	txsfltr := util.NewFilterBitArrayFromBytes(block.Metadata.Metadata[common.BlockMetadataIndex_TRANSACTIONS_FILTER])
+
+	assert.False(t, txsfltr.IsSet(0))
+	assert.True(t, txsfltr.IsSet(1))
+}
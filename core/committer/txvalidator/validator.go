@@ -97,6 +97,7 @@ func (v *txValidator) Validate(block *common.Block) error {
 	logger.Debug("START Block Validation")
 	defer logger.Debug("END Block Validation")
 	txsfltr := ledgerUtil.NewFilterBitArray(uint(len(block.Data.Data)))
+	genesis := block.Header != nil && block.Header.Number == 0
 	for tIdx, d := range block.Data.Data {
 		// Start by marking transaction as invalid, before
 		// doing any validation checks.
@@ -113,7 +114,13 @@ func (v *txValidator) Validate(block *common.Block) error {
 				logger.Debug("Validating transaction peer.ValidateTransaction()")
 				var payload *common.Payload
 				var err error
-				if payload, err = validation.ValidateTransaction(env); err != nil {
+				if genesis {
+					// the genesis config may not be signed
+					payload, err = validation.ValidateGenesisTransaction(env)
+				} else {
+					payload, err = validation.ValidateTransaction(env)
+				}
+				if err != nil {
 					logger.Errorf("Invalid transaction with index %d, error %s", tIdx, err)
 					continue
 				}
@@ -0,0 +1,62 @@
+/*
+Copyright IBM Corp. 2017 All Rights Reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+		 http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package validation
+
+import (
+	"github.com/hyperledger/fabric/protos/common"
+	"golang.org/x/net/context"
+)
+
+// aadKey is the key of the associated data in contexts
+type aadKey struct{}
+
+// withAAD returns a context holding the additional associated data that
+// the signature of the envelope covers
+func withAAD(ctx context.Context, aad []byte) context.Context {
+	return context.WithValue(ctx, aadKey{}, aad)
+}
+
+// getSignedPayload returns the message the signature of an envelope with
+// the given payload is computed over: the payload followed by the
+// additional associated data held by the context, if any
+func getSignedPayload(ctx context.Context, payload []byte) []byte {
+	aad, _ := ctx.Value(aadKey{}).([]byte)
+	if len(aad) == 0 {
+		return payload
+	}
+
+	msg := make([]byte, 0, len(payload)+len(aad))
+	return append(append(msg, payload...), aad...)
+}
+
+// ValidateTransactionWithAAD checks that the transaction envelope is
+// properly formed with the default validator, see
+// Validator.ValidateTransactionWithAAD
+func ValidateTransactionWithAAD(e *common.Envelope, aad []byte) (*common.Payload, error) {
+	return defaultValidator.ValidateTransactionWithAAD(e, aad)
+}
+
+// ValidateTransactionWithAAD checks that the transaction envelope is
+// properly formed, its signature covering the payload followed by aad, the
+// additional associated data binding the transaction to its context, e.g.
+// the ID of a session negotiated at the transport layer, so that it cannot
+// be relayed out of that context. The co-signatures of co-signed envelopes
+// cover the payload followed by aad and by their signature header. With an
+// empty aad, the transaction is validated as by ValidateTransaction
+func (v *Validator) ValidateTransactionWithAAD(e *common.Envelope, aad []byte) (*common.Payload, error) {
+	return v.ValidateTransactionWithContext(withAAD(context.Background(), aad), e)
+}
@@ -0,0 +1,66 @@
+/*
+Copyright IBM Corp. 2017 All Rights Reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+		 http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package validation
+
+import (
+	"testing"
+
+	"github.com/hyperledger/fabric/protos/common"
+)
+
+func TestValidateTransactionWithAAD(t *testing.T) {
+	tx, err := getTransaction([]byte("simulation_result"))
+	if err != nil {
+		t.Fatalf("getTransaction failed, err %s", err)
+	}
+
+	// the transaction is bound to a session
+	aad := []byte("session-1234")
+	boundTx := &common.Envelope{Payload: tx.Payload}
+	boundTx.Signature, err = signer.Sign(append(append([]byte{}, tx.Payload...), aad...))
+	if err != nil {
+		t.Fatalf("Sign failed, err %s", err)
+	}
+
+	tests := []struct {
+		name  string
+		tx    *common.Envelope
+		aad   []byte
+		valid bool
+	}{
+		{"Matching", boundTx, aad, true},
+		{"Mismatched", boundTx, []byte("session-5678"), false},
+		{"Missing", boundTx, nil, false},
+		{"NoAAD", tx, nil, true},
+		{"Unexpected", tx, aad, false},
+	}
+
+	for _, test := range tests {
+		_, err := ValidateTransactionWithAAD(test.tx, test.aad)
+		if test.valid && err != nil {
+			t.Fatalf("%s: ValidateTransactionWithAAD failed, err %s", test.name, err)
+		}
+		if !test.valid && err == nil {
+			t.Fatalf("%s: ValidateTransactionWithAAD should have failed", test.name)
+		}
+	}
+
+	// the associated data is not part of the default validation
+	if _, err = ValidateTransaction(boundTx); err == nil {
+		t.Fatalf("ValidateTransaction should have failed for a transaction bound to a session")
+	}
+}
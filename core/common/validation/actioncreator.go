@@ -0,0 +1,134 @@
+/*
+Copyright IBM Corp. 2017 All Rights Reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+		 http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package validation
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+
+	"github.com/hyperledger/fabric/msp"
+	"golang.org/x/net/context"
+)
+
+// ErrActionCreatorMismatch is returned when the creator of an action is not
+// the same identity as the creator of the transaction
+var ErrActionCreatorMismatch = errors.New("The creator of the action does not match the creator of the transaction")
+
+// actionCreatorChecker checks the creators of the actions of a transaction
+// against the creator of the transaction, whose identity is deserialized at
+// most once for all the actions, if not already supplied by the validation
+// of the signature of the transaction. The actions of single-signer
+// transactions, created by the creator of the transaction with the same
+// encoding, are accepted without deserializing anything
+type actionCreatorChecker struct {
+	v          *Validator
+	chainID    string
+	txCreator  []byte
+	txIdentity msp.Identity
+	mspObj     msp.IdentityDeserializer
+
+	// txFingerprint is the fingerprint of the x.509 creator of the
+	// transaction, once computed
+	txFingerprint string
+}
+
+// newActionCreatorChecker returns a checker of the action creators of a
+// transaction of the given chain; txIdentity, if not nil, is the identity
+// txCreator deserializes to
+func (v *Validator) newActionCreatorChecker(chainID string, txCreator []byte, txIdentity msp.Identity) *actionCreatorChecker {
+	return &actionCreatorChecker{v: v, chainID: chainID, txCreator: txCreator, txIdentity: txIdentity}
+}
+
+// check ensures that the creator in the signature header of an action is
+// the creator of the transaction, possibly encoded differently: both must
+// deserialize to identities of the same MSP with the same certificate, see
+// identityFingerprint. Identities without certificate cannot be told apart
+// publicly, so only their MSP is compared
+func (c *actionCreatorChecker) check(ctx context.Context, actionCreator []byte) error {
+	if bytes.Equal(c.txCreator, actionCreator) {
+		return nil
+	}
+
+	if c.mspObj == nil {
+		mspObj, err := c.v.getIdentityDeserializer(ctx, c.chainID)
+		if err != nil {
+			return err
+		}
+		c.mspObj = mspObj
+	}
+
+	if c.txIdentity == nil {
+		txCreator, err := c.v.resolveCreator(c.chainID, c.txCreator)
+		if err != nil {
+			return err
+		}
+
+		txIdentity, err := c.mspObj.DeserializeIdentity(txCreator)
+		if err != nil {
+			return fmt.Errorf("Failed to deserialize creator identity, err %s", err)
+		}
+		c.txIdentity = txIdentity
+		countStats(ctx, func(stats *ValidationStats) { stats.IdentitiesDeserialized++ })
+	}
+	txIdentity := c.txIdentity
+
+	actionCreator, err := c.v.resolveCreator(c.chainID, actionCreator)
+	if err != nil {
+		return err
+	}
+
+	actionIdentity, err := c.mspObj.DeserializeIdentity(actionCreator)
+	if err != nil {
+		return fmt.Errorf("Failed to deserialize the creator identity of the action, err %s", err)
+	}
+	countStats(ctx, func(stats *ValidationStats) { stats.IdentitiesDeserialized++ })
+
+	if txIdentity.GetMSPIdentifier() != actionIdentity.GetMSPIdentifier() {
+		putilsLogger.Errorf("checkActionCreator error: action created by MSP %s, transaction by MSP %s", actionIdentity.GetMSPIdentifier(), txIdentity.GetMSPIdentifier())
+		return ErrActionCreatorMismatch
+	}
+
+	txType, actionType := getIdentityType(txIdentity), getIdentityType(actionIdentity)
+	if txType != actionType {
+		putilsLogger.Errorf("checkActionCreator error: action created by a %s identity, transaction by a %s one", actionType, txType)
+		return ErrActionCreatorMismatch
+	}
+
+	if txType != X509Identity {
+		return nil
+	}
+
+	if c.txFingerprint == "" {
+		c.txFingerprint, err = identityFingerprint(txIdentity)
+		if err != nil {
+			return err
+		}
+	}
+
+	actionFingerprint, err := identityFingerprint(actionIdentity)
+	if err != nil {
+		return err
+	}
+
+	if actionFingerprint != c.txFingerprint {
+		putilsLogger.Errorf("checkActionCreator error: action created by another identity of MSP %s than the transaction", actionIdentity.GetMSPIdentifier())
+		return ErrActionCreatorMismatch
+	}
+
+	return nil
+}
@@ -0,0 +1,176 @@
+/*
+Copyright IBM Corp. 2017 All Rights Reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+		 http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package validation
+
+import (
+	"testing"
+
+	"github.com/golang/protobuf/proto"
+	"github.com/hyperledger/fabric/common/util"
+	"github.com/hyperledger/fabric/msp"
+	mspmgmt "github.com/hyperledger/fabric/msp/mgmt"
+	"github.com/hyperledger/fabric/protos/common"
+	"github.com/hyperledger/fabric/protos/peer"
+	"github.com/hyperledger/fabric/protos/utils"
+	"golang.org/x/net/context"
+)
+
+// fallbackDeserializer returns the identities registered for the serialized
+// bytes, deferring the others to the MSP manager's deserializer
+type fallbackDeserializer mockDeserializer
+
+func (d fallbackDeserializer) DeserializeIdentity(serializedIdentity []byte) (msp.Identity, error) {
+	if id, ok := d[string(serializedIdentity)]; ok {
+		return id, nil
+	}
+	return mspmgmt.GetIdentityDeserializer(util.GetTestChainID()).DeserializeIdentity(serializedIdentity)
+}
+
+// modifyActionCreator sets the creator of the action of the transaction,
+// updating the proposal hash accordingly
+func modifyActionCreator(t *testing.T, tx *common.Envelope, creator []byte) (*common.Envelope, error) {
+	payload, err := utils.GetPayload(tx)
+	if err != nil {
+		t.Fatalf("GetPayload failed, err %s", err)
+	}
+
+	return modifyTransaction(tx, func(sHdr *common.SignatureHeader, cap *peer.ChaincodeActionPayload, prp *peer.ProposalResponsePayload) {
+		sHdr.Creator = creator
+
+		hdrBytes := utils.MarshalOrPanic(&common.Header{ChannelHeader: payload.Header.ChannelHeader, SignatureHeader: sHdr})
+		prp.ProposalHash, err = utils.GetProposalHash2(hdrBytes, cap.ChaincodeProposalPayload)
+		if err != nil {
+			t.Fatalf("GetProposalHash2 failed, err %s", err)
+		}
+	})
+}
+
+func TestActionCreator(t *testing.T) {
+	tx, err := getTransaction([]byte("simulation_result"))
+	if err != nil {
+		t.Fatalf("getTransaction failed, err %s", err)
+	}
+
+	signerIdentity, err := mspmgmt.GetIdentityDeserializer(util.GetTestChainID()).DeserializeIdentity(signerSerialized)
+	if err != nil {
+		t.Fatalf("DeserializeIdentity failed, err %s", err)
+	}
+
+	otherMSP := utils.MarshalOrPanic(&msp.SerializedIdentity{Mspid: "Org2", IdBytes: []byte("cert")})
+	otherIdentity := utils.MarshalOrPanic(&msp.SerializedIdentity{Mspid: "DEFAULT", IdBytes: []byte("other")})
+	reencoded := utils.MarshalOrPanic(&msp.SerializedIdentity{Mspid: "DEFAULT", IdBytes: []byte("reencoded")})
+
+	v := &Validator{
+		CheckActionCreators: true,
+		DeserializerProvider: &mockDeserializerProvider{fallbackDeserializer{
+			string(otherMSP):      &mockIdentity{mspID: "Org2", id: "cert", valid: true},
+			string(otherIdentity): &mockIdentity{mspID: "DEFAULT", id: "other", valid: true},
+			string(reencoded):     signerIdentity,
+		}},
+	}
+
+	tests := []struct {
+		name    string
+		creator []byte
+		valid   bool
+	}{
+		{"Same", signerSerialized, true},
+		{"SameIdentityReencoded", reencoded, true},
+		// empty creators are rejected by the checks on signature headers
+		{"Empty", nil, false},
+		{"OtherMSP", otherMSP, false},
+		{"OtherIdentity", otherIdentity, false},
+		{"OtherCertificateSameMSP", getSampleIdentity(t, "admincerts/admincert.pem", nil), false},
+		{"Undeserializable", []byte("garbage"), false},
+	}
+
+	for _, test := range tests {
+		mtx, err := modifyActionCreator(t, tx, test.creator)
+		if err != nil {
+			t.Fatalf("%s: modifyTransaction failed, err %s", test.name, err)
+		}
+
+		_, err = v.ValidateTransaction(mtx)
+		if test.valid && err != nil {
+			t.Fatalf("%s: ValidateTransaction failed, err %s", test.name, err)
+		}
+		if !test.valid && err == nil {
+			t.Fatalf("%s: ValidateTransaction should have failed", test.name)
+		}
+	}
+
+	// the check is configurable
+	mtx, err := modifyActionCreator(t, tx, otherMSP)
+	if err != nil {
+		t.Fatalf("modifyTransaction failed, err %s", err)
+	}
+
+	_, err = (&Validator{}).ValidateTransaction(mtx)
+	if err != nil {
+		t.Fatalf("ValidateTransaction failed with the check disabled, err %s", err)
+	}
+}
+
+// reencodedSigner returns the serialized signer with a trailing newline
+// after its certificate, which deserializes to the same identity
+func reencodedSigner(tb testing.TB) []byte {
+	sId := &msp.SerializedIdentity{}
+	err := proto.Unmarshal(signerSerialized, sId)
+	if err != nil {
+		tb.Fatalf("Unmarshal failed, err %s", err)
+	}
+	sId.IdBytes = append(sId.IdBytes, '\n')
+
+	return utils.MarshalOrPanic(sId)
+}
+
+func TestActionCreatorChecker(t *testing.T) {
+	chainID := util.GetTestChainID()
+	signerIdentity, err := mspmgmt.GetIdentityDeserializer(chainID).DeserializeIdentity(signerSerialized)
+	if err != nil {
+		t.Fatalf("DeserializeIdentity failed, err %s", err)
+	}
+
+	otherMSP := utils.MarshalOrPanic(&msp.SerializedIdentity{Mspid: "Org2", IdBytes: []byte("cert")})
+	otherIdentity := utils.MarshalOrPanic(&msp.SerializedIdentity{Mspid: "DEFAULT", IdBytes: []byte("other")})
+	v := &Validator{
+		DeserializerProvider: &mockDeserializerProvider{fallbackDeserializer{
+			string(otherMSP):      &mockIdentity{mspID: "Org2", id: "cert", valid: true},
+			string(otherIdentity): &mockIdentity{mspID: "DEFAULT", id: "other", valid: true},
+		}},
+	}
+
+	creators := [][]byte{signerSerialized, reencodedSigner(t), otherMSP, otherIdentity, []byte("garbage"), reencodedSigner(t)}
+
+	// the checker shared by the actions, reusing the verified identity of
+	// the creator, has the results of a new checker for each action
+	shared := v.newActionCreatorChecker(chainID, signerSerialized, signerIdentity)
+	for i, creator := range creators {
+		expected := v.newActionCreatorChecker(chainID, signerSerialized, nil).check(context.Background(), creator)
+		err := shared.check(context.Background(), creator)
+		if (err == nil) != (expected == nil) || (err != nil && err.Error() != expected.Error()) {
+			t.Fatalf("Creator %d: expected err %v, got %v", i, expected, err)
+		}
+	}
+
+	// the transaction of a single signer is checked without deserializing
+	// anything, even if its creator would not deserialize
+	err = (&Validator{}).newActionCreatorChecker("nosuchchain", []byte("creator"), nil).check(context.Background(), []byte("creator"))
+	if err != nil {
+		t.Fatalf("check failed for a single signer, err %s", err)
+	}
+}
@@ -0,0 +1,45 @@
+/*
+Copyright IBM Corp. 2017 All Rights Reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+		 http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package validation
+
+import (
+	"bytes"
+	"errors"
+
+	"github.com/hyperledger/fabric/protos/common"
+)
+
+// ErrActionNonceMismatch is returned for the single-action transactions
+// whose action has a different nonce than the transaction
+var ErrActionNonceMismatch = errors.New("The nonce of the action does not match that of the transaction")
+
+// checkActionNonce ensures that the only action of a transaction has the
+// same nonce as the transaction, both being taken from the proposal the
+// transaction was assembled from; the actions of the transactions with
+// several actions may come from different proposals and are not checked
+func checkActionNonce(hdr *common.Header, sHdr *common.SignatureHeader, actions int) error {
+	if actions != 1 {
+		return nil
+	}
+
+	if !bytes.Equal(sHdr.Nonce, hdr.SignatureHeader.Nonce) {
+		putilsLogger.Errorf("checkActionNonce error: action nonce %x, transaction [%s] nonce %x", sHdr.Nonce, hdr.ChannelHeader.TxId, hdr.SignatureHeader.Nonce)
+		return ErrActionNonceMismatch
+	}
+
+	return nil
+}
@@ -0,0 +1,66 @@
+/*
+Copyright IBM Corp. 2017 All Rights Reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+		 http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package validation
+
+import (
+	"testing"
+
+	"github.com/hyperledger/fabric/protos/common"
+	"github.com/hyperledger/fabric/protos/peer"
+	"github.com/hyperledger/fabric/protos/utils"
+)
+
+func TestActionNonce(t *testing.T) {
+	tx, err := getTransaction([]byte("simulation_result"))
+	if err != nil {
+		t.Fatalf("getTransaction failed, err %s", err)
+	}
+
+	mismatchedTx, err := modifyTransaction(tx, func(sHdr *common.SignatureHeader, cap *peer.ChaincodeActionPayload, prp *peer.ProposalResponsePayload) {
+		sHdr.Nonce = utils.CreateNonceOrPanic()
+	})
+	if err != nil {
+		t.Fatalf("modifyTransaction failed, err %s", err)
+	}
+
+	// the actions of multi-action transactions are not checked
+	multiTx := getCostTransaction(t, 2, 1)
+	multiTx.Signature, err = signer.Sign(multiTx.Payload)
+	if err != nil {
+		t.Fatalf("Sign failed, err %s", err)
+	}
+
+	tests := []struct {
+		name  string
+		tx    *common.Envelope
+		check bool
+		err   error
+	}{
+		{"Matching", tx, true, nil},
+		{"Mismatched", mismatchedTx, true, ErrActionNonceMismatch},
+		// caught later on, by the proposal hash
+		{"MismatchedNotChecked", mismatchedTx, false, ErrProposalHashMismatch},
+		{"MultipleActions", multiTx, true, nil},
+	}
+
+	for _, test := range tests {
+		_, err := (&Validator{CheckActionNonce: test.check}).ValidateTransaction(test.tx)
+		if err != test.err {
+			t.Fatalf("%s: expected err %v, got %v", test.name, test.err, err)
+		}
+	}
+}
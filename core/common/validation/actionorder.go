@@ -0,0 +1,86 @@
+/*
+Copyright IBM Corp. 2017 All Rights Reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+		 http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package validation
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+
+	"github.com/hyperledger/fabric/protos/common"
+	"golang.org/x/net/context"
+)
+
+// ErrActionsOutOfOrder is returned for the transactions with ordered actions
+// whose actions are not sorted by their ordering index
+var ErrActionsOutOfOrder = errors.New("The actions of the transaction are out of order")
+
+// ErrActionIndexGap is returned for the transactions with ordered actions
+// whose ordering indexes skip an index
+var ErrActionIndexGap = errors.New("The ordering indexes of the actions have gaps")
+
+// ErrDuplicateActionIndex is returned for the transactions with ordered
+// actions of which several have the same ordering index
+var ErrDuplicateActionIndex = errors.New("Several actions have the same ordering index")
+
+// ActionIndexLength is the length of the ordering index prefixed to the
+// nonces of the actions of the transactions with ordered actions, as a big
+// endian uint32; being part of the nonce, it is covered by the proposal
+// hash of the action
+const ActionIndexLength = 4
+
+// checkActionOrdering ensures that the actions of a transaction declaring
+// ordered actions in the OrderedActions field of its chaincode header
+// extension, whose nonces are given in the order of the actions, carry the
+// ordering indexes 0 to n-1 in that order, without gaps nor duplicates
+func checkActionOrdering(ctx context.Context, chdr *common.ChannelHeader, nonces [][]byte) error {
+	ext, err := getHeaderExtension(ctx, chdr)
+	if err != nil || !ext.OrderedActions {
+		return err
+	}
+
+	indexes := make([]uint32, len(nonces))
+	seen := make(map[uint32]struct{}, len(nonces))
+	for i, nonce := range nonces {
+		if len(nonce) < ActionIndexLength {
+			return fmt.Errorf("Nonce of action %d too short to carry an ordering index, got %d bytes", i, len(nonce))
+		}
+
+		indexes[i] = binary.BigEndian.Uint32(nonce[:ActionIndexLength])
+		if _, ok := seen[indexes[i]]; ok {
+			putilsLogger.Errorf("checkActionOrdering error: action %d of transaction [%s] has the ordering index %d of a previous action", i, chdr.TxId, indexes[i])
+			return ErrDuplicateActionIndex
+		}
+		seen[indexes[i]] = struct{}{}
+	}
+
+	for i, index := range indexes {
+		if index >= uint32(len(indexes)) {
+			putilsLogger.Errorf("checkActionOrdering error: action %d of transaction [%s] has ordering index %d, there are %d actions", i, chdr.TxId, index, len(indexes))
+			return ErrActionIndexGap
+		}
+	}
+
+	for i, index := range indexes {
+		if index != uint32(i) {
+			putilsLogger.Errorf("checkActionOrdering error: action %d of transaction [%s] has ordering index %d", i, chdr.TxId, index)
+			return ErrActionsOutOfOrder
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,114 @@
+/*
+Copyright IBM Corp. 2017 All Rights Reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+		 http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package validation
+
+import (
+	"encoding/binary"
+	"testing"
+
+	"github.com/hyperledger/fabric/common/util"
+	"github.com/hyperledger/fabric/protos/common"
+	"github.com/hyperledger/fabric/protos/peer"
+	"github.com/hyperledger/fabric/protos/utils"
+	"golang.org/x/net/context"
+)
+
+// getOrderedTransaction returns a signed transaction declaring ordered
+// actions if ordered is set, whose actions carry the given ordering indexes
+func getOrderedTransaction(t *testing.T, ordered bool, indexes ...uint32) *common.Envelope {
+	nonces := make([][]byte, len(indexes))
+	for i, index := range indexes {
+		nonces[i] = utils.CreateNonceOrPanic()
+		binary.BigEndian.PutUint32(nonces[i], index)
+	}
+
+	txID, err := utils.ComputeProposalTxID(nonces[0], signerSerialized)
+	if err != nil {
+		t.Fatalf("ComputeProposalTxID failed, err %s", err)
+	}
+
+	cis := &peer.ChaincodeInvocationSpec{
+		ChaincodeSpec: &peer.ChaincodeSpec{
+			ChaincodeId: &peer.ChaincodeID{Name: "foo"},
+			Type:        peer.ChaincodeSpec_GOLANG}}
+
+	prop, _, err := utils.CreateChaincodeProposalWithTxIDNonceAndTransient(txID, common.HeaderType_ENDORSER_TRANSACTION, util.GetTestChainID(), cis, nonces[0], signerSerialized, nil)
+	if err != nil {
+		t.Fatalf("CreateChaincodeProposalWithTxIDNonceAndTransient failed, err %s", err)
+	}
+
+	hdr, err := utils.GetHeader(prop.Header)
+	if err != nil {
+		t.Fatalf("GetHeader failed, err %s", err)
+	}
+	if ordered {
+		ext := utils.MarshalOrPanic(&peer.ChaincodeHeaderExtension{OrderedActions: true})
+		hdr.ChannelHeader.Extension = append(hdr.ChannelHeader.Extension, ext...)
+	}
+
+	// all the actions share the channel header of the transaction
+	var tx *common.Envelope
+	for _, nonce := range nonces {
+		hdr.SignatureHeader.Nonce = nonce
+		prop.Header = utils.MarshalOrPanic(hdr)
+
+		actionTx, err := getTransactionForProposal(prop, []byte("simulation_result"))
+		if err != nil {
+			t.Fatalf("getTransactionForProposal failed, err %s", err)
+		}
+
+		if tx == nil {
+			tx = actionTx
+		} else {
+			tx = appendActions(t, tx, actionTx)
+		}
+	}
+
+	return tx
+}
+
+func TestActionOrdering(t *testing.T) {
+	tests := []struct {
+		name    string
+		tx      *common.Envelope
+		enforce bool
+		err     error
+	}{
+		{"Ordered", getOrderedTransaction(t, true, 0, 1, 2), true, nil},
+		{"Single", getOrderedTransaction(t, true, 0), true, nil},
+		{"Gapped", getOrderedTransaction(t, true, 0, 2, 3), true, ErrActionIndexGap},
+		{"Duplicated", getOrderedTransaction(t, true, 0, 1, 1), true, ErrDuplicateActionIndex},
+		{"OutOfOrder", getOrderedTransaction(t, true, 0, 2, 1), true, ErrActionsOutOfOrder},
+		{"NotEnforced", getOrderedTransaction(t, true, 0, 2, 2), false, nil},
+		{"NotOrdered", getOrderedTransaction(t, false, 0, 2, 2), true, nil},
+	}
+
+	for _, test := range tests {
+		v := &Validator{EnforceActionOrdering: test.enforce}
+		_, err := v.ValidateTransaction(test.tx)
+		if err != test.err {
+			t.Fatalf("%s: expected err %v, got %v", test.name, test.err, err)
+		}
+	}
+
+	// the nonces of ordered actions must be long enough for an index
+	ext := utils.MarshalOrPanic(&peer.ChaincodeHeaderExtension{OrderedActions: true})
+	err := checkActionOrdering(context.Background(), &common.ChannelHeader{Extension: ext}, [][]byte{{0, 0}})
+	if err == nil {
+		t.Fatalf("checkActionOrdering should have failed for a short nonce")
+	}
+}
@@ -0,0 +1,89 @@
+/*
+Copyright IBM Corp. 2017 All Rights Reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+		 http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package validation
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+
+	pb "github.com/hyperledger/fabric/protos/peer"
+	"golang.org/x/net/context"
+)
+
+// ErrInvalidAggregateEndorsement is returned for actions whose aggregated
+// endorsement is malformed or has an invalid signature
+var ErrInvalidAggregateEndorsement = errors.New("Invalid aggregated endorsement")
+
+// AggregateVerifier verifies aggregated endorsements
+type AggregateVerifier interface {
+	// VerifyAggregate verifies the aggregated signature over msg of the
+	// endorsers described by signers, on the given chain, against their
+	// aggregated public key
+	VerifyAggregate(chainID string, signers, msg, sig []byte) error
+}
+
+// isAggregateEndorsement tells whether an endorsement is aggregated
+func isAggregateEndorsement(endorsement *pb.Endorsement) bool {
+	return endorsement != nil && bytes.HasPrefix(endorsement.Endorser, AggregateEndorsementMarker)
+}
+
+// hasAggregateEndorsement tells whether an action carries an aggregated
+// endorsement
+func hasAggregateEndorsement(action *pb.ChaincodeEndorsedAction) bool {
+	for _, endorsement := range action.Endorsements {
+		if isAggregateEndorsement(endorsement) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// verifyAggregateEndorsement verifies the aggregated endorsement of an
+// action, which must be its only endorsement
+func (v *Validator) verifyAggregateEndorsement(ctx context.Context, chainID string, action *pb.ChaincodeEndorsedAction) error {
+	if len(action.Endorsements) != 1 {
+		putilsLogger.Errorf("verifyAggregateEndorsement error: aggregated endorsement along with %d others", len(action.Endorsements)-1)
+		return ErrInvalidAggregateEndorsement
+	}
+
+	if v.AggregateVerifier == nil {
+		return fmt.Errorf("No verifier for the aggregated endorsement")
+	}
+
+	endorsement := action.Endorsements[0]
+	signers := endorsement.Endorser[len(AggregateEndorsementMarker):]
+	if len(signers) == 0 {
+		putilsLogger.Errorf("verifyAggregateEndorsement error: no signers")
+		return ErrInvalidAggregateEndorsement
+	}
+
+	msg := make([]byte, 0, len(action.ProposalResponsePayload)+len(endorsement.Endorser))
+	msg = append(append(msg, action.ProposalResponsePayload...), endorsement.Endorser...)
+	err := v.AggregateVerifier.VerifyAggregate(chainID, signers, msg, endorsement.Signature)
+	if err != nil {
+		putilsLogger.Errorf("verifyAggregateEndorsement error: invalid aggregated signature, err %s", err)
+		return ErrInvalidAggregateEndorsement
+	}
+	countStats(ctx, func(stats *ValidationStats) {
+		stats.SignaturesVerified++
+		stats.BytesHashed += len(msg)
+	})
+
+	return nil
+}
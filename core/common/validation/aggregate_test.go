@@ -0,0 +1,107 @@
+/*
+Copyright IBM Corp. 2017 All Rights Reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+		 http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package validation
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"errors"
+	"testing"
+
+	"github.com/hyperledger/fabric/protos/common"
+	"github.com/hyperledger/fabric/protos/peer"
+	"github.com/hyperledger/fabric/protos/utils"
+)
+
+// mockAggregateVerifier accepts the signatures computed by aggregateSign
+type mockAggregateVerifier struct{}
+
+func aggregateSign(signers, msg []byte) []byte {
+	sig := sha256.Sum256(append(append([]byte{}, signers...), msg...))
+	return sig[:]
+}
+
+func (mockAggregateVerifier) VerifyAggregate(chainID string, signers, msg, sig []byte) error {
+	if !bytes.Equal(sig, aggregateSign(signers, msg)) {
+		return errors.New("invalid aggregated signature")
+	}
+	return nil
+}
+
+// getAggregateTransaction returns the transaction with its endorsements
+// replaced by an aggregated one of the signers, signed by sign, followed
+// by the extra endorsements
+func getAggregateTransaction(t *testing.T, tx *common.Envelope, signers []byte, sign func(signers, msg []byte) []byte, extra ...*peer.Endorsement) *common.Envelope {
+	aggTx, err := modifyTransaction(tx, func(sHdr *common.SignatureHeader, cap *peer.ChaincodeActionPayload, prp *peer.ProposalResponsePayload) {
+		endorser := append(append([]byte{}, AggregateEndorsementMarker...), signers...)
+		msg := append(utils.MarshalOrPanic(prp), endorser...)
+		cap.Action.Endorsements = append([]*peer.Endorsement{{Endorser: endorser, Signature: sign(signers, msg)}}, extra...)
+	})
+	if err != nil {
+		t.Fatalf("modifyTransaction failed, err %s", err)
+	}
+
+	return aggTx
+}
+
+func TestAggregateEndorsements(t *testing.T) {
+	tx, err := getTransaction([]byte("simulation_result"))
+	if err != nil {
+		t.Fatalf("getTransaction failed, err %s", err)
+	}
+
+	badSign := func(signers, msg []byte) []byte {
+		sig := aggregateSign(signers, msg)
+		corrupt(sig)
+		return sig
+	}
+
+	signers := []byte("org1.peer0,org2.peer0")
+	valid := getAggregateTransaction(t, tx, signers, aggregateSign)
+
+	tests := []struct {
+		name     string
+		tx       *common.Envelope
+		verifier AggregateVerifier
+		valid    bool
+	}{
+		{"Individual", tx, mockAggregateVerifier{}, true},
+		{"Valid", valid, mockAggregateVerifier{}, true},
+		{"InvalidSignature", getAggregateTransaction(t, tx, signers, badSign), mockAggregateVerifier{}, false},
+		{"NoSigners", getAggregateTransaction(t, tx, nil, aggregateSign), mockAggregateVerifier{}, false},
+		{"WithIndividual", getAggregateTransaction(t, tx, signers, aggregateSign, &peer.Endorsement{Endorser: signerSerialized, Signature: []byte("signature")}), mockAggregateVerifier{}, false},
+		{"NoVerifier", valid, nil, false},
+	}
+
+	for _, test := range tests {
+		v := &Validator{AggregateVerifier: test.verifier}
+		_, err := v.ValidateTransaction(test.tx)
+		if test.valid && err != nil {
+			t.Fatalf("%s: ValidateTransaction failed, err %s", test.name, err)
+		}
+		if !test.valid && err == nil {
+			t.Fatalf("%s: ValidateTransaction should have failed", test.name)
+		}
+	}
+
+	// aggregated endorsements cannot be attributed to allowed MSPs
+	v := &Validator{AggregateVerifier: mockAggregateVerifier{}, AllowedEndorserMSPs: map[string]struct{}{"DEFAULT": struct{}{}}}
+	_, err = v.ValidateTransaction(valid)
+	if err != ErrEndorserNotAllowed {
+		t.Fatalf("ValidateTransaction should have failed with ErrEndorserNotAllowed, got %v", err)
+	}
+}
@@ -0,0 +1,61 @@
+/*
+Copyright IBM Corp. 2017 All Rights Reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+		 http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package validation
+
+import (
+	"errors"
+
+	pb "github.com/hyperledger/fabric/protos/peer"
+)
+
+// ErrArgsTooLarge is returned when the arguments of a chaincode invocation
+// exceed the maximum size allowed by the validator
+var ErrArgsTooLarge = errors.New("The arguments of the chaincode invocation are too large")
+
+// DefaultMaxArgsSize is the maximum total size of the arguments of a
+// chaincode invocation when the Validator sets no MaxArgsSize
+const DefaultMaxArgsSize = 64 * 1024 * 1024
+
+// maxArgsSize returns the maximum total size of the arguments of a
+// chaincode invocation
+func (v *Validator) maxArgsSize() int {
+	if v.MaxArgsSize == 0 {
+		return DefaultMaxArgsSize
+	}
+
+	return v.MaxArgsSize
+}
+
+// checkArgsSize ensures that the total size of the arguments of the
+// chaincode invocation, if any, does not exceed the maximum
+func (v *Validator) checkArgsSize(cis *pb.ChaincodeInvocationSpec) error {
+	if cis == nil || cis.ChaincodeSpec == nil || cis.ChaincodeSpec.Input == nil {
+		return nil
+	}
+
+	max := v.maxArgsSize()
+	size := 0
+	for _, arg := range cis.ChaincodeSpec.Input.Args {
+		size += len(arg)
+		if size > max {
+			putilsLogger.Errorf("checkArgsSize error: the arguments exceed the maximum size of %d bytes", max)
+			return ErrArgsTooLarge
+		}
+	}
+
+	return nil
+}
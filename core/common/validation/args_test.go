@@ -0,0 +1,82 @@
+/*
+Copyright IBM Corp. 2017 All Rights Reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+		 http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package validation
+
+import (
+	"testing"
+
+	"github.com/hyperledger/fabric/common/util"
+	"github.com/hyperledger/fabric/protos/common"
+	"github.com/hyperledger/fabric/protos/peer"
+	"github.com/hyperledger/fabric/protos/utils"
+)
+
+// getProposalWithArgs returns a toy proposal invoking chaincode foo with
+// arguments of the given total size
+func getProposalWithArgs(t *testing.T, size int) *peer.Proposal {
+	cis := &peer.ChaincodeInvocationSpec{
+		ChaincodeSpec: &peer.ChaincodeSpec{
+			ChaincodeId: &peer.ChaincodeID{Name: "foo"},
+			Type:        peer.ChaincodeSpec_GOLANG,
+			Input:       &peer.ChaincodeInput{Args: [][]byte{[]byte("invoke"), make([]byte, size-len("invoke"))}}}}
+
+	prop, _, err := utils.CreateProposalFromCIS(common.HeaderType_ENDORSER_TRANSACTION, util.GetTestChainID(), cis, signerSerialized)
+	if err != nil {
+		t.Fatalf("CreateProposalFromCIS failed, err %s", err)
+	}
+
+	return prop
+}
+
+func TestArgsSize(t *testing.T) {
+	tests := []struct {
+		name string
+		size int
+		max  int
+		err  error
+	}{
+		{"BelowLimit", 64, 100, nil},
+		{"AtLimit", 100, 100, nil},
+		{"AboveLimit", 101, 100, ErrArgsTooLarge},
+		{"Default", 1024 * 1024, 0, nil},
+	}
+
+	for _, test := range tests {
+		v := &Validator{MaxArgsSize: test.max}
+		prop := getProposalWithArgs(t, test.size)
+
+		sProp, err := utils.GetSignedProposal(prop, signer)
+		if err != nil {
+			t.Fatalf("%s: GetSignedProposal failed, err %s", test.name, err)
+		}
+
+		_, _, _, err = v.ValidateProposalMessage(sProp)
+		if err != test.err {
+			t.Fatalf("%s: expected err %v validating the proposal, got %v", test.name, test.err, err)
+		}
+
+		tx, err := getTransactionForProposal(prop, []byte("simulation_result"))
+		if err != nil {
+			t.Fatalf("%s: getTransactionForProposal failed, err %s", test.name, err)
+		}
+
+		_, err = v.ValidateTransaction(tx)
+		if err != test.err {
+			t.Fatalf("%s: expected err %v validating the transaction, got %v", test.name, test.err, err)
+		}
+	}
+}
@@ -0,0 +1,233 @@
+/*
+Copyright IBM Corp. 2017 All Rights Reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+		 http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package validation
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/hyperledger/fabric/bccsp"
+	"github.com/hyperledger/fabric/bccsp/factory"
+)
+
+// ErrAuditChainBroken is returned when the entries of an audit log are not
+// correctly chained, as happens if any of them was altered or removed
+var ErrAuditChainBroken = errors.New("The audit log hash chain is broken")
+
+// DefaultAuditBufferSize is the number of outcomes an AuditSink buffers
+// when created with no buffer size
+const DefaultAuditBufferSize = 1024
+
+// AuditEntry records the outcome of the validation of a transaction
+type AuditEntry struct {
+	// Sequence is the position of the entry in the log, starting from 0
+	Sequence uint64
+
+	// Timestamp is the time the validation completed
+	Timestamp time.Time
+
+	// ChannelID and TxID identify the transaction, if it could be decoded
+	ChannelID string
+	TxID      string
+
+	// Valid is true if the transaction is valid, otherwise Error holds
+	// the reason why it is not
+	Valid bool
+	Error string
+
+	// Dropped is the number of outcomes lost since the previous entry,
+	// because the buffer of the sink was full or their entry could not be
+	// hashed
+	Dropped uint64
+
+	// PrevHash is the hash of the previous entry, nil for the first one
+	PrevHash []byte
+
+	// Hash is the hash of the entry, as computed by ComputeHash
+	Hash []byte
+}
+
+// ComputeHash returns the hash of the entry: the SHA-256 hash of the
+// concatenation of its sequence number, timestamp in nanoseconds since the
+// epoch and dropped count, as big endian uint64s, of its valid flag as a
+// single byte, and of its previous hash, channel ID, TxID and error, each
+// prefixed by its length as a big endian uint32. Since each entry includes
+// the hash of the previous one, altering an entry breaks the chain
+func (e *AuditEntry) ComputeHash() ([]byte, error) {
+	buf := make([]byte, 24, 24+1+4*4+len(e.PrevHash)+len(e.ChannelID)+len(e.TxID)+len(e.Error))
+	binary.BigEndian.PutUint64(buf, e.Sequence)
+	binary.BigEndian.PutUint64(buf[8:], uint64(e.Timestamp.UnixNano()))
+	binary.BigEndian.PutUint64(buf[16:], e.Dropped)
+	if e.Valid {
+		buf = append(buf, 1)
+	} else {
+		buf = append(buf, 0)
+	}
+	for _, field := range [][]byte{e.PrevHash, []byte(e.ChannelID), []byte(e.TxID), []byte(e.Error)} {
+		buf = appendUint32(buf, uint32(len(field)))
+		buf = append(buf, field...)
+	}
+
+	return factory.GetDefault().Hash(buf, &bccsp.SHA256Opts{})
+}
+
+// VerifyAuditChain checks that consecutive entries of an audit log are
+// correctly chained and that the hash of each entry is the right one
+func VerifyAuditChain(entries []*AuditEntry) error {
+	for i, entry := range entries {
+		hash, err := entry.ComputeHash()
+		if err != nil {
+			return err
+		}
+
+		if !bytes.Equal(hash, entry.Hash) {
+			putilsLogger.Errorf("Audit entry %d has hash %x, expected %x", entry.Sequence, entry.Hash, hash)
+			return ErrAuditChainBroken
+		}
+
+		if i == 0 {
+			continue
+		}
+
+		prev := entries[i-1]
+		if entry.Sequence != prev.Sequence+1 || !bytes.Equal(entry.PrevHash, prev.Hash) {
+			putilsLogger.Errorf("Audit entry %d is not chained to entry %d", entry.Sequence, prev.Sequence)
+			return ErrAuditChainBroken
+		}
+	}
+
+	return nil
+}
+
+// AuditLog stores the entries recorded by an AuditSink
+type AuditLog interface {
+	// Append appends an entry to the log; entries are appended in order,
+	// one at a time
+	Append(entry *AuditEntry)
+}
+
+// AuditSink chains the outcomes of validations into the entries of an
+// AuditLog. Outcomes are buffered and appended to the log asynchronously,
+// so that a slow log never blocks validation: if the buffer is full, the
+// outcome is dropped and accounted for in the next entry
+type AuditSink struct {
+	log      AuditLog
+	outcomes chan *AuditEntry
+	done     chan struct{}
+
+	// computeHash computes the hash of the entries
+	computeHash func(entry *AuditEntry) ([]byte, error)
+
+	lock    sync.Mutex
+	closed  bool
+	dropped uint64
+}
+
+// NewAuditSink returns an AuditSink appending to the given log, buffering
+// up to bufferSize outcomes; if zero, DefaultAuditBufferSize is used
+func NewAuditSink(log AuditLog, bufferSize int) *AuditSink {
+	return newAuditSink(log, bufferSize, (*AuditEntry).ComputeHash)
+}
+
+// newAuditSink returns an AuditSink hashing its entries with computeHash
+func newAuditSink(log AuditLog, bufferSize int, computeHash func(entry *AuditEntry) ([]byte, error)) *AuditSink {
+	if bufferSize == 0 {
+		bufferSize = DefaultAuditBufferSize
+	}
+
+	s := &AuditSink{
+		log:         log,
+		outcomes:    make(chan *AuditEntry, bufferSize),
+		done:        make(chan struct{}),
+		computeHash: computeHash,
+	}
+	go s.run()
+
+	return s
+}
+
+// Close stops the sink once the buffered outcomes have been appended to
+// the log; outcomes recorded afterwards are discarded
+func (s *AuditSink) Close() {
+	s.lock.Lock()
+	if !s.closed {
+		s.closed = true
+		close(s.outcomes)
+	}
+	s.lock.Unlock()
+
+	<-s.done
+}
+
+// record buffers the outcome of the validation of a transaction
+func (s *AuditSink) record(result *ValidationResult, err error) {
+	entry := &AuditEntry{Timestamp: time.Now(), Valid: err == nil}
+	if err != nil {
+		entry.Error = err.Error()
+	}
+	if result.Payload != nil && result.Payload.Header != nil && result.Payload.Header.ChannelHeader != nil {
+		entry.ChannelID = result.Payload.Header.ChannelHeader.ChannelId
+		entry.TxID = result.Payload.Header.ChannelHeader.TxId
+	}
+
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	if s.closed {
+		return
+	}
+
+	entry.Dropped = s.dropped
+	select {
+	case s.outcomes <- entry:
+		s.dropped = 0
+	default:
+		s.dropped++
+		putilsLogger.Warningf("Audit buffer full, dropping the outcome of transaction [%s]", entry.TxID)
+	}
+}
+
+// run chains the buffered outcomes and appends them to the log
+func (s *AuditSink) run() {
+	defer close(s.done)
+
+	var sequence, lost uint64
+	var prevHash []byte
+	for entry := range s.outcomes {
+		entry.Sequence = sequence
+		entry.PrevHash = prevHash
+		entry.Dropped += lost
+
+		hash, err := s.computeHash(entry)
+		if err != nil {
+			// the outcome is lost, along with those it accounted for
+			putilsLogger.Errorf("Could not compute the hash of audit entry %d, err %s", sequence, err)
+			lost = entry.Dropped + 1
+			continue
+		}
+		lost = 0
+		entry.Hash = hash
+
+		s.log.Append(entry)
+
+		sequence++
+		prevHash = hash
+	}
+}
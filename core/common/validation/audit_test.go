@@ -0,0 +1,238 @@
+/*
+Copyright IBM Corp. 2017 All Rights Reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+		 http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package validation
+
+import (
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/hyperledger/fabric/common/util"
+	"github.com/hyperledger/fabric/protos/common"
+)
+
+// mockAuditLog keeps the entries in memory; if blocked, the first Append
+// signals appending and then waits for unblock
+type mockAuditLog struct {
+	lock    sync.Mutex
+	entries []*AuditEntry
+
+	blocked   bool
+	appending chan struct{}
+	unblock   chan struct{}
+}
+
+func (l *mockAuditLog) Append(entry *AuditEntry) {
+	if l.blocked {
+		l.blocked = false
+		close(l.appending)
+		<-l.unblock
+	}
+
+	l.lock.Lock()
+	defer l.lock.Unlock()
+	l.entries = append(l.entries, entry)
+}
+
+func (l *mockAuditLog) count() int {
+	l.lock.Lock()
+	defer l.lock.Unlock()
+	return len(l.entries)
+}
+
+func TestAuditSink(t *testing.T) {
+	tx, err := getTransaction([]byte("simulation_result"))
+	if err != nil {
+		t.Fatalf("getTransaction failed, err %s", err)
+	}
+
+	badTx := &common.Envelope{Payload: tx.Payload, Signature: append([]byte(nil), tx.Signature...)}
+	corrupt(badTx.Signature)
+
+	log := &mockAuditLog{}
+	sink := NewAuditSink(log, 0)
+	v := &Validator{AuditSink: sink}
+
+	envelopes := []*common.Envelope{tx, badTx, {Payload: []byte("garbage")}, tx}
+	for _, e := range envelopes {
+		v.ValidateTransaction(e)
+	}
+	sink.Close()
+
+	if len(log.entries) != len(envelopes) {
+		t.Fatalf("Expected %d entries, got %d", len(envelopes), len(log.entries))
+	}
+
+	err = VerifyAuditChain(log.entries)
+	if err != nil {
+		t.Fatalf("VerifyAuditChain failed, err %s", err)
+	}
+
+	for i, entry := range log.entries {
+		if entry.Sequence != uint64(i) {
+			t.Fatalf("Expected sequence %d, got %d", i, entry.Sequence)
+		}
+		if (i == 0) != (entry.PrevHash == nil) {
+			t.Fatalf("Only the first entry should have no previous hash, entry %d has %x", i, entry.PrevHash)
+		}
+	}
+
+	first, second, third := log.entries[0], log.entries[1], log.entries[2]
+	if !first.Valid || first.Error != "" || first.ChannelID != util.GetTestChainID() || first.TxID == "" {
+		t.Fatalf("Unexpected entry for a valid transaction %+v", first)
+	}
+	if second.Valid || second.Error == "" {
+		t.Fatalf("Unexpected entry for an invalid transaction %+v", second)
+	}
+	if third.Valid || third.ChannelID != "" || third.TxID != "" {
+		t.Fatalf("Unexpected entry for an undecodable transaction %+v", third)
+	}
+
+	// altering an entry breaks the chain
+	second.Valid = true
+	if VerifyAuditChain(log.entries) != ErrAuditChainBroken {
+		t.Fatalf("VerifyAuditChain should have failed with ErrAuditChainBroken")
+	}
+	second.Valid = false
+
+	// and so does removing one
+	if VerifyAuditChain([]*AuditEntry{first, third}) != ErrAuditChainBroken {
+		t.Fatalf("VerifyAuditChain should have failed with ErrAuditChainBroken")
+	}
+
+	// outcomes recorded after closing are discarded
+	v.ValidateTransaction(tx)
+	if len(log.entries) != len(envelopes) {
+		t.Fatalf("Expected %d entries, got %d", len(envelopes), len(log.entries))
+	}
+}
+
+func TestAuditSinkFull(t *testing.T) {
+	tx, err := getTransaction([]byte("simulation_result"))
+	if err != nil {
+		t.Fatalf("getTransaction failed, err %s", err)
+	}
+
+	log := &mockAuditLog{blocked: true, appending: make(chan struct{}), unblock: make(chan struct{})}
+	sink := NewAuditSink(log, 1)
+	v := &Validator{AuditSink: sink}
+
+	// the first outcome is being appended
+	v.ValidateTransaction(tx)
+	<-log.appending
+
+	// the second one is buffered and the next two dropped, without
+	// blocking validation
+	for i := 0; i < 3; i++ {
+		v.ValidateTransaction(tx)
+	}
+
+	// once the buffer drained, the drops are accounted for in the entry
+	// recorded next
+	close(log.unblock)
+	for deadline := time.Now().Add(5 * time.Second); log.count() < 2; time.Sleep(time.Millisecond) {
+		if time.Now().After(deadline) {
+			t.Fatalf("The buffered outcome was not appended")
+		}
+	}
+	v.ValidateTransaction(tx)
+	sink.Close()
+
+	if len(log.entries) != 3 {
+		t.Fatalf("Expected 3 entries, got %d", len(log.entries))
+	}
+	if log.entries[0].Dropped != 0 || log.entries[1].Dropped != 0 || log.entries[2].Dropped != 2 {
+		t.Fatalf("Expected 2 drops before the last entry, got %d, %d and %d", log.entries[0].Dropped, log.entries[1].Dropped, log.entries[2].Dropped)
+	}
+
+	err = VerifyAuditChain(log.entries)
+	if err != nil {
+		t.Fatalf("VerifyAuditChain failed, err %s", err)
+	}
+}
+
+func TestAuditFinalOutcome(t *testing.T) {
+	tx, err := getTransaction([]byte("simulation_result"))
+	if err != nil {
+		t.Fatalf("getTransaction failed, err %s", err)
+	}
+
+	log := &mockAuditLog{}
+	sink := NewAuditSink(log, 0)
+	v := &Validator{AuditSink: sink}
+
+	// a transaction rejected by the additional checks of an entry point
+	// after passing the built-in validation; no policy provider is set to
+	// check the digest against
+	_, err = v.ValidateTransactionWithPolicyDigest(tx, []byte("digest"))
+	if err == nil {
+		t.Fatalf("ValidateTransactionWithPolicyDigest should have failed")
+	}
+
+	// a transaction rejected by a middleware
+	var calls []string
+	rejected := errors.New("rejected")
+	v.Use(recordingMiddleware("rejecting", &calls, rejected))
+	v.ValidateTransaction(tx)
+	sink.Close()
+
+	if len(log.entries) != 2 {
+		t.Fatalf("Expected 2 entries, got %d", len(log.entries))
+	}
+	if log.entries[0].Valid || log.entries[0].Error != err.Error() || log.entries[0].TxID == "" {
+		t.Fatalf("Unexpected entry for a transaction rejected by the entry point %+v", log.entries[0])
+	}
+	if log.entries[1].Valid || log.entries[1].Error != rejected.Error() {
+		t.Fatalf("Unexpected entry for a transaction rejected by a middleware %+v", log.entries[1])
+	}
+}
+
+func TestAuditSinkHashFailure(t *testing.T) {
+	tx, err := getTransaction([]byte("simulation_result"))
+	if err != nil {
+		t.Fatalf("getTransaction failed, err %s", err)
+	}
+
+	// the entry of the second outcome cannot be hashed
+	hashed := 0
+	log := &mockAuditLog{}
+	sink := newAuditSink(log, 0, func(entry *AuditEntry) ([]byte, error) {
+		hashed++
+		if hashed == 2 {
+			return nil, errors.New("hash failure")
+		}
+		return entry.ComputeHash()
+	})
+	v := &Validator{AuditSink: sink}
+	for i := 0; i < 3; i++ {
+		v.ValidateTransaction(tx)
+	}
+	sink.Close()
+
+	if len(log.entries) != 2 {
+		t.Fatalf("Expected 2 entries, got %d", len(log.entries))
+	}
+	if log.entries[0].Dropped != 0 || log.entries[1].Dropped != 1 {
+		t.Fatalf("Expected the lost outcome to be accounted for in the last entry, got %d and %d drops", log.entries[0].Dropped, log.entries[1].Dropped)
+	}
+
+	err = VerifyAuditChain(log.entries)
+	if err != nil {
+		t.Fatalf("VerifyAuditChain failed, err %s", err)
+	}
+}
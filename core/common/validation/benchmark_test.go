@@ -0,0 +1,323 @@
+/*
+Copyright IBM Corp. 2017 All Rights Reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+		 http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package validation
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/asn1"
+	"errors"
+	"fmt"
+	"math/big"
+	"testing"
+
+	"github.com/hyperledger/fabric/common/util"
+	"github.com/hyperledger/fabric/msp"
+	mspmgmt "github.com/hyperledger/fabric/msp/mgmt"
+	"github.com/hyperledger/fabric/protos/common"
+	"github.com/hyperledger/fabric/protos/peer"
+	"github.com/hyperledger/fabric/protos/utils"
+	"golang.org/x/net/context"
+)
+
+// benchIdentity is a signing identity of any MSP backed by an ECDSA P-256
+// key, so that benchmarks sign and verify real signatures without needing
+// MSP material on disk
+type benchIdentity struct {
+	mockIdentity
+	key        *ecdsa.PrivateKey
+	serialized []byte
+}
+
+type ecdsaSignature struct {
+	R, S *big.Int
+}
+
+func newBenchIdentity(tb testing.TB, mspID, id string) *benchIdentity {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		tb.Fatalf("GenerateKey failed, err %s", err)
+	}
+
+	return &benchIdentity{
+		mockIdentity: mockIdentity{mspID: mspID, id: id, valid: true},
+		key:          key,
+		serialized:   utils.MarshalOrPanic(&msp.SerializedIdentity{Mspid: mspID, IdBytes: []byte(id)}),
+	}
+}
+
+func (id *benchIdentity) Sign(msg []byte) ([]byte, error) {
+	digest := sha256.Sum256(msg)
+	r, s, err := ecdsa.Sign(rand.Reader, id.key, digest[:])
+	if err != nil {
+		return nil, err
+	}
+	return asn1.Marshal(ecdsaSignature{r, s})
+}
+
+func (id *benchIdentity) SignOpts(msg []byte, opts msp.SignatureOpts) ([]byte, error) {
+	return id.Sign(msg)
+}
+
+func (id *benchIdentity) GetAttributeProof(spec *msp.AttributeProofSpec) ([]byte, error) {
+	return nil, errors.New("not implemented")
+}
+
+func (id *benchIdentity) GetPublicVersion() msp.Identity {
+	return id
+}
+
+func (id *benchIdentity) Renew() error {
+	return nil
+}
+
+func (id *benchIdentity) Verify(msg []byte, sig []byte) error {
+	ecdsaSig := &ecdsaSignature{}
+	if _, err := asn1.Unmarshal(sig, ecdsaSig); err != nil {
+		return err
+	}
+
+	digest := sha256.Sum256(msg)
+	if !ecdsa.Verify(&id.key.PublicKey, digest[:], ecdsaSig.R, ecdsaSig.S) {
+		return errors.New("invalid signature")
+	}
+	return nil
+}
+
+func (id *benchIdentity) VerifyOpts(msg []byte, sig []byte, opts msp.SignatureOpts) error {
+	return id.Verify(msg, sig)
+}
+
+func (id *benchIdentity) Serialize() ([]byte, error) {
+	return id.serialized, nil
+}
+
+// benchFixture builds signed proposals, transactions and blocks created by
+// a member of an MSP and endorsed by the given number of its members, along
+// with a validator knowing those identities
+type benchFixture struct {
+	creator   *benchIdentity
+	endorsers []*benchIdentity
+	validator *Validator
+}
+
+func newBenchFixture(tb testing.TB, mspID string, endorsements int) *benchFixture {
+	f := &benchFixture{creator: newBenchIdentity(tb, mspID, "creator")}
+	deserializer := mockDeserializer{string(f.creator.serialized): f.creator}
+	for i := 0; i < endorsements; i++ {
+		endorser := newBenchIdentity(tb, mspID, fmt.Sprintf("endorser%d", i))
+		deserializer[string(endorser.serialized)] = endorser
+		f.endorsers = append(f.endorsers, endorser)
+	}
+	f.validator = &Validator{DeserializerProvider: &mockDeserializerProvider{deserializer}}
+
+	return f
+}
+
+func (f *benchFixture) getProposal(tb testing.TB) *peer.Proposal {
+	cis := &peer.ChaincodeInvocationSpec{
+		ChaincodeSpec: &peer.ChaincodeSpec{
+			ChaincodeId: &peer.ChaincodeID{Name: "foo", Version: "1.0"},
+			Type:        peer.ChaincodeSpec_GOLANG,
+			Input:       &peer.ChaincodeInput{Args: [][]byte{[]byte("invoke"), []byte("a"), []byte("b"), []byte("10")}}}}
+
+	prop, _, err := utils.CreateProposalFromCIS(common.HeaderType_ENDORSER_TRANSACTION, util.GetTestChainID(), cis, f.creator.serialized)
+	if err != nil {
+		tb.Fatalf("CreateProposalFromCIS failed, err %s", err)
+	}
+
+	return prop
+}
+
+func (f *benchFixture) getSignedProposal(tb testing.TB) *peer.SignedProposal {
+	sProp, err := utils.GetSignedProposal(f.getProposal(tb), f.creator)
+	if err != nil {
+		tb.Fatalf("GetSignedProposal failed, err %s", err)
+	}
+
+	return sProp
+}
+
+// getTransaction returns a signed transaction with the given number of
+// actions, each endorsed by all the endorsers of the fixture
+func (f *benchFixture) getTransaction(tb testing.TB, actions int) *common.Envelope {
+	prop := f.getProposal(tb)
+
+	var presps []*peer.ProposalResponse
+	for _, endorser := range f.endorsers {
+		presp, err := utils.CreateProposalResponse(prop.Header, prop.Payload, &peer.Response{Status: 200}, []byte("simulation_result"), nil, nil, endorser)
+		if err != nil {
+			tb.Fatalf("CreateProposalResponse failed, err %s", err)
+		}
+		presps = append(presps, presp)
+	}
+
+	tx, err := utils.CreateSignedTx(prop, f.creator, presps...)
+	if err != nil {
+		tb.Fatalf("CreateSignedTx failed, err %s", err)
+	}
+
+	if actions == 1 {
+		return tx
+	}
+
+	payload, err := utils.GetPayload(tx)
+	if err != nil {
+		tb.Fatalf("GetPayload failed, err %s", err)
+	}
+
+	transaction, err := utils.GetTransaction(payload.Data)
+	if err != nil {
+		tb.Fatalf("GetTransaction failed, err %s", err)
+	}
+
+	for len(transaction.Actions) < actions {
+		transaction.Actions = append(transaction.Actions, transaction.Actions[0])
+	}
+
+	payload.Data = utils.MarshalOrPanic(transaction)
+	tx.Payload = utils.MarshalOrPanic(payload)
+	tx.Signature, err = f.creator.Sign(tx.Payload)
+	if err != nil {
+		tb.Fatalf("Sign failed, err %s", err)
+	}
+
+	return tx
+}
+
+// getBlock returns a block holding the given number of transactions
+func (f *benchFixture) getBlock(tb testing.TB, txs, actions int) *common.Block {
+	block := common.NewBlock(1, []byte("previous_hash"))
+	for i := 0; i < txs; i++ {
+		block.Data.Data = append(block.Data.Data, utils.MarshalOrPanic(f.getTransaction(tb, actions)))
+	}
+	block.Header.DataHash = block.Data.Hash()
+
+	return block
+}
+
+func TestBenchFixture(t *testing.T) {
+	f := newBenchFixture(t, "Org1MSP", 3)
+
+	_, _, _, err := f.validator.ValidateProposalMessage(f.getSignedProposal(t))
+	if err != nil {
+		t.Fatalf("ValidateProposalMessage failed, err %s", err)
+	}
+
+	_, err = f.validator.ValidateTransaction(f.getTransaction(t, 4))
+	if err != nil {
+		t.Fatalf("ValidateTransaction failed, err %s", err)
+	}
+
+	result, err := f.validator.ValidateBlock(f.getBlock(t, 5, 2))
+	if err != nil {
+		t.Fatalf("ValidateBlock failed, err %s", err)
+	}
+	for i, err := range result.Errors {
+		if err != nil {
+			t.Fatalf("Transaction %d of the block is not valid, err %s", i, err)
+		}
+	}
+}
+
+func BenchmarkValidateProposalMessage(b *testing.B) {
+	f := newBenchFixture(b, "Org1MSP", 1)
+	sProp := f.getSignedProposal(b)
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, _, _, err := f.validator.ValidateProposalMessage(sProp); err != nil {
+			b.Fatalf("ValidateProposalMessage failed, err %s", err)
+		}
+	}
+}
+
+func BenchmarkValidateTransaction(b *testing.B) {
+	for _, shape := range []struct{ actions, endorsements int }{{1, 1}, {1, 4}, {4, 1}, {4, 4}} {
+		b.Run(fmt.Sprintf("actions=%d,endorsements=%d", shape.actions, shape.endorsements), func(b *testing.B) {
+			f := newBenchFixture(b, "Org1MSP", shape.endorsements)
+			tx := f.getTransaction(b, shape.actions)
+
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				if _, err := f.validator.ValidateTransaction(tx); err != nil {
+					b.Fatalf("ValidateTransaction failed, err %s", err)
+				}
+			}
+		})
+	}
+}
+
+func BenchmarkValidateBlock(b *testing.B) {
+	f := newBenchFixture(b, "Org1MSP", 2)
+	block := f.getBlock(b, 100, 1)
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, err := f.validator.ValidateBlock(block); err != nil {
+			b.Fatalf("ValidateBlock failed, err %s", err)
+		}
+	}
+}
+
+func BenchmarkActionCreators(b *testing.B) {
+	chainID := util.GetTestChainID()
+	signerIdentity, err := mspmgmt.GetIdentityDeserializer(chainID).DeserializeIdentity(signerSerialized)
+	if err != nil {
+		b.Fatalf("DeserializeIdentity failed, err %s", err)
+	}
+
+	// the actions of a transaction created by its creator, encoded
+	// differently
+	const actions = 8
+	creator := reencodedSigner(b)
+	v := &Validator{}
+
+	b.Run("PerAction", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			for j := 0; j < actions; j++ {
+				if err := v.newActionCreatorChecker(chainID, signerSerialized, nil).check(context.Background(), creator); err != nil {
+					b.Fatalf("check failed, err %s", err)
+				}
+			}
+		}
+	})
+
+	b.Run("Shared", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			creators := v.newActionCreatorChecker(chainID, signerSerialized, signerIdentity)
+			for j := 0; j < actions; j++ {
+				if err := creators.check(context.Background(), creator); err != nil {
+					b.Fatalf("check failed, err %s", err)
+				}
+			}
+		}
+	})
+
+	b.Run("SingleSigner", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			creators := v.newActionCreatorChecker(chainID, signerSerialized, signerIdentity)
+			for j := 0; j < actions; j++ {
+				if err := creators.check(context.Background(), signerSerialized); err != nil {
+					b.Fatalf("check failed, err %s", err)
+				}
+			}
+		}
+	})
+}
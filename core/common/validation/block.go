@@ -0,0 +1,230 @@
+/*
+Copyright IBM Corp. 2017 All Rights Reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+		 http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package validation
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+
+	"github.com/hyperledger/fabric/bccsp"
+	"github.com/hyperledger/fabric/bccsp/factory"
+	ledgerUtil "github.com/hyperledger/fabric/core/ledger/util"
+	"github.com/hyperledger/fabric/protos/common"
+	"github.com/hyperledger/fabric/protos/utils"
+	"golang.org/x/net/context"
+)
+
+// ErrUndecodable is recorded for the entries of a block that cannot be
+// decoded into an envelope
+var ErrUndecodable = errors.New("Could not decode the envelope")
+
+// ErrBlockSizeBudgetExceeded is recorded for the entries of a block past
+// the size budget of its channel
+var ErrBlockSizeBudgetExceeded = errors.New("Block size budget exceeded")
+
+// DefaultBlockSizeBudget is the size budget of the blocks of the channels
+// with no budget in the BlockSizeBudgets of the validator
+const DefaultBlockSizeBudget = 256 * 1024 * 1024
+
+// BlockValidationResult holds the outcome of the validation of a block
+type BlockValidationResult struct {
+	// Results holds the result of the validation of each entry of the
+	// block data, at the same index as the entry; the results of entries
+	// that could not be decoded hold neither an envelope nor a payload
+	Results []*ValidationResult
+
+	// Errors holds the error returned by the validation of each entry of
+	// the block data, at the same index as the entry, or nil if the entry
+	// is valid; it is ErrUndecodable for entries that could not be decoded
+	Errors []error
+
+	// Valid has the i-th bit set if the i-th transaction of the block is
+	// valid. Note that this is the opposite of the transactions filter
+	// stored in the block metadata, which flags the invalid ones
+	Valid ledgerUtil.FilterBitArray
+
+	// Commitment is the hash committing to the validity bitmap of the
+	// block, as computed by BlockValidityCommitment; peers may sign it
+	// so that light clients can learn which transactions were valid
+	// without validating them again
+	Commitment []byte
+}
+
+// ValidateBlock validates the transactions of the block one after the other,
+// in the order they appear in the block, and returns their validity bitmap
+// along with the commitment to it. The entries past the size budget of the
+// channel are rejected without being decoded. Only the checks of Validate
+// are performed: endorsement policies and duplicate TxIds are not checked.
+// The envelopes of block 0 are validated as by ValidateGenesisTransaction
+func (v *Validator) ValidateBlock(block *common.Block) (*BlockValidationResult, error) {
+	return v.validateBlock(block, nil)
+}
+
+// validateBlock validates the block as ValidateBlock does, performing the
+// additional check, if any, on the result of each valid transaction
+func (v *Validator) validateBlock(block *common.Block, check func(result *ValidationResult) error) (*BlockValidationResult, error) {
+	if block == nil || block.Header == nil || block.Data == nil {
+		return nil, fmt.Errorf("Nil block, block header or block data")
+	}
+
+	// the envelopes of the block are committed as they are
+	ctx := withCommittedEnvelopes(context.Background())
+	if block.Header.Number == 0 {
+		ctx = withGenesis(ctx)
+	}
+
+	txCount := len(block.Data.Data)
+	blockResult := &BlockValidationResult{
+		Results: make([]*ValidationResult, txCount),
+		Errors:  make([]error, txCount),
+		Valid:   make(ledgerUtil.FilterBitArray, (txCount+7)/8),
+	}
+
+	budget := v.getBlockSizeBudget(block)
+	total := 0
+
+	for i, d := range block.Data.Data {
+		// every entry counts against the budget, whether valid or not
+		total += len(d)
+		if total > budget {
+			putilsLogger.Warningf("Invalid transaction with index %d, the block exceeds its size budget of %d bytes", i, budget)
+			blockResult.Results[i] = &ValidationResult{}
+			blockResult.Errors[i] = ErrBlockSizeBudgetExceeded
+			continue
+		}
+
+		env, err := utils.GetEnvelopeFromBlock(d)
+		if err != nil {
+			putilsLogger.Warningf("Invalid transaction with index %d, could not get envelope, err %s", i, err)
+			blockResult.Results[i] = &ValidationResult{}
+			blockResult.Errors[i] = ErrUndecodable
+			continue
+		}
+
+		blockResult.Results[i], err = v.validateWith(ctx, env, check)
+		if err != nil {
+			putilsLogger.Warningf("Invalid transaction with index %d, err %s", i, err)
+			blockResult.Errors[i] = err
+			continue
+		}
+
+		blockResult.Valid.Set(uint(i))
+	}
+
+	commitment, err := BlockValidityCommitment(block, blockResult.Valid)
+	if err != nil {
+		return nil, err
+	}
+	blockResult.Commitment = commitment
+
+	return blockResult, nil
+}
+
+// ValidateRawBlock decodes the serialized block and validates it with the
+// default validator, see Validator.ValidateRawBlock
+func ValidateRawBlock(blockBytes []byte) ([]*ValidationResult, []error) {
+	return defaultValidator.ValidateRawBlock(blockBytes)
+}
+
+// ValidateRawBlock decodes the serialized block and validates it as
+// ValidateBlock does, returning the results and errors of its entries. If
+// the block itself is malformed, no result is returned and the only error
+// is the one that made it so
+func (v *Validator) ValidateRawBlock(blockBytes []byte) ([]*ValidationResult, []error) {
+	if len(blockBytes) == 0 {
+		return nil, []error{fmt.Errorf("Empty block bytes")}
+	}
+
+	block, err := utils.GetBlockFromBlockBytes(blockBytes)
+	if err != nil {
+		return nil, []error{permanentDecodeError(fmt.Errorf("Could not decode the block, err %s", err))}
+	}
+
+	blockResult, err := v.ValidateBlock(block)
+	if err != nil {
+		return nil, []error{err}
+	}
+
+	return blockResult.Results, blockResult.Errors
+}
+
+// getBlockSizeBudget returns the maximum total size of the entries of the
+// block, according to the channel of its first transaction
+func (v *Validator) getBlockSizeBudget(block *common.Block) int {
+	if len(v.BlockSizeBudgets) == 0 {
+		return DefaultBlockSizeBudget
+	}
+
+	chainID, err := utils.GetChainIDFromBlock(block)
+	if err != nil {
+		return DefaultBlockSizeBudget
+	}
+
+	if budget, ok := v.BlockSizeBudgets[chainID]; ok {
+		return budget
+	}
+
+	return DefaultBlockSizeBudget
+}
+
+// BlockValidityCommitment returns the commitment to the validity bitmap of
+// the block: the SHA-256 hash of the concatenation of
+//
+//	the block number, as a big endian uint64
+//	the hash of the block header, prefixed by its length as a big endian uint32
+//	the number of transactions in the block, as a big endian uint32
+//	the bitmap, one bit per transaction starting from the least significant
+//	bit of the first byte, padded with zero bits to a whole number of bytes
+//
+// Binding the commitment to the block header ensures it cannot be replayed
+// for another block with the same number of transactions
+func BlockValidityCommitment(block *common.Block, valid ledgerUtil.FilterBitArray) ([]byte, error) {
+	if block == nil || block.Header == nil || block.Data == nil {
+		return nil, fmt.Errorf("Nil block, block header or block data")
+	}
+
+	txCount := len(block.Data.Data)
+	bitmapLen := (txCount + 7) / 8
+	if len(valid) > bitmapLen {
+		return nil, fmt.Errorf("Validity bitmap too long for %d transactions, got %d bytes", txCount, len(valid))
+	}
+
+	bitmap := make([]byte, bitmapLen)
+	copy(bitmap, valid)
+	if txCount%8 != 0 && bitmap[bitmapLen-1]>>uint(txCount%8) != 0 {
+		return nil, fmt.Errorf("Validity bitmap has bits set past the %d transactions of the block", txCount)
+	}
+
+	headerHash := block.Header.Hash()
+
+	buf := make([]byte, 8, 8+4+len(headerHash)+4+bitmapLen)
+	binary.BigEndian.PutUint64(buf, block.Header.Number)
+	buf = appendUint32(buf, uint32(len(headerHash)))
+	buf = append(buf, headerHash...)
+	buf = appendUint32(buf, uint32(txCount))
+	buf = append(buf, bitmap...)
+
+	return factory.GetDefault().Hash(buf, &bccsp.SHA256Opts{})
+}
+
+// appendUint32 appends the big endian encoding of n to buf
+func appendUint32(buf []byte, n uint32) []byte {
+	b := make([]byte, 4)
+	binary.BigEndian.PutUint32(b, n)
+	return append(buf, b...)
+}
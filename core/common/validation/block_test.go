@@ -0,0 +1,239 @@
+/*
+Copyright IBM Corp. 2017 All Rights Reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+		 http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package validation
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/hyperledger/fabric/common/util"
+	ledgerUtil "github.com/hyperledger/fabric/core/ledger/util"
+	"github.com/hyperledger/fabric/protos/common"
+	"github.com/hyperledger/fabric/protos/utils"
+)
+
+// getBlock returns a block holding a valid transaction, a corrupted one
+// and another valid one
+func getBlock(t *testing.T) *common.Block {
+	block := common.NewBlock(1, []byte("previous_hash"))
+	for i := 0; i < 3; i++ {
+		tx, err := getTransaction([]byte("simulation_result"))
+		if err != nil {
+			t.Fatalf("getTransaction failed, err %s", err)
+		}
+
+		if i == 1 {
+			corrupt(tx.Signature)
+		}
+
+		txBytes, err := utils.Marshal(tx)
+		if err != nil {
+			t.Fatalf("Marshal failed, err %s", err)
+		}
+
+		block.Data.Data = append(block.Data.Data, txBytes)
+	}
+	block.Header.DataHash = block.Data.Hash()
+
+	return block
+}
+
+func TestValidateBlock(t *testing.T) {
+	block := getBlock(t)
+
+	result, err := defaultValidator.ValidateBlock(block)
+	if err != nil {
+		t.Fatalf("ValidateBlock failed, err %s", err)
+	}
+
+	if !result.Valid.IsSet(0) || result.Valid.IsSet(1) || !result.Valid.IsSet(2) {
+		t.Fatalf("Unexpected validity bitmap %x", result.Valid.ToBytes())
+	}
+
+	again, err := defaultValidator.ValidateBlock(block)
+	if err != nil {
+		t.Fatalf("ValidateBlock failed, err %s", err)
+	}
+
+	if !bytes.Equal(result.Valid, again.Valid) || !bytes.Equal(result.Commitment, again.Commitment) {
+		t.Fatalf("Validity bitmap and commitment should be reproducible")
+	}
+
+	commitment, err := BlockValidityCommitment(block, result.Valid)
+	if err != nil {
+		t.Fatalf("BlockValidityCommitment failed, err %s", err)
+	}
+
+	if !bytes.Equal(commitment, result.Commitment) {
+		t.Fatalf("Commitment should be recomputable from the bitmap")
+	}
+}
+
+func TestBlockValidityCommitment(t *testing.T) {
+	block := getBlock(t)
+
+	commitment, err := BlockValidityCommitment(block, ledgerUtil.FilterBitArray{0x05})
+	if err != nil {
+		t.Fatalf("BlockValidityCommitment failed, err %s", err)
+	}
+
+	other, err := BlockValidityCommitment(block, ledgerUtil.FilterBitArray{0x07})
+	if err != nil {
+		t.Fatalf("BlockValidityCommitment failed, err %s", err)
+	}
+
+	if bytes.Equal(commitment, other) {
+		t.Fatalf("Different bitmaps should have different commitments")
+	}
+
+	otherBlock := getBlock(t)
+	otherBlock.Header.Number = 2
+	other, err = BlockValidityCommitment(otherBlock, ledgerUtil.FilterBitArray{0x05})
+	if err != nil {
+		t.Fatalf("BlockValidityCommitment failed, err %s", err)
+	}
+
+	if bytes.Equal(commitment, other) {
+		t.Fatalf("Different blocks should have different commitments")
+	}
+
+	_, err = BlockValidityCommitment(block, ledgerUtil.FilterBitArray{0x0d})
+	if err == nil {
+		t.Fatalf("BlockValidityCommitment should have failed for bits past the transactions")
+	}
+
+	_, err = BlockValidityCommitment(block, ledgerUtil.FilterBitArray{0x05, 0x00})
+	if err == nil {
+		t.Fatalf("BlockValidityCommitment should have failed for a bitmap too long")
+	}
+
+	_, err = defaultValidator.ValidateBlock(&common.Block{})
+	if err == nil {
+		t.Fatalf("ValidateBlock should have failed for an empty block")
+	}
+}
+
+func TestValidateBlockUndecodableEntry(t *testing.T) {
+	block := getBlock(t)
+	block.Data.Data = append(block.Data.Data[:1], append([][]byte{[]byte("garbage")}, block.Data.Data[1:]...)...)
+
+	result, err := defaultValidator.ValidateBlock(block)
+	if err != nil {
+		t.Fatalf("ValidateBlock failed, err %s", err)
+	}
+
+	if len(result.Results) != 4 || len(result.Errors) != 4 {
+		t.Fatalf("Expected a result for each of the 4 entries, got %d results and %d errors", len(result.Results), len(result.Errors))
+	}
+
+	if result.Errors[1] != ErrUndecodable || result.Valid.IsSet(1) {
+		t.Fatalf("The garbage entry should have been recorded as undecodable, got %v", result.Errors[1])
+	}
+
+	if result.Errors[0] != nil || result.Errors[3] != nil || !result.Valid.IsSet(0) || !result.Valid.IsSet(3) {
+		t.Fatalf("The valid entries should have been validated, got %v", result.Errors)
+	}
+
+	if result.Errors[2] == nil || result.Errors[2] == ErrUndecodable || result.Valid.IsSet(2) {
+		t.Fatalf("The corrupted entry should have failed validation, got %v", result.Errors[2])
+	}
+
+	if result.Results[0].Envelope == nil || result.Results[1].Envelope != nil {
+		t.Fatalf("Results are not aligned with the entries of the block")
+	}
+}
+
+func TestValidateBlockSizeBudget(t *testing.T) {
+	block := getBlock(t)
+	block.Data.Data = append(block.Data.Data, block.Data.Data[0])
+
+	// the budget only accommodates the first two entries
+	budget := len(block.Data.Data[0]) + len(block.Data.Data[1])
+	v := &Validator{BlockSizeBudgets: map[string]int{util.GetTestChainID(): budget}}
+
+	result, err := v.ValidateBlock(block)
+	if err != nil {
+		t.Fatalf("ValidateBlock failed, err %s", err)
+	}
+
+	if result.Errors[0] != nil || !result.Valid.IsSet(0) {
+		t.Fatalf("The first entry should be valid, got %v", result.Errors[0])
+	}
+
+	if result.Errors[1] == nil || result.Errors[1] == ErrBlockSizeBudgetExceeded {
+		t.Fatalf("The second entry should be invalid but within the budget, got %v", result.Errors[1])
+	}
+
+	for i := 2; i < 4; i++ {
+		if result.Errors[i] != ErrBlockSizeBudgetExceeded || result.Valid.IsSet(uint(i)) {
+			t.Fatalf("Entry %d should have exceeded the budget, got %v", i, result.Errors[i])
+		}
+	}
+
+	// the budget of other channels does not apply
+	v = &Validator{BlockSizeBudgets: map[string]int{"otherchannel": budget}}
+	result, err = v.ValidateBlock(block)
+	if err != nil {
+		t.Fatalf("ValidateBlock failed, err %s", err)
+	}
+
+	if result.Errors[2] != nil || result.Errors[3] != nil {
+		t.Fatalf("Entries should be within the default budget, got %v", result.Errors)
+	}
+}
+
+func TestValidateRawBlock(t *testing.T) {
+	blockBytes, err := utils.Marshal(getBlock(t))
+	if err != nil {
+		t.Fatalf("Marshal failed, err %s", err)
+	}
+
+	results, errs := ValidateRawBlock(blockBytes)
+	if len(results) != 3 || len(errs) != 3 {
+		t.Fatalf("Expected 3 results and errors, got %d and %d", len(results), len(errs))
+	}
+
+	if errs[0] != nil || errs[1] == nil || errs[2] != nil {
+		t.Fatalf("Unexpected errors %v", errs)
+	}
+
+	if results[0].Payload == nil || results[2].Payload == nil {
+		t.Fatalf("Expected the payloads of the valid transactions")
+	}
+
+	tests := []struct {
+		name       string
+		blockBytes []byte
+	}{
+		{"Nil", nil},
+		{"Corrupt", []byte("garbage")},
+		{"Truncated", blockBytes[:len(blockBytes)/2]},
+		{"NoHeader", utils.MarshalOrPanic(&common.Block{Data: &common.BlockData{}})},
+	}
+
+	for _, test := range tests {
+		results, errs := ValidateRawBlock(test.blockBytes)
+		if results != nil || len(errs) != 1 || errs[0] == nil {
+			t.Fatalf("%s: expected a single error, got results %v and errors %v", test.name, results, errs)
+		}
+	}
+
+	_, errs = ValidateRawBlock([]byte("garbage"))
+	if GetErrorClass(errs[0]) != PermanentError {
+		t.Fatalf("Expected a permanent error for corrupt block bytes, got %v", errs[0])
+	}
+}
@@ -0,0 +1,111 @@
+/*
+Copyright IBM Corp. 2017 All Rights Reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+		 http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package validation
+
+import (
+	"fmt"
+
+	"golang.org/x/net/context"
+)
+
+// Names of the caches of the validator, as reported in dry-run mode
+const (
+	// CryptoConfigCache caches the crypto configs of the channels fetched
+	// from the ChannelCryptoConfigProvider, keyed by channel ID
+	CryptoConfigCache = "cryptoconfig"
+
+	// SequenceCache holds the last sequence numbers recorded by the
+	// SequenceTracker, keyed by channel ID and creator
+	SequenceCache = "sequence"
+)
+
+// CacheOperationKind is the kind of an operation on a cache
+type CacheOperationKind int
+
+const (
+	// CacheHit is a lookup that found an entry
+	CacheHit CacheOperationKind = iota
+
+	// CacheMiss is a lookup that found no entry, and that the cache is not
+	// populated for, e.g. because the value is not available yet
+	CacheMiss
+
+	// CachePopulate is an entry being added or replaced
+	CachePopulate
+)
+
+func (k CacheOperationKind) String() string {
+	switch k {
+	case CacheHit:
+		return "hit"
+	case CacheMiss:
+		return "miss"
+	case CachePopulate:
+		return "populate"
+	default:
+		return fmt.Sprintf("unknown(%d)", int(k))
+	}
+}
+
+// CacheOperation is an operation performed on a cache of the validator,
+// or that would have been in dry-run mode
+type CacheOperation struct {
+	// Cache is the name of the cache, e.g. CryptoConfigCache
+	Cache string
+
+	// Key identifies the entry of the cache
+	Key string
+
+	// Kind is the kind of the operation
+	Kind CacheOperationKind
+}
+
+// cacheReport records the cache operations of a validation
+type cacheReport struct {
+	operations []CacheOperation
+}
+
+// cacheReportKey is the key of the cache report in contexts
+type cacheReportKey struct{}
+
+// withCacheReport returns a context holding a new cache report, if the
+// validator runs in dry-run mode, along with the report
+func (v *Validator) withCacheReport(ctx context.Context) (context.Context, *cacheReport) {
+	if !v.DryRun {
+		return ctx, nil
+	}
+
+	report := &cacheReport{}
+	return context.WithValue(ctx, cacheReportKey{}, report), report
+}
+
+// reportCacheOperation records a cache operation in the report held by the
+// context, if any
+func reportCacheOperation(ctx context.Context, cache, key string, kind CacheOperationKind) {
+	if report, ok := ctx.Value(cacheReportKey{}).(*cacheReport); ok {
+		report.operations = append(report.operations, CacheOperation{Cache: cache, Key: key, Kind: kind})
+	}
+}
+
+// getOperations returns the operations recorded, if any
+func (r *cacheReport) getOperations() []CacheOperation {
+	if r == nil {
+		return nil
+	}
+
+	return r.operations
+}
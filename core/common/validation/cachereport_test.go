@@ -0,0 +1,127 @@
+/*
+Copyright IBM Corp. 2017 All Rights Reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+		 http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package validation
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/hyperledger/fabric/common/util"
+)
+
+// cacheOperationKinds returns the kinds of the operations on the given cache
+func cacheOperationKinds(result *ValidationResult, cache string) []CacheOperationKind {
+	var kinds []CacheOperationKind
+	for _, op := range result.CacheOperations {
+		if op.Cache == cache {
+			kinds = append(kinds, op.Kind)
+		}
+	}
+	return kinds
+}
+
+// allKinds tells whether the kinds are all the given one, and there are some
+func allKinds(kinds []CacheOperationKind, kind CacheOperationKind) bool {
+	for _, k := range kinds {
+		if k != kind {
+			return false
+		}
+	}
+	return len(kinds) != 0
+}
+
+func TestDryRun(t *testing.T) {
+	chainID := util.GetTestChainID()
+	provider := &mockCryptoConfigProvider{
+		configs: map[string]*ChannelCryptoConfig{chainID: {}},
+		lookups: make(map[string]int),
+	}
+
+	// nothing is cached in dry-run mode
+	v := &Validator{ChannelCryptoConfigProvider: provider, SequenceTracker: NewSequenceTracker(), DryRun: true}
+	for i := 0; i < 2; i++ {
+		result, err := v.Validate(getSequencedTransaction(t, 1))
+		if err != nil {
+			t.Fatalf("Validate failed, err %s", err)
+		}
+		if kinds := cacheOperationKinds(result, CryptoConfigCache); !allKinds(kinds, CachePopulate) {
+			t.Fatalf("Expected the crypto config to be populated, got %v", kinds)
+		}
+		if kinds := cacheOperationKinds(result, SequenceCache); !reflect.DeepEqual(kinds, []CacheOperationKind{CacheMiss, CachePopulate}) {
+			t.Fatalf("Expected the sequence to be populated, got %v", kinds)
+		}
+		if result.CacheOperations[0].Key != chainID {
+			t.Fatalf("Expected the crypto config of chain %s, got %s", chainID, result.CacheOperations[0].Key)
+		}
+	}
+	if _, ok := v.cryptoConfigCache.configs[chainID]; ok {
+		t.Fatalf("The crypto config should not have been cached")
+	}
+	if provider.lookups[chainID] < 2 {
+		t.Fatalf("The crypto config should have been fetched on each lookup, got %d lookups", provider.lookups[chainID])
+	}
+
+	// the caches are populated outside of dry-run mode, without reports
+	v = &Validator{ChannelCryptoConfigProvider: provider, SequenceTracker: NewSequenceTracker()}
+	result, err := v.Validate(getSequencedTransaction(t, 1))
+	if err != nil {
+		t.Fatalf("Validate failed, err %s", err)
+	}
+	if result.CacheOperations != nil {
+		t.Fatalf("No cache operation should be reported, got %v", result.CacheOperations)
+	}
+
+	// and then hit in dry-run mode
+	v.DryRun = true
+	result, err = v.Validate(getSequencedTransaction(t, 2))
+	if err != nil {
+		t.Fatalf("Validate failed, err %s", err)
+	}
+	if kinds := cacheOperationKinds(result, CryptoConfigCache); !allKinds(kinds, CacheHit) {
+		t.Fatalf("Expected the crypto config to be hit, got %v", kinds)
+	}
+	if kinds := cacheOperationKinds(result, SequenceCache); !reflect.DeepEqual(kinds, []CacheOperationKind{CacheHit, CachePopulate}) {
+		t.Fatalf("Expected the sequence to be hit and populated, got %v", kinds)
+	}
+
+	// the sequence number 2 was not recorded
+	result, err = v.Validate(getSequencedTransaction(t, 2))
+	if err != nil {
+		t.Fatalf("Validate failed, err %s", err)
+	}
+	result, err = v.Validate(getSequencedTransaction(t, 1))
+	if err != ErrSequenceOutOfOrder {
+		t.Fatalf("Expected ErrSequenceOutOfOrder, got %v", err)
+	}
+	if kinds := cacheOperationKinds(result, SequenceCache); !reflect.DeepEqual(kinds, []CacheOperationKind{CacheHit}) {
+		t.Fatalf("Expected the sequence to be hit, got %v", kinds)
+	}
+
+	// the chains unknown to the provider are not cached
+	v = &Validator{ChannelCryptoConfigProvider: &mockCryptoConfigProvider{lookups: make(map[string]int)}, DryRun: true}
+	result, err = v.Validate(getSequencedTransaction(t, 1))
+	if err != nil {
+		t.Fatalf("Validate failed, err %s", err)
+	}
+	if kinds := cacheOperationKinds(result, CryptoConfigCache); !allKinds(kinds, CacheMiss) {
+		t.Fatalf("Expected the crypto config to be missed, got %v", kinds)
+	}
+
+	if CachePopulate.String() != "populate" || CacheOperationKind(-1).String() != "unknown(-1)" {
+		t.Fatalf("Unexpected cache operation kind names")
+	}
+}
@@ -0,0 +1,95 @@
+/*
+Copyright IBM Corp. 2017 All Rights Reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+		 http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package validation
+
+import (
+	"bytes"
+	"errors"
+
+	"github.com/golang/protobuf/proto"
+	"github.com/hyperledger/fabric/protos/common"
+	pb "github.com/hyperledger/fabric/protos/peer"
+	"github.com/hyperledger/fabric/protos/utils"
+)
+
+// ErrNonCanonicalPayload is returned in strict mode when the payload of an
+// envelope is not encoded as re-marshalling its decoded form would encode it
+var ErrNonCanonicalPayload = errors.New("Non-canonical payload encoding")
+
+// ErrNonCanonicalArgs is returned in strict mode when the chaincode
+// invocation spec of a proposal is not encoded as re-marshalling its decoded
+// form would encode it
+var ErrNonCanonicalArgs = errors.New("Non-canonical chaincode invocation spec encoding")
+
+// checkCanonicalPayload ensures that re-marshalling the decoded payload
+// yields the exact bytes it was decoded from, i.e. those covered by the
+// signature of the envelope once decompressed. Different
+// encodings of the same payload, or unknown fields smuggled in it, would
+// otherwise all be accepted under their own signatures. Note that protobuf
+// does not guarantee a canonical encoding: payloads produced by another
+// implementation or version of protobuf may legitimately fail this check,
+// which is why it is only performed if StrictPayloadEncoding is set
+func checkCanonicalPayload(raw []byte, payload *common.Payload) error {
+	remarshalled, err := proto.Marshal(payload)
+	if err != nil {
+		putilsLogger.Errorf("Could not re-marshal the payload, err %s", err)
+		return ErrNonCanonicalPayload
+	}
+
+	if !bytes.Equal(remarshalled, raw) {
+		putilsLogger.Errorf("Re-marshalled payload differs from the signed bytes, got %d bytes, expected %d", len(remarshalled), len(raw))
+		return ErrNonCanonicalPayload
+	}
+
+	return nil
+}
+
+// checkCanonicalArgs ensures that re-marshalling the chaincode invocation
+// spec of a serialized chaincode proposal payload, if any, yields the exact
+// bytes covered by the proposal hash, so that the same invocation cannot
+// be proposed under several encodings, e.g. with reordered or unknown
+// fields. As for payloads, protobuf does not guarantee a canonical
+// encoding, which is why this is only checked if StrictArgsEncoding is set
+func checkCanonicalArgs(cppBytes []byte) error {
+	cpp, err := utils.GetChaincodeProposalPayload(cppBytes)
+	if err != nil {
+		return permanentDecodeError(err)
+	}
+
+	if len(cpp.Input) == 0 {
+		return nil
+	}
+
+	cis := &pb.ChaincodeInvocationSpec{}
+	err = proto.Unmarshal(cpp.Input, cis)
+	if err != nil {
+		return permanentDecodeError(err)
+	}
+
+	remarshalled, err := proto.Marshal(cis)
+	if err != nil {
+		putilsLogger.Errorf("Could not re-marshal the chaincode invocation spec, err %s", err)
+		return ErrNonCanonicalArgs
+	}
+
+	if !bytes.Equal(remarshalled, cpp.Input) {
+		putilsLogger.Errorf("Re-marshalled chaincode invocation spec differs from the proposed bytes, got %d bytes, expected %d", len(remarshalled), len(cpp.Input))
+		return ErrNonCanonicalArgs
+	}
+
+	return nil
+}
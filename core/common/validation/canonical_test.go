@@ -0,0 +1,158 @@
+/*
+Copyright IBM Corp. 2017 All Rights Reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+		 http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package validation
+
+import (
+	"testing"
+
+	"github.com/golang/protobuf/proto"
+	"github.com/hyperledger/fabric/protos/common"
+	"github.com/hyperledger/fabric/protos/peer"
+	"github.com/hyperledger/fabric/protos/utils"
+)
+
+// appendUnknownField appends an unknown field to the payload of the
+// envelope, which is dropped when decoding it
+func appendUnknownField(t *testing.T, e *common.Envelope) *common.Envelope {
+	buf := proto.NewBuffer(append([]byte(nil), e.Payload...))
+	buf.EncodeVarint(15<<3 | proto.WireBytes)
+	buf.EncodeRawBytes([]byte("smuggled"))
+
+	sig, err := signer.Sign(buf.Bytes())
+	if err != nil {
+		t.Fatalf("Sign failed, err %s", err)
+	}
+
+	return &common.Envelope{Payload: buf.Bytes(), Signature: sig}
+}
+
+func TestStrictPayloadEncoding(t *testing.T) {
+	tx, err := getTransaction([]byte("simulation_result"))
+	if err != nil {
+		t.Fatalf("getTransaction failed, err %s", err)
+	}
+
+	tests := []struct {
+		name      string
+		tx        *common.Envelope
+		canonical bool
+	}{
+		{"Canonical", tx, true},
+		{"Reordered", reorderPayload(t, tx), false},
+		{"UnknownField", appendUnknownField(t, tx), false},
+	}
+
+	for _, test := range tests {
+		// the payloads are accepted unless the check is enabled
+		_, err = (&Validator{}).ValidateTransaction(test.tx)
+		if err != nil {
+			t.Fatalf("%s: ValidateTransaction failed, err %s", test.name, err)
+		}
+
+		_, err = (&Validator{StrictPayloadEncoding: true}).ValidateTransaction(test.tx)
+		if test.canonical && err != nil {
+			t.Fatalf("%s: ValidateTransaction failed in strict mode, err %s", test.name, err)
+		}
+		if !test.canonical && err != ErrNonCanonicalPayload {
+			t.Fatalf("%s: ValidateTransaction should have failed with ErrNonCanonicalPayload, got %v", test.name, err)
+		}
+	}
+}
+
+// getProposalWithInput returns a toy proposal whose chaincode proposal
+// payload holds the given serialized invocation spec
+func getProposalWithInput(t *testing.T, input []byte) *peer.Proposal {
+	prop, err := getProposal()
+	if err != nil {
+		t.Fatalf("getProposal failed, err %s", err)
+	}
+
+	cpp, err := utils.GetChaincodeProposalPayload(prop.Payload)
+	if err != nil {
+		t.Fatalf("GetChaincodeProposalPayload failed, err %s", err)
+	}
+	cpp.Input = input
+	prop.Payload = utils.MarshalOrPanic(cpp)
+
+	return prop
+}
+
+func TestStrictArgsEncoding(t *testing.T) {
+	spec := &peer.ChaincodeSpec{
+		Type:        peer.ChaincodeSpec_GOLANG,
+		ChaincodeId: &peer.ChaincodeID{Name: "foo"},
+		Input:       &peer.ChaincodeInput{Args: [][]byte{[]byte("invoke"), []byte("a"), []byte("b")}}}
+	canonical := utils.MarshalOrPanic(&peer.ChaincodeInvocationSpec{ChaincodeSpec: spec})
+
+	// the fields of the spec encoded in reverse order
+	reorderedSpec := append(utils.MarshalOrPanic(&peer.ChaincodeSpec{Input: spec.Input}), utils.MarshalOrPanic(&peer.ChaincodeSpec{ChaincodeId: spec.ChaincodeId})...)
+	reorderedSpec = append(reorderedSpec, utils.MarshalOrPanic(&peer.ChaincodeSpec{Type: spec.Type})...)
+	reordered := proto.NewBuffer(nil)
+	reordered.EncodeVarint(1<<3 | proto.WireBytes)
+	reordered.EncodeRawBytes(reorderedSpec)
+
+	unknown := proto.NewBuffer(append([]byte(nil), canonical...))
+	unknown.EncodeVarint(15<<3 | proto.WireBytes)
+	unknown.EncodeRawBytes([]byte("smuggled"))
+
+	tests := []struct {
+		name      string
+		input     []byte
+		canonical bool
+	}{
+		{"Canonical", canonical, true},
+		{"Reordered", reordered.Bytes(), false},
+		{"UnknownField", unknown.Bytes(), false},
+	}
+
+	for _, test := range tests {
+		prop := getProposalWithInput(t, test.input)
+		sProp, err := utils.GetSignedProposal(prop, signer)
+		if err != nil {
+			t.Fatalf("%s: GetSignedProposal failed, err %s", test.name, err)
+		}
+		tx, err := getTransactionForProposal(prop, []byte("simulation_result"))
+		if err != nil {
+			t.Fatalf("%s: getTransactionForProposal failed, err %s", test.name, err)
+		}
+
+		// the encodings are accepted unless the check is enabled
+		_, _, _, err = (&Validator{}).ValidateProposalMessage(sProp)
+		if err != nil {
+			t.Fatalf("%s: ValidateProposalMessage failed, err %s", test.name, err)
+		}
+		_, err = (&Validator{}).ValidateTransaction(tx)
+		if err != nil {
+			t.Fatalf("%s: ValidateTransaction failed, err %s", test.name, err)
+		}
+
+		var expected error
+		if !test.canonical {
+			expected = ErrNonCanonicalArgs
+		}
+
+		v := &Validator{StrictArgsEncoding: true}
+		_, _, _, err = v.ValidateProposalMessage(sProp)
+		if err != expected {
+			t.Fatalf("%s: expected err %v validating the proposal in strict mode, got %v", test.name, expected, err)
+		}
+		_, err = v.ValidateTransaction(tx)
+		if err != expected {
+			t.Fatalf("%s: expected err %v validating the transaction in strict mode, got %v", test.name, expected, err)
+		}
+	}
+}
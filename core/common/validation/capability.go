@@ -0,0 +1,60 @@
+/*
+Copyright IBM Corp. 2017 All Rights Reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+		 http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package validation
+
+import (
+	"errors"
+
+	"github.com/hyperledger/fabric/protos/common"
+)
+
+// ErrCapabilityExceeded is recorded for the transactions of a block that
+// require a capability level above the one the block declares
+var ErrCapabilityExceeded = errors.New("The transaction requires a capability level above that of the block")
+
+// RequiredCapability returns the capability level a transaction requires,
+// which is the version of its channel header: clients set it to the level
+// of the features the transaction relies on
+func RequiredCapability(payload *common.Payload) int32 {
+	if payload == nil || payload.Header == nil || payload.Header.ChannelHeader == nil {
+		return 0
+	}
+
+	return payload.Header.ChannelHeader.Version
+}
+
+// ValidateBlockWithCapability validates the block with the default
+// validator, see Validator.ValidateBlockWithCapability
+func ValidateBlockWithCapability(block *common.Block, capabilityLevel int32) (*BlockValidationResult, error) {
+	return defaultValidator.ValidateBlockWithCapability(block, capabilityLevel)
+}
+
+// ValidateBlockWithCapability validates the block as ValidateBlock does, and
+// additionally rejects with ErrCapabilityExceeded the transactions requiring
+// a capability level above the one declared for the block, see
+// RequiredCapability, so that no transaction relies on a capability that
+// not all the peers have enabled yet during an upgrade
+func (v *Validator) ValidateBlockWithCapability(block *common.Block, capabilityLevel int32) (*BlockValidationResult, error) {
+	return v.validateBlock(block, func(result *ValidationResult) error {
+		if required := RequiredCapability(result.Payload); required > capabilityLevel {
+			putilsLogger.Warningf("Transaction [%s] requires capability level %d, the block declares %d", result.Payload.Header.ChannelHeader.TxId, required, capabilityLevel)
+			return ErrCapabilityExceeded
+		}
+
+		return nil
+	})
+}
@@ -0,0 +1,96 @@
+/*
+Copyright IBM Corp. 2017 All Rights Reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+		 http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package validation
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/hyperledger/fabric/protos/common"
+	"github.com/hyperledger/fabric/protos/utils"
+)
+
+// getTransactionAtVersion returns a signed transaction for a toy proposal
+// whose channel header has the given version
+func getTransactionAtVersion(t *testing.T, version int32) []byte {
+	prop, err := getProposal()
+	if err != nil {
+		t.Fatalf("getProposal failed, err %s", err)
+	}
+
+	hdr, err := utils.GetHeader(prop.Header)
+	if err != nil {
+		t.Fatalf("GetHeader failed, err %s", err)
+	}
+	hdr.ChannelHeader.Version = version
+	prop.Header = utils.MarshalOrPanic(hdr)
+
+	tx, err := getTransactionForProposal(prop, []byte("simulation_result"))
+	if err != nil {
+		t.Fatalf("getTransactionForProposal failed, err %s", err)
+	}
+
+	return utils.MarshalOrPanic(tx)
+}
+
+func TestValidateBlockWithCapability(t *testing.T) {
+	block := common.NewBlock(1, []byte("previous_hash"))
+	for _, version := range []int32{0, 2, 1, 3} {
+		block.Data.Data = append(block.Data.Data, getTransactionAtVersion(t, version))
+	}
+	// an invalid transaction keeps its own error
+	block.Data.Data = append(block.Data.Data, []byte("garbage"))
+	block.Header.DataHash = block.Data.Hash()
+
+	result, err := ValidateBlockWithCapability(block, 1)
+	if err != nil {
+		t.Fatalf("ValidateBlockWithCapability failed, err %s", err)
+	}
+
+	expected := []error{nil, ErrCapabilityExceeded, nil, ErrCapabilityExceeded, ErrUndecodable}
+	for i, err := range expected {
+		if result.Errors[i] != err {
+			t.Fatalf("Expected err %v for transaction %d, got %v", err, i, result.Errors[i])
+		}
+		if result.Valid.IsSet(uint(i)) != (err == nil) {
+			t.Fatalf("Unexpected validity of transaction %d in bitmap %x", i, result.Valid.ToBytes())
+		}
+	}
+
+	commitment, err := BlockValidityCommitment(block, result.Valid)
+	if err != nil {
+		t.Fatalf("BlockValidityCommitment failed, err %s", err)
+	}
+	if !bytes.Equal(result.Commitment, commitment) {
+		t.Fatalf("The commitment should cover the rejected transactions")
+	}
+
+	// all the transactions are compatible with a high enough level
+	result, err = ValidateBlockWithCapability(block, 3)
+	if err != nil {
+		t.Fatalf("ValidateBlockWithCapability failed, err %s", err)
+	}
+	for i := 0; i < 4; i++ {
+		if result.Errors[i] != nil {
+			t.Fatalf("Transaction %d should be valid, got %v", i, result.Errors[i])
+		}
+	}
+
+	if _, err = ValidateBlockWithCapability(nil, 1); err == nil {
+		t.Fatalf("ValidateBlockWithCapability should have failed for a nil block")
+	}
+}
@@ -0,0 +1,98 @@
+/*
+Copyright IBM Corp. 2017 All Rights Reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+		 http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package validation
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/golang/protobuf/proto"
+	"github.com/hyperledger/fabric/protos/common"
+	pb "github.com/hyperledger/fabric/protos/peer"
+	"github.com/hyperledger/fabric/protos/utils"
+)
+
+// ErrChaincodeMismatch is returned when the chaincode an action was endorsed
+// for is not the chaincode the proposal of the transaction invoked
+var ErrChaincodeMismatch = errors.New("The endorsed chaincode does not match the proposed chaincode")
+
+// getInvokedChaincode returns the ID of the chaincode invoked by an
+// endorser transaction, as named in its chaincode header extension
+func getInvokedChaincode(hdr *common.Header) (*pb.ChaincodeID, error) {
+	hdrExt, err := utils.GetChaincodeHeaderExtension(hdr)
+	if err != nil {
+		return nil, permanentDecodeError(err)
+	}
+
+	if hdrExt.ChaincodeId == nil || hdrExt.ChaincodeId.Name == "" {
+		return nil, fmt.Errorf("Missing chaincode ID in the chaincode header extension")
+	}
+
+	return hdrExt.ChaincodeId, nil
+}
+
+// getInvocationSpec returns the chaincode invocation spec held by a
+// serialized chaincode proposal payload, or nil if it holds none
+func getInvocationSpec(cppBytes []byte) (*pb.ChaincodeInvocationSpec, error) {
+	cpp, err := utils.GetChaincodeProposalPayload(cppBytes)
+	if err != nil {
+		return nil, permanentDecodeError(err)
+	}
+
+	return getPayloadInvocationSpec(cpp)
+}
+
+// getPayloadInvocationSpec returns the chaincode invocation spec held by a
+// chaincode proposal payload, or nil if it holds none
+func getPayloadInvocationSpec(cpp *pb.ChaincodeProposalPayload) (*pb.ChaincodeInvocationSpec, error) {
+	if len(cpp.Input) == 0 {
+		return nil, nil
+	}
+
+	cis := &pb.ChaincodeInvocationSpec{}
+	err := proto.Unmarshal(cpp.Input, cis)
+	if err != nil {
+		return nil, permanentDecodeError(fmt.Errorf("Could not unmarshal the chaincode invocation spec, err %s", err))
+	}
+
+	return cis, nil
+}
+
+// checkActionChaincode ensures that an action is about the chaincode named
+// in the chaincode header extension: the chaincode invocation spec in its
+// proposal payload, if any, must name that chaincode, and so must the
+// chaincode event of its chaincode action, if any and if it names one. The
+// chaincode action does not otherwise name the chaincode that produced it
+func checkActionChaincode(ccName string, cis *pb.ChaincodeInvocationSpec, ca *pb.ChaincodeAction) error {
+	if cis != nil && cis.ChaincodeSpec != nil && cis.ChaincodeSpec.ChaincodeId != nil && cis.ChaincodeSpec.ChaincodeId.Name != ccName {
+		putilsLogger.Errorf("checkActionChaincode error: proposal payload invokes chaincode %s, header names chaincode %s", cis.ChaincodeSpec.ChaincodeId.Name, ccName)
+		return ErrChaincodeMismatch
+	}
+
+	// the events are opaque to validation, those that cannot be decoded
+	// are left to their consumers
+	if len(ca.Events) != 0 {
+		event := &pb.ChaincodeEvent{}
+		err := proto.Unmarshal(ca.Events, event)
+		if err == nil && event.ChaincodeId != "" && event.ChaincodeId != ccName {
+			putilsLogger.Errorf("checkActionChaincode error: event emitted by chaincode %s, header names chaincode %s", event.ChaincodeId, ccName)
+			return ErrChaincodeMismatch
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,95 @@
+/*
+Copyright IBM Corp. 2017 All Rights Reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+		 http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package validation
+
+import (
+	"testing"
+
+	"github.com/hyperledger/fabric/protos/common"
+	"github.com/hyperledger/fabric/protos/peer"
+	"github.com/hyperledger/fabric/protos/utils"
+)
+
+// modifyInvokedChaincode sets the chaincode invoked by the proposal payload
+// of the action of the transaction, updating the proposal hash accordingly
+func modifyInvokedChaincode(t *testing.T, tx *common.Envelope, ccName string) (*common.Envelope, error) {
+	payload, err := utils.GetPayload(tx)
+	if err != nil {
+		t.Fatalf("GetPayload failed, err %s", err)
+	}
+
+	return modifyTransaction(tx, func(sHdr *common.SignatureHeader, cap *peer.ChaincodeActionPayload, prp *peer.ProposalResponsePayload) {
+		cpp, err := utils.GetChaincodeProposalPayload(cap.ChaincodeProposalPayload)
+		if err != nil {
+			t.Fatalf("GetChaincodeProposalPayload failed, err %s", err)
+		}
+
+		cis := &peer.ChaincodeInvocationSpec{ChaincodeSpec: &peer.ChaincodeSpec{ChaincodeId: &peer.ChaincodeID{Name: ccName}}}
+		cpp.Input = utils.MarshalOrPanic(cis)
+		cap.ChaincodeProposalPayload = utils.MarshalOrPanic(cpp)
+
+		hdrBytes := utils.MarshalOrPanic(&common.Header{ChannelHeader: payload.Header.ChannelHeader, SignatureHeader: sHdr})
+		prp.ProposalHash, err = utils.GetProposalHash2(hdrBytes, cap.ChaincodeProposalPayload)
+		if err != nil {
+			t.Fatalf("GetProposalHash2 failed, err %s", err)
+		}
+	})
+}
+
+func TestActionChaincode(t *testing.T) {
+	eventFrom := func(ccName string) func(string) []byte {
+		return func(txID string) []byte {
+			return utils.MarshalOrPanic(&peer.ChaincodeEvent{ChaincodeId: ccName, TxId: txID, EventName: "transfer"})
+		}
+	}
+
+	tx, err := getTransaction([]byte("simulation_result"))
+	if err != nil {
+		t.Fatalf("getTransaction failed, err %s", err)
+	}
+	sameTx, err := modifyInvokedChaincode(t, tx, "foo")
+	if err != nil {
+		t.Fatalf("modifyInvokedChaincode failed, err %s", err)
+	}
+	otherTx, err := modifyInvokedChaincode(t, tx, "bar")
+	if err != nil {
+		t.Fatalf("modifyInvokedChaincode failed, err %s", err)
+	}
+	sameEventTx, _ := getTransactionWithEvents(t, eventFrom("foo"))
+	unnamedEventTx, _ := getTransactionWithEvents(t, eventFrom(""))
+	otherEventTx, _ := getTransactionWithEvents(t, eventFrom("bar"))
+
+	tests := []struct {
+		name string
+		tx   *common.Envelope
+		err  error
+	}{
+		{"Matching", tx, nil},
+		{"MatchingProposalPayload", sameTx, nil},
+		{"MismatchedProposalPayload", otherTx, ErrChaincodeMismatch},
+		{"MatchingEvent", sameEventTx, nil},
+		{"UnnamedEvent", unnamedEventTx, nil},
+		{"MismatchedEvent", otherEventTx, ErrChaincodeMismatch},
+	}
+
+	for _, test := range tests {
+		_, err := ValidateTransaction(test.tx)
+		if err != test.err {
+			t.Fatalf("%s: expected err %v, got %v", test.name, test.err, err)
+		}
+	}
+}
@@ -0,0 +1,107 @@
+/*
+Copyright IBM Corp. 2017 All Rights Reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+		 http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package validation
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/hyperledger/fabric/core/common/ccprovider"
+	"github.com/hyperledger/fabric/protos/common"
+	pb "github.com/hyperledger/fabric/protos/peer"
+	"github.com/hyperledger/fabric/protos/utils"
+)
+
+// ErrChaincodeVersionUnknown is returned when the version of the chaincode
+// a transaction was endorsed against is no longer known to the channel
+var ErrChaincodeVersionUnknown = errors.New("Unknown chaincode version")
+
+// ErrChaincodeVersionMismatch is returned when an action invokes another
+// version of the chaincode than the transaction was endorsed against
+var ErrChaincodeVersionMismatch = errors.New("The invoked chaincode version does not match the endorsed version")
+
+// ChaincodeDefinitionProvider provides the definitions of the versions of
+// the chaincodes of a channel, including those since upgraded
+type ChaincodeDefinitionProvider interface {
+	// GetChaincodeDefinition returns the definition of the given version of
+	// the chaincode on the given chain, as stored by LCCC, or nil if that
+	// version is not known
+	GetChaincodeDefinition(chainID, chaincodeName, version string) (*ccprovider.ChaincodeData, error)
+}
+
+// getPinnedChaincodeDefinition returns the definition of the chaincode a
+// transaction invokes, in the version it was endorsed against as named in
+// its chaincode header extension: a transaction endorsed before an upgrade
+// is validated against the definition it was endorsed with, not the latest
+func (v *Validator) getPinnedChaincodeDefinition(hdr *common.Header) (*ccprovider.ChaincodeData, error) {
+	hdrExt, err := utils.GetChaincodeHeaderExtension(hdr)
+	if err != nil {
+		return nil, permanentDecodeError(err)
+	}
+
+	ccID := hdrExt.ChaincodeId
+	if ccID == nil || ccID.Name == "" {
+		return nil, fmt.Errorf("Missing chaincode ID in the chaincode header extension")
+	}
+
+	if ccID.Version == "" {
+		return nil, fmt.Errorf("Missing version of chaincode %s in the chaincode header extension", ccID.Name)
+	}
+
+	return v.getChaincodeDefinition(hdr.ChannelHeader.ChannelId, ccID.Name, ccID.Version)
+}
+
+// getChaincodeDefinition returns the definition of the given version of the
+// chaincode, which must be known on the chain
+func (v *Validator) getChaincodeDefinition(chainID, name, version string) (*ccprovider.ChaincodeData, error) {
+	def, err := v.ChaincodeDefinitionProvider.GetChaincodeDefinition(chainID, name, version)
+	if err != nil {
+		return nil, fmt.Errorf("Could not get the definition of chaincode %s:%s, err %s", name, version, err)
+	}
+
+	if def == nil {
+		putilsLogger.Errorf("Chaincode %s:%s is not known on chain [%s]", name, version, chainID)
+		return nil, ErrChaincodeVersionUnknown
+	}
+
+	if def.Name != name || def.Version != version {
+		return nil, fmt.Errorf("Definition of chaincode %s:%s supplied for chaincode %s:%s", def.Name, def.Version, name, version)
+	}
+
+	return def, nil
+}
+
+// checkActionChaincodeVersion ensures that the version of the chaincode
+// invoked by an action, as named in its chaincode invocation spec, is the
+// version named in the chaincode header extension, and that it is known on
+// the chain. Actions naming no version invoke that of the header, whose
+// definition is checked once for the transaction by
+// getPinnedChaincodeDefinition
+func (v *Validator) checkActionChaincodeVersion(chainID string, ccID *pb.ChaincodeID, cis *pb.ChaincodeInvocationSpec) error {
+	if cis == nil || cis.ChaincodeSpec == nil || cis.ChaincodeSpec.ChaincodeId == nil || cis.ChaincodeSpec.ChaincodeId.Version == "" {
+		return nil
+	}
+
+	version := cis.ChaincodeSpec.ChaincodeId.Version
+	if version != ccID.Version {
+		putilsLogger.Errorf("checkActionChaincodeVersion error: action invokes chaincode %s:%s, endorsed against version %s", ccID.Name, version, ccID.Version)
+		return ErrChaincodeVersionMismatch
+	}
+
+	_, err := v.getChaincodeDefinition(chainID, ccID.Name, version)
+	return err
+}
@@ -0,0 +1,188 @@
+/*
+Copyright IBM Corp. 2017 All Rights Reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+		 http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package validation
+
+import (
+	"testing"
+
+	"github.com/hyperledger/fabric/bccsp"
+	"github.com/hyperledger/fabric/bccsp/factory"
+	"github.com/hyperledger/fabric/common/cauthdsl"
+	"github.com/hyperledger/fabric/common/util"
+	"github.com/hyperledger/fabric/core/common/ccprovider"
+	"github.com/hyperledger/fabric/protos/common"
+	"github.com/hyperledger/fabric/protos/peer"
+	"github.com/hyperledger/fabric/protos/utils"
+)
+
+// mockDefinitionProvider maps chaincode versions to their definitions
+type mockDefinitionProvider map[string]*ccprovider.ChaincodeData
+
+func (m mockDefinitionProvider) GetChaincodeDefinition(chainID, chaincodeName, version string) (*ccprovider.ChaincodeData, error) {
+	return m[chaincodeName+":"+version], nil
+}
+
+// getVersionedTransaction returns a signed transaction endorsed against
+// the given version of chaincode foo
+func getVersionedTransaction(t *testing.T, version string) *common.Envelope {
+	cis := &peer.ChaincodeInvocationSpec{
+		ChaincodeSpec: &peer.ChaincodeSpec{
+			ChaincodeId: &peer.ChaincodeID{Name: "foo", Version: version},
+			Type:        peer.ChaincodeSpec_GOLANG}}
+
+	prop, _, err := utils.CreateProposalFromCIS(common.HeaderType_ENDORSER_TRANSACTION, util.GetTestChainID(), cis, signerSerialized)
+	if err != nil {
+		t.Fatalf("CreateProposalFromCIS failed, err %s", err)
+	}
+
+	tx, err := getTransactionForProposal(prop, []byte("simulation_result"))
+	if err != nil {
+		t.Fatalf("getTransactionForProposal failed, err %s", err)
+	}
+
+	return tx
+}
+
+func TestChaincodeVersionPinning(t *testing.T) {
+	v1 := &ccprovider.ChaincodeData{Name: "foo", Version: "1.0", Policy: utils.MarshalOrPanic(cauthdsl.SignedByMspMember("DEFAULT"))}
+	v2 := &ccprovider.ChaincodeData{Name: "foo", Version: "2.0", Policy: utils.MarshalOrPanic(cauthdsl.AcceptAllPolicy)}
+
+	// foo was upgraded from 1.0 to 2.0 between endorsement and commit
+	upgrading := mockDefinitionProvider{"foo:1.0": v1, "foo:2.0": v2}
+	// 1.0 was removed once the upgrade completed
+	upgraded := mockDefinitionProvider{"foo:2.0": v2}
+
+	tests := []struct {
+		name       string
+		version    string
+		provider   ChaincodeDefinitionProvider
+		definition *ccprovider.ChaincodeData
+		err        error
+	}{
+		{"EndorsedBeforeUpgrade", "1.0", upgrading, v1, nil},
+		{"EndorsedAfterUpgrade", "2.0", upgrading, v2, nil},
+		{"VersionRemoved", "1.0", upgraded, nil, ErrChaincodeVersionUnknown},
+	}
+
+	for _, test := range tests {
+		v := &Validator{ChaincodeDefinitionProvider: test.provider}
+		result, err := v.Validate(getVersionedTransaction(t, test.version))
+		if err != test.err {
+			t.Fatalf("%s: expected err %v, got %v", test.name, test.err, err)
+		}
+		if result.ChaincodeDefinition != test.definition {
+			t.Fatalf("%s: expected definition %v, got %v", test.name, test.definition, result.ChaincodeDefinition)
+		}
+	}
+
+	// transactions naming no version cannot be pinned
+	v := &Validator{ChaincodeDefinitionProvider: upgrading}
+	_, err := v.Validate(getVersionedTransaction(t, ""))
+	if err == nil {
+		t.Fatalf("Validate should have failed")
+	}
+
+	// no pinning by default
+	_, err = (&Validator{}).Validate(getVersionedTransaction(t, "1.0"))
+	if err != nil {
+		t.Fatalf("Validate failed, err %s", err)
+	}
+}
+
+func TestPinnedPolicyDigest(t *testing.T) {
+	v1 := &ccprovider.ChaincodeData{Name: "foo", Version: "1.0", Policy: utils.MarshalOrPanic(cauthdsl.SignedByMspMember("DEFAULT"))}
+	v2 := &ccprovider.ChaincodeData{Name: "foo", Version: "2.0", Policy: utils.MarshalOrPanic(cauthdsl.AcceptAllPolicy)}
+
+	digest, err := factory.GetDefault().Hash(v1.Policy, &bccsp.SHA256Opts{})
+	if err != nil {
+		t.Fatalf("Hash failed, err %s", err)
+	}
+
+	// the latest policy is that of 2.0, but the transaction was endorsed
+	// against 1.0
+	v := &Validator{
+		ChaincodeDefinitionProvider: mockDefinitionProvider{"foo:1.0": v1, "foo:2.0": v2},
+		EndorsementPolicyProvider:   mockPolicyProvider{"foo": v2.Policy},
+	}
+	_, err = v.ValidateTransactionWithPolicyDigest(getVersionedTransaction(t, "1.0"), digest)
+	if err != nil {
+		t.Fatalf("ValidateTransactionWithPolicyDigest failed, err %s", err)
+	}
+
+	_, err = v.ValidateTransactionWithPolicyDigest(getVersionedTransaction(t, "2.0"), digest)
+	if err != ErrPolicyDigestMismatch {
+		t.Fatalf("ValidateTransactionWithPolicyDigest should have failed with ErrPolicyDigestMismatch, got %v", err)
+	}
+}
+
+// getTransactionInvokingVersion returns a signed transaction endorsed
+// against the given version of chaincode foo, whose action invokes the
+// other given version
+func getTransactionInvokingVersion(t *testing.T, endorsed, invoked string) *common.Envelope {
+	cis := &peer.ChaincodeInvocationSpec{
+		ChaincodeSpec: &peer.ChaincodeSpec{
+			ChaincodeId: &peer.ChaincodeID{Name: "foo", Version: endorsed},
+			Type:        peer.ChaincodeSpec_GOLANG}}
+
+	prop, _, err := utils.CreateProposalFromCIS(common.HeaderType_ENDORSER_TRANSACTION, util.GetTestChainID(), cis, signerSerialized)
+	if err != nil {
+		t.Fatalf("CreateProposalFromCIS failed, err %s", err)
+	}
+
+	cis.ChaincodeSpec.ChaincodeId.Version = invoked
+	cpp, err := utils.GetChaincodeProposalPayload(prop.Payload)
+	if err != nil {
+		t.Fatalf("GetChaincodeProposalPayload failed, err %s", err)
+	}
+	cpp.Input = utils.MarshalOrPanic(cis)
+	prop.Payload = utils.MarshalOrPanic(cpp)
+
+	tx, err := getTransactionForProposal(prop, []byte("simulation_result"))
+	if err != nil {
+		t.Fatalf("getTransactionForProposal failed, err %s", err)
+	}
+
+	return tx
+}
+
+func TestActionChaincodeVersion(t *testing.T) {
+	v1 := &ccprovider.ChaincodeData{Name: "foo", Version: "1.0"}
+	v2 := &ccprovider.ChaincodeData{Name: "foo", Version: "2.0"}
+	provider := mockDefinitionProvider{"foo:1.0": v1, "foo:2.0": v2}
+
+	tests := []struct {
+		name     string
+		endorsed string
+		invoked  string
+		provider ChaincodeDefinitionProvider
+		err      error
+	}{
+		{"Matching", "1.0", "1.0", provider, nil},
+		{"NoActionVersion", "2.0", "", provider, nil},
+		{"Mismatched", "1.0", "2.0", provider, ErrChaincodeVersionMismatch},
+		{"NotInstantiated", "3.0", "3.0", provider, ErrChaincodeVersionUnknown},
+		{"NotChecked", "1.0", "2.0", nil, nil},
+	}
+
+	for _, test := range tests {
+		v := &Validator{ChaincodeDefinitionProvider: test.provider}
+		_, err := v.Validate(getTransactionInvokingVersion(t, test.endorsed, test.invoked))
+		if err != test.err {
+			t.Fatalf("%s: expected err %v, got %v", test.name, test.err, err)
+		}
+	}
+}
@@ -0,0 +1,88 @@
+/*
+Copyright IBM Corp. 2017 All Rights Reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+		 http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package validation
+
+import (
+	"crypto/x509"
+	"errors"
+
+	"github.com/hyperledger/fabric/msp"
+	"golang.org/x/net/context"
+)
+
+// ErrCertificateNotYetValid is returned when the certificate of a creator is
+// not valid yet, its NotBefore being in the future
+var ErrCertificateNotYetValid = errors.New("The creator certificate is not valid yet")
+
+// checkCertificateNotBefore ensures that the certificate of the creator, if
+// it has one, is already valid according to the clock of the validator,
+// which may be up to MaxClockSkew behind that of the issuer of the
+// certificate. MSPs are expected to reject such certificates when validating
+// them, but not all do, nor against the same clock. The certificates only
+// valid thanks to the skew are warned about
+func (v *Validator) checkCertificateNotBefore(ctx context.Context, sId *msp.SerializedIdentity, idType IdentityType) error {
+	if !v.RejectNotYetValidCertificates || idType != X509Identity {
+		return nil
+	}
+
+	certs, err := parseCertificateChain(sId.IdBytes)
+	if err != nil {
+		return err
+	}
+
+	if now := v.now(); certs[0].NotBefore.After(now.Add(v.MaxClockSkew)) {
+		putilsLogger.Errorf("checkCertificateNotBefore error: creator certificate %s is valid from %s, it is %s", certs[0].Subject.CommonName, certs[0].NotBefore, now)
+		return ErrCertificateNotYetValid
+	} else if certs[0].NotBefore.After(now) {
+		addWarning(ctx, WarningClockSkew, "Creator certificate %s is valid from %s, within the clock skew, it is %s", certs[0].Subject.CommonName, certs[0].NotBefore, now)
+	}
+
+	return nil
+}
+
+// deprecatedSignatureAlgorithms are the signature algorithms of
+// certificates that are still accepted but warned about
+var deprecatedSignatureAlgorithms = map[x509.SignatureAlgorithm]struct{}{
+	x509.MD5WithRSA:    {},
+	x509.SHA1WithRSA:   {},
+	x509.DSAWithSHA1:   {},
+	x509.ECDSAWithSHA1: {},
+}
+
+// checkCertificateWarnings warns about the certificate of the creator, if it
+// has one, if it expires within the CertificateExpiryWarning of the
+// validator or is signed with a deprecated algorithm
+func (v *Validator) checkCertificateWarnings(ctx context.Context, sId *msp.SerializedIdentity, idType IdentityType) error {
+	if v.CertificateExpiryWarning <= 0 || idType != X509Identity {
+		return nil
+	}
+
+	certs, err := parseCertificateChain(sId.IdBytes)
+	if err != nil {
+		return err
+	}
+
+	if now := v.now(); certs[0].NotAfter.Before(now.Add(v.CertificateExpiryWarning)) {
+		addWarning(ctx, WarningCertificateNearExpiry, "Creator certificate %s expires at %s, it is %s", certs[0].Subject.CommonName, certs[0].NotAfter, now)
+	}
+
+	if _, ok := deprecatedSignatureAlgorithms[certs[0].SignatureAlgorithm]; ok {
+		addWarning(ctx, WarningDeprecatedAlgorithm, "Creator certificate %s is signed with %s", certs[0].Subject.CommonName, certs[0].SignatureAlgorithm)
+	}
+
+	return nil
+}
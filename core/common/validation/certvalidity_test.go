@@ -0,0 +1,79 @@
+/*
+Copyright IBM Corp. 2017 All Rights Reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+		 http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package validation
+
+import (
+	"testing"
+	"time"
+
+	"github.com/hyperledger/fabric/common/util"
+	"github.com/hyperledger/fabric/msp"
+	"github.com/hyperledger/fabric/protos/utils"
+)
+
+func TestCertificateNotBefore(t *testing.T) {
+	sig := []byte("signature")
+	msg := []byte("message")
+
+	// the certificate is valid from an hour ago
+	certPEM, cert, _ := newTestCertificate(t, "creator", false, nil, nil)
+	creator := utils.MarshalOrPanic(&msp.SerializedIdentity{Mspid: "Org1", IdBytes: certPEM})
+	anonymous := utils.MarshalOrPanic(&msp.SerializedIdentity{Mspid: "Org1", IdBytes: []byte("credential")})
+
+	// the MSP validates every creator, whatever its NotBefore
+	provider := &mockDeserializerProvider{mockDeserializer{
+		string(creator):   &mockIdentity{mspID: "Org1", valid: true, sig: sig},
+		string(anonymous): &mockIdemixIdentity{mockIdentity{mspID: "Org1", valid: true, sig: sig}},
+	}}
+
+	tests := []struct {
+		name    string
+		creator []byte
+		now     time.Time
+		skew    time.Duration
+		reject  bool
+		err     error
+	}{
+		{"Valid", creator, cert.NotBefore.Add(time.Minute), 0, true, nil},
+		{"ValidFromNow", creator, cert.NotBefore, 0, true, nil},
+		{"NotYetValid", creator, cert.NotBefore.Add(-time.Minute), 0, true, ErrCertificateNotYetValid},
+		{"WithinSkew", creator, cert.NotBefore.Add(-time.Minute), 2 * time.Minute, true, nil},
+		{"NotRejected", creator, cert.NotBefore.Add(-time.Minute), 0, false, nil},
+		{"Anonymous", anonymous, cert.NotBefore.Add(-time.Minute), 0, true, nil},
+	}
+
+	for _, test := range tests {
+		now := test.now
+		v := &Validator{
+			DeserializerProvider:          provider,
+			RejectNotYetValidCertificates: test.reject,
+			MaxClockSkew:                  test.skew,
+			Clock:                         func() time.Time { return now },
+		}
+		err := v.checkSignatureFromCreator(test.creator, sig, msg, util.GetTestChainID())
+		if err != test.err {
+			t.Fatalf("%s: expected err %v, got %v", test.name, test.err, err)
+		}
+	}
+
+	// the certificate is checked against the current time by default
+	v := &Validator{DeserializerProvider: provider, RejectNotYetValidCertificates: true}
+	err := v.checkSignatureFromCreator(creator, sig, msg, util.GetTestChainID())
+	if err != nil {
+		t.Fatalf("checkSignatureFromCreator failed, err %s", err)
+	}
+}
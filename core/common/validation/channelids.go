@@ -0,0 +1,69 @@
+/*
+Copyright IBM Corp. 2017 All Rights Reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+		 http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package validation
+
+import (
+	"fmt"
+
+	"github.com/hyperledger/fabric/common/configtx"
+	"github.com/hyperledger/fabric/protos/common"
+	"github.com/hyperledger/fabric/protos/utils"
+)
+
+// ExtractChannelIDs returns the channel ID of each envelope of the batch,
+// along with the error encountered extracting it, at the same index as the
+// envelope. Only the channel header is decoded and no signature is verified,
+// so that callers can quickly group envelopes by channel before validating
+// them; channel IDs are checked against the restrictions on channel names
+// but are otherwise returned as they are, since they are case sensitive
+func ExtractChannelIDs(envs []*common.Envelope) ([]string, []error) {
+	chainIDs := make([]string, len(envs))
+	errs := make([]error, len(envs))
+
+	for i, e := range envs {
+		chainIDs[i], errs[i] = extractChannelID(e)
+	}
+
+	return chainIDs, errs
+}
+
+// extractChannelID returns the channel ID of the envelope
+func extractChannelID(e *common.Envelope) (string, error) {
+	if e == nil {
+		return "", fmt.Errorf("Nil Envelope")
+	}
+
+	if isCompressedPayload(e) {
+		return "", ErrCompressedPayload
+	}
+
+	payload, err := utils.GetPayload(e)
+	if err != nil {
+		return "", transientDecodeError(fmt.Errorf("Could not extract payload from envelope, err %s", err))
+	}
+
+	if payload.Header == nil || payload.Header.ChannelHeader == nil {
+		return "", fmt.Errorf("Nil channel header")
+	}
+
+	chainID := payload.Header.ChannelHeader.ChannelId
+	if err = configtx.ValidateChainID(chainID); err != nil {
+		return "", fmt.Errorf("Invalid channel ID, err %s", err)
+	}
+
+	return chainID, nil
+}
@@ -0,0 +1,64 @@
+/*
+Copyright IBM Corp. 2017 All Rights Reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+		 http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package validation
+
+import (
+	"testing"
+	"time"
+
+	"github.com/hyperledger/fabric/protos/common"
+	"github.com/hyperledger/fabric/protos/utils"
+)
+
+// getEnvelopeForChannel returns an unsigned envelope for the given channel
+func getEnvelopeForChannel(chainID string) *common.Envelope {
+	hdr := getHeaderAt(time.Now())
+	hdr.ChannelHeader.ChannelId = chainID
+	return &common.Envelope{Payload: utils.MarshalOrPanic(&common.Payload{Header: hdr})}
+}
+
+func TestExtractChannelIDs(t *testing.T) {
+	envs := []*common.Envelope{
+		getEnvelopeForChannel("channel1"),
+		getEnvelopeForChannel("channel2"),
+		nil,
+		{Payload: []byte("garbage")},
+		{Payload: utils.MarshalOrPanic(&common.Payload{})},
+		getEnvelopeForChannel("Invalid/Channel"),
+		getEnvelopeForChannel("channel1"),
+	}
+
+	expected := []string{"channel1", "channel2", "", "", "", "", "channel1"}
+	valid := []bool{true, true, false, false, false, false, true}
+
+	chainIDs, errs := ExtractChannelIDs(envs)
+	if len(chainIDs) != len(envs) || len(errs) != len(envs) {
+		t.Fatalf("Expected %d results, got %d channel IDs and %d errors", len(envs), len(chainIDs), len(errs))
+	}
+
+	for i := range envs {
+		if chainIDs[i] != expected[i] {
+			t.Fatalf("Envelope %d: expected channel ID %q, got %q", i, expected[i], chainIDs[i])
+		}
+		if valid[i] && errs[i] != nil {
+			t.Fatalf("Envelope %d: ExtractChannelIDs failed, err %s", i, errs[i])
+		}
+		if !valid[i] && errs[i] == nil {
+			t.Fatalf("Envelope %d: ExtractChannelIDs should have failed", i)
+		}
+	}
+}
@@ -0,0 +1,70 @@
+/*
+Copyright IBM Corp. 2017 All Rights Reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+		 http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package validation
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/hyperledger/fabric/msp"
+)
+
+// ErrForeignMSP is returned when a creator claims an MSP that is not one of
+// the MSPs of the channel its message is for
+var ErrForeignMSP = errors.New("The MSP of the creator is not an MSP of the channel")
+
+// mspSet is implemented by the deserializers that know the MSPs they
+// deserialize the identities of, such as the MSP managers
+type mspSet interface {
+	GetMSPs() (map[string]msp.MSP, error)
+}
+
+// singleMSP is implemented by the deserializers that are themselves an
+// MSP, such as the local MSP used for messages without channel
+type singleMSP interface {
+	GetIdentifier() (string, error)
+}
+
+// checkChannelMSP ensures that the MSP claimed by a creator is one of the
+// MSPs known to the deserializer of the channel; deserializers that do not
+// expose their MSPs are trusted to reject the identities of foreign MSPs
+func checkChannelMSP(chainID string, mspObj msp.IdentityDeserializer, mspID string) error {
+	switch d := mspObj.(type) {
+	case mspSet:
+		msps, err := d.GetMSPs()
+		if err != nil {
+			return fmt.Errorf("Could not get the MSPs of chain [%s], err %s", chainID, err)
+		}
+
+		if _, ok := msps[mspID]; !ok {
+			putilsLogger.Errorf("checkChannelMSP error: creator claims MSP %s, which is not an MSP of chain [%s]", mspID, chainID)
+			return ErrForeignMSP
+		}
+	case singleMSP:
+		id, err := d.GetIdentifier()
+		if err != nil {
+			return fmt.Errorf("Could not get the identifier of the MSP of chain [%s], err %s", chainID, err)
+		}
+
+		if id != mspID {
+			putilsLogger.Errorf("checkChannelMSP error: creator claims MSP %s, chain [%s] has MSP %s", mspID, chainID, id)
+			return ErrForeignMSP
+		}
+	}
+
+	return nil
+}
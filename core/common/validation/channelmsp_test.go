@@ -0,0 +1,61 @@
+/*
+Copyright IBM Corp. 2017 All Rights Reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+		 http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package validation
+
+import (
+	"testing"
+
+	"github.com/hyperledger/fabric/common/util"
+	"github.com/hyperledger/fabric/msp"
+	"github.com/hyperledger/fabric/protos/utils"
+)
+
+func TestChannelMSP(t *testing.T) {
+	msg := []byte("message")
+	sig, err := signer.Sign(msg)
+	if err != nil {
+		t.Fatalf("Sign failed, err %s", err)
+	}
+
+	foreign := utils.MarshalOrPanic(&msp.SerializedIdentity{Mspid: "Org2", IdBytes: []byte("cert")})
+
+	// the deserializer does not expose its MSPs
+	mockValidator := &Validator{DeserializerProvider: &mockDeserializerProvider{mockDeserializer{
+		string(foreign): &mockIdentity{mspID: "Org2", valid: true, sig: sig},
+	}}}
+
+	tests := []struct {
+		name    string
+		v       *Validator
+		creator []byte
+		chainID string
+		err     error
+	}{
+		{"InChannel", defaultValidator, signerSerialized, util.GetTestChainID(), nil},
+		{"Foreign", defaultValidator, foreign, util.GetTestChainID(), ErrForeignMSP},
+		{"LocalMSP", defaultValidator, signerSerialized, "", nil},
+		{"ForeignToLocalMSP", defaultValidator, foreign, "", ErrForeignMSP},
+		{"UnknownMSPs", mockValidator, foreign, util.GetTestChainID(), nil},
+	}
+
+	for _, test := range tests {
+		err := test.v.checkSignatureFromCreator(test.creator, sig, msg, test.chainID)
+		if err != test.err {
+			t.Fatalf("%s: expected err %v, got %v", test.name, test.err, err)
+		}
+	}
+}
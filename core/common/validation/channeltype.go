@@ -0,0 +1,94 @@
+/*
+Copyright IBM Corp. 2017 All Rights Reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+		 http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package validation
+
+import (
+	"fmt"
+
+	"github.com/hyperledger/fabric/common/configtx"
+	configtxorderer "github.com/hyperledger/fabric/common/configvalues/channel/orderer"
+	"github.com/hyperledger/fabric/protos/common"
+)
+
+// ChannelType is the type of a channel
+type ChannelType int
+
+const (
+	// ApplicationChannel is the type of the channels transacted on by applications
+	ApplicationChannel ChannelType = iota
+
+	// SystemChannel is the type of the orderer system channel
+	SystemChannel
+)
+
+func (t ChannelType) String() string {
+	switch t {
+	case ApplicationChannel:
+		return "application"
+	case SystemChannel:
+		return "system"
+	default:
+		return fmt.Sprintf("unknown(%d)", int(t))
+	}
+}
+
+// ChannelTypeProvider provides the type of the channels
+type ChannelTypeProvider interface {
+	// GetChannelType returns the type of the given chain
+	GetChannelType(chainID string) ChannelType
+}
+
+// getConfigChannelType returns the type of channel a config is meant
+// for: only the config of the orderer system channel carries the names
+// of the policies used to create channels
+func getConfigChannelType(config *common.Config) ChannelType {
+	if config.Channel == nil {
+		return ApplicationChannel
+	}
+
+	ordererGroup, ok := config.Channel.Groups[configtxorderer.GroupKey]
+	if !ok {
+		return ApplicationChannel
+	}
+
+	if _, ok := ordererGroup.Values[configtxorderer.ChainCreationPolicyNamesKey]; ok {
+		return SystemChannel
+	}
+
+	return ApplicationChannel
+}
+
+// validateConfigChannelType ensures that the config carried by a config
+// transaction is meant for the type of channel the transaction targets
+func (v *Validator) validateConfigChannelType(data []byte, chainID string) error {
+	configEnv, err := configtx.UnmarshalConfigEnvelope(data)
+	if err != nil {
+		return permanentDecodeError(err)
+	}
+
+	if configEnv.Config == nil {
+		return fmt.Errorf("Nil config in the config envelope")
+	}
+
+	configType := getConfigChannelType(configEnv.Config)
+	channelType := v.ChannelTypeProvider.GetChannelType(chainID)
+	if configType != channelType {
+		return fmt.Errorf("Config for a %s channel submitted to %s channel [%s]", configType, channelType, chainID)
+	}
+
+	return nil
+}
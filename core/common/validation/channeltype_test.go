@@ -0,0 +1,84 @@
+/*
+Copyright IBM Corp. 2017 All Rights Reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+		 http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package validation
+
+import (
+	"testing"
+
+	configtxapplication "github.com/hyperledger/fabric/common/configvalues/channel/application"
+	configtxorderer "github.com/hyperledger/fabric/common/configvalues/channel/orderer"
+	"github.com/hyperledger/fabric/protos/common"
+	"github.com/hyperledger/fabric/protos/utils"
+)
+
+type mockChannelTypeProvider map[string]ChannelType
+
+func (m mockChannelTypeProvider) GetChannelType(chainID string) ChannelType {
+	return m[chainID]
+}
+
+func getConfigEnvelopeBytes(channelType ChannelType) []byte {
+	ordererGroup := common.NewConfigGroup()
+	channelGroup := common.NewConfigGroup()
+	channelGroup.Groups[configtxorderer.GroupKey] = ordererGroup
+
+	if channelType == SystemChannel {
+		ordererGroup.Values[configtxorderer.ChainCreationPolicyNamesKey] = &common.ConfigValue{}
+	} else {
+		channelGroup.Groups[configtxapplication.GroupKey] = common.NewConfigGroup()
+	}
+
+	return utils.MarshalOrPanic(&common.ConfigEnvelope{Config: &common.Config{Channel: channelGroup}})
+}
+
+func TestConfigChannelType(t *testing.T) {
+	v := &Validator{ChannelTypeProvider: mockChannelTypeProvider{
+		"syschannel": SystemChannel,
+		"appchannel": ApplicationChannel,
+	}}
+
+	tests := []struct {
+		name       string
+		chainID    string
+		configType ChannelType
+		valid      bool
+	}{
+		{"SystemOnSystem", "syschannel", SystemChannel, true},
+		{"ApplicationOnApplication", "appchannel", ApplicationChannel, true},
+		{"SystemOnApplication", "appchannel", SystemChannel, false},
+		{"ApplicationOnSystem", "syschannel", ApplicationChannel, false},
+	}
+
+	for _, test := range tests {
+		hdr := &common.Header{ChannelHeader: utils.MakeChannelHeader(common.HeaderType_CONFIG, 0, test.chainID, 0)}
+		err := v.validateConfigTransaction(getConfigEnvelopeBytes(test.configType), hdr)
+		if test.valid && err != nil {
+			t.Fatalf("%s: validateConfigTransaction failed, err %s", test.name, err)
+		}
+		if !test.valid && err == nil {
+			t.Fatalf("%s: validateConfigTransaction should have failed", test.name)
+		}
+	}
+}
+
+func TestConfigChannelTypePermissive(t *testing.T) {
+	hdr := &common.Header{ChannelHeader: utils.MakeChannelHeader(common.HeaderType_CONFIG, 0, "appchannel", 0)}
+	err := defaultValidator.validateConfigTransaction(getConfigEnvelopeBytes(SystemChannel), hdr)
+	if err != nil {
+		t.Fatalf("validateConfigTransaction failed, err %s", err)
+	}
+}
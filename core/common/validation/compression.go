@@ -0,0 +1,116 @@
+/*
+Copyright IBM Corp. 2017 All Rights Reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+		 http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package validation
+
+import (
+	"bytes"
+	"compress/gzip"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+
+	"github.com/hyperledger/fabric/protos/common"
+	"golang.org/x/net/context"
+)
+
+// ErrDecompressedPayloadTooLarge is returned when a compressed payload
+// decompresses to more than the allowed size
+var ErrDecompressedPayloadTooLarge = errors.New("Decompressed payload too large")
+
+// ErrCompressedPayload is returned for envelopes with a compressed payload
+// where those are not accepted: when the validator does not decompress
+// them, by the entry points validating the envelopes of blocks, which are
+// committed as they are, and by the helpers decoding envelopes directly
+var ErrCompressedPayload = errors.New("Compressed payloads are not accepted")
+
+// gzipMagic is the header of gzip streams. Clients may submit envelopes
+// whose payload is the gzip compression of the marshalled Payload message;
+// as no marshalled Payload can start with these bytes (0x1f would be the
+// tag of field 3 with the invalid wire type 7), they also mark compressed
+// payloads. The signature of the envelope covers the compressed bytes
+var gzipMagic = []byte{0x1f, 0x8b}
+
+// isCompressedPayload tells whether the payload of the envelope is
+// compressed
+func isCompressedPayload(e *common.Envelope) bool {
+	return bytes.HasPrefix(e.Payload, gzipMagic)
+}
+
+// getPayloadBytes returns the marshalled payload of the envelope. If the
+// validator has a MaxDecompressedPayloadSize and the payload is compressed,
+// it is decompressed as long as it does not exceed that size; otherwise
+// compressed payloads are rejected
+func (v *Validator) getPayloadBytes(e *common.Envelope) ([]byte, error) {
+	if !isCompressedPayload(e) {
+		return e.Payload, nil
+	}
+
+	if v.MaxDecompressedPayloadSize <= 0 {
+		putilsLogger.Errorf("getPayloadBytes error: compressed payload with decompression disabled")
+		return nil, ErrCompressedPayload
+	}
+
+	return decompressPayload(e.Payload, v.MaxDecompressedPayloadSize)
+}
+
+// decompressPayload decompresses a gzip compressed payload, failing if it
+// decompresses to more than limit bytes
+func decompressPayload(compressed []byte, limit int) ([]byte, error) {
+	r, err := gzip.NewReader(bytes.NewReader(compressed))
+	if err != nil {
+		return nil, fmt.Errorf("Could not decompress payload, err %s", err)
+	}
+	defer r.Close()
+
+	// read at most one byte past the limit to detect larger payloads
+	// without decompressing them entirely
+	raw, err := ioutil.ReadAll(io.LimitReader(r, int64(limit)+1))
+	if err != nil {
+		return nil, fmt.Errorf("Could not decompress payload, err %s", err)
+	}
+
+	if len(raw) > limit {
+		putilsLogger.Errorf("Compressed payload of %d bytes decompresses to more than %d bytes", len(compressed), limit)
+		return nil, ErrDecompressedPayloadTooLarge
+	}
+
+	return raw, nil
+}
+
+// committedEnvelopesKey is the key in contexts of the mark of the
+// validations of envelopes that are committed as they are
+type committedEnvelopesKey struct{}
+
+// withCommittedEnvelopes returns a context marking the validation of
+// envelopes that are committed as they are, e.g. those of blocks: as the
+// ledger and the other consumers of the blocks decode their payloads
+// directly, compressed payloads are rejected regardless of the validator
+func withCommittedEnvelopes(ctx context.Context) context.Context {
+	return context.WithValue(ctx, committedEnvelopesKey{}, true)
+}
+
+// checkCommittedPayload rejects the compressed payloads of the envelopes
+// committed as they are, see withCommittedEnvelopes
+func checkCommittedPayload(ctx context.Context, e *common.Envelope) error {
+	if committed, _ := ctx.Value(committedEnvelopesKey{}).(bool); committed && isCompressedPayload(e) {
+		putilsLogger.Errorf("checkCommittedPayload error: compressed payload in an envelope committed as it is")
+		return ErrCompressedPayload
+	}
+
+	return nil
+}
@@ -0,0 +1,145 @@
+/*
+Copyright IBM Corp. 2017 All Rights Reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+		 http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package validation
+
+import (
+	"bytes"
+	"compress/gzip"
+	"testing"
+
+	"github.com/hyperledger/fabric/protos/common"
+	"github.com/hyperledger/fabric/protos/utils"
+)
+
+// compressPayload returns an envelope holding the gzip compression of the
+// supplied payload bytes, signed by signer
+func compressPayload(t *testing.T, payload []byte) *common.Envelope {
+	var buf bytes.Buffer
+	w := gzip.NewWriter(&buf)
+	if _, err := w.Write(payload); err != nil {
+		t.Fatalf("Write failed, err %s", err)
+	}
+	if err := w.Close(); err != nil {
+		t.Fatalf("Close failed, err %s", err)
+	}
+
+	sig, err := signer.Sign(buf.Bytes())
+	if err != nil {
+		t.Fatalf("Sign failed, err %s", err)
+	}
+
+	return &common.Envelope{Payload: buf.Bytes(), Signature: sig}
+}
+
+func TestCompressedPayload(t *testing.T) {
+	tx, err := getTransaction([]byte("simulation_result"))
+	if err != nil {
+		t.Fatalf("getTransaction failed, err %s", err)
+	}
+
+	ctx := compressPayload(t, tx.Payload)
+
+	payload, err := (&Validator{MaxDecompressedPayloadSize: 1 << 20}).ValidateTransaction(ctx)
+	if err != nil {
+		t.Fatalf("ValidateTransaction failed, err %s", err)
+	}
+	if payload.Header.ChannelHeader.TxId == "" {
+		t.Fatalf("ValidateTransaction should have returned the decompressed payload")
+	}
+
+	// decompression is opt-in
+	_, err = (&Validator{}).ValidateTransaction(ctx)
+	if err != ErrCompressedPayload || GetErrorClass(err) != PermanentError {
+		t.Fatalf("ValidateTransaction should have failed with ErrCompressedPayload with decompression disabled, got %v", err)
+	}
+
+	// the decompressed payload is bounded
+	_, err = (&Validator{MaxDecompressedPayloadSize: len(tx.Payload) - 1}).ValidateTransaction(ctx)
+	if err != ErrDecompressedPayloadTooLarge {
+		t.Fatalf("ValidateTransaction should have failed with ErrDecompressedPayloadTooLarge, got %v", err)
+	}
+}
+
+func TestCompressedPayloadBomb(t *testing.T) {
+	bomb := compressPayload(t, make([]byte, 64<<20))
+	if len(bomb.Payload) > 1<<20 {
+		t.Fatalf("The bomb should compress well, got %d bytes", len(bomb.Payload))
+	}
+
+	_, err := (&Validator{MaxDecompressedPayloadSize: 1 << 20}).ValidateTransaction(bomb)
+	if err != ErrDecompressedPayloadTooLarge {
+		t.Fatalf("ValidateTransaction should have failed with ErrDecompressedPayloadTooLarge, got %v", err)
+	}
+}
+
+func TestCompressedPayloadInBlock(t *testing.T) {
+	tx, err := getTransaction([]byte("simulation_result"))
+	if err != nil {
+		t.Fatalf("getTransaction failed, err %s", err)
+	}
+
+	block := common.NewBlock(1, []byte("previous_hash"))
+	block.Data.Data = [][]byte{
+		utils.MarshalOrPanic(tx),
+		utils.MarshalOrPanic(compressPayload(t, tx.Payload)),
+	}
+	block.Header.DataHash = block.Data.Hash()
+
+	// the envelopes of blocks are committed as they are, so compressed
+	// payloads are rejected even with decompression enabled
+	result, err := (&Validator{MaxDecompressedPayloadSize: 1 << 20}).ValidateBlock(block)
+	if err != nil {
+		t.Fatalf("ValidateBlock failed, err %s", err)
+	}
+
+	if !result.Valid.IsSet(0) || result.Errors[0] != nil {
+		t.Fatalf("The uncompressed entry should be valid, got %v", result.Errors[0])
+	}
+
+	if result.Valid.IsSet(1) || result.Errors[1] != ErrCompressedPayload {
+		t.Fatalf("The compressed entry should have failed with ErrCompressedPayload, got %v", result.Errors[1])
+	}
+}
+
+func TestCompressedPayloadHelpers(t *testing.T) {
+	tx, err := getTransaction([]byte("simulation_result"))
+	if err != nil {
+		t.Fatalf("getTransaction failed, err %s", err)
+	}
+
+	env := compressPayload(t, tx.Payload)
+
+	_, err = TransactionFingerprint(env)
+	if err != ErrCompressedPayload {
+		t.Fatalf("TransactionFingerprint should have failed with ErrCompressedPayload, got %v", err)
+	}
+
+	_, err = EstimateValidationCost(env)
+	if err != ErrCompressedPayload {
+		t.Fatalf("EstimateValidationCost should have failed with ErrCompressedPayload, got %v", err)
+	}
+
+	_, errs := ExtractChannelIDs([]*common.Envelope{env})
+	if errs[0] != ErrCompressedPayload {
+		t.Fatalf("ExtractChannelIDs should have failed with ErrCompressedPayload, got %v", errs[0])
+	}
+
+	_, err = ExtractChaincodeEvents(env)
+	if err != ErrCompressedPayload {
+		t.Fatalf("ExtractChaincodeEvents should have failed with ErrCompressedPayload, got %v", err)
+	}
+}
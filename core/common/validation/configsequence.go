@@ -0,0 +1,73 @@
+/*
+Copyright IBM Corp. 2017 All Rights Reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+		 http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package validation
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/hyperledger/fabric/common/configtx"
+)
+
+// ErrStaleConfigSequence is returned for config transactions whose config
+// sequence does not increase that of their channel
+var ErrStaleConfigSequence = errors.New("The config transaction does not bump the config sequence")
+
+// ErrSkippedConfigSequence is returned for config transactions whose config
+// sequence skips sequence numbers
+var ErrSkippedConfigSequence = errors.New("The config transaction skips config sequence numbers")
+
+// ConfigSequenceProvider provides the current config sequence of the
+// channels, e.g. as tracked by their configtx.Manager
+type ConfigSequenceProvider interface {
+	// GetConfigSequence returns the config sequence of the given chain,
+	// or false if it is not available
+	GetConfigSequence(chainID string) (uint64, bool)
+}
+
+// checkConfigSequence ensures that the config carried by a config
+// transaction bumps the config sequence of its channel by exactly one, as
+// provided by the ConfigSequenceProvider of the validator. This is only a
+// cheap structural check, the configtx.Manager validating the config in
+// depth; the channels whose sequence is not available are not checked
+func (v *Validator) checkConfigSequence(data []byte, chainID string) error {
+	current, ok := v.ConfigSequenceProvider.GetConfigSequence(chainID)
+	if !ok {
+		return nil
+	}
+
+	configEnv, err := configtx.UnmarshalConfigEnvelope(data)
+	if err != nil {
+		return permanentDecodeError(err)
+	}
+
+	if configEnv.Config == nil || configEnv.Config.Channel == nil {
+		return fmt.Errorf("Nil config in the config envelope")
+	}
+
+	seq := configtx.ComputeSequence(configEnv.Config.Channel)
+	switch {
+	case seq <= current:
+		putilsLogger.Errorf("checkConfigSequence error: config sequence %d on chain [%s] whose config sequence is %d", seq, chainID, current)
+		return ErrStaleConfigSequence
+	case seq > current+1:
+		putilsLogger.Errorf("checkConfigSequence error: config sequence %d on chain [%s] whose config sequence is %d, expected %d", seq, chainID, current, current+1)
+		return ErrSkippedConfigSequence
+	}
+
+	return nil
+}
@@ -0,0 +1,94 @@
+/*
+Copyright IBM Corp. 2017 All Rights Reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+		 http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package validation
+
+import (
+	"testing"
+
+	"github.com/hyperledger/fabric/common/configtx"
+	"github.com/hyperledger/fabric/common/util"
+	cb "github.com/hyperledger/fabric/protos/common"
+	"github.com/hyperledger/fabric/protos/utils"
+)
+
+// mockConfigSequenceProvider provides the config sequences in its map
+type mockConfigSequenceProvider map[string]uint64
+
+func (m mockConfigSequenceProvider) GetConfigSequence(chainID string) (uint64, bool) {
+	seq, ok := m[chainID]
+	return seq, ok
+}
+
+// getConfigTransactionWithSequence returns a config transaction whose
+// config has the given sequence
+func getConfigTransactionWithSequence(t *testing.T, seq uint64) *cb.Envelope {
+	env := getConfigTransaction(t, func(sigs []*cb.ConfigSignature) []*cb.ConfigSignature { return sigs })
+
+	payload := utils.UnmarshalPayloadOrPanic(env.Payload)
+	configEnv, err := configtx.UnmarshalConfigEnvelope(payload.Data)
+	if err != nil {
+		t.Fatalf("UnmarshalConfigEnvelope failed, err %s", err)
+	}
+	configEnv.Config = &cb.Config{Channel: &cb.ConfigGroup{
+		Values: map[string]*cb.ConfigValue{"foo": {Version: 1}},
+		Groups: map[string]*cb.ConfigGroup{
+			"bar": {Values: map[string]*cb.ConfigValue{"baz": {Version: seq}}},
+		},
+	}}
+	payload.Data = utils.MarshalOrPanic(configEnv)
+	env.Payload = utils.MarshalOrPanic(payload)
+
+	env.Signature, err = signer.Sign(env.Payload)
+	if err != nil {
+		t.Fatalf("Sign failed, err %s", err)
+	}
+
+	return env
+}
+
+func TestConfigSequence(t *testing.T) {
+	chainID := util.GetTestChainID()
+
+	tests := []struct {
+		name      string
+		seq       uint64
+		sequences ConfigSequenceProvider
+		err       error
+	}{
+		{"Bumped", 5, mockConfigSequenceProvider{chainID: 4}, nil},
+		{"Same", 4, mockConfigSequenceProvider{chainID: 4}, ErrStaleConfigSequence},
+		{"Stale", 3, mockConfigSequenceProvider{chainID: 4}, ErrStaleConfigSequence},
+		{"Skipped", 6, mockConfigSequenceProvider{chainID: 4}, ErrSkippedConfigSequence},
+		{"UnknownChain", 6, mockConfigSequenceProvider{"otherchain": 4}, nil},
+		{"NoProvider", 3, nil, nil},
+	}
+
+	for _, test := range tests {
+		v := &Validator{ConfigSequenceProvider: test.sequences}
+		_, err := v.ValidateTransaction(getConfigTransactionWithSequence(t, test.seq))
+		if err != test.err {
+			t.Fatalf("%s: expected err %v, got %v", test.name, test.err, err)
+		}
+	}
+
+	// a config transaction must carry a config to be checked
+	v := &Validator{ConfigSequenceProvider: mockConfigSequenceProvider{chainID: 0}}
+	_, err := v.ValidateTransaction(getConfigTransaction(t, func(sigs []*cb.ConfigSignature) []*cb.ConfigSignature { return sigs }))
+	if err == nil {
+		t.Fatalf("ValidateTransaction should have failed for a missing config")
+	}
+}
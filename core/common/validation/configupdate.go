@@ -0,0 +1,83 @@
+/*
+Copyright IBM Corp. 2017 All Rights Reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+		 http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package validation
+
+import (
+	"fmt"
+
+	"github.com/hyperledger/fabric/common/configtx"
+	"github.com/hyperledger/fabric/protos/common"
+	"github.com/hyperledger/fabric/protos/utils"
+)
+
+// validateLastUpdate checks the CONFIG_UPDATE envelope that produced the
+// config carried by a config transaction, if any: the genesis config is
+// the only one with no last update
+func validateLastUpdate(data []byte) error {
+	configEnv, err := configtx.UnmarshalConfigEnvelope(data)
+	if err != nil {
+		return permanentDecodeError(err)
+	}
+
+	if configEnv.LastUpdate == nil {
+		return nil
+	}
+
+	return validateConfigUpdateEnvelope(configEnv.LastUpdate)
+}
+
+// validateConfigUpdateEnvelope performs a structural check of the signatures
+// of a CONFIG_UPDATE envelope. Evaluating them against the modification
+// policies is left to the configtx.Manager; an update with no signatures
+// though can never satisfy any policy and is cheaply rejected here
+func validateConfigUpdateEnvelope(e *common.Envelope) error {
+	payload, err := utils.GetPayload(e)
+	if err != nil {
+		return permanentDecodeError(fmt.Errorf("Could not extract payload from config update envelope, err %s", err))
+	}
+
+	configUpdateEnv, err := configtx.UnmarshalConfigUpdateEnvelope(payload.Data)
+	if err != nil {
+		return permanentDecodeError(err)
+	}
+
+	if len(configUpdateEnv.Signatures) == 0 {
+		return fmt.Errorf("Config update carries no signatures")
+	}
+
+	for i, configSig := range configUpdateEnv.Signatures {
+		if configSig == nil {
+			return fmt.Errorf("Nil signature at index %d of the config update", i)
+		}
+
+		if len(configSig.Signature) == 0 {
+			return fmt.Errorf("Empty signature at index %d of the config update", i)
+		}
+
+		sHdr, err := utils.GetSignatureHeader(configSig.SignatureHeader)
+		if err != nil {
+			return permanentDecodeError(fmt.Errorf("Invalid signature header at index %d of the config update, err %s", i, err))
+		}
+
+		err = validateSignatureHeader(sHdr)
+		if err != nil {
+			return fmt.Errorf("Invalid signature header at index %d of the config update, err %s", i, err)
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,99 @@
+/*
+Copyright IBM Corp. 2017 All Rights Reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+		 http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package validation
+
+import (
+	"testing"
+
+	"github.com/hyperledger/fabric/common/configtx"
+	configtxtest "github.com/hyperledger/fabric/common/configtx/test"
+	"github.com/hyperledger/fabric/common/util"
+	cb "github.com/hyperledger/fabric/protos/common"
+	"github.com/hyperledger/fabric/protos/utils"
+)
+
+// getConfigTransaction returns a config transaction whose last update
+// carries the signatures returned by f when passed the valid ones
+func getConfigTransaction(t *testing.T, f func(sigs []*cb.ConfigSignature) []*cb.ConfigSignature) *cb.Envelope {
+	chainID := util.GetTestChainID()
+	chCrtEnv, err := configtx.MakeChainCreationTransaction(configtxtest.AcceptAllPolicyKey, chainID, signer, configtxtest.CompositeTemplate())
+	if err != nil {
+		t.Fatalf("MakeChainCreationTransaction failed, err %s", err)
+	}
+
+	payload := utils.UnmarshalPayloadOrPanic(chCrtEnv.Payload)
+	configUpdateEnv := configtx.UnmarshalConfigUpdateEnvelopeOrPanic(payload.Data)
+	configUpdateEnv.Signatures = f(configUpdateEnv.Signatures)
+	payload.Data = utils.MarshalOrPanic(configUpdateEnv)
+	chCrtEnv.Payload = utils.MarshalOrPanic(payload)
+
+	env := &cb.Envelope{
+		Payload: utils.MarshalOrPanic(&cb.Payload{
+			Header: &cb.Header{
+				ChannelHeader: &cb.ChannelHeader{
+					Type:      int32(cb.HeaderType_CONFIG),
+					ChannelId: chainID,
+				},
+				SignatureHeader: &cb.SignatureHeader{
+					Creator: signerSerialized,
+					Nonce:   utils.CreateNonceOrPanic(),
+				},
+			},
+			Data: utils.MarshalOrPanic(&cb.ConfigEnvelope{LastUpdate: chCrtEnv}),
+		}),
+	}
+	env.Signature, err = signer.Sign(env.Payload)
+	if err != nil {
+		t.Fatalf("Sign failed, err %s", err)
+	}
+
+	return env
+}
+
+func TestConfigUpdateSignatures(t *testing.T) {
+	tests := []struct {
+		name  string
+		f     func(sigs []*cb.ConfigSignature) []*cb.ConfigSignature
+		valid bool
+	}{
+		{"Valid", func(sigs []*cb.ConfigSignature) []*cb.ConfigSignature { return sigs }, true},
+		{"NoSignatures", func(sigs []*cb.ConfigSignature) []*cb.ConfigSignature { return nil }, false},
+		{"EmptyConfigSignature", func(sigs []*cb.ConfigSignature) []*cb.ConfigSignature { return append(sigs, &cb.ConfigSignature{}) }, false},
+		{"EmptySignature", func(sigs []*cb.ConfigSignature) []*cb.ConfigSignature {
+			sigs[0].Signature = nil
+			return sigs
+		}, false},
+		{"EmptySignatureHeader", func(sigs []*cb.ConfigSignature) []*cb.ConfigSignature {
+			sigs[0].SignatureHeader = nil
+			return sigs
+		}, false},
+		{"CorruptedSignatureHeader", func(sigs []*cb.ConfigSignature) []*cb.ConfigSignature {
+			sigs[0].SignatureHeader = []byte("corrupted")
+			return sigs
+		}, false},
+	}
+
+	for _, test := range tests {
+		_, err := ValidateTransaction(getConfigTransaction(t, test.f))
+		if test.valid && err != nil {
+			t.Fatalf("%s: ValidateTransaction failed, err %s", test.name, err)
+		}
+		if !test.valid && err == nil {
+			t.Fatalf("%s: ValidateTransaction should have failed", test.name)
+		}
+	}
+}
@@ -0,0 +1,51 @@
+/*
+Copyright IBM Corp. 2017 All Rights Reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+		 http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package validation
+
+import (
+	"errors"
+	"fmt"
+)
+
+// ErrChannelConfigUnverified is returned for the application transactions
+// of channels whose config chain the peer has not fully verified yet
+var ErrChannelConfigUnverified = errors.New("The config of the channel is not verified")
+
+// ChannelConfigVerifier tells whether the peer has verified the config
+// chain of the channels, from their genesis config on
+type ChannelConfigVerifier interface {
+	// IsConfigVerified returns true if the config of the given chain is in
+	// a verified state
+	IsConfigVerified(chainID string) (bool, error)
+}
+
+// checkChannelConfigVerified ensures that the config of the chain is
+// verified, so that application transactions are only accepted on the
+// channels whose members and policies the peer can trust
+func (v *Validator) checkChannelConfigVerified(chainID string) error {
+	verified, err := v.ChannelConfigVerifier.IsConfigVerified(chainID)
+	if err != nil {
+		return fmt.Errorf("Could not get the verification state of the config of chain [%s], err %s", chainID, err)
+	}
+
+	if !verified {
+		putilsLogger.Errorf("The config of chain [%s] is not verified", chainID)
+		return ErrChannelConfigUnverified
+	}
+
+	return nil
+}
@@ -0,0 +1,77 @@
+/*
+Copyright IBM Corp. 2017 All Rights Reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+		 http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package validation
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/hyperledger/fabric/common/util"
+	cb "github.com/hyperledger/fabric/protos/common"
+)
+
+// mockConfigVerifier maps channel IDs to the verification state of their
+// config; the channels it does not know cannot be looked up
+type mockConfigVerifier map[string]bool
+
+func (m mockConfigVerifier) IsConfigVerified(chainID string) (bool, error) {
+	verified, ok := m[chainID]
+	if !ok {
+		return false, errors.New("unknown channel")
+	}
+	return verified, nil
+}
+
+func TestChannelConfigVerified(t *testing.T) {
+	tx, err := getTransaction([]byte("simulation_result"))
+	if err != nil {
+		t.Fatalf("getTransaction failed, err %s", err)
+	}
+
+	tests := []struct {
+		name     string
+		verifier ChannelConfigVerifier
+		valid    bool
+		err      error
+	}{
+		{"Verified", mockConfigVerifier{util.GetTestChainID(): true}, true, nil},
+		{"Unverified", mockConfigVerifier{util.GetTestChainID(): false}, false, ErrChannelConfigUnverified},
+		{"Unknown", mockConfigVerifier{}, false, nil},
+		{"Permissive", nil, true, nil},
+	}
+
+	for _, test := range tests {
+		_, err := (&Validator{ChannelConfigVerifier: test.verifier}).ValidateTransaction(tx)
+		if test.valid && err != nil {
+			t.Fatalf("%s: ValidateTransaction failed, err %s", test.name, err)
+		}
+		if !test.valid && err == nil {
+			t.Fatalf("%s: ValidateTransaction should have failed", test.name)
+		}
+		if test.err != nil && err != test.err {
+			t.Fatalf("%s: expected err %v, got %v", test.name, test.err, err)
+		}
+	}
+
+	// the config transactions, which the verification of the config
+	// relies on, are not checked
+	configTx := getConfigTransaction(t, func(sigs []*cb.ConfigSignature) []*cb.ConfigSignature { return sigs })
+	_, err = (&Validator{ChannelConfigVerifier: mockConfigVerifier{}}).ValidateTransaction(configTx)
+	if err != nil {
+		t.Fatalf("ValidateTransaction failed, err %s", err)
+	}
+}
@@ -0,0 +1,131 @@
+/*
+Copyright IBM Corp. 2017 All Rights Reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+		 http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package validation
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+
+	"github.com/golang/protobuf/proto"
+	"github.com/hyperledger/fabric/msp"
+	"github.com/hyperledger/fabric/protos/common"
+	"golang.org/x/net/context"
+)
+
+// ErrInsufficientCoSignatures is returned for co-signed envelopes with fewer
+// co-signatures than the validator requires
+var ErrInsufficientCoSignatures = errors.New("The envelope has too few co-signatures")
+
+// ErrInvalidCoSignatures is returned for co-signed envelopes whose
+// co-signatures are malformed
+var ErrInvalidCoSignatures = errors.New("Invalid co-signatures")
+
+// isCoSigned tells whether an envelope is co-signed
+func isCoSigned(e *common.Envelope) bool {
+	return bytes.HasPrefix(e.Signature, CoSignatureMarker)
+}
+
+// verifyEnvelopeSignature verifies the signature of the creator of the
+// envelope, or its co-signatures if it is co-signed, and returns the
+// identity of the creator
+func (v *Validator) verifyEnvelopeSignature(ctx context.Context, e *common.Envelope, hdr *common.Header) (msp.Identity, error) {
+	if !isCoSigned(e) {
+		return v.verifyCreator(ctx, hdr.SignatureHeader.Creator, e.Signature, getSignedPayload(ctx, e.Payload), hdr.ChannelHeader.ChannelId)
+	}
+
+	return v.verifyCoSignatures(ctx, e, hdr)
+}
+
+// verifyCoSignatures verifies the co-signatures of an envelope, of which
+// there must be at least MinCoSignatures from distinct parties, the first
+// of them being the creator of the envelope. Parties are told apart by
+// their resolved and deserialized identities, see identityKey, so that the
+// encodings of an identity cannot be counted as several parties
+func (v *Validator) verifyCoSignatures(ctx context.Context, e *common.Envelope, hdr *common.Header) (msp.Identity, error) {
+	if v.MinCoSignatures <= 0 {
+		return nil, fmt.Errorf("Co-signed envelopes are not accepted")
+	}
+
+	coSigned := &common.Metadata{}
+	err := proto.Unmarshal(e.Signature[len(CoSignatureMarker):], coSigned)
+	if err != nil {
+		putilsLogger.Errorf("verifyCoSignatures error: could not decode the co-signatures, err %s", err)
+		return nil, ErrInvalidCoSignatures
+	}
+
+	if len(coSigned.Signatures) < v.MinCoSignatures {
+		putilsLogger.Errorf("verifyCoSignatures error: %d co-signatures, at least %d required", len(coSigned.Signatures), v.MinCoSignatures)
+		return nil, ErrInsufficientCoSignatures
+	}
+
+	chainID := hdr.ChannelHeader.ChannelId
+	signed := getSignedPayload(ctx, e.Payload)
+	var creator msp.Identity
+	parties := make(map[string]struct{}, len(coSigned.Signatures))
+	for i, coSig := range coSigned.Signatures {
+		if coSig == nil {
+			return nil, ErrInvalidCoSignatures
+		}
+
+		sHdr := &common.SignatureHeader{}
+		err = proto.Unmarshal(coSig.SignatureHeader, sHdr)
+		if err != nil || len(sHdr.Creator) == 0 {
+			putilsLogger.Errorf("verifyCoSignatures error: co-signature %d has an invalid signature header, err %v", i, err)
+			return nil, ErrInvalidCoSignatures
+		}
+
+		if i == 0 && !bytes.Equal(sHdr.Creator, hdr.SignatureHeader.Creator) {
+			putilsLogger.Errorf("verifyCoSignatures error: the first co-signature is not from the creator of the envelope")
+			return nil, ErrInvalidCoSignatures
+		}
+
+		msg := make([]byte, 0, len(signed)+len(coSig.SignatureHeader))
+		msg = append(append(msg, signed...), coSig.SignatureHeader...)
+		party, err := v.verifyCreator(ctx, sHdr.Creator, coSig.Signature, msg, chainID)
+		if err != nil {
+			return nil, fmt.Errorf("Invalid co-signature %d, err %s", i, err)
+		}
+
+		resolved, err := v.resolveCreator(chainID, sHdr.Creator)
+		if err != nil {
+			return nil, err
+		}
+
+		key, err := identityKey(party, resolved)
+		if err != nil {
+			return nil, err
+		}
+
+		if _, ok := parties[key]; ok {
+			putilsLogger.Errorf("verifyCoSignatures error: co-signature %d is from a party that already signed", i)
+			return nil, ErrInvalidCoSignatures
+		}
+		parties[key] = struct{}{}
+		if i == 0 {
+			creator = party
+		} else {
+			countStats(ctx, func(stats *ValidationStats) {
+				stats.IdentitiesDeserialized++
+				stats.SignaturesVerified++
+				stats.BytesHashed += len(msg)
+			})
+		}
+	}
+
+	return creator, nil
+}
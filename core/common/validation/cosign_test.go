@@ -0,0 +1,97 @@
+/*
+Copyright IBM Corp. 2017 All Rights Reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+		 http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package validation
+
+import (
+	"testing"
+
+	"github.com/hyperledger/fabric/msp"
+	"github.com/hyperledger/fabric/protos/common"
+	"github.com/hyperledger/fabric/protos/utils"
+)
+
+// coSigner signs as a party of a co-signed envelope
+type coSigner struct {
+	creator []byte
+	sign    func(msg []byte) ([]byte, error)
+}
+
+// coSign returns a copy of the envelope co-signed by the parties
+func coSign(t *testing.T, e *common.Envelope, parties ...coSigner) *common.Envelope {
+	coSigned := &common.Metadata{}
+	for _, party := range parties {
+		sHdr := utils.MarshalOrPanic(&common.SignatureHeader{Creator: party.creator, Nonce: utils.CreateNonceOrPanic()})
+		sig, err := party.sign(append(append([]byte(nil), e.Payload...), sHdr...))
+		if err != nil {
+			t.Fatalf("Sign failed, err %s", err)
+		}
+		coSigned.Signatures = append(coSigned.Signatures, &common.MetadataSignature{SignatureHeader: sHdr, Signature: sig})
+	}
+
+	return &common.Envelope{
+		Payload:   e.Payload,
+		Signature: append(append([]byte(nil), CoSignatureMarker...), utils.MarshalOrPanic(coSigned)...),
+	}
+}
+
+func TestCoSignedEnvelope(t *testing.T) {
+	tx, err := getTransaction([]byte("simulation_result"))
+	if err != nil {
+		t.Fatalf("getTransaction failed, err %s", err)
+	}
+
+	coSignature := []byte("cosignature")
+	coSignerCreator := utils.MarshalOrPanic(&msp.SerializedIdentity{Mspid: "DEFAULT", IdBytes: []byte("cosigner")})
+	provider := &mockDeserializerProvider{fallbackDeserializer{
+		string(coSignerCreator): &mockIdentity{mspID: "DEFAULT", valid: true, sig: coSignature},
+	}}
+
+	creator := coSigner{signerSerialized, signer.Sign}
+	other := coSigner{coSignerCreator, func(msg []byte) ([]byte, error) { return coSignature, nil }}
+	forger := coSigner{coSignerCreator, func(msg []byte) ([]byte, error) { return []byte("forged"), nil }}
+
+	tests := []struct {
+		name  string
+		tx    *common.Envelope
+		min   int
+		valid bool
+		err   error
+	}{
+		{"SingleSignature", tx, 2, true, nil},
+		{"Sufficient", coSign(t, tx, creator, other), 2, true, nil},
+		{"Insufficient", coSign(t, tx, creator), 2, false, ErrInsufficientCoSignatures},
+		{"CreatorNotFirst", coSign(t, tx, other, creator), 2, false, ErrInvalidCoSignatures},
+		{"SameParty", coSign(t, tx, creator, creator), 2, false, ErrInvalidCoSignatures},
+		{"SamePartyReencoded", coSign(t, tx, creator, coSigner{reencodedSigner(t), signer.Sign}), 2, false, ErrInvalidCoSignatures},
+		{"InvalidCoSignature", coSign(t, tx, creator, forger), 2, false, nil},
+		{"NotAccepted", coSign(t, tx, creator, other), 0, false, nil},
+	}
+
+	for _, test := range tests {
+		v := &Validator{DeserializerProvider: provider, MinCoSignatures: test.min}
+		_, err := v.ValidateTransaction(test.tx)
+		if test.valid && err != nil {
+			t.Fatalf("%s: ValidateTransaction failed, err %s", test.name, err)
+		}
+		if !test.valid && err == nil {
+			t.Fatalf("%s: ValidateTransaction should have failed", test.name)
+		}
+		if test.err != nil && err != test.err {
+			t.Fatalf("%s: expected err %v, got %v", test.name, test.err, err)
+		}
+	}
+}
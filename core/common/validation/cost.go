@@ -0,0 +1,101 @@
+/*
+Copyright IBM Corp. 2017 All Rights Reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+		 http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package validation
+
+import (
+	"fmt"
+
+	"github.com/hyperledger/fabric/protos/common"
+	"github.com/hyperledger/fabric/protos/utils"
+)
+
+// ValidationCost estimates the work needed to validate a transaction
+type ValidationCost struct {
+	// SignatureVerifications is the number of signatures ValidateTransaction verifies
+	SignatureVerifications int
+
+	// ProposalHashes is the number of proposal hashes ValidateTransaction recomputes
+	ProposalHashes int
+
+	// Endorsements is the number of endorsements, whose signatures are
+	// verified later on by VSCC rather than by ValidateTransaction
+	Endorsements int
+
+	// DecodedBytes is the total size of the messages decoded during validation
+	DecodedBytes int
+}
+
+// EstimateValidationCost returns an estimate of the cost of validating the
+// transaction envelope, so that it can be used for admission control or
+// load balancing. The envelope is decoded but no crypto is performed, so
+// a transaction with a low estimate may still turn out to be invalid
+func EstimateValidationCost(e *common.Envelope) (ValidationCost, error) {
+	cost := ValidationCost{}
+
+	// check for nil argument
+	if e == nil {
+		return cost, fmt.Errorf("Nil Envelope")
+	}
+
+	if isCompressedPayload(e) {
+		return cost, ErrCompressedPayload
+	}
+
+	payload, err := utils.GetPayload(e)
+	if err != nil {
+		return cost, fmt.Errorf("Could not extract payload from envelope, err %s", err)
+	}
+
+	if payload.Header == nil || payload.Header.ChannelHeader == nil {
+		return cost, fmt.Errorf("Nil header")
+	}
+
+	// the signature of the creator over the envelope
+	cost.SignatureVerifications = 1
+	cost.DecodedBytes = len(e.Payload)
+
+	if common.HeaderType(payload.Header.ChannelHeader.Type) != common.HeaderType_ENDORSER_TRANSACTION {
+		return cost, nil
+	}
+
+	tx, err := utils.GetTransaction(payload.Data)
+	if err != nil {
+		return cost, err
+	}
+	cost.DecodedBytes += len(payload.Data)
+
+	for _, act := range tx.Actions {
+		if act == nil {
+			return cost, fmt.Errorf("Nil action")
+		}
+
+		cap, err := utils.GetChaincodeActionPayload(act.Payload)
+		if err != nil {
+			return cost, err
+		}
+
+		if cap.Action == nil {
+			return cost, fmt.Errorf("Nil ChaincodeEndorsedAction")
+		}
+
+		cost.ProposalHashes++
+		cost.Endorsements += len(cap.Action.Endorsements)
+		cost.DecodedBytes += len(act.Header) + len(act.Payload) + len(cap.Action.ProposalResponsePayload)
+	}
+
+	return cost, nil
+}
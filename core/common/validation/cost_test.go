@@ -0,0 +1,112 @@
+/*
+Copyright IBM Corp. 2017 All Rights Reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+		 http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package validation
+
+import (
+	"testing"
+
+	"github.com/hyperledger/fabric/protos/common"
+	"github.com/hyperledger/fabric/protos/peer"
+	"github.com/hyperledger/fabric/protos/utils"
+)
+
+// getCostTransaction returns a transaction with the given number of
+// actions, each endorsed the given number of times
+func getCostTransaction(t *testing.T, actions, endorsements int) *common.Envelope {
+	prop, err := getProposal()
+	if err != nil {
+		t.Fatalf("getProposal failed, err %s", err)
+	}
+
+	var presps []*peer.ProposalResponse
+	for i := 0; i < endorsements; i++ {
+		presp, err := utils.CreateProposalResponse(prop.Header, prop.Payload, &peer.Response{Status: 200}, []byte("simulation_result"), nil, nil, signer)
+		if err != nil {
+			t.Fatalf("CreateProposalResponse failed, err %s", err)
+		}
+		presps = append(presps, presp)
+	}
+
+	tx, err := utils.CreateSignedTx(prop, signer, presps...)
+	if err != nil {
+		t.Fatalf("CreateSignedTx failed, err %s", err)
+	}
+
+	payload, err := utils.GetPayload(tx)
+	if err != nil {
+		t.Fatalf("GetPayload failed, err %s", err)
+	}
+
+	transaction, err := utils.GetTransaction(payload.Data)
+	if err != nil {
+		t.Fatalf("GetTransaction failed, err %s", err)
+	}
+
+	for len(transaction.Actions) < actions {
+		transaction.Actions = append(transaction.Actions, transaction.Actions[0])
+	}
+
+	payload.Data = utils.MarshalOrPanic(transaction)
+	tx.Payload = utils.MarshalOrPanic(payload)
+
+	return tx
+}
+
+func TestEstimateValidationCost(t *testing.T) {
+	base, err := EstimateValidationCost(getCostTransaction(t, 1, 1))
+	if err != nil {
+		t.Fatalf("EstimateValidationCost failed, err %s", err)
+	}
+
+	if base.SignatureVerifications != 1 || base.ProposalHashes != 1 || base.Endorsements != 1 || base.DecodedBytes == 0 {
+		t.Fatalf("Unexpected cost %+v", base)
+	}
+
+	cost, err := EstimateValidationCost(getCostTransaction(t, 1, 3))
+	if err != nil {
+		t.Fatalf("EstimateValidationCost failed, err %s", err)
+	}
+
+	if cost.SignatureVerifications != 1 || cost.ProposalHashes != 1 || cost.Endorsements != 3 {
+		t.Fatalf("Unexpected cost %+v", cost)
+	}
+
+	cost, err = EstimateValidationCost(getCostTransaction(t, 4, 2))
+	if err != nil {
+		t.Fatalf("EstimateValidationCost failed, err %s", err)
+	}
+
+	if cost.SignatureVerifications != 1 || cost.ProposalHashes != 4 || cost.Endorsements != 8 {
+		t.Fatalf("Unexpected cost %+v", cost)
+	}
+
+	if cost.DecodedBytes <= base.DecodedBytes {
+		t.Fatalf("Decoded size should grow with the number of actions, got %d", cost.DecodedBytes)
+	}
+}
+
+func TestEstimateValidationCostBadTx(t *testing.T) {
+	_, err := EstimateValidationCost(nil)
+	if err == nil {
+		t.Fatalf("EstimateValidationCost should have failed for a nil envelope")
+	}
+
+	_, err = EstimateValidationCost(&common.Envelope{Payload: []byte("corrupted")})
+	if err == nil {
+		t.Fatalf("EstimateValidationCost should have failed for a corrupted payload")
+	}
+}
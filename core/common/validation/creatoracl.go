@@ -0,0 +1,44 @@
+/*
+Copyright IBM Corp. 2017 All Rights Reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+		 http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package validation
+
+import (
+	"errors"
+
+	"github.com/hyperledger/fabric/msp"
+)
+
+// ErrChaincodeNotAuthorizedForCreator is returned for the proposals and
+// transactions invoking a chaincode the MSP of their creator may not invoke
+var ErrChaincodeNotAuthorizedForCreator = errors.New("The creator is not authorized to invoke the chaincode")
+
+// checkCreatorChaincode ensures that the MSP of a creator, if restricted by
+// the CreatorChaincodeACL, may invoke the given chaincode
+func (v *Validator) checkCreatorChaincode(creator msp.Identity, ccName string) error {
+	mspID := creator.GetMSPIdentifier()
+	allowed, restricted := v.CreatorChaincodeACL[mspID]
+	if !restricted {
+		return nil
+	}
+
+	if _, ok := allowed[ccName]; !ok {
+		putilsLogger.Errorf("checkCreatorChaincode error: creator of MSP %s may not invoke chaincode %s", mspID, ccName)
+		return ErrChaincodeNotAuthorizedForCreator
+	}
+
+	return nil
+}
@@ -0,0 +1,65 @@
+/*
+Copyright IBM Corp. 2017 All Rights Reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+		 http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package validation
+
+import (
+	"testing"
+
+	"github.com/hyperledger/fabric/protos/utils"
+)
+
+func TestCreatorChaincodeACL(t *testing.T) {
+	tx, err := getTransaction([]byte("simulation_result"))
+	if err != nil {
+		t.Fatalf("getTransaction failed, err %s", err)
+	}
+
+	prop, err := getProposal()
+	if err != nil {
+		t.Fatalf("getProposal failed, err %s", err)
+	}
+
+	sProp, err := utils.GetSignedProposal(prop, signer)
+	if err != nil {
+		t.Fatalf("GetSignedProposal failed, err %s", err)
+	}
+
+	tests := []struct {
+		name string
+		acl  map[string]map[string]struct{}
+		err  error
+	}{
+		{"Unrestricted", nil, nil},
+		{"Authorized", map[string]map[string]struct{}{"DEFAULT": {"foo": {}, "bar": {}}}, nil},
+		{"Unauthorized", map[string]map[string]struct{}{"DEFAULT": {"bar": {}}}, ErrChaincodeNotAuthorizedForCreator},
+		{"NoChaincodeAllowed", map[string]map[string]struct{}{"DEFAULT": {}}, ErrChaincodeNotAuthorizedForCreator},
+		{"OtherMSPRestricted", map[string]map[string]struct{}{"Org1MSP": {"bar": {}}}, nil},
+	}
+
+	for _, test := range tests {
+		v := &Validator{CreatorChaincodeACL: test.acl}
+		_, err := v.ValidateTransaction(tx)
+		if err != test.err {
+			t.Fatalf("%s: expected err %v validating the transaction, got %v", test.name, test.err, err)
+		}
+
+		_, _, _, err = v.ValidateProposalMessage(sProp)
+		if err != test.err {
+			t.Fatalf("%s: expected err %v validating the proposal, got %v", test.name, test.err, err)
+		}
+	}
+}
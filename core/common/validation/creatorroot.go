@@ -0,0 +1,100 @@
+/*
+Copyright IBM Corp. 2017 All Rights Reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+		 http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package validation
+
+import (
+	"crypto/x509"
+	"encoding/pem"
+	"errors"
+	"fmt"
+
+	"github.com/hyperledger/fabric/msp"
+)
+
+// ErrUntrustedCreatorRoot is returned when the certificate chain of a
+// creator does not terminate at one of the required roots
+var ErrUntrustedCreatorRoot = errors.New("The certificate chain of the creator does not terminate at a trusted root")
+
+// parseCertificateChain parses the certificates of an identity: its own
+// certificate followed, if it presents them inline, by the intermediate
+// certificates of its chain, as consecutive PEM blocks. A single DER
+// encoded certificate is accepted as well
+func parseCertificateChain(idBytes []byte) ([]*x509.Certificate, error) {
+	var certs []*x509.Certificate
+	for rest := idBytes; ; {
+		var block *pem.Block
+		block, rest = pem.Decode(rest)
+		if block == nil {
+			break
+		}
+
+		cert, err := x509.ParseCertificate(block.Bytes)
+		if err != nil {
+			return nil, fmt.Errorf("Could not parse certificate %d of the chain, err %s", len(certs), err)
+		}
+		certs = append(certs, cert)
+	}
+
+	if len(certs) == 0 {
+		cert, err := x509.ParseCertificate(idBytes)
+		if err != nil {
+			return nil, fmt.Errorf("Could not parse the certificate, err %s", err)
+		}
+		certs = append(certs, cert)
+	}
+
+	return certs, nil
+}
+
+// checkCreatorRoot ensures that the certificate chain of the creator,
+// completed with the intermediate certificates it presents inline,
+// terminates at one of the required roots, if any is; creators without a
+// certificate have no chain and are then rejected. This is in addition to
+// the validation of the creator by its MSP, whose roots may be broader
+func (v *Validator) checkCreatorRoot(sId *msp.SerializedIdentity, idType IdentityType) error {
+	if v.CreatorRoots == nil {
+		return nil
+	}
+
+	if idType != X509Identity {
+		putilsLogger.Errorf("checkCreatorRoot error: creator is a %s identity with no certificate chain", idType)
+		return ErrUntrustedCreatorRoot
+	}
+
+	certs, err := parseCertificateChain(sId.IdBytes)
+	if err != nil {
+		return err
+	}
+
+	intermediates := x509.NewCertPool()
+	for _, cert := range certs[1:] {
+		intermediates.AddCert(cert)
+	}
+
+	_, err = certs[0].Verify(x509.VerifyOptions{
+		Roots:         v.CreatorRoots,
+		Intermediates: intermediates,
+		CurrentTime:   v.now(),
+		KeyUsages:     []x509.ExtKeyUsage{x509.ExtKeyUsageAny},
+	})
+	if err != nil {
+		putilsLogger.Errorf("checkCreatorRoot error: the chain of creator %s does not terminate at a required root, err %s", certs[0].Subject.CommonName, err)
+		return ErrUntrustedCreatorRoot
+	}
+
+	return nil
+}
@@ -0,0 +1,172 @@
+/*
+Copyright IBM Corp. 2017 All Rights Reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+		 http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package validation
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"io/ioutil"
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/golang/protobuf/proto"
+	"github.com/hyperledger/fabric/common/util"
+	"github.com/hyperledger/fabric/msp"
+	"github.com/hyperledger/fabric/protos/utils"
+)
+
+// newTestCertificate returns a PEM encoded certificate with the given common
+// name, signed by the parent or self-signed if parent is nil, along with
+// the certificate and its key
+func newTestCertificate(t *testing.T, cn string, isCA bool, parent *x509.Certificate, parentKey *ecdsa.PrivateKey) ([]byte, *x509.Certificate, *ecdsa.PrivateKey) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("GenerateKey failed, err %s", err)
+	}
+
+	template := &x509.Certificate{
+		SerialNumber:          big.NewInt(time.Now().UnixNano()),
+		Subject:               pkix.Name{CommonName: cn},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		IsCA:                  isCA,
+		BasicConstraintsValid: true,
+		KeyUsage:              x509.KeyUsageDigitalSignature | x509.KeyUsageCertSign,
+	}
+	if parent == nil {
+		parent, parentKey = template, key
+	}
+
+	der, err := x509.CreateCertificate(rand.Reader, template, parent, &key.PublicKey, parentKey)
+	if err != nil {
+		t.Fatalf("CreateCertificate failed, err %s", err)
+	}
+
+	cert, err := x509.ParseCertificate(der)
+	if err != nil {
+		t.Fatalf("ParseCertificate failed, err %s", err)
+	}
+
+	return pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}), cert, key
+}
+
+func TestCreatorRoot(t *testing.T) {
+	sig := []byte("signature")
+	msg := []byte("message")
+
+	_, rootA, rootAKey := newTestCertificate(t, "rootA", true, nil, nil)
+	_, rootB, _ := newTestCertificate(t, "rootB", true, nil, nil)
+	intermediatePEM, intermediate, intermediateKey := newTestCertificate(t, "intermediate", true, rootA, rootAKey)
+	leafPEM, _, _ := newTestCertificate(t, "leaf", false, intermediate, intermediateKey)
+
+	chain := utils.MarshalOrPanic(&msp.SerializedIdentity{Mspid: "Org1", IdBytes: append(append([]byte(nil), leafPEM...), intermediatePEM...)})
+	leafOnly := utils.MarshalOrPanic(&msp.SerializedIdentity{Mspid: "Org1", IdBytes: leafPEM})
+	anonymous := utils.MarshalOrPanic(&msp.SerializedIdentity{Mspid: "Org1", IdBytes: []byte("credential")})
+
+	poolA := x509.NewCertPool()
+	poolA.AddCert(rootA)
+	poolB := x509.NewCertPool()
+	poolB.AddCert(rootB)
+
+	// the MSP validates every creator
+	provider := &mockDeserializerProvider{mockDeserializer{
+		string(chain):     &mockIdentity{mspID: "Org1", valid: true, sig: sig},
+		string(leafOnly):  &mockIdentity{mspID: "Org1", valid: true, sig: sig},
+		string(anonymous): &mockIdemixIdentity{mockIdentity{mspID: "Org1", valid: true, sig: sig}},
+	}}
+
+	tests := []struct {
+		name    string
+		creator []byte
+		roots   *x509.CertPool
+		err     error
+	}{
+		{"ExpectedRoot", chain, poolA, nil},
+		{"OtherRoot", chain, poolB, ErrUntrustedCreatorRoot},
+		{"MissingIntermediate", leafOnly, poolA, ErrUntrustedCreatorRoot},
+		{"Anonymous", anonymous, poolA, ErrUntrustedCreatorRoot},
+		{"NoRoots", leafOnly, nil, nil},
+		{"NoRootsAnonymous", anonymous, nil, nil},
+	}
+
+	for _, test := range tests {
+		v := &Validator{DeserializerProvider: provider, CreatorRoots: test.roots}
+		err := v.checkSignatureFromCreator(test.creator, sig, msg, util.GetTestChainID())
+		if err != test.err {
+			t.Fatalf("%s: expected err %v, got %v", test.name, test.err, err)
+		}
+	}
+}
+
+func TestCreatorRootWithMSP(t *testing.T) {
+	msg := []byte("message")
+	sig, err := signer.Sign(msg)
+	if err != nil {
+		t.Fatalf("Sign failed, err %s", err)
+	}
+
+	caPEM, err := ioutil.ReadFile("../../../msp/sampleconfig/cacerts/cacert.pem")
+	if err != nil {
+		t.Fatalf("ReadFile failed, err %s", err)
+	}
+	block, _ := pem.Decode(caPEM)
+	ca, err := x509.ParseCertificate(block.Bytes)
+	if err != nil {
+		t.Fatalf("ParseCertificate failed, err %s", err)
+	}
+
+	// the signer presents the certificate of its CA inline
+	sId := &msp.SerializedIdentity{}
+	err = proto.Unmarshal(signerSerialized, sId)
+	if err != nil {
+		t.Fatalf("Unmarshal failed, err %s", err)
+	}
+	sId.IdBytes = append(sId.IdBytes, caPEM...)
+	creator := utils.MarshalOrPanic(sId)
+
+	mspRoots := x509.NewCertPool()
+	mspRoots.AddCert(ca)
+	_, other, _ := newTestCertificate(t, "other", true, nil, nil)
+	otherRoots := x509.NewCertPool()
+	otherRoots.AddCert(other)
+
+	// the sample certificates were valid then
+	clock := func() time.Time { return time.Date(2017, time.June, 1, 0, 0, 0, 0, time.UTC) }
+
+	tests := []struct {
+		name  string
+		roots *x509.CertPool
+		err   error
+	}{
+		{"NoRoots", nil, nil},
+		{"MSPRoot", mspRoots, nil},
+		{"OtherRoot", otherRoots, ErrUntrustedCreatorRoot},
+	}
+
+	for _, test := range tests {
+		v := &Validator{CreatorRoots: test.roots, Clock: clock}
+		err := v.checkSignatureFromCreator(creator, sig, msg, util.GetTestChainID())
+		if err != test.err {
+			t.Fatalf("%s: expected err %v, got %v", test.name, test.err, err)
+		}
+	}
+}
@@ -0,0 +1,98 @@
+/*
+Copyright IBM Corp. 2017 All Rights Reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+		 http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package validation
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/hyperledger/fabric/common/configtx"
+	"github.com/hyperledger/fabric/core/ledger/kvledger/txmgmt/rwset"
+)
+
+// ErrTooManyReferencedChannels is returned for transactions reading from
+// more distinct channels than allowed
+var ErrTooManyReferencedChannels = errors.New("The transaction references too many channels")
+
+// DefaultMaxReferencedChannels is the maximum number of distinct channels,
+// other than its own, a transaction may read from when the Validator sets no
+// MaxReferencedChannels
+const DefaultMaxReferencedChannels = 4
+
+// ChannelMembership tells whether the peer is joined to a channel
+type ChannelMembership interface {
+	// IsMember returns true if the peer is joined to the supplied channel
+	IsMember(chainID string) bool
+}
+
+// getReferencedChannels returns the channels other than chainID whose state
+// is read by the supplied simulation results. Following the naming used for
+// chaincode-to-chaincode invocations ("name:version/channel"), a namespace
+// of the form "chaincode/channel" declares a read on another channel
+func getReferencedChannels(chainID string, txRWSet *rwset.TxReadWriteSet) []string {
+	var channels []string
+	for _, nsRWSet := range txRWSet.NsRWs {
+		i := strings.IndexByte(nsRWSet.NameSpace, '/')
+		if i < 0 {
+			continue
+		}
+
+		channel := nsRWSet.NameSpace[i+1:]
+		if channel == chainID {
+			continue
+		}
+
+		channels = append(channels, channel)
+	}
+
+	return channels
+}
+
+// maxReferencedChannels returns the maximum number of distinct channels a
+// transaction may read from
+func (v *Validator) maxReferencedChannels() int {
+	if v.MaxReferencedChannels != 0 {
+		return v.MaxReferencedChannels
+	}
+
+	return DefaultMaxReferencedChannels
+}
+
+// validateCrossChannelReads ensures that every channel referenced by the
+// read-write set of an action is a valid channel the peer is joined to, and
+// adds it to the channels referenced by the previous actions of the
+// transaction, whose number is bounded
+func (v *Validator) validateCrossChannelReads(chainID string, txRWSet *rwset.TxReadWriteSet, referenced map[string]struct{}) error {
+	for _, channel := range getReferencedChannels(chainID, txRWSet) {
+		if err := configtx.ValidateChainID(channel); err != nil {
+			return fmt.Errorf("Invalid cross-channel reference, err %s", err)
+		}
+
+		if !v.ChannelMembership.IsMember(channel) {
+			return fmt.Errorf("Cross-channel reference to unknown channel [%s]", channel)
+		}
+
+		referenced[channel] = struct{}{}
+		if max := v.maxReferencedChannels(); len(referenced) > max {
+			putilsLogger.Errorf("Transaction on chain [%s] references more than %d channels", chainID, max)
+			return ErrTooManyReferencedChannels
+		}
+	}
+
+	return nil
+}
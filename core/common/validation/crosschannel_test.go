@@ -0,0 +1,172 @@
+/*
+Copyright IBM Corp. 2017 All Rights Reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+		 http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package validation
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/hyperledger/fabric/common/util"
+	"github.com/hyperledger/fabric/core/ledger/kvledger/txmgmt/rwset"
+	"github.com/hyperledger/fabric/protos/common"
+	"github.com/hyperledger/fabric/protos/peer"
+	"github.com/hyperledger/fabric/protos/utils"
+)
+
+type mockChannelMembership map[string]bool
+
+func (m mockChannelMembership) IsMember(chainID string) bool {
+	return m[chainID]
+}
+
+func getRWSetBytes(t *testing.T, namespaces ...string) []byte {
+	txRWSet := &rwset.TxReadWriteSet{}
+	for _, ns := range namespaces {
+		txRWSet.NsRWs = append(txRWSet.NsRWs, &rwset.NsReadWriteSet{
+			NameSpace: ns,
+			Reads:     []*rwset.KVRead{rwset.NewKVRead("key", nil)},
+		})
+	}
+
+	rwsetBytes, err := txRWSet.Marshal()
+	if err != nil {
+		t.Fatalf("Marshal failed, err %s", err)
+	}
+
+	return rwsetBytes
+}
+
+func TestCrossChannelReads(t *testing.T) {
+	v := &Validator{ChannelMembership: mockChannelMembership{util.GetTestChainID(): true, "otherchannel": true}}
+
+	tests := []struct {
+		name       string
+		namespaces []string
+		valid      bool
+	}{
+		{"NoReference", []string{"foo"}, true},
+		{"SameChannel", []string{"foo", "bar/" + util.GetTestChainID()}, true},
+		{"JoinedChannel", []string{"foo", "bar/otherchannel"}, true},
+		{"UnknownChannel", []string{"foo", "bar/unknownchannel"}, false},
+		{"InvalidChannel", []string{"foo", "bar/bad_channel"}, false},
+		{"EmptyChannel", []string{"foo", "bar/"}, false},
+	}
+
+	for _, test := range tests {
+		tx, err := getTransaction(getRWSetBytes(t, test.namespaces...))
+		if err != nil {
+			t.Fatalf("getTransaction failed, err %s", err)
+		}
+
+		_, err = v.ValidateTransaction(tx)
+		if test.valid && err != nil {
+			t.Fatalf("%s: ValidateTransaction failed, err %s", test.name, err)
+		}
+		if !test.valid && err == nil {
+			t.Fatalf("%s: ValidateTransaction should have failed", test.name)
+		}
+	}
+}
+
+func TestCrossChannelReadsDisabled(t *testing.T) {
+	tx, err := getTransaction(getRWSetBytes(t, "foo", "bar/unknownchannel"))
+	if err != nil {
+		t.Fatalf("getTransaction failed, err %s", err)
+	}
+
+	_, err = ValidateTransaction(tx)
+	if err != nil {
+		t.Fatalf("ValidateTransaction failed, err %s", err)
+	}
+}
+
+// getTransactionWithResults returns a transaction with an action for each
+// of the supplied simulation results of a single proposal
+func getTransactionWithResults(t *testing.T, results ...[]byte) *common.Envelope {
+	prop, err := getProposal()
+	if err != nil {
+		t.Fatalf("getProposal failed, err %s", err)
+	}
+
+	var payload *common.Payload
+	transaction := &peer.Transaction{}
+	for _, res := range results {
+		tx, err := getTransactionForProposal(prop, res)
+		if err != nil {
+			t.Fatalf("getTransactionForProposal failed, err %s", err)
+		}
+
+		payload, err = utils.GetPayload(tx)
+		if err != nil {
+			t.Fatalf("GetPayload failed, err %s", err)
+		}
+
+		txx, err := utils.GetTransaction(payload.Data)
+		if err != nil {
+			t.Fatalf("GetTransaction failed, err %s", err)
+		}
+		transaction.Actions = append(transaction.Actions, txx.Actions...)
+	}
+
+	payload.Data = utils.MarshalOrPanic(transaction)
+	tx := &common.Envelope{Payload: utils.MarshalOrPanic(payload)}
+	tx.Signature, err = signer.Sign(tx.Payload)
+	if err != nil {
+		t.Fatalf("Sign failed, err %s", err)
+	}
+
+	return tx
+}
+
+func TestMaxReferencedChannels(t *testing.T) {
+	membership := mockChannelMembership{util.GetTestChainID(): true}
+	for i := 0; i < 6; i++ {
+		membership[fmt.Sprintf("channel%d", i)] = true
+	}
+
+	tests := []struct {
+		name    string
+		results [][]byte
+		max     int
+		err     error
+	}{
+		{"SingleChannel", [][]byte{getRWSetBytes(t, "foo", "bar/"+util.GetTestChainID())}, 0, nil},
+		{"AtDefaultLimit", [][]byte{getRWSetBytes(t, "a/channel0", "b/channel1", "c/channel2", "d/channel3")}, 0, nil},
+		{"AboveDefaultLimit", [][]byte{getRWSetBytes(t, "a/channel0", "b/channel1", "c/channel2", "d/channel3", "e/channel4")}, 0, ErrTooManyReferencedChannels},
+		{"Duplicates", [][]byte{getRWSetBytes(t, "a/channel0", "b/channel0", "c/channel1", "d/channel1", "e/channel2")}, 2, ErrTooManyReferencedChannels},
+		{"DistinctAtLimit", [][]byte{getRWSetBytes(t, "a/channel0", "b/channel0", "c/channel1", "d/channel1")}, 2, nil},
+		{"AcrossActionsAtLimit", [][]byte{getRWSetBytes(t, "a/channel0", "b/channel1"), getRWSetBytes(t, "a/channel0", "c/channel2")}, 3, nil},
+		{"AcrossActionsAboveLimit", [][]byte{getRWSetBytes(t, "a/channel0", "b/channel1"), getRWSetBytes(t, "c/channel2", "d/channel3")}, 3, ErrTooManyReferencedChannels},
+		{"RaisedLimit", [][]byte{getRWSetBytes(t, "a/channel0", "b/channel1", "c/channel2", "d/channel3", "e/channel4", "f/channel5")}, 6, nil},
+	}
+
+	for _, test := range tests {
+		tx := getTransactionWithResults(t, test.results...)
+
+		v := &Validator{ChannelMembership: membership, MaxReferencedChannels: test.max}
+		_, err := v.ValidateTransaction(tx)
+		if err != test.err {
+			t.Fatalf("%s: expected err %v, got %v", test.name, test.err, err)
+		}
+
+		// the limit is only checked along with the channels
+		_, err = (&Validator{MaxReferencedChannels: test.max}).ValidateTransaction(tx)
+		if err != nil {
+			t.Fatalf("%s: ValidateTransaction failed, err %s", test.name, err)
+		}
+	}
+}
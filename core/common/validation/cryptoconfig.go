@@ -0,0 +1,111 @@
+/*
+Copyright IBM Corp. 2017 All Rights Reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+		 http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package validation
+
+import (
+	"fmt"
+	"sync"
+
+	"github.com/hyperledger/fabric/bccsp"
+	"golang.org/x/net/context"
+)
+
+// ChannelCryptoConfig is the crypto config of a channel: the hash function
+// its transaction IDs are computed with, the signature mode of its
+// creators and the minimum length of its nonces
+type ChannelCryptoConfig struct {
+	// HashOpts selects the BCCSP hash function of the channel; if nil,
+	// SHA-256 is used
+	HashOpts bccsp.HashOpts
+
+	// SignatureMode is the signature mode of the creators of the channel
+	SignatureMode SignatureMode
+
+	// MinNonceLength is the minimum length of the nonces of the channel;
+	// if zero, the MinNonceLength of the validator is used
+	MinNonceLength int
+}
+
+// ChannelCryptoConfigProvider provides the crypto config of the channels,
+// as set in their channel config. It supersedes the HashFunctionProvider,
+// SignatureModeProvider and NonceLengthProvider for the channels whose
+// config it provides
+type ChannelCryptoConfigProvider interface {
+	// GetChannelCryptoConfig returns the crypto config of the given chain,
+	// or nil if it is not available
+	GetChannelCryptoConfig(chainID string) (*ChannelCryptoConfig, error)
+}
+
+// cryptoConfigCache caches the crypto configs fetched from the
+// ChannelCryptoConfigProvider, by channel
+type cryptoConfigCache struct {
+	sync.RWMutex
+	configs map[string]*ChannelCryptoConfig
+}
+
+// getCryptoConfig returns the crypto config of the chain, fetched from the
+// ChannelCryptoConfigProvider the first time it is needed, or nil if the
+// validator has no provider or the provider does not know the chain. In
+// dry-run mode, the configs fetched are not cached
+func (v *Validator) getCryptoConfig(ctx context.Context, chainID string) (*ChannelCryptoConfig, error) {
+	if v.ChannelCryptoConfigProvider == nil {
+		return nil, nil
+	}
+
+	v.cryptoConfigCache.RLock()
+	config, ok := v.cryptoConfigCache.configs[chainID]
+	v.cryptoConfigCache.RUnlock()
+	if ok {
+		reportCacheOperation(ctx, CryptoConfigCache, chainID, CacheHit)
+		return config, nil
+	}
+
+	config, err := v.ChannelCryptoConfigProvider.GetChannelCryptoConfig(chainID)
+	if err != nil {
+		return nil, fmt.Errorf("Failed to get the crypto config of chain [%s], err %s", chainID, err)
+	}
+
+	// the chains that are not known yet are looked up again next time
+	if config == nil {
+		reportCacheOperation(ctx, CryptoConfigCache, chainID, CacheMiss)
+		return nil, nil
+	}
+
+	reportCacheOperation(ctx, CryptoConfigCache, chainID, CachePopulate)
+	if v.DryRun {
+		return config, nil
+	}
+
+	v.cryptoConfigCache.Lock()
+	if v.cryptoConfigCache.configs == nil {
+		v.cryptoConfigCache.configs = make(map[string]*ChannelCryptoConfig)
+	}
+	v.cryptoConfigCache.configs[chainID] = config
+	v.cryptoConfigCache.Unlock()
+
+	return config, nil
+}
+
+// RefreshChannelCryptoConfig drops the cached crypto config of a chain, so
+// that it is fetched again from the ChannelCryptoConfigProvider the next
+// time it is needed; it is meant to be called on the config updates of the
+// chain
+func (v *Validator) RefreshChannelCryptoConfig(chainID string) {
+	v.cryptoConfigCache.Lock()
+	delete(v.cryptoConfigCache.configs, chainID)
+	v.cryptoConfigCache.Unlock()
+}
@@ -0,0 +1,166 @@
+/*
+Copyright IBM Corp. 2017 All Rights Reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+		 http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package validation
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"errors"
+	"sync"
+	"testing"
+
+	"github.com/hyperledger/fabric/bccsp"
+	"github.com/hyperledger/fabric/common/util"
+	"golang.org/x/net/context"
+)
+
+// mockCryptoConfigProvider provides the crypto configs of the channels in
+// its map, counting the lookups of each channel
+type mockCryptoConfigProvider struct {
+	sync.Mutex
+	configs map[string]*ChannelCryptoConfig
+	lookups map[string]int
+}
+
+func (p *mockCryptoConfigProvider) GetChannelCryptoConfig(chainID string) (*ChannelCryptoConfig, error) {
+	p.Lock()
+	defer p.Unlock()
+
+	p.lookups[chainID]++
+	if chainID == "brokenchain" {
+		return nil, errors.New("config unavailable")
+	}
+
+	return p.configs[chainID], nil
+}
+
+func TestChannelCryptoConfig(t *testing.T) {
+	chainID := util.GetTestChainID()
+	provider := &mockCryptoConfigProvider{
+		configs: map[string]*ChannelCryptoConfig{
+			chainID:       {HashOpts: &bccsp.SHA3_256Opts{}, SignatureMode: RawMessageSignature, MinNonceLength: 24},
+			"digestchain": {HashOpts: &bccsp.SHA256Opts{}, SignatureMode: DigestSignature, MinNonceLength: 48},
+		},
+		lookups: make(map[string]int),
+	}
+	v := &Validator{
+		ChannelCryptoConfigProvider: provider,
+		// superseded on the channels whose crypto config is provided
+		HashFunctionProvider: &mockHashProvider{&bccsp.SHA384Opts{}},
+	}
+
+	sha256Tx, err := getTransactionForProposal(getProposalWithTxID(t, &bccsp.SHA256Opts{}), []byte("simulation_result"))
+	if err != nil {
+		t.Fatalf("getTransactionForProposal failed, err %s", err)
+	}
+	sha3Tx, err := getTransactionForProposal(getProposalWithTxID(t, &bccsp.SHA3_256Opts{}), []byte("simulation_result"))
+	if err != nil {
+		t.Fatalf("getTransactionForProposal failed, err %s", err)
+	}
+
+	// the TxIDs of the test chain are computed with SHA3-256
+	_, err = v.ValidateTransaction(sha3Tx)
+	if err != nil {
+		t.Fatalf("ValidateTransaction failed, err %s", err)
+	}
+	_, err = v.ValidateTransaction(sha256Tx)
+	if err == nil {
+		t.Fatalf("ValidateTransaction should have failed for a SHA-256 TxID")
+	}
+
+	// and those of the other chain with SHA-256
+	nonce := []byte("nonce")
+	creator := []byte("creator")
+	txID, err := v.ComputeTxID(nonce, creator, "digestchain")
+	if err != nil {
+		t.Fatalf("ComputeTxID failed, err %s", err)
+	}
+	if txID != "709184f9d24f6ade8fcd4d6521a6eef295fef6c2e67216c58b68ac15e8946492" {
+		t.Fatalf("Unexpected TxID %s", txID)
+	}
+
+	// the chains have different signature modes
+	msg := []byte("message")
+	signed, err := v.getSignedMessage(context.Background(), chainID, msg)
+	if err != nil || !bytes.Equal(signed, msg) {
+		t.Fatalf("Expected the raw message to be signed, got %x, err %v", signed, err)
+	}
+	digest := sha256.Sum256(msg)
+	signed, err = v.getSignedMessage(context.Background(), "digestchain", msg)
+	if err != nil || !bytes.Equal(signed, digest[:]) {
+		t.Fatalf("Expected the digest of the message to be signed, got %x, err %v", signed, err)
+	}
+
+	// and different nonce lengths
+	if err := v.validateNonceLength(context.Background(), chainID, make([]byte, 24)); err != nil {
+		t.Fatalf("validateNonceLength failed, err %s", err)
+	}
+	if err := v.validateNonceLength(context.Background(), "digestchain", make([]byte, 24)); err == nil {
+		t.Fatalf("validateNonceLength should have failed for a short nonce")
+	}
+
+	// the configs are fetched once
+	if provider.lookups[chainID] != 1 || provider.lookups["digestchain"] != 1 {
+		t.Fatalf("Expected a single lookup of each config, got %v", provider.lookups)
+	}
+
+	// until a config update
+	provider.Lock()
+	provider.configs[chainID] = &ChannelCryptoConfig{HashOpts: &bccsp.SHA256Opts{}}
+	provider.Unlock()
+	_, err = v.ValidateTransaction(sha256Tx)
+	if err == nil {
+		t.Fatalf("ValidateTransaction should have failed against the cached config")
+	}
+	v.RefreshChannelCryptoConfig(chainID)
+	_, err = v.ValidateTransaction(sha256Tx)
+	if err != nil {
+		t.Fatalf("ValidateTransaction failed, err %s", err)
+	}
+	if provider.lookups[chainID] != 2 {
+		t.Fatalf("Expected the config to be fetched again, got %d lookups", provider.lookups[chainID])
+	}
+
+	// the chains without crypto config fall back to the other providers,
+	// and are looked up again
+	txID, err = v.ComputeTxID(nonce, creator, "unknownchain")
+	if err != nil {
+		t.Fatalf("ComputeTxID failed, err %s", err)
+	}
+	expected, err := (&Validator{HashFunctionProvider: &mockHashProvider{&bccsp.SHA384Opts{}}}).ComputeTxID(nonce, creator, "unknownchain")
+	if err != nil {
+		t.Fatalf("ComputeTxID failed, err %s", err)
+	}
+	if txID != expected {
+		t.Fatalf("Expected TxID %s, got %s", expected, txID)
+	}
+	v.ComputeTxID(nonce, creator, "unknownchain")
+	if provider.lookups["unknownchain"] != 2 {
+		t.Fatalf("Expected the unknown chain to be looked up again, got %d lookups", provider.lookups["unknownchain"])
+	}
+
+	// failing to fetch a config fails the checks
+	if _, err := v.ComputeTxID(nonce, creator, "brokenchain"); err == nil {
+		t.Fatalf("ComputeTxID should have failed")
+	}
+	if _, err := v.getSignedMessage(context.Background(), "brokenchain", msg); err == nil {
+		t.Fatalf("getSignedMessage should have failed")
+	}
+	if err := v.validateNonceLength(context.Background(), "brokenchain", make([]byte, 24)); err == nil {
+		t.Fatalf("validateNonceLength should have failed")
+	}
+}
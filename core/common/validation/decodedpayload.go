@@ -0,0 +1,97 @@
+/*
+Copyright IBM Corp. 2017 All Rights Reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+		 http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package validation
+
+import (
+	"errors"
+
+	"github.com/golang/protobuf/proto"
+	"github.com/hyperledger/fabric/protos/common"
+	"golang.org/x/net/context"
+)
+
+// ErrPayloadMismatch is returned when the payload supplied along with an
+// envelope is not the payload of the envelope
+var ErrPayloadMismatch = errors.New("The supplied payload does not match the payload of the envelope")
+
+// decodedPayloadKey is the key of the payload decoded by the caller in
+// contexts
+type decodedPayloadKey struct{}
+
+// withDecodedPayload returns a context holding the payload of the envelope
+// being validated, as already decoded by the caller
+func withDecodedPayload(ctx context.Context, payload *common.Payload) context.Context {
+	return context.WithValue(ctx, decodedPayloadKey{}, payload)
+}
+
+// getContextPayload returns the payload of the envelope held by the
+// context, if any, once checked against the envelope, or else decodes it;
+// compressed payloads are rejected if the envelope is committed as it is
+func (v *Validator) getContextPayload(ctx context.Context, e *common.Envelope) (*common.Payload, error) {
+	err := checkCommittedPayload(ctx, e)
+	if err != nil {
+		return nil, err
+	}
+
+	payload, ok := ctx.Value(decodedPayloadKey{}).(*common.Payload)
+	if !ok {
+		return v.getPayload(e)
+	}
+
+	return v.checkDecodedPayload(e, payload)
+}
+
+// checkDecodedPayload ensures that a payload decoded by the caller is the
+// payload of the envelope, as decoded and checked by getPayload, so that
+// the signature verified over the payload bytes of the envelope covers the
+// payload being validated
+func (v *Validator) checkDecodedPayload(e *common.Envelope, payload *common.Payload) (*common.Payload, error) {
+	if payload == nil {
+		putilsLogger.Errorf("checkDecodedPayload error: nil payload supplied")
+		return nil, ErrPayloadMismatch
+	}
+
+	decoded, err := v.getPayload(e)
+	if err != nil {
+		return nil, err
+	}
+
+	if !proto.Equal(payload, decoded) {
+		putilsLogger.Errorf("checkDecodedPayload error: the supplied payload is not the payload of the envelope")
+		return nil, ErrPayloadMismatch
+	}
+
+	return payload, nil
+}
+
+// ValidateDecodedTransaction checks that the transaction envelope is
+// properly formed with the default validator, see
+// Validator.ValidateDecodedTransaction
+func ValidateDecodedTransaction(e *common.Envelope, payload *common.Payload) (*common.Payload, error) {
+	return defaultValidator.ValidateDecodedTransaction(e, payload)
+}
+
+// ValidateDecodedTransaction checks that the transaction envelope is
+// properly formed as ValidateTransaction does, given its payload as already
+// decoded by the caller. The payload bytes of the envelope, which its
+// signature covers, are still decoded and the supplied payload must equal
+// them, or else it is rejected with ErrPayloadMismatch; the verdict on an
+// envelope is then that of ValidateTransaction, and the supplied payload is
+// returned
+func (v *Validator) ValidateDecodedTransaction(e *common.Envelope, payload *common.Payload) (*common.Payload, error) {
+	return v.ValidateTransactionWithContext(withDecodedPayload(context.Background(), payload), e)
+}
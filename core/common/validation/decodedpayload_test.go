@@ -0,0 +1,88 @@
+/*
+Copyright IBM Corp. 2017 All Rights Reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+		 http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package validation
+
+import (
+	"testing"
+
+	"github.com/golang/protobuf/proto"
+	"github.com/hyperledger/fabric/protos/common"
+	"github.com/hyperledger/fabric/protos/utils"
+)
+
+func TestValidateDecodedTransaction(t *testing.T) {
+	tx, err := getTransaction([]byte("simulation_result"))
+	if err != nil {
+		t.Fatalf("getTransaction failed, err %s", err)
+	}
+
+	otherTx, err := getTransaction([]byte("other_simulation_result"))
+	if err != nil {
+		t.Fatalf("getTransaction failed, err %s", err)
+	}
+
+	unsignedTx := &common.Envelope{Payload: tx.Payload, Signature: otherTx.Signature}
+
+	// a validly signed payload that is not canonically encoded
+	nonCanonicalTx := appendUnknownField(t, tx)
+
+	decode := func(e *common.Envelope) *common.Payload {
+		payload, err := utils.GetPayload(e)
+		if err != nil {
+			t.Fatalf("GetPayload failed, err %s", err)
+		}
+		return payload
+	}
+
+	// the decoded payload has the verdict of the full path
+	for _, v := range []*Validator{{}, {StrictPayloadEncoding: true}} {
+		for _, e := range []*common.Envelope{tx, unsignedTx, nonCanonicalTx} {
+			expected, expectedErr := v.ValidateTransaction(e)
+			payload, err := v.ValidateDecodedTransaction(e, decode(e))
+			if (err == nil) != (expectedErr == nil) {
+				t.Fatalf("ValidateDecodedTransaction returned err %v, ValidateTransaction returned err %v", err, expectedErr)
+			}
+			if err == nil && !proto.Equal(payload, expected) {
+				t.Fatalf("ValidateDecodedTransaction returned payload %v, ValidateTransaction returned payload %v", payload, expected)
+			}
+		}
+	}
+
+	tampered := decode(tx)
+	tampered.Header.ChannelHeader.TxId = "tampered"
+
+	tests := []struct {
+		name    string
+		payload *common.Payload
+	}{
+		// the payload of another transaction, supplied along with the
+		// envelope and its payload bytes, is not covered by its signature
+		{"OtherTransaction", decode(otherTx)},
+		{"Tampered", tampered},
+		{"Nil", nil},
+	}
+
+	for _, test := range tests {
+		payload, err := ValidateDecodedTransaction(tx, test.payload)
+		if err != ErrPayloadMismatch {
+			t.Fatalf("%s: expected err %v, got %v", test.name, ErrPayloadMismatch, err)
+		}
+		if payload != nil {
+			t.Fatalf("%s: ValidateDecodedTransaction returned payload %v", test.name, payload)
+		}
+	}
+}
@@ -0,0 +1,107 @@
+/*
+Copyright IBM Corp. 2017 All Rights Reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+		 http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package validation
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/hyperledger/fabric/protos/common"
+	"golang.org/x/net/context"
+)
+
+// ErrInvalidDelegationToken is returned for transactions whose delegation
+// token was not issued by a trusted authority
+var ErrInvalidDelegationToken = errors.New("Invalid delegation token")
+
+// ErrDelegationTokenExpired is returned for transactions whose delegation
+// token has expired
+var ErrDelegationTokenExpired = errors.New("The delegation token has expired")
+
+// ErrDelegationNotAuthorized is returned for transactions whose delegation
+// token does not authorize their creator to submit on their channel
+var ErrDelegationNotAuthorized = errors.New("The delegation token does not authorize the creator")
+
+// DelegationToken holds the claims of a delegation token
+type DelegationToken struct {
+	// Delegate is the serialized identity that the token delegates to, which
+	// must be the creator of the transaction
+	Delegate []byte
+
+	// Channels are the channels on which the delegate may submit
+	// transactions
+	Channels []string
+
+	// Expiry is the time at which the token expires
+	Expiry time.Time
+}
+
+// TokenVerifier verifies delegation tokens
+type TokenVerifier interface {
+	// VerifyToken ensures that a delegation token was issued by a trusted
+	// authority for the given chain, and returns its claims
+	VerifyToken(chainID string, token []byte) (*DelegationToken, error)
+}
+
+// checkDelegationToken ensures that the delegation token presented by the
+// creator of a transaction in the DelegationToken field of its chaincode
+// header extension, if any, was issued by a trusted authority, as
+// verified by the TokenVerifier of the validator, has not expired and
+// authorizes the creator to submit on the channel; the clock of the
+// validator may be up to MaxClockSkew ahead of that of the authority
+func (v *Validator) checkDelegationToken(ctx context.Context, chdr *common.ChannelHeader, shdr *common.SignatureHeader) error {
+	ext, err := getHeaderExtension(ctx, chdr)
+	if err != nil {
+		return err
+	}
+
+	token := ext.DelegationToken
+	if len(token) == 0 {
+		return nil
+	}
+
+	claims, err := v.TokenVerifier.VerifyToken(chdr.ChannelId, token)
+	if err != nil {
+		putilsLogger.Errorf("checkDelegationToken error: invalid delegation token on transaction [%s], err %s", chdr.TxId, err)
+		return ErrInvalidDelegationToken
+	}
+
+	if claims == nil {
+		return fmt.Errorf("No claims for the delegation token")
+	}
+
+	if now := v.now(); now.After(claims.Expiry.Add(v.MaxClockSkew)) {
+		putilsLogger.Errorf("Delegation token of transaction [%s] expired at %s, it is %s", chdr.TxId, claims.Expiry, now)
+		return ErrDelegationTokenExpired
+	}
+
+	if !bytes.Equal(claims.Delegate, shdr.Creator) {
+		putilsLogger.Errorf("checkDelegationToken error: the delegation token of transaction [%s] is for another creator", chdr.TxId)
+		return ErrDelegationNotAuthorized
+	}
+
+	for _, channel := range claims.Channels {
+		if channel == chdr.ChannelId {
+			return nil
+		}
+	}
+
+	putilsLogger.Errorf("checkDelegationToken error: the delegation token of transaction [%s] does not cover chain [%s]", chdr.TxId, chdr.ChannelId)
+	return ErrDelegationNotAuthorized
+}
@@ -0,0 +1,86 @@
+/*
+Copyright IBM Corp. 2017 All Rights Reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+		 http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package validation
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/hyperledger/fabric/common/util"
+	"github.com/hyperledger/fabric/protos/peer"
+	"github.com/hyperledger/fabric/protos/utils"
+)
+
+// mockTokenVerifier trusts the tokens it holds the claims of
+type mockTokenVerifier map[string]*DelegationToken
+
+func (m mockTokenVerifier) VerifyToken(chainID string, token []byte) (*DelegationToken, error) {
+	claims, ok := m[string(token)]
+	if !ok {
+		return nil, errors.New("untrusted issuer")
+	}
+	return claims, nil
+}
+
+// encodeDelegationToken encodes a delegation token as a field of the
+// chaincode header extension
+func encodeDelegationToken(token string) []byte {
+	return utils.MarshalOrPanic(&peer.ChaincodeHeaderExtension{DelegationToken: []byte(token)})
+}
+
+func TestDelegationToken(t *testing.T) {
+	now := time.Now()
+	chainID := util.GetTestChainID()
+	verifier := mockTokenVerifier{
+		"valid":        {Delegate: signerSerialized, Channels: []string{"other", chainID}, Expiry: now.Add(time.Minute)},
+		"expired":      {Delegate: signerSerialized, Channels: []string{chainID}, Expiry: now.Add(-time.Minute)},
+		"otherCreator": {Delegate: []byte("someone else"), Channels: []string{chainID}, Expiry: now.Add(time.Minute)},
+		"otherChannel": {Delegate: signerSerialized, Channels: []string{"other"}, Expiry: now.Add(time.Minute)},
+	}
+
+	tests := []struct {
+		name     string
+		token    string
+		verifier TokenVerifier
+		skew     time.Duration
+		err      error
+	}{
+		{"Valid", "valid", verifier, 0, nil},
+		{"Expired", "expired", verifier, 0, ErrDelegationTokenExpired},
+		{"WithinSkew", "expired", verifier, 2 * time.Minute, nil},
+		{"OtherCreator", "otherCreator", verifier, 0, ErrDelegationNotAuthorized},
+		{"OtherChannel", "otherChannel", verifier, 0, ErrDelegationNotAuthorized},
+		{"Untrusted", "forged", verifier, 0, ErrInvalidDelegationToken},
+		{"NoToken", "", verifier, 0, nil},
+		{"NotVerified", "forged", nil, 0, nil},
+	}
+
+	for _, test := range tests {
+		var extra []byte
+		if test.token != "" {
+			extra = encodeDelegationToken(test.token)
+		}
+		tx, _ := getTransactionWithExtension(t, extra)
+
+		v := &Validator{TokenVerifier: test.verifier, MaxClockSkew: test.skew, Clock: func() time.Time { return now }}
+		_, err := v.ValidateTransaction(tx)
+		if err != test.err {
+			t.Fatalf("%s: expected err %v, got %v", test.name, test.err, err)
+		}
+	}
+}
@@ -0,0 +1,38 @@
+/*
+Copyright IBM Corp. 2017 All Rights Reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+		 http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package validation
+
+import (
+	"errors"
+
+	"github.com/hyperledger/fabric/protos/common"
+)
+
+// ErrTxIDDenied is returned when the transaction ID of a message is denied
+// on its channel
+var ErrTxIDDenied = errors.New("The transaction ID is denied")
+
+// checkDeniedTxID ensures that the transaction ID of the channel header is
+// not among those denied on its channel
+func (v *Validator) checkDeniedTxID(chdr *common.ChannelHeader) error {
+	if _, denied := v.DeniedTxIDs[chdr.ChannelId][chdr.TxId]; denied {
+		putilsLogger.Errorf("checkDeniedTxID error: transaction [%s] is denied on chain [%s]", chdr.TxId, chdr.ChannelId)
+		return ErrTxIDDenied
+	}
+
+	return nil
+}
@@ -0,0 +1,56 @@
+/*
+Copyright IBM Corp. 2017 All Rights Reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+		 http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package validation
+
+import (
+	"testing"
+
+	"github.com/hyperledger/fabric/common/util"
+	"github.com/hyperledger/fabric/protos/utils"
+)
+
+func TestDeniedTxIDs(t *testing.T) {
+	tx, err := getTransaction([]byte("simulation_result"))
+	if err != nil {
+		t.Fatalf("getTransaction failed, err %s", err)
+	}
+
+	payload, err := utils.GetPayload(tx)
+	if err != nil {
+		t.Fatalf("GetPayload failed, err %s", err)
+	}
+	txID := payload.Header.ChannelHeader.TxId
+
+	tests := []struct {
+		name   string
+		denied map[string]map[string]struct{}
+		err    error
+	}{
+		{"NoneDenied", nil, nil},
+		{"OtherTxIDDenied", map[string]map[string]struct{}{util.GetTestChainID(): {"other": struct{}{}}}, nil},
+		{"DeniedOnOtherChannel", map[string]map[string]struct{}{"otherchannel": {txID: struct{}{}}}, nil},
+		{"Denied", map[string]map[string]struct{}{util.GetTestChainID(): {txID: struct{}{}}}, ErrTxIDDenied},
+	}
+
+	for _, test := range tests {
+		v := &Validator{DeniedTxIDs: test.denied}
+		_, err := v.ValidateTransaction(tx)
+		if err != test.err {
+			t.Fatalf("%s: expected err %v, got %v", test.name, test.err, err)
+		}
+	}
+}
@@ -0,0 +1,79 @@
+/*
+Copyright IBM Corp. 2017 All Rights Reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+		 http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package validation
+
+import (
+	"errors"
+	"time"
+
+	"github.com/hyperledger/fabric/msp"
+	mspmgmt "github.com/hyperledger/fabric/msp/mgmt"
+	"golang.org/x/net/context"
+)
+
+// ErrChannelUnavailable is returned when no IdentityDeserializer is found
+// for a channel, as happens briefly while the peer shuts down or leaves
+// the channel: validating the message again later may succeed
+var ErrChannelUnavailable = errors.New("The channel is not available")
+
+// DefaultChannelUnavailableBackoff is the time waited before the first
+// retry when the Validator sets no ChannelUnavailableBackoff
+const DefaultChannelUnavailableBackoff = 10 * time.Millisecond
+
+// getIdentityDeserializer returns the IdentityDeserializer for the given
+// chain; if none is found, the lookup is retried ChannelUnavailableRetries
+// times, doubling the backoff between retries, before giving up. The
+// retries stop early once the context is done
+func (v *Validator) getIdentityDeserializer(ctx context.Context, chainID string) (msp.IdentityDeserializer, error) {
+	backoff := v.ChannelUnavailableBackoff
+	if backoff == 0 {
+		backoff = DefaultChannelUnavailableBackoff
+	}
+
+	for retry := 0; ; retry++ {
+		mspObj := v.lookupIdentityDeserializer(chainID)
+		if mspObj != nil {
+			return mspObj, nil
+		}
+
+		if retry >= v.ChannelUnavailableRetries {
+			putilsLogger.Errorf("could not get msp for chain [%s] after %d retries", chainID, retry)
+			return nil, ErrChannelUnavailable
+		}
+
+		putilsLogger.Warningf("could not get msp for chain [%s], retrying in %s", chainID, backoff)
+		timer := time.NewTimer(backoff)
+		select {
+		case <-timer.C:
+		case <-ctx.Done():
+			timer.Stop()
+			putilsLogger.Errorf("could not get msp for chain [%s] after %d retries, err %s", chainID, retry, ctx.Err())
+			return nil, ErrChannelUnavailable
+		}
+		backoff *= 2
+	}
+}
+
+// lookupIdentityDeserializer returns the IdentityDeserializer for the
+// given chain, or nil if there is none
+func (v *Validator) lookupIdentityDeserializer(chainID string) msp.IdentityDeserializer {
+	if v.DeserializerProvider != nil {
+		return v.DeserializerProvider.GetIdentityDeserializer(chainID)
+	}
+
+	return mspmgmt.GetIdentityDeserializer(chainID)
+}
@@ -0,0 +1,120 @@
+/*
+Copyright IBM Corp. 2017 All Rights Reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+		 http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package validation
+
+import (
+	"testing"
+	"time"
+
+	"github.com/hyperledger/fabric/common/util"
+	"github.com/hyperledger/fabric/msp"
+	"github.com/hyperledger/fabric/protos/utils"
+	"golang.org/x/net/context"
+)
+
+// flakyDeserializerProvider returns no deserializer for the first
+// lookups, as happens while the peer is leaving a channel
+type flakyDeserializerProvider struct {
+	mockDeserializerProvider
+	unavailable int
+	lookups     int
+}
+
+func (p *flakyDeserializerProvider) GetIdentityDeserializer(chainID string) msp.IdentityDeserializer {
+	p.lookups++
+	if p.lookups <= p.unavailable {
+		return nil
+	}
+	return p.deserializer
+}
+
+func TestChannelUnavailable(t *testing.T) {
+	creator := utils.MarshalOrPanic(&msp.SerializedIdentity{Mspid: "Org1", IdBytes: []byte("cert")})
+	sig := []byte("signature")
+	deserializer := mockDeserializer{
+		string(creator): &mockIdentity{mspID: "Org1", valid: true, sig: sig},
+	}
+
+	tests := []struct {
+		name        string
+		unavailable int
+		retries     int
+		valid       bool
+	}{
+		{"Available", 0, 0, true},
+		{"NoRetries", 1, 0, false},
+		{"RecoveredOnRetry", 2, 3, true},
+		{"RetriesExhausted", 4, 3, false},
+	}
+
+	for _, test := range tests {
+		provider := &flakyDeserializerProvider{
+			mockDeserializerProvider: mockDeserializerProvider{deserializer},
+			unavailable:              test.unavailable,
+		}
+		v := &Validator{
+			DeserializerProvider:      provider,
+			ChannelUnavailableRetries: test.retries,
+			ChannelUnavailableBackoff: time.Millisecond,
+		}
+		err := v.checkSignatureFromCreator(creator, sig, []byte("message"), util.GetTestChainID())
+		if test.valid && err != nil {
+			t.Fatalf("%s: checkSignatureFromCreator failed, err %s", test.name, err)
+		}
+		if !test.valid {
+			if err != ErrChannelUnavailable {
+				t.Fatalf("%s: checkSignatureFromCreator should have failed with ErrChannelUnavailable, got %v", test.name, err)
+			}
+			if GetErrorClass(err) != TransientError {
+				t.Fatalf("%s: ErrChannelUnavailable should be transient, got %s", test.name, GetErrorClass(err))
+			}
+		}
+	}
+}
+
+func TestChannelUnavailableContextDeadline(t *testing.T) {
+	creator := utils.MarshalOrPanic(&msp.SerializedIdentity{Mspid: "Org1", IdBytes: []byte("cert")})
+	sig := []byte("signature")
+	deserializer := mockDeserializer{
+		string(creator): &mockIdentity{mspID: "Org1", valid: true, sig: sig},
+	}
+
+	provider := &flakyDeserializerProvider{
+		mockDeserializerProvider: mockDeserializerProvider{deserializer},
+		unavailable:              2,
+	}
+	v := &Validator{
+		DeserializerProvider:      provider,
+		ChannelUnavailableRetries: 3,
+		ChannelUnavailableBackoff: time.Minute,
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+
+	start := time.Now()
+	_, err := v.verifyCreator(ctx, creator, sig, []byte("message"), util.GetTestChainID())
+	if err != ErrChannelUnavailable {
+		t.Fatalf("verifyCreator should have failed with ErrChannelUnavailable, got %v", err)
+	}
+	if elapsed := time.Since(start); elapsed > 10*time.Second {
+		t.Fatalf("verifyCreator should have given up at the context deadline, took %s", elapsed)
+	}
+	if provider.lookups != 1 {
+		t.Fatalf("the lookup should not have been retried after the context deadline, got %d lookups", provider.lookups)
+	}
+}
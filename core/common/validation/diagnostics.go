@@ -0,0 +1,195 @@
+/*
+Copyright IBM Corp. 2017 All Rights Reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+		 http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package validation
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/golang/protobuf/proto"
+	"github.com/hyperledger/fabric/msp"
+	"github.com/hyperledger/fabric/protos/common"
+	pb "github.com/hyperledger/fabric/protos/peer"
+	"github.com/hyperledger/fabric/protos/utils"
+)
+
+// TransactionDiagnostics is the document returned by ValidateTransactionJSON.
+// It describes as much of the transaction as could be decoded, along with
+// the outcome of its validation:
+//
+//	{
+//	  "channel": "mychannel",               // channel ID
+//	  "txid": "4f2a...",                    // transaction ID
+//	  "type": "ENDORSER_TRANSACTION",       // header type
+//	  "creator": {"msp": "Org1MSP", "id": "-----BEGIN CERTIFICATE-----..."},
+//	  "actions": [                          // endorser transactions only
+//	    {
+//	      "chaincode": "mycc",
+//	      "version": "1.0",
+//	      "endorsers": [{"msp": "Org1MSP", "id": "-----BEGIN..."}]
+//	    }
+//	  ],
+//	  "valid": false,
+//	  "error": "...",                       // if not valid
+//	  "error_class": "permanent"            // if not valid, see ErrorClass
+//	}
+//
+// Fields that could not be decoded are omitted
+type TransactionDiagnostics struct {
+	Channel    string               `json:"channel,omitempty"`
+	TxID       string               `json:"txid,omitempty"`
+	Type       string               `json:"type,omitempty"`
+	Creator    *IdentityDiagnostics `json:"creator,omitempty"`
+	Actions    []*ActionDiagnostics `json:"actions,omitempty"`
+	Valid      bool                 `json:"valid"`
+	Error      string               `json:"error,omitempty"`
+	ErrorClass string               `json:"error_class,omitempty"`
+}
+
+// IdentityDiagnostics describes a serialized identity
+type IdentityDiagnostics struct {
+	MSP string `json:"msp"`
+	ID  string `json:"id"`
+}
+
+// ActionDiagnostics describes an action of an endorser transaction
+type ActionDiagnostics struct {
+	Chaincode string                 `json:"chaincode,omitempty"`
+	Version   string                 `json:"version,omitempty"`
+	Endorsers []*IdentityDiagnostics `json:"endorsers"`
+}
+
+// ValidateTransactionJSON validates a transaction envelope with the default
+// validator and describes it as a JSON TransactionDiagnostics document
+func ValidateTransactionJSON(e *common.Envelope) ([]byte, error) {
+	return defaultValidator.ValidateTransactionJSON(e)
+}
+
+// ValidateTransactionJSON validates a transaction envelope and describes it
+// as a JSON TransactionDiagnostics document. The outcome of the validation
+// is part of the document: an error is only returned if the document could
+// not be produced
+func (v *Validator) ValidateTransactionJSON(e *common.Envelope) ([]byte, error) {
+	result, err := v.Validate(e)
+
+	// the payload is not returned if the validation failed early on, but
+	// may still be described
+	payload := result.Payload
+	if payload == nil && e != nil {
+		payload, _ = v.getPayload(e)
+	}
+
+	diag := describeTransaction(payload)
+	diag.Valid = err == nil
+	if err != nil {
+		diag.Error = err.Error()
+		diag.ErrorClass = GetErrorClass(err).String()
+	}
+
+	doc, err := json.Marshal(diag)
+	if err != nil {
+		return nil, fmt.Errorf("Could not marshal the diagnostics of the transaction, err %s", err)
+	}
+
+	return doc, nil
+}
+
+// describeTransaction describes the decodable parts of a payload
+func describeTransaction(payload *common.Payload) *TransactionDiagnostics {
+	diag := &TransactionDiagnostics{}
+	if payload == nil || payload.Header == nil {
+		return diag
+	}
+
+	if chdr := payload.Header.ChannelHeader; chdr != nil {
+		diag.Channel = chdr.ChannelId
+		diag.TxID = chdr.TxId
+		diag.Type = common.HeaderType(chdr.Type).String()
+	}
+
+	if shdr := payload.Header.SignatureHeader; shdr != nil {
+		diag.Creator = describeIdentity(shdr.Creator)
+	}
+
+	if diag.Type != common.HeaderType_ENDORSER_TRANSACTION.String() {
+		return diag
+	}
+
+	tx, err := utils.GetTransaction(payload.Data)
+	if err != nil {
+		return diag
+	}
+
+	for _, act := range tx.Actions {
+		if act == nil {
+			continue
+		}
+		diag.Actions = append(diag.Actions, describeAction(act))
+	}
+
+	return diag
+}
+
+// describeAction describes the chaincode invoked by an action and the
+// identities that endorsed it
+func describeAction(act *pb.TransactionAction) *ActionDiagnostics {
+	diag := &ActionDiagnostics{Endorsers: []*IdentityDiagnostics{}}
+
+	cap, err := utils.GetChaincodeActionPayload(act.Payload)
+	if err != nil {
+		return diag
+	}
+
+	if cap.Action != nil {
+		for _, endorsement := range cap.Action.Endorsements {
+			if endorsement == nil {
+				continue
+			}
+			if endorser := describeIdentity(endorsement.Endorser); endorser != nil {
+				diag.Endorsers = append(diag.Endorsers, endorser)
+			}
+		}
+	}
+
+	cpp, err := utils.GetChaincodeProposalPayload(cap.ChaincodeProposalPayload)
+	if err != nil {
+		return diag
+	}
+
+	cis := &pb.ChaincodeInvocationSpec{}
+	if err = proto.Unmarshal(cpp.Input, cis); err != nil {
+		return diag
+	}
+
+	if ccID := cis.GetChaincodeSpec().GetChaincodeId(); ccID != nil {
+		diag.Chaincode = ccID.Name
+		diag.Version = ccID.Version
+	}
+
+	return diag
+}
+
+// describeIdentity describes a serialized identity, or returns nil if it
+// cannot be decoded
+func describeIdentity(serialized []byte) *IdentityDiagnostics {
+	sId := &msp.SerializedIdentity{}
+	if err := proto.Unmarshal(serialized, sId); err != nil {
+		return nil
+	}
+
+	return &IdentityDiagnostics{MSP: sId.Mspid, ID: string(sId.IdBytes)}
+}
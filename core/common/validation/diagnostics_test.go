@@ -0,0 +1,107 @@
+/*
+Copyright IBM Corp. 2017 All Rights Reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+		 http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package validation
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/golang/protobuf/proto"
+	"github.com/hyperledger/fabric/common/util"
+	"github.com/hyperledger/fabric/msp"
+	"github.com/hyperledger/fabric/protos/common"
+)
+
+func TestValidateTransactionJSON(t *testing.T) {
+	// a transaction with two actions
+	tx := getCostTransaction(t, 2, 1)
+	sig, err := signer.Sign(tx.Payload)
+	if err != nil {
+		t.Fatalf("Sign failed, err %s", err)
+	}
+	tx.Signature = sig
+
+	sId := &msp.SerializedIdentity{}
+	if err = proto.Unmarshal(signerSerialized, sId); err != nil {
+		t.Fatalf("Unmarshal failed, err %s", err)
+	}
+
+	doc, err := ValidateTransactionJSON(tx)
+	if err != nil {
+		t.Fatalf("ValidateTransactionJSON failed, err %s", err)
+	}
+
+	diag := &TransactionDiagnostics{}
+	if err = json.Unmarshal(doc, diag); err != nil {
+		t.Fatalf("Unmarshal failed, err %s", err)
+	}
+
+	if !diag.Valid || diag.Error != "" || diag.ErrorClass != "" {
+		t.Fatalf("Transaction should be valid, got %s", doc)
+	}
+	if diag.Channel != util.GetTestChainID() || diag.TxID == "" || diag.Type != "ENDORSER_TRANSACTION" {
+		t.Fatalf("Unexpected header fields in %s", doc)
+	}
+	if diag.Creator == nil || diag.Creator.MSP != sId.Mspid || diag.Creator.ID != string(sId.IdBytes) {
+		t.Fatalf("Unexpected creator in %s", doc)
+	}
+	if len(diag.Actions) != 2 {
+		t.Fatalf("Expected 2 actions, got %d", len(diag.Actions))
+	}
+	for i, action := range diag.Actions {
+		if action.Chaincode != "foo" {
+			t.Fatalf("Expected chaincode foo for action %d, got %s", i, action.Chaincode)
+		}
+		if len(action.Endorsers) != 1 || action.Endorsers[0].MSP != sId.Mspid {
+			t.Fatalf("Unexpected endorsers for action %d in %s", i, doc)
+		}
+	}
+
+	// the outcome is reported along with the decoded fields
+	badTx := &common.Envelope{Payload: tx.Payload, Signature: append([]byte(nil), sig...)}
+	corrupt(badTx.Signature)
+
+	doc, err = ValidateTransactionJSON(badTx)
+	if err != nil {
+		t.Fatalf("ValidateTransactionJSON failed, err %s", err)
+	}
+
+	diag = &TransactionDiagnostics{}
+	if err = json.Unmarshal(doc, diag); err != nil {
+		t.Fatalf("Unmarshal failed, err %s", err)
+	}
+	if diag.Valid || diag.Error == "" || diag.ErrorClass != UnclassifiedError.String() {
+		t.Fatalf("Transaction should be invalid, got %s", doc)
+	}
+	if diag.Channel != util.GetTestChainID() || diag.Creator == nil {
+		t.Fatalf("Unexpected fields in %s", doc)
+	}
+
+	// nothing can be described for undecodable envelopes
+	doc, err = ValidateTransactionJSON(&common.Envelope{Payload: []byte("garbage")})
+	if err != nil {
+		t.Fatalf("ValidateTransactionJSON failed, err %s", err)
+	}
+
+	fields := map[string]interface{}{}
+	if err = json.Unmarshal(doc, &fields); err != nil {
+		t.Fatalf("Unmarshal failed, err %s", err)
+	}
+	if len(fields) != 3 || fields["valid"] != false || fields["error_class"] != TransientError.String() {
+		t.Fatalf("Unexpected fields in %s", doc)
+	}
+}
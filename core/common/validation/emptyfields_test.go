@@ -0,0 +1,114 @@
+/*
+Copyright IBM Corp. 2017 All Rights Reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+		 http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package validation
+
+import (
+	"testing"
+
+	"github.com/hyperledger/fabric/common/util"
+	"github.com/hyperledger/fabric/protos/common"
+	"github.com/hyperledger/fabric/protos/utils"
+	"golang.org/x/net/context"
+)
+
+func TestEmptyFields(t *testing.T) {
+	chainID := util.GetTestChainID()
+	hdr := &common.Header{
+		ChannelHeader:   &common.ChannelHeader{Type: int32(common.HeaderType_ENDORSER_TRANSACTION), ChannelId: chainID},
+		SignatureHeader: &common.SignatureHeader{Creator: signerSerialized, Nonce: []byte("nonce")},
+	}
+
+	// each check is fed the nil and the empty slice in turn
+	tests := []struct {
+		name  string
+		check func(b []byte) error
+	}{
+		{"Nonce", func(b []byte) error {
+			return validateSignatureHeader(&common.SignatureHeader{Creator: signerSerialized, Nonce: b})
+		}},
+		{"Creator", func(b []byte) error {
+			return validateSignatureHeader(&common.SignatureHeader{Creator: b, Nonce: []byte("nonce")})
+		}},
+		{"CreatorIdentity", func(b []byte) error {
+			return defaultValidator.checkSignatureFromCreator(b, []byte("signature"), []byte("message"), chainID)
+		}},
+		{"Signature", func(b []byte) error {
+			return defaultValidator.checkSignatureFromCreator(signerSerialized, b, []byte("message"), chainID)
+		}},
+		{"SignedMessage", func(b []byte) error {
+			return defaultValidator.checkSignatureFromCreator(signerSerialized, []byte("signature"), b, chainID)
+		}},
+		{"EndorserTransaction", func(b []byte) error {
+			return defaultValidator.validateEndorserTransaction(context.Background(), b, hdr, nil)
+		}},
+		{"ConfigTransaction", func(b []byte) error {
+			return defaultValidator.validateConfigTransaction(b, hdr)
+		}},
+		{"ProposalHashHeader", func(b []byte) error {
+			return verifyProposalHash(b, []byte("payload"), []byte("hash"))
+		}},
+		{"ProposalHashPayload", func(b []byte) error {
+			return verifyProposalHash([]byte("header"), b, []byte("hash"))
+		}},
+	}
+
+	for _, test := range tests {
+		nilErr := test.check(nil)
+		emptyErr := test.check([]byte{})
+		if nilErr == nil || emptyErr == nil {
+			t.Fatalf("%s: the check should have failed, got %v and %v", test.name, nilErr, emptyErr)
+		}
+		if nilErr.Error() != emptyErr.Error() {
+			t.Fatalf("%s: expected the same error for nil and empty, got %v and %v", test.name, nilErr, emptyErr)
+		}
+	}
+}
+
+func TestEmptyPayloadVisibility(t *testing.T) {
+	prop, err := getProposal()
+	if err != nil {
+		t.Fatalf("getProposal failed, err %s", err)
+	}
+
+	hdr, err := utils.GetHeader(prop.Header)
+	if err != nil {
+		t.Fatalf("GetHeader failed, err %s", err)
+	}
+
+	// the payload visibility, field 1 of the chaincode header extension,
+	// explicitly encoded with no bytes is decoded to an empty slice
+	hdr.ChannelHeader.Extension = append([]byte{0x0a, 0x00}, hdr.ChannelHeader.Extension...)
+	ext, err := utils.GetChaincodeHeaderExtension(hdr)
+	if err != nil {
+		t.Fatalf("GetChaincodeHeaderExtension failed, err %s", err)
+	}
+	if ext.PayloadVisibility == nil || len(ext.PayloadVisibility) != 0 {
+		t.Fatalf("Expected an empty payload visibility, got %#v", ext.PayloadVisibility)
+	}
+	prop.Header = utils.MarshalOrPanic(hdr)
+
+	// and accepted as the omitted one
+	sProp, err := utils.GetSignedProposal(prop, signer)
+	if err != nil {
+		t.Fatalf("GetSignedProposal failed, err %s", err)
+	}
+
+	_, _, _, err = ValidateProposalMessage(sProp)
+	if err != nil {
+		t.Fatalf("ValidateProposalMessage failed, err %s", err)
+	}
+}
@@ -0,0 +1,89 @@
+/*
+Copyright IBM Corp. 2017 All Rights Reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+		 http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package validation
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+
+	"github.com/golang/protobuf/proto"
+	"github.com/hyperledger/fabric/msp"
+	pb "github.com/hyperledger/fabric/protos/peer"
+	"golang.org/x/net/context"
+)
+
+// ErrUnsortedEndorsements is returned in strict mode for actions whose
+// endorsements are not in canonical order
+var ErrUnsortedEndorsements = errors.New("The endorsements of the action are not in canonical order")
+
+// checkEndorsementOrder ensures that the endorsements of an action are in
+// canonical order, as configured by EndorsementOrdering, which must not be
+// off: sorted by the MSP ID of their endorser, then by the bytes of its
+// identity, both as claimed by the serialized endorser so that clients can
+// sort them without the MSPs. Equal endorsers are left to
+// checkDistinctEndorsers. Canonical ordering makes transactions with the
+// same endorsements byte-identical, so that reordering them cannot change
+// the transaction, nor its fingerprint
+func (v *Validator) checkEndorsementOrder(ctx context.Context, chainID string, action *pb.ChaincodeEndorsedAction) error {
+	if action == nil {
+		return fmt.Errorf("Nil endorsed action")
+	}
+
+	var previous *msp.SerializedIdentity
+	for i, endorsement := range action.Endorsements {
+		if endorsement == nil {
+			return fmt.Errorf("Nil endorsement")
+		}
+
+		// an aggregated endorsement is the only one of its action
+		if isAggregateEndorsement(endorsement) {
+			continue
+		}
+
+		endorser := &msp.SerializedIdentity{}
+		err := proto.Unmarshal(endorsement.Endorser, endorser)
+		if err != nil {
+			return fmt.Errorf("Could not decode the endorser of endorsement %d, err %s", i, err)
+		}
+
+		if previous != nil && compareEndorsers(previous, endorser) > 0 {
+			if v.EndorsementOrdering == HeuristicsStrict {
+				putilsLogger.Errorf("checkEndorsementOrder error: endorsement %d by MSP %s endorser out of order on chain [%s]", i, endorser.Mspid, chainID)
+				return ErrUnsortedEndorsements
+			}
+
+			addWarning(ctx, WarningFlagged, "Endorsement %d by MSP %s endorser out of order on chain [%s]", i, endorser.Mspid, chainID)
+			return nil
+		}
+		previous = endorser
+	}
+
+	return nil
+}
+
+// compareEndorsers compares two endorsers by MSP ID, then by identity bytes
+func compareEndorsers(a, b *msp.SerializedIdentity) int {
+	if a.Mspid != b.Mspid {
+		if a.Mspid < b.Mspid {
+			return -1
+		}
+		return 1
+	}
+
+	return bytes.Compare(a.IdBytes, b.IdBytes)
+}
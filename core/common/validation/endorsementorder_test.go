@@ -0,0 +1,81 @@
+/*
+Copyright IBM Corp. 2017 All Rights Reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+		 http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package validation
+
+import (
+	"testing"
+
+	"github.com/hyperledger/fabric/msp"
+	"github.com/hyperledger/fabric/protos/common"
+	"github.com/hyperledger/fabric/protos/peer"
+	"github.com/hyperledger/fabric/protos/utils"
+)
+
+func TestEndorsementOrder(t *testing.T) {
+	tx, err := getTransaction([]byte("simulation_result"))
+	if err != nil {
+		t.Fatalf("getTransaction failed, err %s", err)
+	}
+
+	// the transaction is endorsed by a member of the DEFAULT MSP
+	org2A := utils.MarshalOrPanic(&msp.SerializedIdentity{Mspid: "Org2", IdBytes: []byte("certA")})
+	org2B := utils.MarshalOrPanic(&msp.SerializedIdentity{Mspid: "Org2", IdBytes: []byte("certB")})
+	admins := utils.MarshalOrPanic(&msp.SerializedIdentity{Mspid: "ADMINS", IdBytes: []byte("cert")})
+	provider := &mockDeserializerProvider{fallbackDeserializer{
+		string(org2A):  &mockIdentity{mspID: "Org2", id: "A", valid: true},
+		string(org2B):  &mockIdentity{mspID: "Org2", id: "B", valid: true},
+		string(admins): &mockIdentity{mspID: "ADMINS", id: "0", valid: true},
+	}}
+
+	endorsedBy := func(endorsers ...[]byte) *common.Envelope {
+		etx, err := modifyTransaction(tx, func(sHdr *common.SignatureHeader, cap *peer.ChaincodeActionPayload, prp *peer.ProposalResponsePayload) {
+			for _, endorser := range endorsers {
+				cap.Action.Endorsements = append(cap.Action.Endorsements, &peer.Endorsement{Endorser: endorser, Signature: []byte("signature")})
+			}
+		})
+		if err != nil {
+			t.Fatalf("modifyTransaction failed, err %s", err)
+		}
+		return etx
+	}
+
+	tests := []struct {
+		name     string
+		tx       *common.Envelope
+		ordering HeuristicsMode
+		err      error
+		warned   bool
+	}{
+		{"Single", tx, HeuristicsStrict, nil, false},
+		{"Sorted", endorsedBy(org2A, org2B), HeuristicsStrict, nil, false},
+		{"UnsortedMSPs", endorsedBy(admins), HeuristicsStrict, ErrUnsortedEndorsements, false},
+		{"UnsortedIdentities", endorsedBy(org2B, org2A), HeuristicsStrict, ErrUnsortedEndorsements, false},
+		{"UnsortedLenient", endorsedBy(org2B, org2A), HeuristicsLenient, nil, true},
+		{"UnsortedOff", endorsedBy(org2B, org2A), HeuristicsOff, nil, false},
+	}
+
+	for _, test := range tests {
+		v := &Validator{DeserializerProvider: provider, EndorsementOrdering: test.ordering}
+		result, err := v.Validate(test.tx)
+		if err != test.err {
+			t.Fatalf("%s: expected err %v, got %v", test.name, test.err, err)
+		}
+		if warned := len(result.Warnings) != 0; warned != test.warned {
+			t.Fatalf("%s: expected a warning %t, got %v", test.name, test.warned, result.Warnings)
+		}
+	}
+}
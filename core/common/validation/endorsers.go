@@ -0,0 +1,204 @@
+/*
+Copyright IBM Corp. 2017 All Rights Reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+		 http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package validation
+
+import (
+	"errors"
+	"fmt"
+
+	pb "github.com/hyperledger/fabric/protos/peer"
+	"golang.org/x/net/context"
+)
+
+// ErrDuplicateEndorser is returned when the same identity endorsed an action
+// more than once, which could be an attempt to satisfy a policy requiring a
+// number of endorsements with duplicated signatures
+var ErrDuplicateEndorser = errors.New("The action was endorsed more than once by the same endorser")
+
+// ErrEndorserNotAllowed is returned when an action was endorsed by a member
+// of an MSP that is not allowed to endorse
+var ErrEndorserNotAllowed = errors.New("The endorser MSP is not allowed")
+
+// ErrInvalidEndorsementSignature is returned when the signature of an
+// endorsement does not verify against its endorser
+var ErrInvalidEndorsementSignature = errors.New("Invalid endorsement signature")
+
+// checkEndorsers performs the enabled checks on the endorsers of an action
+func (v *Validator) checkEndorsers(ctx context.Context, chainID string, action *pb.ChaincodeEndorsedAction) error {
+	if !v.AllowDuplicateEndorsers {
+		err := v.checkDistinctEndorsers(ctx, chainID, action)
+		if err != nil {
+			return err
+		}
+	}
+
+	if len(v.AllowedEndorserMSPs) != 0 {
+		err := v.checkAllowedEndorsers(ctx, chainID, action)
+		if err != nil {
+			return err
+		}
+	}
+
+	if v.EndorsementOrdering != HeuristicsOff {
+		err := v.checkEndorsementOrder(ctx, chainID, action)
+		if err != nil {
+			return err
+		}
+	}
+
+	if v.VerifyEndorsementSignatures {
+		return v.verifyEndorsementSignatures(ctx, chainID, action)
+	}
+
+	return nil
+}
+
+// checkDistinctEndorsers ensures that the endorsers of an action are
+// distinct identities, regardless of their encodings: endorsers of the same
+// MSP with the same certificate are the same, see identityFingerprint.
+// Identities without certificate are compared by their serialized bytes.
+// Aggregated endorsements are left to the AggregateVerifier
+func (v *Validator) checkDistinctEndorsers(ctx context.Context, chainID string, action *pb.ChaincodeEndorsedAction) error {
+	if action == nil {
+		return fmt.Errorf("Nil endorsed action")
+	}
+
+	// a single endorser cannot be duplicated
+	if len(action.Endorsements) < 2 {
+		return nil
+	}
+
+	mspObj, err := v.getIdentityDeserializer(ctx, chainID)
+	if err != nil {
+		return err
+	}
+
+	seen := make(map[string]struct{}, len(action.Endorsements))
+	for _, endorsement := range action.Endorsements {
+		if endorsement == nil {
+			return fmt.Errorf("Nil endorsement")
+		}
+
+		if isAggregateEndorsement(endorsement) {
+			continue
+		}
+
+		endorser, err := mspObj.DeserializeIdentity(endorsement.Endorser)
+		if err != nil {
+			return fmt.Errorf("Failed to deserialize endorser identity, err %s", err)
+		}
+		countStats(ctx, func(stats *ValidationStats) { stats.IdentitiesDeserialized++ })
+
+		key, err := identityKey(endorser, endorsement.Endorser)
+		if err != nil {
+			return err
+		}
+
+		if _, ok := seen[key]; ok {
+			putilsLogger.Errorf("checkDistinctEndorsers error: MSP %s endorser appears more than once on chain [%s]", endorser.GetMSPIdentifier(), chainID)
+			return ErrDuplicateEndorser
+		}
+		seen[key] = struct{}{}
+	}
+
+	return nil
+}
+
+// checkAllowedEndorsers ensures that all the endorsers of an action are
+// members of the allowed MSPs; the MSP of each endorser is the one its
+// identity deserializes to, not the one it claims
+func (v *Validator) checkAllowedEndorsers(ctx context.Context, chainID string, action *pb.ChaincodeEndorsedAction) error {
+	if action == nil {
+		return fmt.Errorf("Nil endorsed action")
+	}
+
+	mspObj, err := v.getIdentityDeserializer(ctx, chainID)
+	if err != nil {
+		return err
+	}
+
+	for _, endorsement := range action.Endorsements {
+		if endorsement == nil {
+			return fmt.Errorf("Nil endorsement")
+		}
+
+		if isAggregateEndorsement(endorsement) {
+			putilsLogger.Errorf("checkAllowedEndorsers error: the MSPs of aggregated endorsements cannot be checked")
+			return ErrEndorserNotAllowed
+		}
+
+		endorser, err := mspObj.DeserializeIdentity(endorsement.Endorser)
+		if err != nil {
+			return fmt.Errorf("Failed to deserialize endorser identity, err %s", err)
+		}
+		countStats(ctx, func(stats *ValidationStats) { stats.IdentitiesDeserialized++ })
+
+		if _, ok := v.AllowedEndorserMSPs[endorser.GetMSPIdentifier()]; !ok {
+			putilsLogger.Errorf("checkAllowedEndorsers error: endorser MSP %s is not allowed on chain [%s]", endorser.GetMSPIdentifier(), chainID)
+			return ErrEndorserNotAllowed
+		}
+	}
+
+	return nil
+}
+
+// verifyEndorsementSignatures ensures that the signature of each endorsement
+// of an action is valid over the proposal response payload concatenated with
+// the endorser, as signed by the endorsers. The endorsement policy is still
+// evaluated by VSCC, but forged endorsements are rejected early on, without
+// regard to the policy. Aggregated endorsements are left to the
+// AggregateVerifier
+func (v *Validator) verifyEndorsementSignatures(ctx context.Context, chainID string, action *pb.ChaincodeEndorsedAction) error {
+	if action == nil {
+		return fmt.Errorf("Nil endorsed action")
+	}
+
+	mspObj, err := v.getIdentityDeserializer(ctx, chainID)
+	if err != nil {
+		return err
+	}
+
+	for i, endorsement := range action.Endorsements {
+		if endorsement == nil {
+			return fmt.Errorf("Nil endorsement")
+		}
+
+		if isAggregateEndorsement(endorsement) {
+			continue
+		}
+
+		endorser, err := mspObj.DeserializeIdentity(endorsement.Endorser)
+		if err != nil {
+			return fmt.Errorf("Failed to deserialize endorser identity, err %s", err)
+		}
+		countStats(ctx, func(stats *ValidationStats) { stats.IdentitiesDeserialized++ })
+
+		msg := make([]byte, 0, len(action.ProposalResponsePayload)+len(endorsement.Endorser))
+		msg = append(append(msg, action.ProposalResponsePayload...), endorsement.Endorser...)
+		err = endorser.Verify(msg, endorsement.Signature)
+		if err != nil {
+			putilsLogger.Errorf("verifyEndorsementSignatures error: invalid signature of endorsement %d by MSP %s endorser on chain [%s], err %s", i, endorser.GetMSPIdentifier(), chainID, err)
+			return ErrInvalidEndorsementSignature
+		}
+		countStats(ctx, func(stats *ValidationStats) {
+			stats.SignaturesVerified++
+			stats.BytesHashed += len(msg)
+		})
+	}
+
+	return nil
+}
@@ -0,0 +1,247 @@
+/*
+Copyright IBM Corp. 2017 All Rights Reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+		 http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package validation
+
+import (
+	"io/ioutil"
+	"testing"
+
+	"github.com/hyperledger/fabric/common/util"
+	"github.com/hyperledger/fabric/msp"
+	mspmgmt "github.com/hyperledger/fabric/msp/mgmt"
+	"github.com/hyperledger/fabric/protos/common"
+	"github.com/hyperledger/fabric/protos/peer"
+	"github.com/hyperledger/fabric/protos/utils"
+)
+
+func TestAllowedEndorsers(t *testing.T) {
+	tx, err := getTransaction([]byte("simulation_result"))
+	if err != nil {
+		t.Fatalf("getTransaction failed, err %s", err)
+	}
+
+	// the transaction is also endorsed by a member of Org2
+	org2Endorser := utils.MarshalOrPanic(&msp.SerializedIdentity{Mspid: "Org2", IdBytes: []byte("cert")})
+	org2Tx, err := modifyTransaction(tx, func(sHdr *common.SignatureHeader, cap *peer.ChaincodeActionPayload, prp *peer.ProposalResponsePayload) {
+		cap.Action.Endorsements = append(cap.Action.Endorsements, &peer.Endorsement{Endorser: org2Endorser, Signature: []byte("signature")})
+	})
+	if err != nil {
+		t.Fatalf("modifyTransaction failed, err %s", err)
+	}
+
+	provider := &mockDeserializerProvider{fallbackDeserializer{
+		string(org2Endorser): &mockIdentity{mspID: "Org2", valid: true},
+	}}
+
+	tests := []struct {
+		name    string
+		tx      *common.Envelope
+		allowed map[string]struct{}
+		err     error
+	}{
+		{"AllowAll", org2Tx, nil, nil},
+		{"Allowed", tx, map[string]struct{}{"DEFAULT": struct{}{}}, nil},
+		{"AllAllowed", org2Tx, map[string]struct{}{"DEFAULT": struct{}{}, "Org2": struct{}{}}, nil},
+		{"Forbidden", tx, map[string]struct{}{"Org2": struct{}{}}, ErrEndorserNotAllowed},
+		{"OneForbidden", org2Tx, map[string]struct{}{"DEFAULT": struct{}{}}, ErrEndorserNotAllowed},
+	}
+
+	for _, test := range tests {
+		v := &Validator{DeserializerProvider: provider, AllowedEndorserMSPs: test.allowed}
+		_, err := v.ValidateTransaction(test.tx)
+		if err != test.err {
+			t.Fatalf("%s: expected err %v, got %v", test.name, test.err, err)
+		}
+	}
+}
+
+func TestDistinctEndorsers(t *testing.T) {
+	tx, err := getTransaction([]byte("simulation_result"))
+	if err != nil {
+		t.Fatalf("getTransaction failed, err %s", err)
+	}
+
+	signerIdentity, err := mspmgmt.GetIdentityDeserializer(util.GetTestChainID()).DeserializeIdentity(signerSerialized)
+	if err != nil {
+		t.Fatalf("DeserializeIdentity failed, err %s", err)
+	}
+
+	other := utils.MarshalOrPanic(&msp.SerializedIdentity{Mspid: "Org2", IdBytes: []byte("cert")})
+	reencoded := utils.MarshalOrPanic(&msp.SerializedIdentity{Mspid: "DEFAULT", IdBytes: []byte("reencoded")})
+	anonymous1 := utils.MarshalOrPanic(&msp.SerializedIdentity{Mspid: "Org3", IdBytes: []byte("anonymous1")})
+	anonymous2 := utils.MarshalOrPanic(&msp.SerializedIdentity{Mspid: "Org3", IdBytes: []byte("anonymous2")})
+
+	provider := &mockDeserializerProvider{fallbackDeserializer{
+		string(other):      &mockIdentity{mspID: "Org2", id: "cert", valid: true},
+		string(reencoded):  signerIdentity,
+		string(anonymous1): &mockIdemixIdentity{mockIdentity{mspID: "Org3", valid: true}},
+		string(anonymous2): &mockIdemixIdentity{mockIdentity{mspID: "Org3", valid: true}},
+	}}
+
+	tests := []struct {
+		name      string
+		endorsers [][]byte
+		err       error
+	}{
+		{"Distinct", [][]byte{other}, nil},
+		{"DistinctAnonymous", [][]byte{anonymous1, anonymous2}, nil},
+		{"Duplicated", [][]byte{signerSerialized}, ErrDuplicateEndorser},
+		{"DuplicatedReencoded", [][]byte{reencoded}, ErrDuplicateEndorser},
+		{"DuplicatedAnonymous", [][]byte{anonymous1, anonymous1}, ErrDuplicateEndorser},
+		{"DuplicatedLater", [][]byte{other, other}, ErrDuplicateEndorser},
+	}
+
+	for _, test := range tests {
+		mtx, err := modifyTransaction(tx, func(sHdr *common.SignatureHeader, cap *peer.ChaincodeActionPayload, prp *peer.ProposalResponsePayload) {
+			for _, endorser := range test.endorsers {
+				cap.Action.Endorsements = append(cap.Action.Endorsements, &peer.Endorsement{Endorser: endorser, Signature: []byte("signature")})
+			}
+		})
+		if err != nil {
+			t.Fatalf("%s: modifyTransaction failed, err %s", test.name, err)
+		}
+
+		_, err = (&Validator{DeserializerProvider: provider}).ValidateTransaction(mtx)
+		if err != test.err {
+			t.Fatalf("%s: expected err %v, got %v", test.name, test.err, err)
+		}
+
+		// the check is configurable
+		_, err = (&Validator{DeserializerProvider: provider, AllowDuplicateEndorsers: true}).ValidateTransaction(mtx)
+		if err != nil {
+			t.Fatalf("%s: ValidateTransaction failed with the check disabled, err %s", test.name, err)
+		}
+	}
+}
+
+// getSampleIdentity returns the serialized identity of the sample MSP
+// holding the certificate of the given file of its config, followed by
+// the trailing bytes
+func getSampleIdentity(t *testing.T, file string, trailing []byte) []byte {
+	cert, err := ioutil.ReadFile("../../../msp/sampleconfig/" + file)
+	if err != nil {
+		t.Fatalf("ReadFile failed, err %s", err)
+	}
+
+	return utils.MarshalOrPanic(&msp.SerializedIdentity{Mspid: "DEFAULT", IdBytes: append(cert, trailing...)})
+}
+
+func TestDistinctEndorsersSameMSP(t *testing.T) {
+	tx, err := getTransaction([]byte("simulation_result"))
+	if err != nil {
+		t.Fatalf("getTransaction failed, err %s", err)
+	}
+
+	// the sample MSP gives the same identifier to all its identities
+	admin := getSampleIdentity(t, "admincerts/admincert.pem", nil)
+	padded := getSampleIdentity(t, "signcerts/peer.pem", []byte("padding"))
+
+	tests := []struct {
+		name     string
+		endorser []byte
+		err      error
+	}{
+		{"OtherCertificate", admin, nil},
+		{"SameCertificatePadded", padded, ErrDuplicateEndorser},
+	}
+
+	for _, test := range tests {
+		mtx, err := modifyTransaction(tx, func(sHdr *common.SignatureHeader, cap *peer.ChaincodeActionPayload, prp *peer.ProposalResponsePayload) {
+			cap.Action.Endorsements = append(cap.Action.Endorsements, &peer.Endorsement{Endorser: test.endorser, Signature: []byte("signature")})
+		})
+		if err != nil {
+			t.Fatalf("%s: modifyTransaction failed, err %s", test.name, err)
+		}
+
+		_, err = ValidateTransaction(mtx)
+		if err != test.err {
+			t.Fatalf("%s: expected err %v, got %v", test.name, test.err, err)
+		}
+	}
+}
+
+func TestEndorsementSignatures(t *testing.T) {
+	tx, err := getTransaction([]byte("simulation_result"))
+	if err != nil {
+		t.Fatalf("getTransaction failed, err %s", err)
+	}
+
+	// the signature of the endorsement is replaced by a forged one
+	forgedTx, err := modifyTransaction(tx, func(sHdr *common.SignatureHeader, cap *peer.ChaincodeActionPayload, prp *peer.ProposalResponsePayload) {
+		sig := cap.Action.Endorsements[0].Signature
+		forged := append([]byte{}, sig...)
+		forged[len(forged)-1] ^= 0xff
+		cap.Action.Endorsements[0].Signature = forged
+	})
+	if err != nil {
+		t.Fatalf("modifyTransaction failed, err %s", err)
+	}
+
+	// the endorsement is genuine, but over another proposal response payload
+	otherSig, err := signer.Sign(append([]byte("other_payload"), signerSerialized...))
+	if err != nil {
+		t.Fatalf("Sign failed, err %s", err)
+	}
+	replayedTx, err := modifyTransaction(tx, func(sHdr *common.SignatureHeader, cap *peer.ChaincodeActionPayload, prp *peer.ProposalResponsePayload) {
+		cap.Action.Endorsements[0].Signature = otherSig
+	})
+	if err != nil {
+		t.Fatalf("modifyTransaction failed, err %s", err)
+	}
+
+	tests := []struct {
+		name   string
+		tx     *common.Envelope
+		verify bool
+		err    error
+	}{
+		{"Valid", tx, true, nil},
+		{"Forged", forgedTx, true, ErrInvalidEndorsementSignature},
+		{"OtherPayload", replayedTx, true, ErrInvalidEndorsementSignature},
+		{"NotVerified", forgedTx, false, nil},
+	}
+
+	for _, test := range tests {
+		v := &Validator{VerifyEndorsementSignatures: test.verify}
+		_, err := v.ValidateTransaction(test.tx)
+		if err != test.err {
+			t.Fatalf("%s: expected err %v, got %v", test.name, test.err, err)
+		}
+	}
+
+	// endorsers are verified with the deserializer of the channel
+	org2Endorser := utils.MarshalOrPanic(&msp.SerializedIdentity{Mspid: "Org2", IdBytes: []byte("cert")})
+	org2Tx, err := modifyTransaction(tx, func(sHdr *common.SignatureHeader, cap *peer.ChaincodeActionPayload, prp *peer.ProposalResponsePayload) {
+		cap.Action.Endorsements = append(cap.Action.Endorsements, &peer.Endorsement{Endorser: org2Endorser, Signature: []byte("signature")})
+	})
+	if err != nil {
+		t.Fatalf("modifyTransaction failed, err %s", err)
+	}
+
+	for _, sig := range []string{"signature", "forged"} {
+		provider := &mockDeserializerProvider{fallbackDeserializer{
+			string(org2Endorser): &mockIdentity{mspID: "Org2", valid: true, sig: []byte(sig)},
+		}}
+		v := &Validator{DeserializerProvider: provider, VerifyEndorsementSignatures: true}
+		_, err = v.ValidateTransaction(org2Tx)
+		if sig == "signature" && err != nil {
+			t.Fatalf("ValidateTransaction failed, err %s", err)
+		} else if sig == "forged" && err != ErrInvalidEndorsementSignature {
+			t.Fatalf("ValidateTransaction should have failed with ErrInvalidEndorsementSignature, got %v", err)
+		}
+	}
+}
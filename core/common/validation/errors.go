@@ -0,0 +1,94 @@
+/*
+Copyright IBM Corp. 2017 All Rights Reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+		 http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package validation
+
+import "fmt"
+
+// ErrorClass tells whether validating a message again may succeed
+type ErrorClass int
+
+const (
+	// UnclassifiedError is the class of the errors that are not known to
+	// be either transient or permanent
+	UnclassifiedError ErrorClass = iota
+
+	// TransientError is the class of the errors decoding an envelope, whose
+	// signature is yet to be verified: they may be due to data truncated or
+	// corrupted in transport, and fetching the envelope again may help;
+	// ErrChannelUnavailable and ErrFramingMismatch are transient as well
+	TransientError
+
+	// PermanentError is the class of the errors decoding the structures
+	// inside an envelope whose signature has been verified: those were
+	// signed as they are, so the message is malformed; ErrCompressedPayload
+	// is permanent as well
+	PermanentError
+)
+
+func (c ErrorClass) String() string {
+	switch c {
+	case UnclassifiedError:
+		return "unclassified"
+	case TransientError:
+		return "transient"
+	case PermanentError:
+		return "permanent"
+	default:
+		return fmt.Sprintf("unknown(%d)", int(c))
+	}
+}
+
+// ValidationError is a validation error along with its class
+type ValidationError struct {
+	// Class is the class of the error
+	Class ErrorClass
+
+	// Err is the underlying error
+	Err error
+}
+
+func (e *ValidationError) Error() string {
+	return e.Err.Error()
+}
+
+// GetErrorClass returns the class of an error returned by the validation
+func GetErrorClass(err error) ErrorClass {
+	if err == ErrUndecodable || err == ErrChannelUnavailable || err == ErrFramingMismatch {
+		return TransientError
+	}
+
+	if err == ErrCompressedPayload {
+		return PermanentError
+	}
+
+	if verr, ok := err.(*ValidationError); ok {
+		return verr.Class
+	}
+
+	return UnclassifiedError
+}
+
+// transientDecodeError classifies an error decoding an envelope as transient
+func transientDecodeError(err error) error {
+	return &ValidationError{Class: TransientError, Err: err}
+}
+
+// permanentDecodeError classifies an error decoding the signed content of
+// an envelope as permanent
+func permanentDecodeError(err error) error {
+	return &ValidationError{Class: PermanentError, Err: err}
+}
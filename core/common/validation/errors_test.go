@@ -0,0 +1,74 @@
+/*
+Copyright IBM Corp. 2017 All Rights Reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+		 http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package validation
+
+import (
+	"testing"
+
+	"github.com/hyperledger/fabric/protos/common"
+	"github.com/hyperledger/fabric/protos/utils"
+)
+
+func TestErrorClass(t *testing.T) {
+	tx, err := getTransaction([]byte("simulation_result"))
+	if err != nil {
+		t.Fatalf("getTransaction failed, err %s", err)
+	}
+
+	// the envelope was truncated in transport
+	truncated := &common.Envelope{Payload: tx.Payload[:len(tx.Payload)-10], Signature: tx.Signature}
+
+	// the signed transaction is malformed
+	payload, err := utils.GetPayload(tx)
+	if err != nil {
+		t.Fatalf("GetPayload failed, err %s", err)
+	}
+	payload.Data = []byte("garbage")
+	malformed := &common.Envelope{Payload: utils.MarshalOrPanic(payload)}
+	malformed.Signature, err = signer.Sign(malformed.Payload)
+	if err != nil {
+		t.Fatalf("Sign failed, err %s", err)
+	}
+
+	// the signature is invalid
+	badSig := &common.Envelope{Payload: tx.Payload, Signature: append([]byte(nil), tx.Signature...)}
+	corrupt(badSig.Signature)
+
+	tests := []struct {
+		name  string
+		tx    *common.Envelope
+		class ErrorClass
+	}{
+		{"Truncated", truncated, TransientError},
+		{"Malformed", malformed, PermanentError},
+		{"BadSignature", badSig, UnclassifiedError},
+	}
+
+	for _, test := range tests {
+		_, err = ValidateTransaction(test.tx)
+		if err == nil {
+			t.Fatalf("%s: ValidateTransaction should have failed", test.name)
+		}
+		if class := GetErrorClass(err); class != test.class {
+			t.Fatalf("%s: expected a %s error, got a %s one, err %s", test.name, test.class, class, err)
+		}
+	}
+
+	if GetErrorClass(ErrUndecodable) != TransientError {
+		t.Fatalf("Undecodable block entries should be transient errors")
+	}
+}
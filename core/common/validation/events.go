@@ -0,0 +1,143 @@
+/*
+Copyright IBM Corp. 2017 All Rights Reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+		 http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package validation
+
+import (
+	"fmt"
+
+	"github.com/golang/protobuf/proto"
+	"github.com/hyperledger/fabric/protos/common"
+	pb "github.com/hyperledger/fabric/protos/peer"
+	"github.com/hyperledger/fabric/protos/utils"
+)
+
+// ExtractChaincodeEvents returns the chaincode events emitted by the actions
+// of an endorser transaction, in the order of the actions; actions emitting
+// no event are skipped. The transaction is checked to be structurally valid
+// but no signature is verified, so callers should only extract the events
+// of transactions they have validated. The events are associated with the
+// ID of the transaction and, if they do not name one, with the chaincode
+// invoked by the transaction
+func ExtractChaincodeEvents(e *common.Envelope) ([]*pb.ChaincodeEvent, error) {
+	if e == nil {
+		return nil, fmt.Errorf("Nil Envelope")
+	}
+
+	if isCompressedPayload(e) {
+		return nil, ErrCompressedPayload
+	}
+
+	payload, err := utils.GetPayload(e)
+	if err != nil {
+		return nil, transientDecodeError(fmt.Errorf("Could not extract payload from envelope, err %s", err))
+	}
+
+	if payload.Header == nil || payload.Header.ChannelHeader == nil {
+		return nil, fmt.Errorf("Nil channel header")
+	}
+
+	chdr := payload.Header.ChannelHeader
+	if common.HeaderType(chdr.Type) != common.HeaderType_ENDORSER_TRANSACTION {
+		return nil, fmt.Errorf("Transactions of type %s carry no chaincode events", common.HeaderType(chdr.Type))
+	}
+
+	hdrExt, err := utils.GetChaincodeHeaderExtension(payload.Header)
+	if err != nil {
+		return nil, fmt.Errorf("Could not unmarshal the chaincode header extension, err %s", err)
+	}
+
+	if hdrExt.ChaincodeId == nil || hdrExt.ChaincodeId.Name == "" {
+		return nil, fmt.Errorf("Missing chaincode ID in the chaincode header extension")
+	}
+
+	tx, err := utils.GetTransaction(payload.Data)
+	if err != nil {
+		return nil, fmt.Errorf("Could not unmarshal the transaction, err %s", err)
+	}
+
+	if len(tx.Actions) == 0 {
+		return nil, fmt.Errorf("At least one TransactionAction is required")
+	}
+
+	var events []*pb.ChaincodeEvent
+	for i, act := range tx.Actions {
+		event, err := extractChaincodeEvent(act)
+		if err != nil {
+			return nil, fmt.Errorf("Invalid action %d, err %s", i, err)
+		}
+
+		if event == nil {
+			continue
+		}
+
+		if event.TxId == "" {
+			event.TxId = chdr.TxId
+		} else if event.TxId != chdr.TxId {
+			return nil, fmt.Errorf("Chaincode event of action %d belongs to transaction [%s], not [%s]", i, event.TxId, chdr.TxId)
+		}
+
+		if event.ChaincodeId == "" {
+			event.ChaincodeId = hdrExt.ChaincodeId.Name
+		}
+
+		events = append(events, event)
+	}
+
+	return events, nil
+}
+
+// extractChaincodeEvent returns the chaincode event emitted by an action,
+// or nil if there is none
+func extractChaincodeEvent(act *pb.TransactionAction) (*pb.ChaincodeEvent, error) {
+	if act == nil {
+		return nil, fmt.Errorf("Nil action")
+	}
+
+	cap, err := utils.GetChaincodeActionPayload(act.Payload)
+	if err != nil {
+		return nil, err
+	}
+
+	if cap.Action == nil {
+		return nil, fmt.Errorf("Nil endorsed action")
+	}
+
+	prp, err := utils.GetProposalResponsePayload(cap.Action.ProposalResponsePayload)
+	if err != nil {
+		return nil, err
+	}
+
+	ca, err := utils.GetChaincodeAction(prp.Extension)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(ca.Events) == 0 {
+		return nil, nil
+	}
+
+	event := &pb.ChaincodeEvent{}
+	if err = proto.Unmarshal(ca.Events, event); err != nil {
+		return nil, fmt.Errorf("Malformed chaincode event, err %s", err)
+	}
+
+	if event.EventName == "" {
+		return nil, fmt.Errorf("Malformed chaincode event with no name")
+	}
+
+	return event, nil
+}
@@ -0,0 +1,127 @@
+/*
+Copyright IBM Corp. 2017 All Rights Reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+		 http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package validation
+
+import (
+	"testing"
+
+	"github.com/hyperledger/fabric/protos/common"
+	"github.com/hyperledger/fabric/protos/peer"
+	"github.com/hyperledger/fabric/protos/utils"
+)
+
+// getTransactionWithEvents returns a signed transaction for a toy proposal
+// whose endorsement carries the given events
+func getTransactionWithEvents(t *testing.T, events func(txID string) []byte) (*common.Envelope, string) {
+	prop, err := getProposal()
+	if err != nil {
+		t.Fatalf("getProposal failed, err %s", err)
+	}
+
+	hdr, err := utils.GetHeader(prop.Header)
+	if err != nil {
+		t.Fatalf("GetHeader failed, err %s", err)
+	}
+	txID := hdr.ChannelHeader.TxId
+
+	presp, err := utils.CreateProposalResponse(prop.Header, prop.Payload, &peer.Response{Status: 200}, []byte("simulation_result"), events(txID), nil, signer)
+	if err != nil {
+		t.Fatalf("CreateProposalResponse failed, err %s", err)
+	}
+
+	tx, err := utils.CreateSignedTx(prop, signer, presp)
+	if err != nil {
+		t.Fatalf("CreateSignedTx failed, err %s", err)
+	}
+
+	return tx, txID
+}
+
+func TestExtractChaincodeEvents(t *testing.T) {
+	tx, txID := getTransactionWithEvents(t, func(txID string) []byte {
+		return utils.MarshalOrPanic(&peer.ChaincodeEvent{ChaincodeId: "foo", TxId: txID, EventName: "transfer", Payload: []byte("payload")})
+	})
+
+	// the transaction is valid
+	_, err := ValidateTransaction(tx)
+	if err != nil {
+		t.Fatalf("ValidateTransaction failed, err %s", err)
+	}
+
+	events, err := ExtractChaincodeEvents(tx)
+	if err != nil {
+		t.Fatalf("ExtractChaincodeEvents failed, err %s", err)
+	}
+	if len(events) != 1 {
+		t.Fatalf("Expected 1 event, got %d", len(events))
+	}
+	if events[0].ChaincodeId != "foo" || events[0].TxId != txID || events[0].EventName != "transfer" || string(events[0].Payload) != "payload" {
+		t.Fatalf("Unexpected event %s", events[0])
+	}
+
+	// events naming no transaction or chaincode are associated with those
+	// of the transaction
+	tx, txID = getTransactionWithEvents(t, func(txID string) []byte {
+		return utils.MarshalOrPanic(&peer.ChaincodeEvent{EventName: "transfer"})
+	})
+	events, err = ExtractChaincodeEvents(tx)
+	if err != nil {
+		t.Fatalf("ExtractChaincodeEvents failed, err %s", err)
+	}
+	if len(events) != 1 || events[0].ChaincodeId != "foo" || events[0].TxId != txID {
+		t.Fatalf("Unexpected events %v", events)
+	}
+
+	// transactions with no event
+	tx, err = getTransaction([]byte("simulation_result"))
+	if err != nil {
+		t.Fatalf("getTransaction failed, err %s", err)
+	}
+	events, err = ExtractChaincodeEvents(tx)
+	if err != nil {
+		t.Fatalf("ExtractChaincodeEvents failed, err %s", err)
+	}
+	if len(events) != 0 {
+		t.Fatalf("Expected no events, got %v", events)
+	}
+
+	malformed := []struct {
+		name   string
+		events func(txID string) []byte
+	}{
+		{"Undecodable", func(txID string) []byte { return []byte{0xff, 0xff, 0xff} }},
+		{"NoName", func(txID string) []byte {
+			return utils.MarshalOrPanic(&peer.ChaincodeEvent{ChaincodeId: "foo", TxId: txID, Payload: []byte("payload")})
+		}},
+		{"OtherTransaction", func(txID string) []byte {
+			return utils.MarshalOrPanic(&peer.ChaincodeEvent{ChaincodeId: "foo", TxId: "other", EventName: "transfer"})
+		}},
+	}
+
+	for _, test := range malformed {
+		tx, _ = getTransactionWithEvents(t, test.events)
+		_, err = ExtractChaincodeEvents(tx)
+		if err == nil {
+			t.Fatalf("%s: ExtractChaincodeEvents should have failed", test.name)
+		}
+	}
+
+	_, err = ExtractChaincodeEvents(&common.Envelope{Payload: []byte("garbage")})
+	if GetErrorClass(err) != TransientError {
+		t.Fatalf("ExtractChaincodeEvents should have failed with a transient error, got %v", err)
+	}
+}
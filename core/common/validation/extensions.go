@@ -0,0 +1,106 @@
+/*
+Copyright IBM Corp. 2017 All Rights Reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+		 http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package validation
+
+import (
+	"fmt"
+
+	"github.com/golang/protobuf/proto"
+	"github.com/hyperledger/fabric/protos/common"
+	pb "github.com/hyperledger/fabric/protos/peer"
+	"golang.org/x/net/context"
+)
+
+// The fields 100 to 106 of the ChaincodeHeaderExtension, declared in
+// protos/peer/proposal.proto, carry the TTL, anti-spam token, endorsement
+// policy name, TLS client certificate hash, read height, delegation token
+// and action ordering of endorser transactions, checked as configured by
+// the Validator.
+//
+// The markers below prefix the fields of the messages that carry an
+// alternative encoding instead of the one defined by their proto. They all
+// start with a zero byte, which neither serialized identities nor
+// signatures do, and none is a prefix of another.
+
+// AggregateEndorsementMarker prefixes the Endorser field of aggregated
+// endorsements, in which a single signature aggregates those of several
+// endorsers (e.g. threshold BLS signatures). The rest of the field
+// identifies the endorsers to the AggregateVerifier, and the signature is
+// computed over the proposal response payload concatenated with the whole
+// Endorser field, as for individual endorsements
+var AggregateEndorsementMarker = []byte("\x00aggregate:")
+
+// IdentityReferenceMarker prefixes the creators that reference an identity
+// known to the peer instead of carrying it in full, to save bandwidth. The
+// rest of the creator is the reference, e.g. a hash or an ID of the
+// identity, that the IdentityResolver resolves. The signatures and the TxId
+// are computed over the creator as sent, that is over the reference
+var IdentityReferenceMarker = []byte("\x00idref:")
+
+// CoSignatureMarker prefixes the Signature field of co-signed envelopes,
+// signed by several parties rather than by the creator alone. The rest of
+// the field is a Metadata message holding a MetadataSignature per party, as
+// for the signatures of block metadata: its signature header is a
+// SignatureHeader holding the creator of the party, and its signature is
+// computed over the payload of the envelope concatenated with the signature
+// header. The creator of the transaction, as named in the header of the
+// payload, must be the first party
+var CoSignatureMarker = []byte("\x00cosigned:")
+
+// headerExtension holds the chaincode header extension of the transaction
+// being validated, once decoded
+type headerExtension struct {
+	chdr *common.ChannelHeader
+	ext  *pb.ChaincodeHeaderExtension
+	err  error
+}
+
+// headerExtensionKey is the key of the decoded chaincode header extension
+// in contexts
+type headerExtensionKey struct{}
+
+// withHeaderExtension returns a context in which the chaincode header
+// extension of the transaction being validated is decoded once and shared
+// by all the checks on it; a context already sharing it is returned as is
+func withHeaderExtension(ctx context.Context) context.Context {
+	if _, ok := ctx.Value(headerExtensionKey{}).(*headerExtension); ok {
+		return ctx
+	}
+
+	return context.WithValue(ctx, headerExtensionKey{}, &headerExtension{})
+}
+
+// getHeaderExtension returns the chaincode header extension of a channel
+// header, as decoded once for the context, if it shares it
+func getHeaderExtension(ctx context.Context, chdr *common.ChannelHeader) (*pb.ChaincodeHeaderExtension, error) {
+	holder, ok := ctx.Value(headerExtensionKey{}).(*headerExtension)
+	if ok && holder.chdr == chdr {
+		return holder.ext, holder.err
+	}
+
+	ext := &pb.ChaincodeHeaderExtension{}
+	err := proto.Unmarshal(chdr.Extension, ext)
+	if err != nil {
+		ext, err = nil, permanentDecodeError(fmt.Errorf("Could not decode the chaincode header extension, err %s", err))
+	}
+
+	if ok {
+		holder.chdr, holder.ext, holder.err = chdr, ext, err
+	}
+
+	return ext, err
+}
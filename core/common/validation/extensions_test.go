@@ -0,0 +1,92 @@
+/*
+Copyright IBM Corp. 2017 All Rights Reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+		 http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package validation
+
+import (
+	"bytes"
+	"reflect"
+	"testing"
+
+	"github.com/hyperledger/fabric/protos/common"
+	"github.com/hyperledger/fabric/protos/peer"
+	"github.com/hyperledger/fabric/protos/utils"
+	"golang.org/x/net/context"
+)
+
+func TestExtensionFieldsDeclared(t *testing.T) {
+	ext := &peer.ChaincodeHeaderExtension{
+		Ttl:             60,
+		SpamToken:       []byte("token"),
+		PolicyName:      "majority",
+		TlsCertHash:     []byte("hash"),
+		ReadHeight:      10,
+		DelegationToken: []byte("token"),
+		OrderedActions:  true,
+	}
+
+	unknown, err := findUnknownField(utils.MarshalOrPanic(ext), reflect.TypeOf(*ext), "ChaincodeHeaderExtension")
+	if err != nil || unknown != nil {
+		t.Fatalf("The extension fields should be declared by ChaincodeHeaderExtension, got %v, err %v", unknown, err)
+	}
+}
+
+func TestMarkers(t *testing.T) {
+	markers := [][]byte{AggregateEndorsementMarker, IdentityReferenceMarker, CoSignatureMarker}
+	for i, marker := range markers {
+		if len(marker) == 0 || marker[0] != 0 {
+			t.Fatalf("Marker %q should start with a zero byte", marker)
+		}
+
+		for j, other := range markers {
+			if i != j && bytes.HasPrefix(other, marker) {
+				t.Fatalf("Marker %q is a prefix of marker %q", marker, other)
+			}
+		}
+	}
+}
+
+func TestGetHeaderExtension(t *testing.T) {
+	chdr := &common.ChannelHeader{Extension: utils.MarshalOrPanic(&peer.ChaincodeHeaderExtension{Ttl: 60})}
+	otherChdr := &common.ChannelHeader{Extension: utils.MarshalOrPanic(&peer.ChaincodeHeaderExtension{Ttl: 120})}
+
+	// the extension is decoded once per channel header for the context
+	ctx := withHeaderExtension(context.Background())
+	if withHeaderExtension(ctx) != ctx {
+		t.Fatalf("withHeaderExtension should have returned the context sharing the extension")
+	}
+
+	ext, err := getHeaderExtension(ctx, chdr)
+	if err != nil || ext.Ttl != 60 {
+		t.Fatalf("getHeaderExtension returned %v, err %v", ext, err)
+	}
+
+	again, err := getHeaderExtension(ctx, chdr)
+	if err != nil || again != ext {
+		t.Fatalf("getHeaderExtension should have returned the extension already decoded, got %v, err %v", again, err)
+	}
+
+	other, err := getHeaderExtension(ctx, otherChdr)
+	if err != nil || other.Ttl != 120 {
+		t.Fatalf("getHeaderExtension returned %v for another channel header, err %v", other, err)
+	}
+
+	// an undecodable extension is permanently invalid
+	_, err = getHeaderExtension(context.Background(), &common.ChannelHeader{Extension: []byte{0xff}})
+	if err == nil || GetErrorClass(err) != PermanentError {
+		t.Fatalf("getHeaderExtension should have failed permanently, got %v", err)
+	}
+}
@@ -0,0 +1,105 @@
+/*
+Copyright IBM Corp. 2017 All Rights Reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+		 http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package validation
+
+import (
+	"encoding/binary"
+	"fmt"
+
+	"github.com/hyperledger/fabric/bccsp"
+	"github.com/hyperledger/fabric/bccsp/factory"
+	"github.com/hyperledger/fabric/protos/common"
+	"github.com/hyperledger/fabric/protos/utils"
+	"golang.org/x/net/context"
+)
+
+// TransactionFingerprint checks that the transaction envelope is
+// structurally valid and returns its fingerprint, that is, the SHA-256
+// hash of the channel ID, the TxId, the creator and the proposal hashes
+// of the actions of the transaction, each prefixed by its length as a
+// big endian uint32.
+//
+// The fingerprint does not depend on the encoding of the envelope nor
+// on its signatures: envelopes that decode to the same transaction have
+// the same fingerprint, across re-marshallings and re-signings. No
+// signature is verified by this function
+func TransactionFingerprint(e *common.Envelope) ([]byte, error) {
+	// check for nil argument
+	if e == nil {
+		return nil, fmt.Errorf("Nil Envelope")
+	}
+
+	if isCompressedPayload(e) {
+		return nil, ErrCompressedPayload
+	}
+
+	payload, err := utils.GetPayload(e)
+	if err != nil {
+		return nil, fmt.Errorf("Could not extract payload from envelope, err %s", err)
+	}
+
+	err = defaultValidator.validateCommonHeader(context.Background(), payload.Header)
+	if err != nil {
+		return nil, err
+	}
+
+	chdr := payload.Header.ChannelHeader
+	fields := [][]byte{[]byte(chdr.ChannelId), []byte(chdr.TxId), payload.Header.SignatureHeader.Creator}
+
+	if common.HeaderType(chdr.Type) == common.HeaderType_ENDORSER_TRANSACTION {
+		tx, err := utils.GetTransaction(payload.Data)
+		if err != nil {
+			return nil, err
+		}
+
+		if len(tx.Actions) == 0 {
+			return nil, fmt.Errorf("At least one TransactionAction is required")
+		}
+
+		for _, act := range tx.Actions {
+			if act == nil {
+				return nil, fmt.Errorf("Nil action")
+			}
+
+			cap, err := utils.GetChaincodeActionPayload(act.Payload)
+			if err != nil {
+				return nil, err
+			}
+
+			if cap.Action == nil {
+				return nil, fmt.Errorf("Nil ChaincodeEndorsedAction")
+			}
+
+			prp, err := utils.GetProposalResponsePayload(cap.Action.ProposalResponsePayload)
+			if err != nil {
+				return nil, err
+			}
+
+			fields = append(fields, prp.ProposalHash)
+		}
+	}
+
+	var buf []byte
+	for _, field := range fields {
+		length := make([]byte, 4)
+		binary.BigEndian.PutUint32(length, uint32(len(field)))
+		buf = append(buf, length...)
+		buf = append(buf, field...)
+	}
+
+	return factory.GetDefault().Hash(buf, &bccsp.SHA256Opts{})
+}
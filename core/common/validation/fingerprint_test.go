@@ -0,0 +1,102 @@
+/*
+Copyright IBM Corp. 2017 All Rights Reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+		 http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package validation
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/golang/protobuf/proto"
+	"github.com/hyperledger/fabric/protos/common"
+	"github.com/hyperledger/fabric/protos/utils"
+)
+
+// reorderPayload re-encodes the payload of the envelope with its
+// fields in reverse order, which decodes to the same Payload message
+func reorderPayload(t *testing.T, e *common.Envelope) *common.Envelope {
+	payload, err := utils.GetPayload(e)
+	if err != nil {
+		t.Fatalf("GetPayload failed, err %s", err)
+	}
+
+	buf := proto.NewBuffer(nil)
+	buf.EncodeVarint(2<<3 | proto.WireBytes)
+	buf.EncodeRawBytes(payload.Data)
+	buf.EncodeVarint(1<<3 | proto.WireBytes)
+	buf.EncodeRawBytes(utils.MarshalOrPanic(payload.Header))
+
+	sig, err := signer.Sign(buf.Bytes())
+	if err != nil {
+		t.Fatalf("Sign failed, err %s", err)
+	}
+
+	return &common.Envelope{Payload: buf.Bytes(), Signature: sig}
+}
+
+func TestTransactionFingerprint(t *testing.T) {
+	tx, err := getTransaction([]byte("simulation_result"))
+	if err != nil {
+		t.Fatalf("getTransaction failed, err %s", err)
+	}
+
+	fp, err := TransactionFingerprint(tx)
+	if err != nil {
+		t.Fatalf("TransactionFingerprint failed, err %s", err)
+	}
+
+	// the same transaction with a different encoding and signature
+	reordered := reorderPayload(t, tx)
+	if bytes.Equal(reordered.Payload, tx.Payload) {
+		t.Fatalf("The reordered payload should differ from the original one")
+	}
+
+	fp2, err := TransactionFingerprint(reordered)
+	if err != nil {
+		t.Fatalf("TransactionFingerprint failed, err %s", err)
+	}
+
+	if !bytes.Equal(fp, fp2) {
+		t.Fatalf("Fingerprints of the same transaction should match")
+	}
+
+	// a different transaction
+	tx2, err := getTransaction([]byte("simulation_result"))
+	if err != nil {
+		t.Fatalf("getTransaction failed, err %s", err)
+	}
+
+	fp3, err := TransactionFingerprint(tx2)
+	if err != nil {
+		t.Fatalf("TransactionFingerprint failed, err %s", err)
+	}
+
+	if bytes.Equal(fp, fp3) {
+		t.Fatalf("Fingerprints of different transactions should not match")
+	}
+}
+
+func TestTransactionFingerprintBadTx(t *testing.T) {
+	_, err := TransactionFingerprint(nil)
+	if err == nil {
+		t.Fatalf("TransactionFingerprint should have failed")
+	}
+
+	_, err = TransactionFingerprint(&common.Envelope{Payload: []byte("garbage")})
+	if err == nil {
+		t.Fatalf("TransactionFingerprint should have failed")
+	}
+}
@@ -0,0 +1,130 @@
+/*
+Copyright IBM Corp. 2017 All Rights Reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+		 http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package validation
+
+import (
+	"bufio"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+
+	"github.com/golang/protobuf/proto"
+	"github.com/hyperledger/fabric/protos/common"
+)
+
+// ErrFramingMismatch is returned for the framed envelopes whose length
+// prefix disagrees with their decoded content, which usually reveals a bug
+// in the framing of the stream they were read from
+var ErrFramingMismatch = errors.New("The length prefix of the envelope does not match its content")
+
+// MaxFrameSize is the maximum length of a framed envelope
+const MaxFrameSize = 100 * 1024 * 1024
+
+// readFrame reads a frame of a stream: a varint length prefix followed by
+// as many bytes. It returns io.EOF only at the end of the stream, before a
+// frame starts
+func readFrame(r *bufio.Reader) ([]byte, error) {
+	length, err := binary.ReadUvarint(r)
+	if err == io.EOF {
+		return nil, io.EOF
+	}
+	if err != nil {
+		return nil, transientDecodeError(fmt.Errorf("Could not read the length prefix of the frame, err %s", err))
+	}
+
+	if length > MaxFrameSize {
+		return nil, transientDecodeError(fmt.Errorf("Frame of %d bytes exceeds the maximum of %d", length, MaxFrameSize))
+	}
+
+	frame := make([]byte, length)
+	_, err = io.ReadFull(r, frame)
+	if err != nil {
+		return nil, transientDecodeError(fmt.Errorf("Could not read the frame of %d bytes, err %s", length, err))
+	}
+
+	return frame, nil
+}
+
+// checkFraming ensures that an envelope decoded from a frame accounts for
+// all of its bytes and is complete: an envelope framed with a shorter
+// length than its own decodes without the fields left out, up to the
+// payload and signature themselves
+func checkFraming(frame []byte, env *common.Envelope) error {
+	if size := proto.Size(env); size != len(frame) {
+		putilsLogger.Errorf("checkFraming error: frame of %d bytes holding an envelope of %d bytes", len(frame), size)
+		return ErrFramingMismatch
+	}
+
+	if len(env.Payload) == 0 || len(env.Signature) == 0 {
+		putilsLogger.Errorf("checkFraming error: frame of %d bytes holding a truncated envelope", len(frame))
+		return ErrFramingMismatch
+	}
+
+	return nil
+}
+
+// ValidateFramedTransactions reads length-prefixed envelopes from r and
+// validates them with the default validator, see
+// Validator.ValidateFramedTransactions
+func ValidateFramedTransactions(r io.Reader) ([]*ValidationResult, []error) {
+	return defaultValidator.ValidateFramedTransactions(r)
+}
+
+// ValidateFramedTransactions reads envelopes from r, each prefixed by its
+// length as a varint, until the end of the stream, and validates them as
+// Validate does, returning the results and errors of the envelopes in the
+// order they were read. If CheckFraming is set, an envelope whose length
+// prefix disagrees with its content is rejected with ErrFramingMismatch.
+// As the stream can no longer be trusted to be in sync past a frame that
+// could not be read or a framing mismatch, the reading stops there, with
+// an empty result for the frame along with its error
+func (v *Validator) ValidateFramedTransactions(r io.Reader) ([]*ValidationResult, []error) {
+	var results []*ValidationResult
+	var errs []error
+
+	br := bufio.NewReader(r)
+	for {
+		frame, err := readFrame(br)
+		if err == io.EOF {
+			return results, errs
+		}
+		if err != nil {
+			return append(results, &ValidationResult{}), append(errs, err)
+		}
+
+		env := &common.Envelope{}
+		err = proto.Unmarshal(frame, env)
+		if err != nil {
+			putilsLogger.Warningf("Could not decode the envelope of frame %d, err %s", len(results), err)
+			results = append(results, &ValidationResult{})
+			errs = append(errs, ErrUndecodable)
+			continue
+		}
+
+		if v.CheckFraming {
+			err = checkFraming(frame, env)
+			if err != nil {
+				return append(results, &ValidationResult{}), append(errs, err)
+			}
+		}
+
+		result, err := v.Validate(env)
+		results = append(results, result)
+		errs = append(errs, err)
+	}
+}
@@ -0,0 +1,93 @@
+/*
+Copyright IBM Corp. 2017 All Rights Reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+		 http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package validation
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/golang/protobuf/proto"
+	"github.com/hyperledger/fabric/protos/common"
+	"github.com/hyperledger/fabric/protos/utils"
+)
+
+// frame prefixes the bytes with the given length
+func frame(length int, b []byte) []byte {
+	return append(proto.EncodeVarint(uint64(length)), b...)
+}
+
+func TestFramedTransactions(t *testing.T) {
+	tx, err := getTransaction([]byte("simulation_result"))
+	if err != nil {
+		t.Fatalf("getTransaction failed, err %s", err)
+	}
+	txBytes := utils.MarshalOrPanic(tx)
+
+	// the envelope without its signature, as decoded from a short frame
+	unsigned := utils.MarshalOrPanic(&common.Envelope{Payload: tx.Payload})
+
+	// an unknown field trailing the envelope in its frame
+	trailing := proto.NewBuffer(append([]byte{}, txBytes...))
+	trailing.EncodeVarint(15<<3 | proto.WireVarint)
+	trailing.EncodeVarint(1)
+
+	tests := []struct {
+		name   string
+		stream []byte
+		check  bool
+		errs   []error
+	}{
+		{"Empty", nil, true, nil},
+		{"Valid", append(frame(len(txBytes), txBytes), frame(len(txBytes), txBytes)...), true, []error{nil, nil}},
+		{"Short", append(frame(len(unsigned), txBytes), frame(len(txBytes), txBytes)...), true, []error{ErrFramingMismatch}},
+		{"Trailing", append(frame(len(trailing.Bytes()), trailing.Bytes()), frame(len(txBytes), txBytes)...), true, []error{ErrFramingMismatch}},
+		{"TrailingUnchecked", append(frame(len(trailing.Bytes()), trailing.Bytes()), frame(len(txBytes), txBytes)...), false, []error{nil, nil}},
+	}
+
+	for _, test := range tests {
+		v := &Validator{CheckFraming: test.check}
+		results, errs := v.ValidateFramedTransactions(bytes.NewReader(test.stream))
+		if len(results) != len(test.errs) || len(errs) != len(test.errs) {
+			t.Fatalf("%s: expected %d results, got %d results and %d errors", test.name, len(test.errs), len(results), len(errs))
+		}
+		for i := range errs {
+			if errs[i] != test.errs[i] {
+				t.Fatalf("%s: expected err %v for frame %d, got %v", test.name, test.errs[i], i, errs[i])
+			}
+			if results[i] == nil {
+				t.Fatalf("%s: nil result for frame %d", test.name, i)
+			}
+		}
+	}
+
+	// without the check, the short frame decodes into an unsigned envelope
+	// and the stream gets out of sync
+	_, errs := (&Validator{}).ValidateFramedTransactions(bytes.NewReader(append(frame(len(unsigned), txBytes), frame(len(txBytes), txBytes)...)))
+	if len(errs) < 2 || errs[0] == nil || errs[0] == ErrFramingMismatch {
+		t.Fatalf("The short frame should have failed validation, got %v", errs)
+	}
+
+	// a frame longer than the rest of the stream
+	_, errs = ValidateFramedTransactions(bytes.NewReader(frame(len(txBytes)+1, txBytes)))
+	if len(errs) != 1 || GetErrorClass(errs[0]) != TransientError {
+		t.Fatalf("The truncated frame should have failed with a transient error, got %v", errs)
+	}
+
+	if GetErrorClass(ErrFramingMismatch) != TransientError {
+		t.Fatalf("ErrFramingMismatch should be transient")
+	}
+}
@@ -43,6 +43,29 @@ func getProposal() (*peer.Proposal, error) {
 	return proposal, err
 }
 
+// getTransaction returns a signed transaction for a toy proposal
+// endorsed with the supplied simulation results
+func getTransaction(simRes []byte) (*common.Envelope, error) {
+	prop, err := getProposal()
+	if err != nil {
+		return nil, err
+	}
+
+	return getTransactionForProposal(prop, simRes)
+}
+
+// getTransactionForProposal returns a signed transaction for the
+// proposal endorsed with the supplied simulation results
+func getTransactionForProposal(prop *peer.Proposal, simRes []byte) (*common.Envelope, error) {
+	response := &peer.Response{Status: 200}
+	presp, err := utils.CreateProposalResponse(prop.Header, prop.Payload, response, simRes, nil, nil, signer)
+	if err != nil {
+		return nil, err
+	}
+
+	return utils.CreateSignedTx(prop, signer, presp)
+}
+
 func TestGoodPath(t *testing.T) {
 	// get a toy proposal
 	prop, err := getProposal()
@@ -117,6 +140,50 @@ func TestGoodPath(t *testing.T) {
 	}
 }
 
+// modifyTransaction decodes the transaction, lets f modify its first
+// action, then re-assembles the transaction and signs it again
+func modifyTransaction(tx *common.Envelope, f func(sHdr *common.SignatureHeader, cap *peer.ChaincodeActionPayload, prp *peer.ProposalResponsePayload)) (*common.Envelope, error) {
+	payl, err := utils.GetPayload(tx)
+	if err != nil {
+		return nil, err
+	}
+
+	txx, err := utils.GetTransaction(payl.Data)
+	if err != nil {
+		return nil, err
+	}
+
+	sHdr, err := utils.GetSignatureHeader(txx.Actions[0].Header)
+	if err != nil {
+		return nil, err
+	}
+
+	cap, err := utils.GetChaincodeActionPayload(txx.Actions[0].Payload)
+	if err != nil {
+		return nil, err
+	}
+
+	prp, err := utils.GetProposalResponsePayload(cap.Action.ProposalResponsePayload)
+	if err != nil {
+		return nil, err
+	}
+
+	f(sHdr, cap, prp)
+
+	cap.Action.ProposalResponsePayload = utils.MarshalOrPanic(prp)
+	txx.Actions[0].Header = utils.MarshalOrPanic(sHdr)
+	txx.Actions[0].Payload = utils.MarshalOrPanic(cap)
+	payl.Data = utils.MarshalOrPanic(txx)
+	paylBytes := utils.MarshalOrPanic(payl)
+
+	sig, err := signer.Sign(paylBytes)
+	if err != nil {
+		return nil, err
+	}
+
+	return &common.Envelope{Payload: paylBytes, Signature: sig}, nil
+}
+
 var r *rand.Rand
 
 func corrupt(bytes []byte) {
@@ -244,6 +311,49 @@ func TestBadTx(t *testing.T) {
 	}
 }
 
+func TestNilEndorsedAction(t *testing.T) {
+	tx, err := getTransaction([]byte("simulation_result"))
+	if err != nil {
+		t.Fatalf("getTransaction failed, err %s", err)
+	}
+
+	payl, err := utils.GetPayload(tx)
+	if err != nil {
+		t.Fatalf("GetPayload failed, err %s", err)
+	}
+
+	txx, err := utils.GetTransaction(payl.Data)
+	if err != nil {
+		t.Fatalf("GetTransaction failed, err %s", err)
+	}
+
+	cap, err := utils.GetChaincodeActionPayload(txx.Actions[0].Payload)
+	if err != nil {
+		t.Fatalf("GetChaincodeActionPayload failed, err %s", err)
+	}
+
+	// drop the endorsed action and sign the transaction again
+	cap.Action = nil
+	txx.Actions[0].Payload = utils.MarshalOrPanic(cap)
+	payl.Data = utils.MarshalOrPanic(txx)
+	paylBytes := utils.MarshalOrPanic(payl)
+
+	sig, err := signer.Sign(paylBytes)
+	if err != nil {
+		t.Fatalf("Sign failed, err %s", err)
+	}
+
+	env := &common.Envelope{Payload: paylBytes, Signature: sig}
+
+	// the endorsers are not checked when duplicates are allowed
+	for _, v := range []*Validator{defaultValidator, {AllowDuplicateEndorsers: true}} {
+		_, err = v.ValidateTransaction(env)
+		if err == nil {
+			t.Fatalf("ValidateTransaction should have failed for a nil endorsed action")
+		}
+	}
+}
+
 func Test2EndorsersAgree(t *testing.T) {
 	// get a toy proposal
 	prop, err := getProposal()
@@ -279,8 +389,16 @@ func Test2EndorsersAgree(t *testing.T) {
 		return
 	}
 
-	// validate the transaction
+	// both endorsements are by the same endorser, which is only
+	// accepted if duplicate endorsers are allowed
 	_, err = ValidateTransaction(tx)
+	if err != ErrDuplicateEndorser {
+		t.Fatalf("ValidateTransaction should have failed with ErrDuplicateEndorser, got %v", err)
+		return
+	}
+
+	// validate the transaction
+	_, err = (&Validator{AllowDuplicateEndorsers: true}).ValidateTransaction(tx)
 	if err != nil {
 		t.Fatalf("ValidateTransaction failed, err %s", err)
 		return
@@ -0,0 +1,128 @@
+/*
+Copyright IBM Corp. 2017 All Rights Reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+		 http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package validation
+
+import (
+	"bytes"
+	"errors"
+
+	"github.com/hyperledger/fabric/protos/peer"
+	"golang.org/x/net/context"
+)
+
+// ErrSuspiciousEndorsements is returned in strict mode for transactions
+// whose endorsements follow a suspicious pattern
+var ErrSuspiciousEndorsements = errors.New("Suspicious endorsement pattern")
+
+// HeuristicsMode tells what to do with the transactions flagged by a
+// heuristic check
+type HeuristicsMode int
+
+const (
+	// HeuristicsOff disables the heuristic check
+	HeuristicsOff HeuristicsMode = iota
+
+	// HeuristicsLenient logs a warning for flagged transactions
+	HeuristicsLenient
+
+	// HeuristicsStrict rejects flagged transactions
+	HeuristicsStrict
+)
+
+// checkEndorsementPatterns applies the endorsement heuristics to the actions
+// of a transaction. These checks are heuristic: they do not evaluate the
+// endorsement policies, which is the job of VSCC, but flag patterns that
+// are unlikely to come from honest endorsers, namely
+//
+//	the same endorsement reused on actions with different proposal responses,
+//	    since an endorser signs the proposal response it produces
+//	an action with no endorsement, or with less than half the endorsements
+//	    of another action of the same transaction
+//
+// Legitimate transactions may be flagged when their actions are subject to
+// very different policies, hence the lenient mode
+func (v *Validator) checkEndorsementPatterns(ctx context.Context, txID string, actions []*peer.ChaincodeEndorsedAction) error {
+	if v.EndorsementHeuristics == HeuristicsOff {
+		return nil
+	}
+
+	reason := suspiciousEndorsementPattern(actions)
+	if reason == "" {
+		return nil
+	}
+
+	if v.EndorsementHeuristics == HeuristicsStrict {
+		putilsLogger.Errorf("Transaction %s rejected: %s", txID, reason)
+		return ErrSuspiciousEndorsements
+	}
+
+	addWarning(ctx, WarningFlagged, "Transaction %s flagged: %s", txID, reason)
+	return nil
+}
+
+// suspiciousEndorsementPattern returns why the endorsements of the actions
+// are suspicious, or the empty string if they are not
+func suspiciousEndorsementPattern(actions []*peer.ChaincodeEndorsedAction) string {
+	if len(actions) == 0 {
+		return ""
+	}
+
+	minCount, maxCount := len(actions[0].Endorsements), len(actions[0].Endorsements)
+	for _, action := range actions {
+		count := len(action.Endorsements)
+		if count == 0 {
+			return "action with no endorsement"
+		}
+		if count < minCount {
+			minCount = count
+		}
+		if count > maxCount {
+			maxCount = count
+		}
+	}
+
+	if maxCount > 2*minCount {
+		return "endorsement counts vary widely across actions"
+	}
+
+	for i := range actions {
+		for j := i + 1; j < len(actions); j++ {
+			if bytes.Equal(actions[i].ProposalResponsePayload, actions[j].ProposalResponsePayload) {
+				continue
+			}
+
+			if sharesEndorsement(actions[i].Endorsements, actions[j].Endorsements) {
+				return "same endorsement on different proposal responses"
+			}
+		}
+	}
+
+	return ""
+}
+
+// sharesEndorsement returns true if a signature appears in both sets of endorsements
+func sharesEndorsement(a, b []*peer.Endorsement) bool {
+	for _, ea := range a {
+		for _, eb := range b {
+			if ea != nil && eb != nil && len(ea.Signature) != 0 && bytes.Equal(ea.Signature, eb.Signature) {
+				return true
+			}
+		}
+	}
+
+	return false
+}
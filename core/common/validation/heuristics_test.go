@@ -0,0 +1,136 @@
+/*
+Copyright IBM Corp. 2017 All Rights Reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+		 http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package validation
+
+import (
+	"testing"
+
+	"github.com/hyperledger/fabric/protos/common"
+	"github.com/hyperledger/fabric/protos/peer"
+	"github.com/hyperledger/fabric/protos/utils"
+)
+
+func endorsedAction(prp string, sigs ...string) *peer.ChaincodeEndorsedAction {
+	action := &peer.ChaincodeEndorsedAction{ProposalResponsePayload: []byte(prp)}
+	for _, sig := range sigs {
+		action.Endorsements = append(action.Endorsements, &peer.Endorsement{Endorser: []byte("endorser"), Signature: []byte(sig)})
+	}
+	return action
+}
+
+func TestSuspiciousEndorsementPattern(t *testing.T) {
+	tests := []struct {
+		name       string
+		actions    []*peer.ChaincodeEndorsedAction
+		suspicious bool
+	}{
+		{"SingleAction", []*peer.ChaincodeEndorsedAction{endorsedAction("prp1", "sig1")}, false},
+		{"DistinctEndorsements", []*peer.ChaincodeEndorsedAction{endorsedAction("prp1", "sig1", "sig2"), endorsedAction("prp2", "sig3", "sig4")}, false},
+		{"SameProposalResponse", []*peer.ChaincodeEndorsedAction{endorsedAction("prp1", "sig1"), endorsedAction("prp1", "sig1")}, false},
+		{"SimilarCounts", []*peer.ChaincodeEndorsedAction{endorsedAction("prp1", "sig1"), endorsedAction("prp2", "sig2", "sig3")}, false},
+		{"CopiedEndorsement", []*peer.ChaincodeEndorsedAction{endorsedAction("prp1", "sig1", "sig2"), endorsedAction("prp2", "sig3", "sig1")}, true},
+		{"NoEndorsement", []*peer.ChaincodeEndorsedAction{endorsedAction("prp1", "sig1"), endorsedAction("prp2")}, true},
+		{"WildlyVaryingCounts", []*peer.ChaincodeEndorsedAction{endorsedAction("prp1", "sig1"), endorsedAction("prp2", "sig2", "sig3", "sig4")}, true},
+	}
+
+	for _, test := range tests {
+		reason := suspiciousEndorsementPattern(test.actions)
+		if test.suspicious && reason == "" {
+			t.Fatalf("%s: pattern should have been flagged", test.name)
+		}
+		if !test.suspicious && reason != "" {
+			t.Fatalf("%s: pattern should not have been flagged, got %s", test.name, reason)
+		}
+	}
+}
+
+// getCopiedEndorsementTransaction returns a transaction with two actions
+// for different proposal responses, both carrying the same endorsement
+func getCopiedEndorsementTransaction(t *testing.T) *common.Envelope {
+	prop, err := getProposal()
+	if err != nil {
+		t.Fatalf("getProposal failed, err %s", err)
+	}
+
+	var actions []*peer.TransactionAction
+	for _, simRes := range []string{"simulation_result_1", "simulation_result_2"} {
+		tx, err := getTransactionForProposal(prop, []byte(simRes))
+		if err != nil {
+			t.Fatalf("getTransactionForProposal failed, err %s", err)
+		}
+
+		transaction, err := utils.GetTransaction(utils.UnmarshalPayloadOrPanic(tx.Payload).Data)
+		if err != nil {
+			t.Fatalf("GetTransaction failed, err %s", err)
+		}
+		actions = append(actions, transaction.Actions[0])
+	}
+
+	first, err := utils.GetChaincodeActionPayload(actions[0].Payload)
+	if err != nil {
+		t.Fatalf("GetChaincodeActionPayload failed, err %s", err)
+	}
+	second, err := utils.GetChaincodeActionPayload(actions[1].Payload)
+	if err != nil {
+		t.Fatalf("GetChaincodeActionPayload failed, err %s", err)
+	}
+	second.Action.Endorsements = first.Action.Endorsements
+	actions[1].Payload = utils.MarshalOrPanic(second)
+
+	tx, err := getTransactionForProposal(prop, []byte("simulation_result_1"))
+	if err != nil {
+		t.Fatalf("getTransactionForProposal failed, err %s", err)
+	}
+	payload := utils.UnmarshalPayloadOrPanic(tx.Payload)
+	payload.Data = utils.MarshalOrPanic(&peer.Transaction{Actions: actions})
+	tx.Payload = utils.MarshalOrPanic(payload)
+	tx.Signature, err = signer.Sign(tx.Payload)
+	if err != nil {
+		t.Fatalf("Sign failed, err %s", err)
+	}
+
+	return tx
+}
+
+func TestEndorsementHeuristicsModes(t *testing.T) {
+	tx := getCopiedEndorsementTransaction(t)
+
+	_, err := (&Validator{}).ValidateTransaction(tx)
+	if err != nil {
+		t.Fatalf("ValidateTransaction failed with heuristics off, err %s", err)
+	}
+
+	_, err = (&Validator{EndorsementHeuristics: HeuristicsLenient}).ValidateTransaction(tx)
+	if err != nil {
+		t.Fatalf("ValidateTransaction failed with lenient heuristics, err %s", err)
+	}
+
+	_, err = (&Validator{EndorsementHeuristics: HeuristicsStrict}).ValidateTransaction(tx)
+	if err != ErrSuspiciousEndorsements {
+		t.Fatalf("ValidateTransaction should have failed with ErrSuspiciousEndorsements, got %v", err)
+	}
+
+	good, err := getTransaction([]byte("simulation_result"))
+	if err != nil {
+		t.Fatalf("getTransaction failed, err %s", err)
+	}
+
+	_, err = (&Validator{EndorsementHeuristics: HeuristicsStrict}).ValidateTransaction(good)
+	if err != nil {
+		t.Fatalf("ValidateTransaction failed with strict heuristics, err %s", err)
+	}
+}
@@ -0,0 +1,248 @@
+/*
+Copyright IBM Corp. 2017 All Rights Reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+		 http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package validation
+
+import (
+	"testing"
+
+	"github.com/golang/protobuf/proto"
+	"github.com/hyperledger/fabric/common/util"
+	"github.com/hyperledger/fabric/msp"
+	"github.com/hyperledger/fabric/protos/common"
+	"github.com/hyperledger/fabric/protos/utils"
+)
+
+func TestCreatorMSPMismatch(t *testing.T) {
+	creator := utils.MarshalOrPanic(&msp.SerializedIdentity{Mspid: "Org1", IdBytes: []byte("cert")})
+	sig := []byte("signature")
+	msg := []byte("message")
+
+	// the creator's certificate chains to the MSP it claims
+	v := &Validator{DeserializerProvider: &mockDeserializerProvider{mockDeserializer{
+		string(creator): &mockIdentity{mspID: "Org1", valid: true, sig: sig},
+	}}}
+	err := v.checkSignatureFromCreator(creator, sig, msg, util.GetTestChainID())
+	if err != nil {
+		t.Fatalf("checkSignatureFromCreator failed, err %s", err)
+	}
+
+	// the creator claims Org1 but its certificate chains to Org2
+	v = &Validator{DeserializerProvider: &mockDeserializerProvider{mockDeserializer{
+		string(creator): &mockIdentity{mspID: "Org2", valid: true, sig: sig},
+	}}}
+	err = v.checkSignatureFromCreator(creator, sig, msg, util.GetTestChainID())
+	if err != ErrMSPMismatch {
+		t.Fatalf("checkSignatureFromCreator should have failed with ErrMSPMismatch, got %v", err)
+	}
+}
+
+// mockIdemixIdentity is an anonymous identity: it exposes neither an
+// identifier nor organizational units, and panics if asked for them
+type mockIdemixIdentity struct {
+	mockIdentity
+}
+
+func (id *mockIdemixIdentity) GetIdentityType() IdentityType {
+	return IdemixIdentity
+}
+
+func (id *mockIdemixIdentity) GetIdentifier() *msp.IdentityIdentifier {
+	panic("idemix identities have no public identifier")
+}
+
+func (id *mockIdemixIdentity) GetOrganizationalUnits() []string {
+	panic("idemix identities have no certificate subject")
+}
+
+func TestIdemixCreator(t *testing.T) {
+	creator := utils.MarshalOrPanic(&msp.SerializedIdentity{Mspid: "Org1", IdBytes: []byte("credential")})
+	sig := []byte("signature")
+	msg := []byte("message")
+
+	tests := []struct {
+		name     string
+		identity *mockIdemixIdentity
+		valid    bool
+	}{
+		{"Valid", &mockIdemixIdentity{mockIdentity{mspID: "Org1", valid: true, sig: sig}}, true},
+		{"BadSignature", &mockIdemixIdentity{mockIdentity{mspID: "Org1", valid: true, sig: []byte("other")}}, false},
+		{"BadCredential", &mockIdemixIdentity{mockIdentity{mspID: "Org1", valid: false, sig: sig}}, false},
+		{"MSPMismatch", &mockIdemixIdentity{mockIdentity{mspID: "Org2", valid: true, sig: sig}}, false},
+	}
+
+	for _, test := range tests {
+		v := &Validator{DeserializerProvider: &mockDeserializerProvider{mockDeserializer{
+			string(creator): test.identity,
+		}}}
+		err := v.checkSignatureFromCreator(creator, sig, msg, util.GetTestChainID())
+		if test.valid && err != nil {
+			t.Fatalf("%s: checkSignatureFromCreator failed, err %s", test.name, err)
+		}
+		if !test.valid && err == nil {
+			t.Fatalf("%s: checkSignatureFromCreator should have failed", test.name)
+		}
+	}
+}
+
+func TestPinnedCreator(t *testing.T) {
+	tx, err := getTransaction([]byte("simulation_result"))
+	if err != nil {
+		t.Fatalf("getTransaction failed, err %s", err)
+	}
+
+	sId := &msp.SerializedIdentity{}
+	if err = proto.Unmarshal(signerSerialized, sId); err != nil {
+		t.Fatalf("Unmarshal failed, err %s", err)
+	}
+
+	fingerprint, err := CertificateFingerprint(sId.IdBytes)
+	if err != nil {
+		t.Fatalf("CertificateFingerprint failed, err %s", err)
+	}
+
+	// no pinning by default
+	_, err = (&Validator{}).ValidateTransaction(tx)
+	if err != nil {
+		t.Fatalf("ValidateTransaction failed, err %s", err)
+	}
+
+	// the creator certificate is pinned
+	v := &Validator{PinnedCreatorFingerprints: map[string]struct{}{fingerprint: struct{}{}}}
+	_, err = v.ValidateTransaction(tx)
+	if err != nil {
+		t.Fatalf("ValidateTransaction failed, err %s", err)
+	}
+
+	// another certificate is pinned
+	other, err := CertificateFingerprint([]byte("another certificate"))
+	if err != nil {
+		t.Fatalf("CertificateFingerprint failed, err %s", err)
+	}
+	v = &Validator{PinnedCreatorFingerprints: map[string]struct{}{other: struct{}{}}}
+	_, err = v.ValidateTransaction(tx)
+	if err != ErrCreatorNotPinned {
+		t.Fatalf("ValidateTransaction should have failed with ErrCreatorNotPinned, got %v", err)
+	}
+}
+
+func TestPinnedIdemixCreator(t *testing.T) {
+	creator := utils.MarshalOrPanic(&msp.SerializedIdentity{Mspid: "Org1", IdBytes: []byte("credential")})
+	sig := []byte("signature")
+
+	fingerprint, err := CertificateFingerprint([]byte("credential"))
+	if err != nil {
+		t.Fatalf("CertificateFingerprint failed, err %s", err)
+	}
+
+	v := &Validator{
+		DeserializerProvider: &mockDeserializerProvider{mockDeserializer{
+			string(creator): &mockIdemixIdentity{mockIdentity{mspID: "Org1", valid: true, sig: sig}},
+		}},
+		PinnedCreatorFingerprints: map[string]struct{}{fingerprint: struct{}{}},
+	}
+	err = v.checkSignatureFromCreator(creator, sig, []byte("message"), util.GetTestChainID())
+	if err != ErrCreatorNotPinned {
+		t.Fatalf("checkSignatureFromCreator should have failed with ErrCreatorNotPinned, got %v", err)
+	}
+}
+
+func TestCreatorStructure(t *testing.T) {
+	sig := []byte("signature")
+	msg := []byte("message")
+
+	valid := utils.MarshalOrPanic(&msp.SerializedIdentity{Mspid: "Org1", IdBytes: []byte("cert")})
+	noMSP := utils.MarshalOrPanic(&msp.SerializedIdentity{IdBytes: []byte("cert")})
+	noID := utils.MarshalOrPanic(&msp.SerializedIdentity{Mspid: "Org1"})
+	garbage := []byte{0xff, 0xff, 0xff, 0xff}
+
+	// every creator would deserialize, so that only the pre-check fails
+	identity := &mockIdentity{mspID: "Org1", valid: true, sig: sig}
+	v := &Validator{DeserializerProvider: &mockDeserializerProvider{mockDeserializer{
+		string(valid):   identity,
+		string(noMSP):   identity,
+		string(noID):    identity,
+		string(garbage): identity,
+	}}}
+
+	tests := []struct {
+		name    string
+		creator []byte
+		err     error
+	}{
+		{"Valid", valid, nil},
+		{"Garbage", garbage, ErrInvalidCreator},
+		{"Empty", []byte{}, ErrInvalidCreator},
+		{"NoMSP", noMSP, ErrInvalidCreator},
+		{"NoIdBytes", noID, ErrInvalidCreator},
+	}
+
+	for _, test := range tests {
+		err := v.checkSignatureFromCreator(test.creator, sig, msg, util.GetTestChainID())
+		if err != test.err {
+			t.Fatalf("%s: expected err %v, got %v", test.name, test.err, err)
+		}
+	}
+}
+
+func TestCreatorWithoutMSP(t *testing.T) {
+	tx, err := getTransaction([]byte("simulation_result"))
+	if err != nil {
+		t.Fatalf("getTransaction failed, err %s", err)
+	}
+
+	noMSPIdentity := utils.MarshalOrPanic(&msp.SerializedIdentity{Mspid: "Org1", IdBytes: []byte("nomsp")})
+	noIdentity := utils.MarshalOrPanic(&msp.SerializedIdentity{Mspid: "Org1", IdBytes: []byte("none")})
+	emptyMSP := utils.MarshalOrPanic(&msp.SerializedIdentity{IdBytes: []byte("cert")})
+
+	v := &Validator{DeserializerProvider: &mockDeserializerProvider{fallbackDeserializer{
+		string(noMSPIdentity): &mockIdentity{valid: true},
+		string(noIdentity):    nil,
+		string(emptyMSP):      &mockIdentity{mspID: "Org1", valid: true},
+	}}}
+
+	tests := []struct {
+		name    string
+		creator []byte
+		err     error
+	}{
+		{"IdentityWithoutMSP", noMSPIdentity, ErrCreatorWithoutMSP},
+		{"NoIdentity", noIdentity, ErrCreatorWithoutMSP},
+		{"EmptyMSP", emptyMSP, ErrInvalidCreator},
+	}
+
+	for _, test := range tests {
+		payload, err := utils.GetPayload(tx)
+		if err != nil {
+			t.Fatalf("GetPayload failed, err %s", err)
+		}
+		payload.Header.SignatureHeader.Creator = test.creator
+
+		// the envelope is signed, but the creator cannot have signed it
+		mtx := &common.Envelope{Payload: utils.MarshalOrPanic(payload), Signature: tx.Signature}
+
+		_, err = v.ValidateTransaction(mtx)
+		if err != test.err {
+			t.Fatalf("%s: expected err %v, got %v", test.name, test.err, err)
+		}
+	}
+
+	// the transaction of the creator is still valid
+	_, err = v.ValidateTransaction(tx)
+	if err != nil {
+		t.Fatalf("ValidateTransaction failed, err %s", err)
+	}
+}
@@ -0,0 +1,65 @@
+/*
+Copyright IBM Corp. 2017 All Rights Reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+		 http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package validation
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+)
+
+// ErrUnresolvableIdentity is returned for creators referencing an identity
+// the IdentityResolver does not know
+var ErrUnresolvableIdentity = errors.New("The identity reference cannot be resolved")
+
+// IdentityResolver resolves the references to identities known to the peer
+type IdentityResolver interface {
+	// ResolveIdentity returns the serialized identity designated by the
+	// reference on the given chain, or false if it is not known
+	ResolveIdentity(chainID string, reference []byte) ([]byte, bool, error)
+}
+
+// isIdentityReference tells whether a creator references an identity
+func isIdentityReference(creator []byte) bool {
+	return bytes.HasPrefix(creator, IdentityReferenceMarker)
+}
+
+// resolveCreator returns the serialized identity of a creator: the identity
+// it references, if it references one and the validator resolves references,
+// or the creator itself
+func (v *Validator) resolveCreator(chainID string, creator []byte) ([]byte, error) {
+	if v.IdentityResolver == nil || !isIdentityReference(creator) {
+		return creator, nil
+	}
+
+	reference := creator[len(IdentityReferenceMarker):]
+	if len(reference) == 0 {
+		return nil, fmt.Errorf("Empty identity reference")
+	}
+
+	identity, found, err := v.IdentityResolver.ResolveIdentity(chainID, reference)
+	if err != nil {
+		return nil, fmt.Errorf("Could not resolve the identity reference, err %s", err)
+	}
+
+	if !found || len(identity) == 0 {
+		putilsLogger.Errorf("resolveCreator error: unknown identity reference %x on chain [%s]", reference, chainID)
+		return nil, ErrUnresolvableIdentity
+	}
+
+	return identity, nil
+}
@@ -0,0 +1,141 @@
+/*
+Copyright IBM Corp. 2017 All Rights Reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+		 http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package validation
+
+import (
+	"testing"
+
+	"github.com/hyperledger/fabric/common/util"
+	"github.com/hyperledger/fabric/msp"
+	"github.com/hyperledger/fabric/protos/common"
+	"github.com/hyperledger/fabric/protos/peer"
+	"github.com/hyperledger/fabric/protos/utils"
+	"golang.org/x/net/context"
+)
+
+// mockIdentityResolver resolves the references in its map
+type mockIdentityResolver map[string][]byte
+
+func (r mockIdentityResolver) ResolveIdentity(chainID string, reference []byte) ([]byte, bool, error) {
+	identity, ok := r[string(reference)]
+	return identity, ok, nil
+}
+
+// referencingSigner is a signer whose creator references its identity
+type referencingSigner struct {
+	msp.SigningIdentity
+	creator []byte
+}
+
+func (s referencingSigner) Serialize() ([]byte, error) {
+	return s.creator, nil
+}
+
+// getReferencingTransaction returns a proposal of the signer, referencing
+// its identity with the given reference, along with the transaction for it
+func getReferencingTransaction(t *testing.T, reference string) (*peer.Proposal, *common.Envelope) {
+	creator := append(append([]byte(nil), IdentityReferenceMarker...), reference...)
+	nonce := utils.CreateNonceOrPanic()
+
+	txID, err := utils.ComputeProposalTxID(nonce, creator)
+	if err != nil {
+		t.Fatalf("ComputeProposalTxID failed, err %s", err)
+	}
+
+	cis := &peer.ChaincodeInvocationSpec{
+		ChaincodeSpec: &peer.ChaincodeSpec{
+			ChaincodeId: &peer.ChaincodeID{Name: "foo"},
+			Type:        peer.ChaincodeSpec_GOLANG}}
+
+	prop, _, err := utils.CreateChaincodeProposalWithTxIDNonceAndTransient(txID, common.HeaderType_ENDORSER_TRANSACTION, util.GetTestChainID(), cis, nonce, creator, nil)
+	if err != nil {
+		t.Fatalf("CreateChaincodeProposalWithTxIDNonceAndTransient failed, err %s", err)
+	}
+
+	presp, err := utils.CreateProposalResponse(prop.Header, prop.Payload, &peer.Response{Status: 200}, []byte("simulation_result"), nil, nil, signer)
+	if err != nil {
+		t.Fatalf("CreateProposalResponse failed, err %s", err)
+	}
+
+	tx, err := utils.CreateSignedTx(prop, referencingSigner{signer, creator}, presp)
+	if err != nil {
+		t.Fatalf("CreateSignedTx failed, err %s", err)
+	}
+
+	return prop, tx
+}
+
+func TestIdentityReference(t *testing.T) {
+	resolver := mockIdentityResolver{"signer": signerSerialized}
+
+	resolvableProp, resolvableTx := getReferencingTransaction(t, "signer")
+	unresolvableProp, unresolvableTx := getReferencingTransaction(t, "unknown")
+	fullTx, err := getTransaction([]byte("simulation_result"))
+	if err != nil {
+		t.Fatalf("getTransaction failed, err %s", err)
+	}
+
+	tests := []struct {
+		name     string
+		prop     *peer.Proposal
+		tx       *common.Envelope
+		resolver IdentityResolver
+		err      error
+	}{
+		{"Resolvable", resolvableProp, resolvableTx, resolver, nil},
+		{"Unresolvable", unresolvableProp, unresolvableTx, resolver, ErrUnresolvableIdentity},
+		// references are not valid creators by default
+		{"NotResolved", resolvableProp, resolvableTx, nil, ErrInvalidCreator},
+		{"FullIdentity", nil, fullTx, resolver, nil},
+	}
+
+	for _, test := range tests {
+		v := &Validator{IdentityResolver: test.resolver, CheckActionCreators: true}
+
+		_, err := v.ValidateTransaction(test.tx)
+		if err != test.err {
+			t.Fatalf("%s: expected err %v, got %v", test.name, test.err, err)
+		}
+
+		if test.prop == nil {
+			continue
+		}
+
+		sProp, err := utils.GetSignedProposal(test.prop, signer)
+		if err != nil {
+			t.Fatalf("%s: GetSignedProposal failed, err %s", test.name, err)
+		}
+
+		_, _, _, err = v.ValidateProposalMessage(sProp)
+		if err != test.err {
+			t.Fatalf("%s: expected err %v, got %v", test.name, test.err, err)
+		}
+	}
+
+	// the actions may reference the identity of the creator of the
+	// transaction, which is then resolved to check it
+	v := &Validator{IdentityResolver: resolver}
+	creator := append(append([]byte(nil), IdentityReferenceMarker...), "signer"...)
+	err = v.newActionCreatorChecker(util.GetTestChainID(), signerSerialized, nil).check(context.Background(), creator)
+	if err != nil {
+		t.Fatalf("check failed, err %s", err)
+	}
+	err = v.newActionCreatorChecker(util.GetTestChainID(), signerSerialized, nil).check(context.Background(), append(creator, '?'))
+	if err != ErrUnresolvableIdentity {
+		t.Fatalf("Expected err %v, got %v", ErrUnresolvableIdentity, err)
+	}
+}
@@ -0,0 +1,66 @@
+/*
+Copyright IBM Corp. 2017 All Rights Reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+		 http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package validation
+
+import (
+	"fmt"
+
+	"github.com/hyperledger/fabric/msp"
+)
+
+// IdentityType is the type of credentials backing an identity
+type IdentityType int
+
+const (
+	// X509Identity is an identity backed by an x.509 certificate
+	X509Identity IdentityType = iota
+
+	// IdemixIdentity is an anonymous identity backed by an idemix credential,
+	// which has no certificate subject and whose identifier is not public
+	IdemixIdentity
+)
+
+func (t IdentityType) String() string {
+	switch t {
+	case X509Identity:
+		return "x509"
+	case IdemixIdentity:
+		return "idemix"
+	default:
+		return fmt.Sprintf("unknown(%d)", int(t))
+	}
+}
+
+// TypedIdentity is implemented by the identities returned by deserializers
+// that support several types of credentials
+type TypedIdentity interface {
+	// GetIdentityType returns the type of credentials backing the identity
+	GetIdentityType() IdentityType
+}
+
+// getIdentityType returns the type of the identity; identities that do not
+// report their type are backed by x.509 certificates, as those of the MSPs
+// shipped with fabric. Checks relying on the certificate of an identity,
+// such as those on its subject, must only be performed on x.509 identities:
+// the others are verified by their MSP alone
+func getIdentityType(id msp.Identity) IdentityType {
+	if typed, ok := id.(TypedIdentity); ok {
+		return typed.GetIdentityType()
+	}
+
+	return X509Identity
+}
@@ -0,0 +1,78 @@
+/*
+Copyright IBM Corp. 2017 All Rights Reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+		 http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package validation
+
+import (
+	"crypto/ecdsa"
+	"crypto/rsa"
+	"errors"
+
+	"github.com/hyperledger/fabric/msp"
+)
+
+// ErrWeakKey is returned when the public key of the certificate of a
+// creator is weaker than the minimum required
+var ErrWeakKey = errors.New("The creator key is too weak")
+
+// KeySizes holds the minimum sizes, in bits, of the public keys of the
+// creator certificates, by algorithm; zero fields have no minimum
+type KeySizes struct {
+	// RSA is the minimum size of the modulus of RSA keys
+	RSA int
+
+	// ECDSA is the minimum size of the curve of ECDSA keys, e.g. 256 to
+	// accept P-256, P-384 and P-521 but not P-224
+	ECDSA int
+}
+
+// checkCreatorKeySize ensures that the public key of the creator is at
+// least as strong as required. The key is taken from the certificate of the
+// creator, which is parsed from the serialized identity rather than from
+// the identity returned by the MSP, as identities do not expose their keys:
+// the size of an RSA key is that of its modulus, and the size of an ECDSA
+// key that of the order of the base point of its curve. Keys of other
+// algorithms cannot be assessed and are rejected, while creators without a
+// certificate have no key to check
+func (v *Validator) checkCreatorKeySize(sId *msp.SerializedIdentity, idType IdentityType) error {
+	if v.MinCreatorKeySizes == nil || idType != X509Identity {
+		return nil
+	}
+
+	certs, err := parseCertificateChain(sId.IdBytes)
+	if err != nil {
+		return err
+	}
+
+	var size, minSize int
+	var algorithm string
+	switch key := certs[0].PublicKey.(type) {
+	case *rsa.PublicKey:
+		size, minSize, algorithm = key.N.BitLen(), v.MinCreatorKeySizes.RSA, "RSA"
+	case *ecdsa.PublicKey:
+		size, minSize, algorithm = key.Curve.Params().BitSize, v.MinCreatorKeySizes.ECDSA, "ECDSA"
+	default:
+		putilsLogger.Errorf("checkCreatorKeySize error: creator key of type %T cannot be assessed", key)
+		return ErrWeakKey
+	}
+
+	if size < minSize {
+		putilsLogger.Errorf("checkCreatorKeySize error: creator %s key of %d bits, at least %d required", algorithm, size, minSize)
+		return ErrWeakKey
+	}
+
+	return nil
+}
@@ -0,0 +1,116 @@
+/*
+Copyright IBM Corp. 2017 All Rights Reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+		 http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package validation
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/hyperledger/fabric/common/util"
+	"github.com/hyperledger/fabric/msp"
+	"github.com/hyperledger/fabric/protos/utils"
+)
+
+// newSelfSignedCreator returns a serialized identity whose self-signed
+// certificate holds the public key of the signer
+func newSelfSignedCreator(t *testing.T, key crypto.Signer) []byte {
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(time.Now().UnixNano()),
+		Subject:      pkix.Name{CommonName: "creator"},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+	}
+
+	der, err := x509.CreateCertificate(rand.Reader, template, template, key.Public(), key)
+	if err != nil {
+		t.Fatalf("CreateCertificate failed, err %s", err)
+	}
+
+	certPEM := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der})
+	return utils.MarshalOrPanic(&msp.SerializedIdentity{Mspid: "Org1", IdBytes: certPEM})
+}
+
+func TestCreatorKeySize(t *testing.T) {
+	sig := []byte("signature")
+	msg := []byte("message")
+
+	p224, err := ecdsa.GenerateKey(elliptic.P224(), rand.Reader)
+	if err != nil {
+		t.Fatalf("GenerateKey failed, err %s", err)
+	}
+	p256, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("GenerateKey failed, err %s", err)
+	}
+	rsa1024, err := rsa.GenerateKey(rand.Reader, 1024)
+	if err != nil {
+		t.Fatalf("GenerateKey failed, err %s", err)
+	}
+	rsa2048, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatalf("GenerateKey failed, err %s", err)
+	}
+
+	weakECDSA := newSelfSignedCreator(t, p224)
+	strongECDSA := newSelfSignedCreator(t, p256)
+	weakRSA := newSelfSignedCreator(t, rsa1024)
+	strongRSA := newSelfSignedCreator(t, rsa2048)
+	anonymous := utils.MarshalOrPanic(&msp.SerializedIdentity{Mspid: "Org1", IdBytes: []byte("credential")})
+
+	// the MSP validates every creator, whatever its key
+	provider := &mockDeserializerProvider{mockDeserializer{
+		string(weakECDSA):   &mockIdentity{mspID: "Org1", valid: true, sig: sig},
+		string(strongECDSA): &mockIdentity{mspID: "Org1", valid: true, sig: sig},
+		string(weakRSA):     &mockIdentity{mspID: "Org1", valid: true, sig: sig},
+		string(strongRSA):   &mockIdentity{mspID: "Org1", valid: true, sig: sig},
+		string(anonymous):   &mockIdemixIdentity{mockIdentity{mspID: "Org1", valid: true, sig: sig}},
+	}}
+
+	minSizes := &KeySizes{RSA: 2048, ECDSA: 256}
+
+	tests := []struct {
+		name     string
+		creator  []byte
+		minSizes *KeySizes
+		err      error
+	}{
+		{"StrongECDSA", strongECDSA, minSizes, nil},
+		{"WeakECDSA", weakECDSA, minSizes, ErrWeakKey},
+		{"StrongRSA", strongRSA, minSizes, nil},
+		{"WeakRSA", weakRSA, minSizes, ErrWeakKey},
+		{"NoRSAMinimum", weakRSA, &KeySizes{ECDSA: 256}, nil},
+		{"Anonymous", anonymous, minSizes, nil},
+		{"Permissive", weakECDSA, nil, nil},
+	}
+
+	for _, test := range tests {
+		v := &Validator{DeserializerProvider: provider, MinCreatorKeySizes: test.minSizes}
+		err := v.checkSignatureFromCreator(test.creator, sig, msg, util.GetTestChainID())
+		if err != test.err {
+			t.Fatalf("%s: expected err %v, got %v", test.name, test.err, err)
+		}
+	}
+}
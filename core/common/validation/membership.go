@@ -0,0 +1,46 @@
+/*
+Copyright IBM Corp. 2017 All Rights Reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+		 http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package validation
+
+import "errors"
+
+// ErrNotChannelMember is returned when the MSP of a creator is not a member
+// of the channel it targets
+var ErrNotChannelMember = errors.New("The creator is not a member of the channel")
+
+// ChannelMembershipChecker tells which organizations are members of the
+// channels
+type ChannelMembershipChecker interface {
+	// IsChannelMember returns true if the given MSP is a member of the
+	// given chain
+	IsChannelMember(chainID, mspID string) bool
+}
+
+// checkChannelMember ensures that the MSP that validated the creator is a
+// member of the chain, if a ChannelMembershipChecker is set
+func (v *Validator) checkChannelMember(chainID, mspID string) error {
+	if v.ChannelMembershipChecker == nil {
+		return nil
+	}
+
+	if !v.ChannelMembershipChecker.IsChannelMember(chainID, mspID) {
+		putilsLogger.Errorf("checkChannelMember error: MSP %s is not a member of chain [%s]", mspID, chainID)
+		return ErrNotChannelMember
+	}
+
+	return nil
+}
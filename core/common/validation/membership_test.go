@@ -0,0 +1,76 @@
+/*
+Copyright IBM Corp. 2017 All Rights Reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+		 http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package validation
+
+import (
+	"testing"
+
+	"github.com/hyperledger/fabric/common/util"
+	"github.com/hyperledger/fabric/protos/utils"
+)
+
+// mockMembershipChecker maps chains to the MSPs members of them
+type mockMembershipChecker map[string][]string
+
+func (m mockMembershipChecker) IsChannelMember(chainID, mspID string) bool {
+	for _, member := range m[chainID] {
+		if member == mspID {
+			return true
+		}
+	}
+	return false
+}
+
+func TestChannelMembershipChecker(t *testing.T) {
+	tx, err := getTransaction([]byte("simulation_result"))
+	if err != nil {
+		t.Fatalf("getTransaction failed, err %s", err)
+	}
+
+	prop, err := getProposal()
+	if err != nil {
+		t.Fatalf("getProposal failed, err %s", err)
+	}
+	sProp, err := utils.GetSignedProposal(prop, signer)
+	if err != nil {
+		t.Fatalf("GetSignedProposal failed, err %s", err)
+	}
+
+	tests := []struct {
+		name    string
+		checker ChannelMembershipChecker
+		err     error
+	}{
+		{"Permissive", nil, nil},
+		{"Member", mockMembershipChecker{util.GetTestChainID(): {"Org1", "DEFAULT"}}, nil},
+		{"NotMember", mockMembershipChecker{util.GetTestChainID(): {"Org1"}}, ErrNotChannelMember},
+		{"MemberOfOtherChannel", mockMembershipChecker{"otherchannel": {"DEFAULT"}}, ErrNotChannelMember},
+	}
+
+	for _, test := range tests {
+		v := &Validator{ChannelMembershipChecker: test.checker}
+		_, err := v.ValidateTransaction(tx)
+		if err != test.err {
+			t.Fatalf("%s: ValidateTransaction expected err %v, got %v", test.name, test.err, err)
+		}
+
+		_, _, _, err = v.ValidateProposalMessage(sProp)
+		if err != test.err {
+			t.Fatalf("%s: ValidateProposalMessage expected err %v, got %v", test.name, test.err, err)
+		}
+	}
+}
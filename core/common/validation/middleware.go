@@ -0,0 +1,120 @@
+/*
+Copyright IBM Corp. 2017 All Rights Reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+		 http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package validation
+
+import (
+	"errors"
+	"sync"
+
+	"github.com/hyperledger/fabric/protos/common"
+	"golang.org/x/net/context"
+)
+
+// ErrNoValidationResult is returned when a middleware accepts a transaction
+// without the result of its validation, which holds its payload
+var ErrNoValidationResult = errors.New("The middleware returned no validation result")
+
+// ValidationHandler validates a transaction envelope; the result must not
+// be nil, even if the validation failed
+type ValidationHandler func(ctx context.Context, e *common.Envelope) (*ValidationResult, error)
+
+// ValidationMiddleware wraps the validation of transactions: it returns a
+// handler that may act before and after calling next, e.g. to record
+// metrics or enforce rate limits and ACLs, or reject the transaction
+// without calling next at all
+type ValidationMiddleware func(next ValidationHandler) ValidationHandler
+
+// middlewareChain holds the middlewares used by a Validator
+type middlewareChain struct {
+	sync.RWMutex
+	middlewares []ValidationMiddleware
+}
+
+// Use appends a middleware to the chain wrapping the validation of
+// transactions. The middlewares run in the order in which they were used:
+// the first one is the outermost, while the built-in validation, including
+// the plugins, is the innermost handler
+func (v *Validator) Use(mw ValidationMiddleware) {
+	v.middlewareChain.Lock()
+	defer v.middlewareChain.Unlock()
+
+	v.middlewareChain.middlewares = append(v.middlewareChain.middlewares, mw)
+}
+
+// validateWith validates the transaction envelope through the middleware
+// chain and, if it is valid, performs the additional check of the entry
+// point on the result, if any, before completing the validation with its
+// final outcome. Each public entry point validates a transaction through a
+// single call to validateWith
+func (v *Validator) validateWith(ctx context.Context, e *common.Envelope, check func(result *ValidationResult) error) (*ValidationResult, error) {
+	result, err := v.validate(ctx, e)
+	if err == nil && check != nil {
+		err = check(result)
+	}
+
+	return v.finishValidation(result, err)
+}
+
+// finishValidation completes the validation of a transaction once its final
+// outcome is known: it records the sequence number of the accepted
+// transactions, unless in dry-run mode, audits the outcome, if required,
+// and calls the hooks
+func (v *Validator) finishValidation(result *ValidationResult, err error) (*ValidationResult, error) {
+	// the sequence number is checked again, in case a transaction of the
+	// same creator was accepted since
+	if err == nil && !v.DryRun && isSequenced(v.SequenceTracker, result.Payload) {
+		err = v.SequenceTracker.advance(context.Background(), result.Payload.Header, false)
+	}
+
+	if v.AuditSink != nil {
+		v.AuditSink.record(result, err)
+	}
+
+	// the hooks are only reached if no handler panicked
+	if err == nil && v.OnValid != nil {
+		v.OnValid(result)
+	} else if err != nil && v.OnInvalid != nil {
+		v.OnInvalid(result.Envelope, err)
+	}
+
+	return result, err
+}
+
+// validate validates the transaction envelope through the middleware chain;
+// the validation is not complete until finishValidation is called
+func (v *Validator) validate(ctx context.Context, e *common.Envelope) (*ValidationResult, error) {
+	v.middlewareChain.RLock()
+	handler := ValidationHandler(v.validateBuiltin)
+	for i := len(v.middlewareChain.middlewares) - 1; i >= 0; i-- {
+		handler = v.middlewareChain.middlewares[i](handler)
+	}
+	v.middlewareChain.RUnlock()
+
+	result, err := handler(ctx, e)
+	if err == nil && (result == nil || result.Payload == nil) {
+		// a middleware accepted the transaction without the result of
+		// its validation, so that its payload is unknown
+		putilsLogger.Errorf("validate error: a middleware accepted envelope %p without a result", e)
+		err = ErrNoValidationResult
+	}
+	if result == nil {
+		// a middleware rejected the transaction without a result
+		result = &ValidationResult{Envelope: e}
+	}
+
+	return result, err
+}
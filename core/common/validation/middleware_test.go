@@ -0,0 +1,219 @@
+/*
+Copyright IBM Corp. 2017 All Rights Reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+		 http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package validation
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/hyperledger/fabric/protos/common"
+	"golang.org/x/net/context"
+)
+
+// recordingMiddleware returns a middleware appending to calls before and
+// after the next handler, which it does not call if it rejects
+func recordingMiddleware(name string, calls *[]string, reject error) ValidationMiddleware {
+	return func(next ValidationHandler) ValidationHandler {
+		return func(ctx context.Context, e *common.Envelope) (*ValidationResult, error) {
+			*calls = append(*calls, name+"-before")
+			if reject != nil {
+				return nil, reject
+			}
+
+			result, err := next(ctx, e)
+			*calls = append(*calls, name+"-after")
+			return result, err
+		}
+	}
+}
+
+// recordingPlugin records that the built-in validation ran
+type recordingPlugin struct {
+	calls *[]string
+}
+
+func (p *recordingPlugin) Validate(result *ValidationResult) error {
+	*p.calls = append(*p.calls, "builtin")
+	return nil
+}
+
+func TestMiddlewares(t *testing.T) {
+	tx, err := getTransaction([]byte("simulation_result"))
+	if err != nil {
+		t.Fatalf("getTransaction failed, err %s", err)
+	}
+
+	var calls []string
+	v := &Validator{}
+	v.RegisterPlugin("", 0, &recordingPlugin{&calls})
+	v.Use(recordingMiddleware("outer", &calls, nil))
+	v.Use(recordingMiddleware("inner", &calls, nil))
+
+	result, err := v.Validate(tx)
+	if err != nil {
+		t.Fatalf("Validate failed, err %s", err)
+	}
+	if result.Payload == nil {
+		t.Fatalf("Expected the result of the built-in validation")
+	}
+
+	expected := []string{"outer-before", "inner-before", "builtin", "inner-after", "outer-after"}
+	if !reflect.DeepEqual(calls, expected) {
+		t.Fatalf("Expected calls %v, got %v", expected, calls)
+	}
+
+	// a middleware rejecting the transaction short-circuits the chain
+	rejected := errors.New("rejected")
+	calls = nil
+	v = &Validator{}
+	v.RegisterPlugin("", 0, &recordingPlugin{&calls})
+	v.Use(recordingMiddleware("outer", &calls, nil))
+	v.Use(recordingMiddleware("inner", &calls, rejected))
+
+	result, err = v.Validate(tx)
+	if err != rejected {
+		t.Fatalf("Expected err %v, got %v", rejected, err)
+	}
+	if result == nil || result.Envelope != tx {
+		t.Fatalf("Expected a result holding the envelope, got %+v", result)
+	}
+
+	expected = []string{"outer-before", "inner-before", "outer-after"}
+	if !reflect.DeepEqual(calls, expected) {
+		t.Fatalf("Expected calls %v, got %v", expected, calls)
+	}
+
+	_, err = v.ValidateTransaction(tx)
+	if err != rejected {
+		t.Fatalf("Expected err %v from ValidateTransaction, got %v", rejected, err)
+	}
+}
+
+func TestMiddlewareNoResult(t *testing.T) {
+	tx, err := getTransaction([]byte("simulation_result"))
+	if err != nil {
+		t.Fatalf("getTransaction failed, err %s", err)
+	}
+
+	tests := []struct {
+		name   string
+		result *ValidationResult
+	}{
+		{"NilResult", nil},
+		{"NoPayload", &ValidationResult{Envelope: tx}},
+	}
+
+	for _, test := range tests {
+		result := test.result
+		var invalid error
+		v := &Validator{OnInvalid: func(e *common.Envelope, err error) { invalid = err }}
+		v.Use(func(next ValidationHandler) ValidationHandler {
+			return func(ctx context.Context, e *common.Envelope) (*ValidationResult, error) {
+				return result, nil
+			}
+		})
+
+		payload, err := v.ValidateTransaction(tx)
+		if err != ErrNoValidationResult {
+			t.Fatalf("%s: expected err %v, got %v", test.name, ErrNoValidationResult, err)
+		}
+		if payload != nil {
+			t.Fatalf("%s: ValidateTransaction returned payload %v", test.name, payload)
+		}
+		if invalid != ErrNoValidationResult {
+			t.Fatalf("%s: OnInvalid should have been called with %v, got %v", test.name, ErrNoValidationResult, invalid)
+		}
+	}
+}
+
+func TestValidationHooks(t *testing.T) {
+	tx, err := getTransaction([]byte("simulation_result"))
+	if err != nil {
+		t.Fatalf("getTransaction failed, err %s", err)
+	}
+
+	var valid []*ValidationResult
+	var invalid []error
+	v := &Validator{
+		OnValid:   func(result *ValidationResult) { valid = append(valid, result) },
+		OnInvalid: func(e *common.Envelope, err error) { invalid = append(invalid, err) },
+	}
+
+	result, err := v.Validate(tx)
+	if err != nil {
+		t.Fatalf("Validate failed, err %s", err)
+	}
+	if len(valid) != 1 || valid[0] != result || len(invalid) != 0 {
+		t.Fatalf("Expected OnValid to be called once with the result, got %d valid and %d invalid", len(valid), len(invalid))
+	}
+
+	// a transaction failing the built-in validation
+	valid, invalid = nil, nil
+	_, err = v.ValidateTransaction(&common.Envelope{Payload: tx.Payload})
+	if err == nil {
+		t.Fatalf("ValidateTransaction should have failed")
+	}
+	if len(valid) != 0 || len(invalid) != 1 || invalid[0] != err {
+		t.Fatalf("Expected OnInvalid to be called once with the error, got %d valid and %d invalid", len(valid), len(invalid))
+	}
+
+	// a transaction rejected by the additional checks of an entry point
+	// after passing the built-in validation; no policy provider is set to
+	// check the digest against
+	valid, invalid = nil, nil
+	_, err = v.ValidateTransactionWithPolicyDigest(tx, []byte("digest"))
+	if err == nil {
+		t.Fatalf("ValidateTransactionWithPolicyDigest should have failed")
+	}
+	if len(valid) != 0 || len(invalid) != 1 || invalid[0] != err {
+		t.Fatalf("Expected OnInvalid to be called once with the error, got %d valid and %d invalid", len(valid), len(invalid))
+	}
+
+	// a transaction rejected by a middleware
+	rejected := errors.New("rejected")
+	valid, invalid = nil, nil
+	var calls []string
+	v.Use(recordingMiddleware("rejecting", &calls, rejected))
+	_, err = v.Validate(tx)
+	if err != rejected || len(valid) != 0 || len(invalid) != 1 || invalid[0] != rejected {
+		t.Fatalf("Expected OnInvalid to be called once with %v, got err %v, %d valid and %d invalid", rejected, err, len(valid), len(invalid))
+	}
+
+	// neither hook is called if the validation panics
+	valid, invalid = nil, nil
+	v = &Validator{
+		OnValid:   func(result *ValidationResult) { valid = append(valid, result) },
+		OnInvalid: func(e *common.Envelope, err error) { invalid = append(invalid, err) },
+	}
+	v.Use(func(next ValidationHandler) ValidationHandler {
+		return func(ctx context.Context, e *common.Envelope) (*ValidationResult, error) {
+			panic("middleware failure")
+		}
+	})
+	func() {
+		defer func() {
+			if recover() == nil {
+				t.Fatalf("Validate should have panicked")
+			}
+		}()
+		v.Validate(tx)
+	}()
+	if len(valid) != 0 || len(invalid) != 0 {
+		t.Fatalf("Expected no hook to be called, got %d valid and %d invalid", len(valid), len(invalid))
+	}
+}
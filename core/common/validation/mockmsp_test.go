@@ -0,0 +1,95 @@
+/*
+Copyright IBM Corp. 2017 All Rights Reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+		 http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package validation
+
+import (
+	"errors"
+
+	"github.com/golang/protobuf/proto"
+	"github.com/hyperledger/fabric/msp"
+	"github.com/hyperledger/fabric/protos/common"
+)
+
+// mockIdentity is an msp.Identity whose behaviour is controlled by its fields
+type mockIdentity struct {
+	mspID string
+	id    string
+	valid bool
+	sig   []byte
+}
+
+func (id *mockIdentity) GetIdentifier() *msp.IdentityIdentifier {
+	return &msp.IdentityIdentifier{Mspid: id.mspID, Id: id.id}
+}
+
+func (id *mockIdentity) GetMSPIdentifier() string {
+	return id.mspID
+}
+
+func (id *mockIdentity) Validate() error {
+	if !id.valid {
+		return errors.New("invalid identity")
+	}
+	return nil
+}
+
+func (id *mockIdentity) GetOrganizationalUnits() []string {
+	return nil
+}
+
+func (id *mockIdentity) Verify(msg []byte, sig []byte) error {
+	if string(sig) != string(id.sig) {
+		return errors.New("invalid signature")
+	}
+	return nil
+}
+
+func (id *mockIdentity) VerifyOpts(msg []byte, sig []byte, opts msp.SignatureOpts) error {
+	return id.Verify(msg, sig)
+}
+
+func (id *mockIdentity) VerifyAttributes(proof []byte, spec *msp.AttributeProofSpec) error {
+	return nil
+}
+
+func (id *mockIdentity) Serialize() ([]byte, error) {
+	return proto.Marshal(&msp.SerializedIdentity{Mspid: id.mspID, IdBytes: []byte("mock:" + id.id)})
+}
+
+func (id *mockIdentity) SatisfiesPrincipal(principal *common.MSPPrincipal) error {
+	return nil
+}
+
+// mockDeserializer returns the identity registered for the serialized bytes
+type mockDeserializer map[string]msp.Identity
+
+func (d mockDeserializer) DeserializeIdentity(serializedIdentity []byte) (msp.Identity, error) {
+	id, ok := d[string(serializedIdentity)]
+	if !ok {
+		return nil, errors.New("unknown identity")
+	}
+	return id, nil
+}
+
+// mockDeserializerProvider returns the same deserializer for every channel
+type mockDeserializerProvider struct {
+	deserializer msp.IdentityDeserializer
+}
+
+func (p *mockDeserializerProvider) GetIdentityDeserializer(chainID string) msp.IdentityDeserializer {
+	return p.deserializer
+}
@@ -17,21 +17,38 @@ limitations under the License.
 package validation
 
 import (
+	"errors"
 	"fmt"
 
-	"bytes"
-
-	mspmgmt "github.com/hyperledger/fabric/msp/mgmt"
+	"github.com/golang/protobuf/proto"
+	"github.com/hyperledger/fabric/msp"
 	"github.com/hyperledger/fabric/protos/common"
 	pb "github.com/hyperledger/fabric/protos/peer"
 	"github.com/hyperledger/fabric/protos/utils"
 	"github.com/op/go-logging"
+	"golang.org/x/net/context"
 )
 
 var putilsLogger = logging.MustGetLogger("protoutils")
 
+// ErrMSPMismatch is returned when the MSP declared by a creator differs
+// from the MSP that validated the creator's certificate
+var ErrMSPMismatch = errors.New("The MSP declared by the creator does not match the MSP of its certificate")
+
+// ErrInvalidCreator is returned when the creator bytes do not decode as a
+// SerializedIdentity with both an MSP ID and identity bytes
+var ErrInvalidCreator = errors.New("creator is not a valid SerializedIdentity")
+
+// ErrCreatorWithoutMSP is returned when the creator deserializes to no
+// identity, or to an identity that belongs to no MSP
+var ErrCreatorWithoutMSP = errors.New("creator does not deserialize to an identity of an MSP")
+
+// ConfigProposalChaincode is the name of the system chaincode processing
+// the proposals of type CONFIG, such as those joining a peer to a channel
+const ConfigProposalChaincode = "cscc"
+
 // validateChaincodeProposalMessage checks the validity of a Proposal message of type CHAINCODE
-func validateChaincodeProposalMessage(prop *pb.Proposal, hdr *common.Header) (*pb.ChaincodeHeaderExtension, error) {
+func (v *Validator) validateChaincodeProposalMessage(prop *pb.Proposal, hdr *common.Header) (*pb.ChaincodeHeaderExtension, error) {
 	putilsLogger.Infof("validateChaincodeProposalMessage starts for proposal %p, header %p", prop, hdr)
 
 	// 4) based on the header type (assuming it's CHAINCODE), look at the extensions
@@ -40,6 +57,11 @@ func validateChaincodeProposalMessage(prop *pb.Proposal, hdr *common.Header) (*p
 		return nil, fmt.Errorf("Invalid header extension for type CHAINCODE")
 	}
 
+	// ensure that the extension references the chaincode to invoke
+	if chaincodeHdrExt.ChaincodeId == nil || chaincodeHdrExt.ChaincodeId.Name == "" {
+		return nil, fmt.Errorf("Proposal of type %s carries no chaincode header extension", common.HeaderType(hdr.ChannelHeader.Type))
+	}
+
 	putilsLogger.Infof("validateChaincodeProposalMessage info: header extension references chaincode %s", chaincodeHdrExt.ChaincodeId)
 
 	//    - ensure that the chaincodeID is correct (?)
@@ -50,13 +72,49 @@ func validateChaincodeProposalMessage(prop *pb.Proposal, hdr *common.Header) (*p
 	// there are no restrictions on which parts of the proposal payload will
 	// be visible in the final transaction; this default approach requires
 	// no additional instructions in the PayloadVisibility field which is
-	// therefore expected to be nil; however the fabric may be extended to
-	// encode more elaborate visibility mechanisms that shall be encoded in
-	// this field (and handled appropriately by the peer)
-	if chaincodeHdrExt.PayloadVisibility != nil {
+	// therefore expected to be empty; however the fabric may be extended
+	// to encode more elaborate visibility mechanisms that shall be encoded
+	// in this field (and handled appropriately by the peer)
+	if len(chaincodeHdrExt.PayloadVisibility) != 0 {
 		return nil, fmt.Errorf("Invalid payload visibility field")
 	}
 
+	// if required, ensure that the arguments of the invocation are
+	// canonically encoded
+	if v.StrictArgsEncoding {
+		err = checkCanonicalArgs(prop.Payload)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	// ensure that the arguments of the invocation are not too large
+	cis, err := getInvocationSpec(prop.Payload)
+	if err != nil {
+		return nil, err
+	}
+
+	err = v.checkArgsSize(cis)
+	if err != nil {
+		return nil, err
+	}
+
+	return chaincodeHdrExt, nil
+}
+
+// validateConfigProposalMessage checks the validity of a Proposal message of
+// type CONFIG: those are processed by the configuration system chaincode,
+// which must be the chaincode referenced by their chaincode header extension
+func (v *Validator) validateConfigProposalMessage(prop *pb.Proposal, hdr *common.Header) (*pb.ChaincodeHeaderExtension, error) {
+	chaincodeHdrExt, err := v.validateChaincodeProposalMessage(prop, hdr)
+	if err != nil {
+		return nil, err
+	}
+
+	if chaincodeHdrExt.ChaincodeId.Name != ConfigProposalChaincode {
+		return nil, fmt.Errorf("Proposal of type CONFIG references chaincode %s, config proposals are processed by %s", chaincodeHdrExt.ChaincodeId.Name, ConfigProposalChaincode)
+	}
+
 	return chaincodeHdrExt, nil
 }
 
@@ -64,6 +122,19 @@ func validateChaincodeProposalMessage(prop *pb.Proposal, hdr *common.Header) (*p
 // this function returns Header and ChaincodeHeaderExtension messages since they
 // have been unmarshalled and validated
 func ValidateProposalMessage(signedProp *pb.SignedProposal) (*pb.Proposal, *common.Header, *pb.ChaincodeHeaderExtension, error) {
+	return defaultValidator.ValidateProposalMessage(signedProp)
+}
+
+// ValidateProposalMessage checks the validity of a SignedProposal message
+// this function returns Header and ChaincodeHeaderExtension messages since they
+// have been unmarshalled and validated
+func (v *Validator) ValidateProposalMessage(signedProp *pb.SignedProposal) (*pb.Proposal, *common.Header, *pb.ChaincodeHeaderExtension, error) {
+	return v.validateProposalMessage(context.Background(), signedProp)
+}
+
+// validateProposalMessage checks the validity of a SignedProposal message as
+// ValidateProposalMessage does, within the given context
+func (v *Validator) validateProposalMessage(ctx context.Context, signedProp *pb.SignedProposal) (*pb.Proposal, *common.Header, *pb.ChaincodeHeaderExtension, error) {
 	putilsLogger.Infof("ValidateProposalMessage starts for signed proposal %p", signedProp)
 
 	// extract the Proposal message from signedProp
@@ -79,13 +150,19 @@ func ValidateProposalMessage(signedProp *pb.SignedProposal) (*pb.Proposal, *comm
 	}
 
 	// validate the header
-	err = validateCommonHeader(hdr)
+	err = v.validateCommonHeader(ctx, hdr)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+
+	// wait for the MSP config of the channel to be ready
+	err = v.waitForMSP(ctx, hdr.ChannelHeader.ChannelId)
 	if err != nil {
 		return nil, nil, nil, err
 	}
 
 	// validate the signature
-	err = checkSignatureFromCreator(hdr.SignatureHeader.Creator, signedProp.Signature, signedProp.ProposalBytes, hdr.ChannelHeader.ChannelId)
+	creator, err := v.verifyCreator(ctx, hdr.SignatureHeader.Creator, signedProp.Signature, signedProp.ProposalBytes, hdr.ChannelHeader.ChannelId)
 	if err != nil {
 		return nil, nil, nil, err
 	}
@@ -95,7 +172,8 @@ func ValidateProposalMessage(signedProp *pb.SignedProposal) (*pb.Proposal, *comm
 	// Verify that the transaction ID has been computed properly.
 	// This check is needed to ensure that the lookup into the ledger
 	// for the same TxID catches duplicates.
-	err = utils.CheckProposalTxID(
+	err = v.checkTxID(ctx,
+		hdr.ChannelHeader.ChannelId,
 		hdr.ChannelHeader.TxId,
 		hdr.SignatureHeader.Nonce,
 		hdr.SignatureHeader.Creator)
@@ -106,14 +184,26 @@ func ValidateProposalMessage(signedProp *pb.SignedProposal) (*pb.Proposal, *comm
 	// continue the validation in a way that depends on the type specified in the header
 	switch common.HeaderType(hdr.ChannelHeader.Type) {
 	case common.HeaderType_CONFIG:
-		//which the types are different the validation is the same
-		//viz, validate a proposal to a chaincode. If we need other
-		//special validation for confguration, we would have to implement
-		//special validation
-		fallthrough
+		// validation of the proposal message knowing it's of type CONFIG
+		chaincodeHdrExt, err := v.validateConfigProposalMessage(prop, hdr)
+		if err != nil {
+			return nil, nil, nil, err
+		}
+
+		err = v.checkCreatorChaincode(creator, chaincodeHdrExt.ChaincodeId.Name)
+		if err != nil {
+			return nil, nil, nil, err
+		}
+
+		return prop, hdr, chaincodeHdrExt, err
 	case common.HeaderType_ENDORSER_TRANSACTION:
 		// validation of the proposal message knowing it's of type CHAINCODE
-		chaincodeHdrExt, err := validateChaincodeProposalMessage(prop, hdr)
+		chaincodeHdrExt, err := v.validateChaincodeProposalMessage(prop, hdr)
+		if err != nil {
+			return nil, nil, nil, err
+		}
+
+		err = v.checkCreatorChaincode(creator, chaincodeHdrExt.ChaincodeId.Name)
 		if err != nil {
 			return nil, nil, nil, err
 		}
@@ -128,47 +218,156 @@ func ValidateProposalMessage(signedProp *pb.SignedProposal) (*pb.Proposal, *comm
 // given a creator, a message and a signature,
 // this function returns nil if the creator
 // is a valid cert and the signature is valid
-func checkSignatureFromCreator(creatorBytes []byte, sig []byte, msg []byte, ChainID string) error {
+func (v *Validator) checkSignatureFromCreator(creatorBytes []byte, sig []byte, msg []byte, ChainID string) error {
+	_, err := v.verifyCreator(context.Background(), creatorBytes, sig, msg, ChainID)
+	return err
+}
+
+// verifyCreator checks the creator and its signature as
+// checkSignatureFromCreator does, and returns the identity of the creator
+func (v *Validator) verifyCreator(ctx context.Context, creatorBytes []byte, sig []byte, msg []byte, ChainID string) (msp.Identity, error) {
 	putilsLogger.Infof("checkSignatureFromCreator starts")
 
-	// check for nil argument
-	if creatorBytes == nil || sig == nil || msg == nil {
-		return fmt.Errorf("Nil arguments")
+	// check for nil or empty argument; a nil or empty creator is not a
+	// valid SerializedIdentity, as checked below
+	if len(sig) == 0 || len(msg) == 0 {
+		return nil, fmt.Errorf("Nil arguments")
+	}
+
+	// if the creator references its identity, resolve it
+	creatorBytes, err := v.resolveCreator(ChainID, creatorBytes)
+	if err != nil {
+		return nil, err
 	}
 
-	mspObj := mspmgmt.GetIdentityDeserializer(ChainID)
-	if mspObj == nil {
-		return fmt.Errorf("could not get msp for chain [%s]", ChainID)
+	// ensure that the creator is structurally valid before handing it
+	// to the MSP, which would fail with a less clear error
+	sId := &msp.SerializedIdentity{}
+	err = proto.Unmarshal(creatorBytes, sId)
+	if err != nil || sId.Mspid == "" || len(sId.IdBytes) == 0 {
+		putilsLogger.Errorf("checkSignatureFromCreator error: creator is not a valid SerializedIdentity, err %v", err)
+		return nil, ErrInvalidCreator
 	}
 
-	// get the identity of the creator
-	creator, err := mspObj.DeserializeIdentity(creatorBytes)
+	mspObj, err := v.getBatchIdentityDeserializer(ctx, ChainID)
 	if err != nil {
-		return fmt.Errorf("Failed to deserialize creator identity, err %s", err)
+		return nil, err
 	}
 
-	putilsLogger.Infof("checkSignatureFromCreator info: creator is %s", creator.GetIdentifier())
+	// ensure that the creator claims one of the MSPs of the channel
+	err = checkChannelMSP(ChainID, mspObj, sId.Mspid)
+	if err != nil {
+		return nil, err
+	}
 
-	// ensure that creator is a valid certificate
-	err = creator.Validate()
+	// get the identity of the creator
+	creator, err := deserializeBatchIdentity(ctx, ChainID, mspObj, creatorBytes)
 	if err != nil {
-		return fmt.Errorf("The creator certificate is not valid, err %s", err)
+		return nil, fmt.Errorf("Failed to deserialize creator identity, err %s", err)
+	}
+
+	// ensure that the creator deserialized to a concrete identity of some
+	// MSP, whatever the deserializer of the chain, before relying on it
+	if creator == nil || creator.GetMSPIdentifier() == "" {
+		putilsLogger.Errorf("checkSignatureFromCreator error: creator of MSP %s deserialized to an identity without MSP", sId.Mspid)
+		return nil, ErrCreatorWithoutMSP
+	}
+
+	// ensure that creator is a valid certificate, or a valid credential
+	// for anonymous identities which have neither a certificate nor a
+	// public identifier
+	idType := getIdentityType(creator)
+	switch idType {
+	case X509Identity:
+		putilsLogger.Infof("checkSignatureFromCreator info: creator is %s", creator.GetIdentifier())
+
+		err = creator.Validate()
+		if err != nil {
+			return nil, fmt.Errorf("The creator certificate is not valid, err %s", err)
+		}
+	default:
+		putilsLogger.Infof("checkSignatureFromCreator info: creator is a %s identity of MSP %s", idType, creator.GetMSPIdentifier())
+
+		err = creator.Validate()
+		if err != nil {
+			return nil, fmt.Errorf("The creator %s credential is not valid, err %s", idType, err)
+		}
 	}
 
 	putilsLogger.Infof("checkSignatureFromCreator info: creator is valid")
 
-	// validate the signature
-	err = creator.Verify(msg, sig)
+	// ensure that the creator does not claim an MSP other than the
+	// one that has validated its certificate
+	if sId.Mspid != creator.GetMSPIdentifier() {
+		putilsLogger.Errorf("checkSignatureFromCreator error: creator claims MSP %s but was validated by MSP %s", sId.Mspid, creator.GetMSPIdentifier())
+		return nil, ErrMSPMismatch
+	}
+
+	// if required, ensure that the MSP of the creator is a member of
+	// the channel
+	err = v.checkChannelMember(ChainID, creator.GetMSPIdentifier())
+	if err != nil {
+		return nil, err
+	}
+
+	// ensure that the MSP of the creator is not suspended
+	err = v.checkSuspendedMSP(creator.GetMSPIdentifier())
+	if err != nil {
+		return nil, err
+	}
+
+	// if required, ensure that the creator certificate is pinned
+	err = v.checkPinnedCreator(sId, idType)
+	if err != nil {
+		return nil, err
+	}
+
+	// if required, ensure that the creator certificate chains to one of
+	// the required roots
+	err = v.checkCreatorRoot(sId, idType)
 	if err != nil {
-		return fmt.Errorf("The creator's signature over the proposal is not valid, err %s", err)
+		return nil, err
+	}
+
+	// if required, ensure that the creator key is strong enough
+	err = v.checkCreatorKeySize(sId, idType)
+	if err != nil {
+		return nil, err
+	}
+
+	// if required, ensure that the creator certificate is already valid
+	err = v.checkCertificateNotBefore(ctx, sId, idType)
+	if err != nil {
+		return nil, err
+	}
+
+	// if required, warn about borderline creator certificates
+	err = v.checkCertificateWarnings(ctx, sId, idType)
+	if err != nil {
+		return nil, err
+	}
+
+	// validate the signature, over the message or its digest depending
+	// on the signature mode of the chain
+	signed, err := v.getSignedMessage(ctx, ChainID, msg)
+	if err != nil {
+		return nil, err
+	}
+
+	err = creator.Verify(signed, sig)
+	if err != nil {
+		return nil, fmt.Errorf("The creator's signature over the proposal is not valid, err %s", err)
 	}
 
 	putilsLogger.Infof("checkSignatureFromCreator exists successfully")
 
-	return nil
+	return creator, nil
 }
 
-// checks for a valid SignatureHeader
+// checks for a valid SignatureHeader. Like every check of the validation,
+// it treats the empty byte fields as absent, whether they are nil or not:
+// protobuf decodes an omitted field to nil but a field explicitly encoded
+// with no bytes to an empty slice, and both must be handled alike
 func validateSignatureHeader(sHdr *common.SignatureHeader) error {
 	// check for nil argument
 	if sHdr == nil {
@@ -176,12 +375,12 @@ func validateSignatureHeader(sHdr *common.SignatureHeader) error {
 	}
 
 	// ensure that there is a nonce
-	if sHdr.Nonce == nil || len(sHdr.Nonce) == 0 {
+	if len(sHdr.Nonce) == 0 {
 		return fmt.Errorf("Invalid nonce specified in the header")
 	}
 
 	// ensure that there is a creator
-	if sHdr.Creator == nil || len(sHdr.Creator) == 0 {
+	if len(sHdr.Creator) == 0 {
 		return fmt.Errorf("Invalid creator specified in the header")
 	}
 
@@ -220,7 +419,7 @@ func validateChannelHeader(cHdr *common.ChannelHeader) error {
 }
 
 // checks for a valid Header
-func validateCommonHeader(hdr *common.Header) error {
+func (v *Validator) validateCommonHeader(ctx context.Context, hdr *common.Header) error {
 	if hdr == nil {
 		return fmt.Errorf("Nil header")
 	}
@@ -230,43 +429,130 @@ func validateCommonHeader(hdr *common.Header) error {
 		return err
 	}
 
+	err = v.getValidationPolicy().checkHeaderType(hdr.ChannelHeader)
+	if err != nil {
+		return err
+	}
+
+	err = v.checkDeniedTxID(hdr.ChannelHeader)
+	if err != nil {
+		return err
+	}
+
 	err = validateSignatureHeader(hdr.SignatureHeader)
 	if err != nil {
 		return err
 	}
 
+	err = v.validateNonce(ctx, hdr.ChannelHeader.ChannelId, hdr.SignatureHeader.Nonce)
+	if err != nil {
+		return err
+	}
+
+	if v.SubmissionWindow != nil {
+		err = v.SubmissionWindow.validate(hdr.ChannelHeader.Timestamp)
+		if err != nil {
+			return err
+		}
+	}
+
 	return nil
 }
 
 // validateConfigTransaction validates the payload of a
 // transaction assuming its type is CONFIG
-func validateConfigTransaction(data []byte, hdr *common.Header) error {
+func (v *Validator) validateConfigTransaction(data []byte, hdr *common.Header) error {
 	putilsLogger.Infof("validateConfigTransaction starts for data %p, header %s", data, hdr)
 
-	// check for nil argument
-	if data == nil || hdr == nil {
+	// check for nil or empty argument
+	if len(data) == 0 || hdr == nil {
 		return fmt.Errorf("Nil arguments")
 	}
 
 	// There is no need to do this validation here, the configtx.Manager handles this
 
+	// reject updates whose signatures could never satisfy any policy
+	err := validateLastUpdate(data)
+	if err != nil {
+		return err
+	}
+
+	// if required, ensure that the config is meant for this type of channel
+	if v.ChannelTypeProvider != nil {
+		err = v.validateConfigChannelType(data, hdr.ChannelHeader.ChannelId)
+		if err != nil {
+			return err
+		}
+	}
+
+	// if required, ensure that the config bumps the config sequence
+	if v.ConfigSequenceProvider != nil {
+		err = v.checkConfigSequence(data, hdr.ChannelHeader.ChannelId)
+		if err != nil {
+			return err
+		}
+	}
+
 	return nil
 }
 
+// validateActionSignatureHeader decodes and validates the signature header
+// of an action of the transaction with the given header and number of
+// actions; creators checks the creator of the action, if required
+func (v *Validator) validateActionSignatureHeader(ctx context.Context, hdr *common.Header, sHdrBytes []byte, actions int, creators *actionCreatorChecker) (*common.SignatureHeader, error) {
+	// if the type is ENDORSER_TRANSACTION we unmarshal a SignatureHeader
+	sHdr, err := utils.GetSignatureHeader(sHdrBytes)
+	if err != nil {
+		return nil, permanentDecodeError(err)
+	}
+
+	// validate the SignatureHeader - here we actually only
+	// care about the nonce since the creator is in the outer header
+	err = validateSignatureHeader(sHdr)
+	if err != nil {
+		return nil, err
+	}
+
+	err = v.validateNonce(ctx, hdr.ChannelHeader.ChannelId, sHdr.Nonce)
+	if err != nil {
+		return nil, err
+	}
+
+	// if required, ensure that the action comes from the same proposal
+	// as the transaction
+	if v.CheckActionNonce {
+		err = checkActionNonce(hdr, sHdr, actions)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	// if required, ensure that the action was created by the creator
+	// of the transaction
+	if v.CheckActionCreators {
+		err = creators.check(ctx, sHdr.Creator)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return sHdr, nil
+}
+
 // validateEndorserTransaction validates the payload of a
 // transaction assuming its type is ENDORSER_TRANSACTION
-func validateEndorserTransaction(data []byte, hdr *common.Header) error {
+func (v *Validator) validateEndorserTransaction(ctx context.Context, data []byte, hdr *common.Header, creator msp.Identity) error {
 	putilsLogger.Infof("validateEndorserTransaction starts for data %p, header %s", data, hdr)
 
-	// check for nil argument
-	if data == nil || hdr == nil {
+	// check for nil or empty argument
+	if len(data) == 0 || hdr == nil {
 		return fmt.Errorf("Nil arguments")
 	}
 
 	// if the type is ENDORSER_TRANSACTION we unmarshal a Transaction message
 	tx, err := utils.GetTransaction(data)
 	if err != nil {
-		return err
+		return permanentDecodeError(err)
 	}
 
 	// check for nil argument
@@ -274,6 +560,12 @@ func validateEndorserTransaction(data []byte, hdr *common.Header) error {
 		return fmt.Errorf("Nil transaction")
 	}
 
+	// detect the fields this peer does not know of, that it would ignore
+	err = v.checkUnknownFields(ctx, "Transaction", data, tx)
+	if err != nil {
+		return err
+	}
+
 	// TODO: validate tx.Version
 
 	// TODO: validate ChaincodeHeaderExtension
@@ -282,66 +574,338 @@ func validateEndorserTransaction(data []byte, hdr *common.Header) error {
 		return fmt.Errorf("At least one TransactionAction is required")
 	}
 
+	err = v.getValidationPolicy().checkActions(len(tx.Actions))
+	if err != nil {
+		return err
+	}
+
 	putilsLogger.Infof("validateEndorserTransaction info: there are %d actions", len(tx.Actions))
+	setSpanAttribute(spanFromContext(ctx), ActionCountAttribute, len(tx.Actions))
 
-	for _, act := range tx.Actions {
-		// check for nil argument
-		if act == nil {
-			return fmt.Errorf("Nil action")
-		}
+	ccID, err := getInvokedChaincode(hdr)
+	if err != nil {
+		return err
+	}
 
-		// if the type is ENDORSER_TRANSACTION we unmarshal a SignatureHeader
-		sHdr, err := utils.GetSignatureHeader(act.Header)
+	// if restricted, ensure that the creator may invoke the chaincode
+	if len(v.CreatorChaincodeACL) != 0 {
+		err = recordStep(ctx, "creatoracl", v.checkCreatorChaincode(creator, ccID.Name))
 		if err != nil {
 			return err
 		}
+	}
 
-		// validate the SignatureHeader - here we actually only
-		// care about the nonce since the creator is in the outer header
-		err = validateSignatureHeader(sHdr)
+	// the identity of the creator of the transaction, already verified,
+	// is reused to check the creators of all the actions
+	creators := v.newActionCreatorChecker(hdr.ChannelHeader.ChannelId, hdr.SignatureHeader.Creator, creator)
+
+	// the channels read from by all the actions, if checked
+	var referencedChannels map[string]struct{}
+	if v.ChannelMembership != nil {
+		referencedChannels = make(map[string]struct{})
+	}
+
+	var endorsedActions []*pb.ChaincodeEndorsedAction
+	var actionNonces [][]byte
+	for i, act := range tx.Actions {
+		// check for nil argument
+		if act == nil {
+			return fmt.Errorf("Nil action")
+		}
+		countStats(ctx, func(stats *ValidationStats) { stats.ActionsProcessed++ })
+
+		sHdr, err := v.validateActionSignatureHeader(ctx, hdr, act.Header, len(tx.Actions), creators)
+		err = recordStep(ctx, actionStep(i, "sighdr"), err)
 		if err != nil {
 			return err
 		}
 
 		putilsLogger.Infof("validateEndorserTransaction info: signature header is valid")
+		actionNonces = append(actionNonces, sHdr.Nonce)
 
 		// if the type is ENDORSER_TRANSACTION we unmarshal a ChaincodeActionPayload
 		cap, err := utils.GetChaincodeActionPayload(act.Payload)
+		if err != nil {
+			return permanentDecodeError(err)
+		}
+
+		err = v.checkUnknownFields(ctx, fmt.Sprintf("actions[%d].ChaincodeActionPayload", i), act.Payload, cap)
 		if err != nil {
 			return err
 		}
 
+		if cap.Action == nil {
+			return fmt.Errorf("Nil endorsed action")
+		}
+
+		endorsedActions = append(endorsedActions, cap.Action)
+
+		// ensure that the endorsers of the action are distinct and, if
+		// required, only members of the allowed MSPs, in canonical order,
+		// whose signatures verify
+		if !v.AllowDuplicateEndorsers || len(v.AllowedEndorserMSPs) != 0 || v.EndorsementOrdering != HeuristicsOff || v.VerifyEndorsementSignatures {
+			err = recordStep(ctx, actionStep(i, "endorsers"), v.checkEndorsers(ctx, hdr.ChannelHeader.ChannelId, cap.Action))
+			if err != nil {
+				return err
+			}
+		}
+
 		// extract the proposal response payload
 		prp, err := utils.GetProposalResponsePayload(cap.Action.ProposalResponsePayload)
+		if err != nil {
+			return permanentDecodeError(err)
+		}
+
+		err = v.checkUnknownFields(ctx, fmt.Sprintf("actions[%d].ProposalResponsePayload", i), cap.Action.ProposalResponsePayload, prp)
 		if err != nil {
 			return err
 		}
 
 		// build the original header by stitching together
-		// the common ChannelHeader and the per-action SignatureHeader
+		// the common ChannelHeader and the per-action SignatureHeader;
+		// as the actions carry no ChannelHeader of their own, an action
+		// taken from a proposal with another timestamp (or any other
+		// channel header field) fails the proposal hash check below
 		hdrOrig := &common.Header{ChannelHeader: hdr.ChannelHeader, SignatureHeader: sHdr}
 		hdrBytes, err := utils.GetBytesHeader(hdrOrig) // FIXME: here we hope that hdrBytes will be the same one that the endorser had
 		if err != nil {
 			return err
 		}
 
-		// compute proposalHash
-		pHash, err := utils.GetProposalHash2(hdrBytes, cap.ChaincodeProposalPayload)
+		// ensure that the proposal hash matches
+		_, hashSpan := v.startSpan(ctx, VerifyProposalHashSpan)
+		err = verifyProposalHash(hdrBytes, cap.ChaincodeProposalPayload, prp.ProposalHash)
+		if err == ErrProposalHashMismatch {
+			logProposalHashMismatch(i, hdr.ChannelHeader, prp.ProposalHash)
+		}
+		endSpan(hashSpan, err)
+		err = recordStep(ctx, actionStep(i, "prophash"), err)
 		if err != nil {
 			return err
 		}
+		countStats(ctx, func(stats *ValidationStats) {
+			stats.BytesHashed += len(hdrBytes) + len(cap.ChaincodeProposalPayload)
+		})
+
+		// if the action carries an aggregated endorsement, verify it; the
+		// individual endorsements are left to VSCC
+		if hasAggregateEndorsement(cap.Action) {
+			err = v.verifyAggregateEndorsement(ctx, hdr.ChannelHeader.ChannelId, cap.Action)
+			if err != nil {
+				return err
+			}
+		}
 
-		// ensure that the proposal hash matches
-		if bytes.Compare(pHash, prp.ProposalHash) != 0 {
-			return fmt.Errorf("proposal hash does not match")
+		ca, err := utils.GetChaincodeAction(prp.Extension)
+		if err != nil {
+			return permanentDecodeError(err)
+		}
+
+		// if required, ensure that the arguments of the invocation are
+		// canonically encoded
+		if v.StrictArgsEncoding {
+			err = checkCanonicalArgs(cap.ChaincodeProposalPayload)
+			if err != nil {
+				return err
+			}
+		}
+
+		cpp, err := utils.GetChaincodeProposalPayload(cap.ChaincodeProposalPayload)
+		if err != nil {
+			return permanentDecodeError(err)
+		}
+
+		// ensure that no transient data leaked into the transaction
+		err = checkNoTransientData(cpp)
+		if err != nil {
+			return err
+		}
+
+		cis, err := getPayloadInvocationSpec(cpp)
+		if err != nil {
+			return err
+		}
+
+		// ensure that the action was endorsed for the proposed chaincode
+		err = checkActionChaincode(ccID.Name, cis, ca)
+		if err != nil {
+			return err
+		}
+
+		// if required, ensure that the action invokes a known version of
+		// the chaincode, the one the transaction was endorsed against
+		if v.ChaincodeDefinitionProvider != nil {
+			err = recordStep(ctx, actionStep(i, "chaincode"), v.checkActionChaincodeVersion(hdr.ChannelHeader.ChannelId, ccID, cis))
+			if err != nil {
+				return err
+			}
+		}
+
+		// ensure that the arguments of the invocation are not too large
+		err = v.checkArgsSize(cis)
+		if err != nil {
+			return err
+		}
+
+		// extract the read-write set of the action, to tell read-only
+		// transactions apart; it must be well formed if it is checked
+		txRWSet, err := getRWSet(ca.Results)
+		if err != nil && (v.RWSetLimits != nil || v.ChannelMembership != nil || v.LedgerHeightProvider != nil) {
+			return err
+		}
+		countWrites(ctx, txRWSet)
+
+		// if required, check the read-write set of the action
+		if txRWSet != nil {
+			// ensure that the read-write set is well formed and not
+			// too large, before any further work is done on it
+			if v.RWSetLimits != nil {
+				err = v.validateRWSetStructure(txRWSet)
+				if err != nil {
+					return err
+				}
+			}
+
+			// ensure that the channels read from are known
+			if v.ChannelMembership != nil {
+				err = v.validateCrossChannelReads(hdr.ChannelHeader.ChannelId, txRWSet, referencedChannels)
+				if err != nil {
+					return err
+				}
+			}
+
+			// ensure that the reads are not too stale
+			if v.LedgerHeightProvider != nil {
+				err = v.validateReadStaleness(hdr.ChannelHeader.ChannelId, txRWSet)
+				if err != nil {
+					return err
+				}
+			}
 		}
 	}
 
-	return nil
+	// if required, ensure that ordered actions are in order
+	if v.EnforceActionOrdering {
+		err = recordStep(ctx, "actionorder", checkActionOrdering(ctx, hdr.ChannelHeader, actionNonces))
+		if err != nil {
+			return err
+		}
+	}
+
+	return v.checkEndorsementPatterns(ctx, hdr.ChannelHeader.TxId, endorsedActions)
 }
 
 // ValidateTransaction checks that the transaction envelope is properly formed
 func ValidateTransaction(e *common.Envelope) (*common.Payload, error) {
+	return defaultValidator.ValidateTransaction(e)
+}
+
+// ValidateTransaction checks that the transaction envelope is properly formed
+func (v *Validator) ValidateTransaction(e *common.Envelope) (*common.Payload, error) {
+	result, err := v.Validate(e)
+	return result.Payload, err
+}
+
+// Validate checks that the transaction envelope is properly formed and, if
+// so, runs the plugins registered for its channel; the result holds the
+// messages decoded during the validation, even if the validation failed
+func (v *Validator) Validate(e *common.Envelope) (*ValidationResult, error) {
+	return v.validateWith(context.Background(), e, nil)
+}
+
+// ValidateTransactionWithContext checks that the transaction envelope is
+// properly formed; the context bounds the time spent waiting for the MSP
+// config of the channel to be ready
+func (v *Validator) ValidateTransactionWithContext(ctx context.Context, e *common.Envelope) (*common.Payload, error) {
+	result, err := v.validateWith(ctx, e, nil)
+	return result.Payload, err
+}
+
+// validateBuiltin validates the transaction envelope and runs the plugins;
+// it is the innermost handler of the middleware chain
+func (v *Validator) validateBuiltin(ctx context.Context, e *common.Envelope) (*ValidationResult, error) {
+	ctx, span := v.startSpan(ctx, ValidateTransactionSpan)
+	ctx, recorder := v.withStepRecorder(ctx)
+	ctx, stats := v.withStats(ctx)
+	ctx = v.withShadowChecks(ctx)
+	ctx, writes := withWriteCounter(ctx)
+	ctx = withHeaderExtension(ctx)
+	ctx, warnings := withWarningRecorder(ctx)
+	ctx, report := v.withCacheReport(ctx)
+
+	payload, err := v.validateTransaction(ctx, e)
+	result := &ValidationResult{Envelope: e, Payload: payload, Stats: stats, Warnings: warnings.warnings, CacheOperations: report.getOperations()}
+	if err != nil {
+		return v.completeValidation(span, recorder, result, err)
+	}
+	result.IsReadOnly = writes.readOnly()
+
+	// if required, pin the definition of the invoked chaincode to the
+	// version the transaction was endorsed against
+	if v.ChaincodeDefinitionProvider != nil && common.HeaderType(payload.Header.ChannelHeader.Type) == common.HeaderType_ENDORSER_TRANSACTION {
+		result.ChaincodeDefinition, err = v.getPinnedChaincodeDefinition(payload.Header)
+		err = recordStep(ctx, "chaincode", err)
+		if err != nil {
+			return v.completeValidation(span, recorder, result, err)
+		}
+	}
+
+	err = recordStep(ctx, "plugins", v.runPlugins(result))
+	if err != nil {
+		return v.completeValidation(span, recorder, result, err)
+	}
+
+	// if required, enforce the ordering of the transactions of the creator;
+	// the sequence number is only checked here, and recorded by
+	// finishValidation once the transaction is finally accepted
+	if isSequenced(v.SequenceTracker, payload) {
+		err = recordStep(ctx, "sequence", v.SequenceTracker.advance(ctx, payload.Header, true))
+		result.CacheOperations = report.getOperations()
+	}
+
+	return v.completeValidation(span, recorder, result, err)
+}
+
+// completeValidation completes the result of a validation with the steps
+// recorded and ends its span
+func (v *Validator) completeValidation(span Span, recorder *stepRecorder, result *ValidationResult, err error) (*ValidationResult, error) {
+	result.Steps = recorder.getSteps()
+	endValidationSpan(span, result.Payload, err)
+
+	return result, err
+}
+
+// getPayload decodes the payload of the envelope, decompressing it if needed
+// and, if required, ensuring that it is canonically encoded
+func (v *Validator) getPayload(e *common.Envelope) (*common.Payload, error) {
+	err := v.getValidationPolicy().checkPayloadSize(e)
+	if err != nil {
+		return nil, err
+	}
+
+	payloadBytes, err := v.getPayloadBytes(e)
+	if err != nil {
+		return nil, err
+	}
+
+	payload := &common.Payload{}
+	err = proto.Unmarshal(payloadBytes, payload)
+	if err != nil {
+		return nil, transientDecodeError(fmt.Errorf("Could not extract payload from envelope, err %s", err))
+	}
+
+	if v.StrictPayloadEncoding {
+		err = checkCanonicalPayload(payloadBytes, payload)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return payload, nil
+}
+
+// validateTransaction performs the built-in checks on the transaction envelope
+func (v *Validator) validateTransaction(ctx context.Context, e *common.Envelope) (*common.Payload, error) {
 	putilsLogger.Infof("ValidateTransactionEnvelope starts for envelope %p", e)
 
 	// check for nil argument
@@ -349,26 +913,47 @@ func ValidateTransaction(e *common.Envelope) (*common.Payload, error) {
 		return nil, fmt.Errorf("Nil Envelope")
 	}
 
-	// get the payload from the envelope
-	payload, err := utils.GetPayload(e)
+	// get the payload from the envelope, decompressing it if needed, unless
+	// the caller decoded it already
+	payload, err := v.getContextPayload(ctx, e)
+	err = recordStep(ctx, "payload", err)
 	if err != nil {
-		return nil, fmt.Errorf("Could not extract payload from envelope, err %s", err)
+		return nil, err
 	}
 
 	putilsLogger.Infof("Header is %s", payload.Header)
 
-	// validate the header
-	err = validateCommonHeader(payload.Header)
+	// validate the header, ensuring that the envelope is signed unless
+	// it is the genesis config
+	unsigned, err := v.validateEnvelopeHeader(ctx, e, payload)
+	err = recordStep(ctx, "header", err)
 	if err != nil {
 		return nil, err
 	}
 
-	// validate the signature in the envelope
-	err = checkSignatureFromCreator(payload.Header.SignatureHeader.Creator, e.Signature, e.Payload, payload.Header.ChannelHeader.ChannelId)
+	// wait for the MSP config of the channel to be ready
+	err = v.waitForMSP(ctx, payload.Header.ChannelHeader.ChannelId)
 	if err != nil {
 		return nil, err
 	}
 
+	// validate the signature in the envelope
+	var creator msp.Identity
+	if !unsigned {
+		_, sigSpan := v.startSpan(ctx, VerifySignatureSpan)
+		creator, err = v.verifyEnvelopeSignature(ctx, e, payload.Header)
+		endSpan(sigSpan, err)
+		err = recordStep(ctx, "signature", err)
+		if err != nil {
+			return nil, err
+		}
+		countStats(ctx, func(stats *ValidationStats) {
+			stats.IdentitiesDeserialized++
+			stats.SignaturesVerified++
+			stats.BytesHashed += len(getSignedPayload(ctx, e.Payload))
+		})
+	}
+
 	// TODO: ensure that creator can transact with us (some ACLs?) which set of APIs is supposed to give us this info?
 
 	// continue the validation in a way that depends on the type specified in the header
@@ -377,22 +962,78 @@ func ValidateTransaction(e *common.Envelope) (*common.Payload, error) {
 		// Verify that the transaction ID has been computed properly.
 		// This check is needed to ensure that the lookup into the ledger
 		// for the same TxID catches duplicates.
-		err = utils.CheckProposalTxID(
+		err = v.checkTxID(ctx,
+			payload.Header.ChannelHeader.ChannelId,
 			payload.Header.ChannelHeader.TxId,
 			payload.Header.SignatureHeader.Nonce,
 			payload.Header.SignatureHeader.Creator)
+		err = recordStep(ctx, "txid", err)
 		if err != nil {
 			return nil, err
 		}
+		countStats(ctx, func(stats *ValidationStats) {
+			stats.BytesHashed += len(payload.Header.SignatureHeader.Nonce) + len(payload.Header.SignatureHeader.Creator)
+		})
+
+		// if required, ensure that the config of the channel is verified
+		if v.ChannelConfigVerifier != nil {
+			err = v.checkChannelConfigVerified(payload.Header.ChannelHeader.ChannelId)
+			if err != nil {
+				return nil, err
+			}
+		}
+
+		// if required, flag the transactions reading from a stale ledger
+		if v.ReadCommitmentMode != HeuristicsOff && v.LedgerHeightProvider != nil {
+			err = recordStep(ctx, "readcommitment", v.checkReadCommitment(ctx, payload.Header.ChannelHeader))
+			if err != nil {
+				return nil, err
+			}
+		}
+
+		// if required, ensure that the creator is authorized by its
+		// delegation token, if any
+		if v.TokenVerifier != nil {
+			err = recordStep(ctx, "delegation", v.checkDelegationToken(ctx, payload.Header.ChannelHeader, payload.Header.SignatureHeader))
+			if err != nil {
+				return nil, err
+			}
+		}
+
+		// if required, ensure that the transaction has not expired
+		if v.EnforceTTL {
+			err = recordStep(ctx, "ttl", v.validateTTL(ctx, payload.Header.ChannelHeader))
+			if err != nil {
+				return nil, err
+			}
+		}
+
+		// if required, ensure that the transaction carries sufficient
+		// proof of work
+		if v.SpamGuard != nil {
+			err = recordStep(ctx, "spamguard", v.SpamGuard.validate(ctx, payload.Header.ChannelHeader))
+			if err != nil {
+				return nil, err
+			}
+		}
+
+		// if required, ensure that the endorsement policy referenced by
+		// the transaction is defined
+		if v.PolicyResolver != nil {
+			err = recordStep(ctx, "policyname", v.validatePolicyName(ctx, payload.Header.ChannelHeader))
+			if err != nil {
+				return nil, err
+			}
+		}
 
-		err = validateEndorserTransaction(payload.Data, payload.Header)
+		err = v.validateEndorserTransaction(ctx, payload.Data, payload.Header, creator)
 		putilsLogger.Infof("ValidateTransactionEnvelope returns err %s", err)
 		return payload, err
 	case common.HeaderType_CONFIG:
 		// Config transactions have signatures inside which will be validated, especially at genesis there may be no creator or
 		// signature on the outermost envelope
 
-		err = validateConfigTransaction(payload.Data, payload.Header)
+		err = recordStep(ctx, "config", v.validateConfigTransaction(payload.Data, payload.Header))
 		putilsLogger.Infof("ValidateTransactionEnvelope returns err %s", err)
 		return payload, err
 	default:
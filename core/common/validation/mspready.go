@@ -0,0 +1,77 @@
+/*
+Copyright IBM Corp. 2017 All Rights Reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+		 http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package validation
+
+import (
+	"errors"
+	"time"
+
+	"golang.org/x/net/context"
+)
+
+// ErrMSPUnavailable is returned when the MSP config of a channel does not
+// become ready in time to validate a message
+var ErrMSPUnavailable = errors.New("The MSP config of the channel is not available")
+
+// DefaultMSPReadyTimeout is the time validation waits for the MSP config
+// of a channel to be ready when the Validator sets no MSPReadyTimeout
+const DefaultMSPReadyTimeout = 5 * time.Second
+
+// MSPReadinessNotifier is implemented by the IdentityDeserializerProviders
+// whose MSP config may be refreshed in the background (e.g. on CRL updates)
+type MSPReadinessNotifier interface {
+	// ConfigReady returns a channel that is closed once the MSP config of
+	// the given chain is ready to be used; it is already closed unless a
+	// refresh is in progress
+	ConfigReady(chainID string) <-chan struct{}
+}
+
+// waitForMSP waits for the MSP config of the chain to be ready, so that
+// messages are neither validated against a stale config nor blocked
+// indefinitely: the wait is bounded by the deadline of the context and by
+// the MSPReadyTimeout of the validator
+func (v *Validator) waitForMSP(ctx context.Context, chainID string) error {
+	notifier, ok := v.DeserializerProvider.(MSPReadinessNotifier)
+	if !ok {
+		return nil
+	}
+
+	ready := notifier.ConfigReady(chainID)
+	select {
+	case <-ready:
+		return nil
+	default:
+	}
+
+	timeout := v.MSPReadyTimeout
+	if timeout == 0 {
+		timeout = DefaultMSPReadyTimeout
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	putilsLogger.Infof("Waiting for the MSP config of chain [%s] to be ready", chainID)
+
+	select {
+	case <-ready:
+		return nil
+	case <-ctx.Done():
+		putilsLogger.Errorf("The MSP config of chain [%s] is not ready, err %s", chainID, ctx.Err())
+		return ErrMSPUnavailable
+	}
+}
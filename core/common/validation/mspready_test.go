@@ -0,0 +1,119 @@
+/*
+Copyright IBM Corp. 2017 All Rights Reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+		 http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package validation
+
+import (
+	"testing"
+	"time"
+
+	"github.com/hyperledger/fabric/msp"
+	mspmgmt "github.com/hyperledger/fabric/msp/mgmt"
+	"github.com/hyperledger/fabric/protos/utils"
+	"golang.org/x/net/context"
+)
+
+// mockRefreshingProvider supplies the MSP manager's deserializers once
+// the ready channel is closed
+type mockRefreshingProvider struct {
+	ready chan struct{}
+}
+
+func (p *mockRefreshingProvider) GetIdentityDeserializer(chainID string) msp.IdentityDeserializer {
+	return mspmgmt.GetIdentityDeserializer(chainID)
+}
+
+func (p *mockRefreshingProvider) ConfigReady(chainID string) <-chan struct{} {
+	return p.ready
+}
+
+func TestMSPReady(t *testing.T) {
+	tx, err := getTransaction([]byte("simulation_result"))
+	if err != nil {
+		t.Fatalf("getTransaction failed, err %s", err)
+	}
+
+	// the config is ready
+	provider := &mockRefreshingProvider{ready: make(chan struct{})}
+	close(provider.ready)
+	_, err = (&Validator{DeserializerProvider: provider}).ValidateTransaction(tx)
+	if err != nil {
+		t.Fatalf("ValidateTransaction failed, err %s", err)
+	}
+
+	// a refresh is in progress and does not complete in time
+	provider = &mockRefreshingProvider{ready: make(chan struct{})}
+	v := &Validator{DeserializerProvider: provider, MSPReadyTimeout: 10 * time.Millisecond}
+	_, err = v.ValidateTransaction(tx)
+	if err != ErrMSPUnavailable {
+		t.Fatalf("ValidateTransaction should have failed with ErrMSPUnavailable, got %v", err)
+	}
+
+	// the refresh completes while waiting
+	v.MSPReadyTimeout = time.Minute
+	go func() {
+		time.Sleep(10 * time.Millisecond)
+		close(provider.ready)
+	}()
+	_, err = v.ValidateTransaction(tx)
+	if err != nil {
+		t.Fatalf("ValidateTransaction failed, err %s", err)
+	}
+}
+
+func TestMSPReadyContextDeadline(t *testing.T) {
+	tx, err := getTransaction([]byte("simulation_result"))
+	if err != nil {
+		t.Fatalf("getTransaction failed, err %s", err)
+	}
+
+	provider := &mockRefreshingProvider{ready: make(chan struct{})}
+	v := &Validator{DeserializerProvider: provider, MSPReadyTimeout: time.Minute}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+
+	_, err = v.ValidateTransactionWithContext(ctx, tx)
+	if err != ErrMSPUnavailable {
+		t.Fatalf("ValidateTransactionWithContext should have failed with ErrMSPUnavailable, got %v", err)
+	}
+}
+
+func TestMSPReadyProposal(t *testing.T) {
+	prop, err := getProposal()
+	if err != nil {
+		t.Fatalf("getProposal failed, err %s", err)
+	}
+
+	sProp, err := utils.GetSignedProposal(prop, signer)
+	if err != nil {
+		t.Fatalf("GetSignedProposal failed, err %s", err)
+	}
+
+	provider := &mockRefreshingProvider{ready: make(chan struct{})}
+	v := &Validator{DeserializerProvider: provider, MSPReadyTimeout: 10 * time.Millisecond}
+	_, _, _, err = v.ValidateProposalMessage(sProp)
+	if err != ErrMSPUnavailable {
+		t.Fatalf("ValidateProposalMessage should have failed with ErrMSPUnavailable, got %v", err)
+	}
+
+	close(provider.ready)
+	_, _, _, err = v.ValidateProposalMessage(sProp)
+	if err != nil {
+		t.Fatalf("ValidateProposalMessage failed, err %s", err)
+	}
+
+}
@@ -0,0 +1,96 @@
+/*
+Copyright IBM Corp. 2017 All Rights Reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+		 http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package validation
+
+import (
+	"fmt"
+
+	"github.com/hyperledger/fabric/msp"
+	mspmgmt "github.com/hyperledger/fabric/msp/mgmt"
+)
+
+// MSPSnapshot is an immutable copy of the MSP managers of a set of channels,
+// as they were when it was taken. Unlike the managers of the MSP manager
+// registry, it is not affected by later config updates, so that old blocks
+// re-validated against the snapshot taken when they were committed, e.g.
+// during recovery, yield the same results as originally
+type MSPSnapshot struct {
+	managers map[string]msp.MSPManager
+}
+
+// NewMSPSnapshot returns a snapshot of the given MSP managers, by channel;
+// every manager must have been set up
+func NewMSPSnapshot(managers map[string]msp.MSPManager) (*MSPSnapshot, error) {
+	s := &MSPSnapshot{managers: make(map[string]msp.MSPManager, len(managers))}
+
+	for chainID, manager := range managers {
+		msps, err := manager.GetMSPs()
+		if err != nil {
+			return nil, fmt.Errorf("Could not get the MSPs of chain %s, err %s", chainID, err)
+		}
+
+		// the MSPs are copied to a new manager, so that the snapshot does
+		// not depend on the map held by the original one
+		list := make([]msp.MSP, 0, len(msps))
+		for _, mspInst := range msps {
+			list = append(list, mspInst)
+		}
+
+		copied := msp.NewMSPManager()
+		err = copied.Setup(list)
+		if err != nil {
+			return nil, fmt.Errorf("Could not snapshot the MSP manager of chain %s, err %s", chainID, err)
+		}
+		s.managers[chainID] = copied
+	}
+
+	return s, nil
+}
+
+// CaptureMSPSnapshot returns a snapshot of the current MSP managers of the
+// given channels, which must all have been configured
+func CaptureMSPSnapshot(chainIDs ...string) (*MSPSnapshot, error) {
+	managers := make(map[string]msp.MSPManager, len(chainIDs))
+	for _, chainID := range chainIDs {
+		manager := mspmgmt.GetManagerForChainIfExists(chainID)
+		if manager == nil {
+			return nil, fmt.Errorf("No MSP manager for chain %s", chainID)
+		}
+		managers[chainID] = manager
+	}
+
+	return NewMSPSnapshot(managers)
+}
+
+// GetIdentityDeserializer returns the IdentityDeserializer of the given
+// chain in the snapshot, or nil if the snapshot does not cover the chain
+func (s *MSPSnapshot) GetIdentityDeserializer(chainID string) msp.IdentityDeserializer {
+	manager, ok := s.managers[chainID]
+	if !ok {
+		return nil
+	}
+
+	return manager
+}
+
+// NewValidatorWithMSPSnapshot returns a Validator deserializing identities
+// with the MSP managers of the snapshot instead of the current ones; the
+// messages of the channels the snapshot does not cover fail validation with
+// ErrChannelUnavailable
+func NewValidatorWithMSPSnapshot(snapshot *MSPSnapshot) *Validator {
+	return &Validator{DeserializerProvider: snapshot}
+}
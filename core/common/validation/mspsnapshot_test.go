@@ -0,0 +1,79 @@
+/*
+Copyright IBM Corp. 2017 All Rights Reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+		 http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package validation
+
+import (
+	"testing"
+
+	"github.com/hyperledger/fabric/common/util"
+	"github.com/hyperledger/fabric/msp"
+	mspmgmt "github.com/hyperledger/fabric/msp/mgmt"
+)
+
+func TestMSPSnapshot(t *testing.T) {
+	tx, err := getTransaction([]byte("simulation_result"))
+	if err != nil {
+		t.Fatalf("getTransaction failed, err %s", err)
+	}
+
+	chainID := util.GetTestChainID()
+	snapshot, err := CaptureMSPSnapshot(chainID)
+	if err != nil {
+		t.Fatalf("CaptureMSPSnapshot failed, err %s", err)
+	}
+	v := NewValidatorWithMSPSnapshot(snapshot)
+
+	_, err = v.ValidateTransaction(tx)
+	if err != nil {
+		t.Fatalf("ValidateTransaction against the snapshot failed, err %s", err)
+	}
+
+	// a config update removes the MSP of the creator
+	current := mspmgmt.GetManagerForChainIfExists(chainID)
+	mspmgmt.XXXSetMSPManager(chainID, msp.NewMSPManager())
+	defer mspmgmt.XXXSetMSPManager(chainID, current)
+
+	_, err = ValidateTransaction(tx)
+	if err == nil {
+		t.Fatalf("ValidateTransaction against the current config should have failed")
+	}
+
+	// the result against the snapshot is unchanged
+	_, err = v.ValidateTransaction(tx)
+	if err != nil {
+		t.Fatalf("ValidateTransaction against the snapshot failed after the update, err %s", err)
+	}
+
+	// and so is that against a snapshot taken after the update
+	updated, err := CaptureMSPSnapshot(chainID)
+	if err == nil {
+		_, err = NewValidatorWithMSPSnapshot(updated).ValidateTransaction(tx)
+	}
+	if err == nil {
+		t.Fatalf("ValidateTransaction against the updated snapshot should have failed")
+	}
+
+	// channels not covered by the snapshot are unavailable
+	if snapshot.GetIdentityDeserializer("otherchain") != nil {
+		t.Fatalf("The snapshot should not cover otherchain")
+	}
+
+	_, err = CaptureMSPSnapshot("otherchain")
+	if err == nil {
+		t.Fatalf("CaptureMSPSnapshot should have failed for an unknown chain")
+	}
+}
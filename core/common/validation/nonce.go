@@ -0,0 +1,98 @@
+/*
+Copyright IBM Corp. 2017 All Rights Reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+		 http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package validation
+
+import (
+	"errors"
+	"fmt"
+
+	"golang.org/x/net/context"
+)
+
+// ErrNonceRejected is returned for nonces rejected by the NonceValidator of
+// their channel
+var ErrNonceRejected = errors.New("The nonce was rejected by the nonce validator of the channel")
+
+// NonceValidator validates the nonces of a channel encoding structured data
+// in them, e.g. a monotonic counter followed by random bytes; it returns an
+// error if the nonce does not follow the scheme of the channel
+type NonceValidator func(nonce []byte) error
+
+// NonceLengthProvider provides the minimum nonce length required by the
+// crypto suite of a channel, e.g. the size of the digests produced by its
+// hash function, so that a channel hashing with SHA-384 may require longer
+// nonces than one hashing with SHA-256
+type NonceLengthProvider interface {
+	// GetMinNonceLength returns the minimum nonce length for the given
+	// chain, or false if it is not available
+	GetMinNonceLength(chainID string) (int, bool)
+}
+
+// getMinNonceLength returns the minimum nonce length for the given chain
+func (v *Validator) getMinNonceLength(ctx context.Context, chainID string) (int, error) {
+	config, err := v.getCryptoConfig(ctx, chainID)
+	if err != nil {
+		return 0, err
+	}
+
+	if config != nil {
+		if config.MinNonceLength != 0 {
+			return config.MinNonceLength, nil
+		}
+	} else if v.NonceLengthProvider != nil {
+		if length, ok := v.NonceLengthProvider.GetMinNonceLength(chainID); ok {
+			return length, nil
+		}
+	}
+
+	return v.MinNonceLength, nil
+}
+
+// validateNonceLength checks that the nonce is long enough for the given chain
+func (v *Validator) validateNonceLength(ctx context.Context, chainID string, nonce []byte) error {
+	minLength, err := v.getMinNonceLength(ctx, chainID)
+	if err != nil {
+		return err
+	}
+	if len(nonce) < minLength {
+		return fmt.Errorf("Invalid nonce length, expected at least %d, got %d", minLength, len(nonce))
+	}
+
+	return nil
+}
+
+// validateNonce checks that the nonce is long enough for the given chain
+// and, if the chain has a NonceValidator, that the validator accepts it
+func (v *Validator) validateNonce(ctx context.Context, chainID string, nonce []byte) error {
+	err := v.validateNonceLength(ctx, chainID, nonce)
+	if err != nil {
+		return err
+	}
+
+	validate, ok := v.NonceValidators[chainID]
+	if !ok || validate == nil {
+		return nil
+	}
+
+	err = validate(nonce)
+	if err != nil {
+		putilsLogger.Errorf("Nonce %x rejected by the nonce validator of chain [%s], err %s", nonce, chainID, err)
+		return ErrNonceRejected
+	}
+
+	return nil
+}
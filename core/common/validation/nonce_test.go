@@ -0,0 +1,163 @@
+/*
+Copyright IBM Corp. 2017 All Rights Reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+		 http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package validation
+
+import (
+	"encoding/binary"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/hyperledger/fabric/bccsp"
+	"github.com/hyperledger/fabric/bccsp/factory"
+	"github.com/hyperledger/fabric/common/util"
+	"golang.org/x/net/context"
+)
+
+// mockNonceLengthProvider requires nonces as long as the digests of
+// the hash function of each channel
+type mockNonceLengthProvider map[string]bccsp.HashOpts
+
+func (m mockNonceLengthProvider) GetMinNonceLength(chainID string) (int, bool) {
+	opts, ok := m[chainID]
+	if !ok {
+		return 0, false
+	}
+
+	digest, err := factory.GetDefault().Hash([]byte{}, opts)
+	if err != nil {
+		return 0, false
+	}
+
+	return len(digest), true
+}
+
+func TestNonceLength(t *testing.T) {
+	provider := mockNonceLengthProvider{
+		"sha256channel": &bccsp.SHA256Opts{},
+		"sha384channel": &bccsp.SHA384Opts{},
+	}
+
+	tests := []struct {
+		name      string
+		chainID   string
+		minLength int
+		nonceLen  int
+		valid     bool
+	}{
+		{"SHA256Enough", "sha256channel", 0, 32, true},
+		{"SHA256Short", "sha256channel", 0, 31, false},
+		{"SHA384Enough", "sha384channel", 0, 48, true},
+		{"SHA384Short", "sha384channel", 0, 32, false},
+		{"FallbackEnough", "otherchannel", 24, 24, true},
+		{"FallbackShort", "otherchannel", 24, 16, false},
+		{"NoMinimum", "otherchannel", 0, 1, true},
+	}
+
+	for _, test := range tests {
+		v := &Validator{NonceLengthProvider: provider, MinNonceLength: test.minLength}
+		hdr := getHeaderAt(time.Now())
+		hdr.ChannelHeader.ChannelId = test.chainID
+		hdr.SignatureHeader.Nonce = make([]byte, test.nonceLen)
+
+		err := v.validateCommonHeader(context.Background(), hdr)
+		if test.valid && err != nil {
+			t.Fatalf("%s: validateCommonHeader failed, err %s", test.name, err)
+		}
+		if !test.valid && err == nil {
+			t.Fatalf("%s: validateCommonHeader should have failed", test.name)
+		}
+	}
+}
+
+func TestNonceLengthDefault(t *testing.T) {
+	tx, err := getTransaction([]byte("simulation_result"))
+	if err != nil {
+		t.Fatalf("getTransaction failed, err %s", err)
+	}
+
+	_, err = (&Validator{MinNonceLength: 24}).ValidateTransaction(tx)
+	if err != nil {
+		t.Fatalf("ValidateTransaction failed, err %s", err)
+	}
+
+	_, err = (&Validator{MinNonceLength: 25}).ValidateTransaction(tx)
+	if err == nil {
+		t.Fatalf("ValidateTransaction should have failed")
+	}
+}
+
+// counterNonce accepts the nonces made of a non-zero big endian counter
+// followed by 16 random bytes
+func counterNonce(nonce []byte) error {
+	if len(nonce) != 24 {
+		return fmt.Errorf("expected 24 bytes, got %d", len(nonce))
+	}
+	if binary.BigEndian.Uint64(nonce) == 0 {
+		return fmt.Errorf("zero counter")
+	}
+	return nil
+}
+
+func TestNonceValidator(t *testing.T) {
+	counted := make([]byte, 24)
+	binary.BigEndian.PutUint64(counted, 42)
+
+	validators := map[string]NonceValidator{"counterchannel": counterNonce}
+
+	tests := []struct {
+		name    string
+		chainID string
+		nonce   []byte
+		err     error
+	}{
+		{"Accepted", "counterchannel", counted, nil},
+		{"ZeroCounter", "counterchannel", make([]byte, 24), ErrNonceRejected},
+		{"WrongLength", "counterchannel", counted[:16], ErrNonceRejected},
+		{"OtherChannel", "otherchannel", make([]byte, 16), nil},
+	}
+
+	for _, test := range tests {
+		v := &Validator{NonceValidators: validators}
+		hdr := getHeaderAt(time.Now())
+		hdr.ChannelHeader.ChannelId = test.chainID
+		hdr.SignatureHeader.Nonce = test.nonce
+
+		err := v.validateCommonHeader(context.Background(), hdr)
+		if err != test.err {
+			t.Fatalf("%s: expected err %v, got %v", test.name, test.err, err)
+		}
+	}
+
+	// the nonces of the transactions and of their actions are validated
+	tx, err := getTransaction([]byte("simulation_result"))
+	if err != nil {
+		t.Fatalf("getTransaction failed, err %s", err)
+	}
+
+	reject := func(nonce []byte) error { return fmt.Errorf("rejected") }
+	_, err = (&Validator{NonceValidators: map[string]NonceValidator{util.GetTestChainID(): reject}}).ValidateTransaction(tx)
+	if err != ErrNonceRejected {
+		t.Fatalf("Expected err %v, got %v", ErrNonceRejected, err)
+	}
+
+	accept := func(nonce []byte) error { return nil }
+	_, err = (&Validator{NonceValidators: map[string]NonceValidator{util.GetTestChainID(): accept}}).ValidateTransaction(tx)
+	if err != nil {
+		t.Fatalf("ValidateTransaction failed, err %s", err)
+	}
+}
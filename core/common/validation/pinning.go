@@ -0,0 +1,118 @@
+/*
+Copyright IBM Corp. 2017 All Rights Reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+		 http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package validation
+
+import (
+	"encoding/hex"
+	"encoding/pem"
+	"errors"
+	"fmt"
+
+	"github.com/golang/protobuf/proto"
+	"github.com/hyperledger/fabric/bccsp"
+	"github.com/hyperledger/fabric/bccsp/factory"
+	"github.com/hyperledger/fabric/msp"
+)
+
+// ErrCreatorNotPinned is returned when the certificate of a creator is not
+// among the pinned ones
+var ErrCreatorNotPinned = errors.New("The creator certificate is not pinned")
+
+// CertificateFingerprint returns the fingerprint of a certificate, that is
+// the hex encoding of the SHA-256 hash of its DER encoding; the certificate
+// may be supplied either PEM or DER encoded
+func CertificateFingerprint(cert []byte) (string, error) {
+	if len(cert) == 0 {
+		return "", fmt.Errorf("Empty certificate")
+	}
+
+	der := cert
+	if block, _ := pem.Decode(cert); block != nil {
+		der = block.Bytes
+	}
+
+	digest, err := factory.GetDefault().Hash(der, &bccsp.SHA256Opts{})
+	if err != nil {
+		return "", fmt.Errorf("Failed computing the fingerprint of the certificate, err %s", err)
+	}
+
+	return hex.EncodeToString(digest), nil
+}
+
+// identityFingerprint returns a key telling apart the x.509 identities: the
+// MSP of the identity along with the fingerprint of its certificate, as
+// serialized by the identity itself. Unlike the serialized bytes it was
+// deserialized from, which may carry trailing data the MSP ignores, this
+// serialization is derived from the parsed certificate; unlike its
+// identifier, which the MSPs shipped with fabric set to the same value for
+// all their identities, it differs between certificates
+func identityFingerprint(id msp.Identity) (string, error) {
+	serialized, err := id.Serialize()
+	if err != nil {
+		return "", fmt.Errorf("Could not serialize identity, err %s", err)
+	}
+
+	sId := &msp.SerializedIdentity{}
+	err = proto.Unmarshal(serialized, sId)
+	if err != nil {
+		return "", fmt.Errorf("Could not unmarshal the serialized identity, err %s", err)
+	}
+
+	fingerprint, err := CertificateFingerprint(sId.IdBytes)
+	if err != nil {
+		return "", err
+	}
+
+	return id.GetMSPIdentifier() + "\x00" + fingerprint, nil
+}
+
+// identityKey returns a key telling apart the identities deserialized from
+// the given serialized bytes: the fingerprint of x.509 identities, or else
+// the serialized bytes, the identities without certificate having no
+// public identifier
+func identityKey(id msp.Identity, serialized []byte) (string, error) {
+	if getIdentityType(id) == X509Identity {
+		return identityFingerprint(id)
+	}
+
+	return string(serialized), nil
+}
+
+// checkPinnedCreator ensures that the certificate of the creator is pinned,
+// if any certificate is; creators without a certificate are never pinned
+func (v *Validator) checkPinnedCreator(sId *msp.SerializedIdentity, idType IdentityType) error {
+	if len(v.PinnedCreatorFingerprints) == 0 {
+		return nil
+	}
+
+	if idType != X509Identity {
+		putilsLogger.Errorf("checkPinnedCreator error: creator is a %s identity with no certificate to pin", idType)
+		return ErrCreatorNotPinned
+	}
+
+	fingerprint, err := CertificateFingerprint(sId.IdBytes)
+	if err != nil {
+		return err
+	}
+
+	if _, ok := v.PinnedCreatorFingerprints[fingerprint]; !ok {
+		putilsLogger.Errorf("checkPinnedCreator error: creator certificate with fingerprint %s is not pinned", fingerprint)
+		return ErrCreatorNotPinned
+	}
+
+	return nil
+}
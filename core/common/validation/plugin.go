@@ -0,0 +1,95 @@
+/*
+Copyright IBM Corp. 2017 All Rights Reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+		 http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package validation
+
+import (
+	"sort"
+	"sync"
+)
+
+// ValidationPlugin performs deployment-specific checks on transactions
+type ValidationPlugin interface {
+	// Validate returns an error if the transaction that has passed the
+	// built-in validation must nonetheless be rejected. The result is a
+	// copy owned by the plugin: mutating it has no effect on the outcome
+	// of the validation nor on the other plugins
+	Validate(result *ValidationResult) error
+}
+
+// registeredPlugin is a plugin along with its priority
+type registeredPlugin struct {
+	plugin   ValidationPlugin
+	priority int
+}
+
+// byPriority sorts plugins by increasing priority
+type byPriority []registeredPlugin
+
+func (p byPriority) Len() int           { return len(p) }
+func (p byPriority) Swap(i, j int)      { p[i], p[j] = p[j], p[i] }
+func (p byPriority) Less(i, j int) bool { return p[i].priority < p[j].priority }
+
+// pluginRegistry holds the plugins registered with a Validator
+type pluginRegistry struct {
+	sync.RWMutex
+	plugins map[string][]registeredPlugin
+}
+
+// RegisterPlugin registers a plugin to be run on the transactions of the
+// given chain, or on those of every chain if chainID is empty. Plugins run
+// once the built-in validation has passed, by increasing priority; plugins
+// with the same priority run in the order in which they were registered
+func (v *Validator) RegisterPlugin(chainID string, priority int, plugin ValidationPlugin) {
+	v.pluginRegistry.Lock()
+	defer v.pluginRegistry.Unlock()
+
+	if v.pluginRegistry.plugins == nil {
+		v.pluginRegistry.plugins = make(map[string][]registeredPlugin)
+	}
+
+	v.pluginRegistry.plugins[chainID] = append(v.pluginRegistry.plugins[chainID], registeredPlugin{plugin, priority})
+}
+
+// getPlugins returns the plugins to be run on the transactions of the given chain
+func (v *Validator) getPlugins(chainID string) []registeredPlugin {
+	v.pluginRegistry.RLock()
+	defer v.pluginRegistry.RUnlock()
+
+	var plugins []registeredPlugin
+	plugins = append(plugins, v.pluginRegistry.plugins[""]...)
+	if chainID != "" {
+		plugins = append(plugins, v.pluginRegistry.plugins[chainID]...)
+	}
+
+	sort.Stable(byPriority(plugins))
+
+	return plugins
+}
+
+// runPlugins runs the plugins registered for the chain of the transaction,
+// stopping at the first one that rejects it. Each plugin is handed its own
+// copy of the result, so that plugins mutating it cannot race with the
+// caller or with concurrent validations of the same envelope
+func (v *Validator) runPlugins(result *ValidationResult) error {
+	for _, p := range v.getPlugins(result.Payload.Header.ChannelHeader.ChannelId) {
+		if err := p.plugin.Validate(result.Copy()); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,174 @@
+/*
+Copyright IBM Corp. 2017 All Rights Reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+		 http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package validation
+
+import (
+	"errors"
+	"sync"
+	"testing"
+
+	"github.com/hyperledger/fabric/common/util"
+)
+
+// mockPlugin records its invocations and returns err
+type mockPlugin struct {
+	name  string
+	calls *[]string
+	err   error
+}
+
+func (p *mockPlugin) Validate(result *ValidationResult) error {
+	*p.calls = append(*p.calls, p.name)
+	return p.err
+}
+
+func TestPluginsOrder(t *testing.T) {
+	tx, err := getTransaction([]byte("simulation_result"))
+	if err != nil {
+		t.Fatalf("getTransaction failed, err %s", err)
+	}
+
+	var calls []string
+	v := &Validator{}
+	v.RegisterPlugin(util.GetTestChainID(), 20, &mockPlugin{name: "second", calls: &calls})
+	v.RegisterPlugin("", 10, &mockPlugin{name: "first", calls: &calls})
+	v.RegisterPlugin("otherchannel", 0, &mockPlugin{name: "other", calls: &calls, err: errors.New("rejected")})
+
+	_, err = v.ValidateTransaction(tx)
+	if err != nil {
+		t.Fatalf("ValidateTransaction failed, err %s", err)
+	}
+
+	if len(calls) != 2 || calls[0] != "first" || calls[1] != "second" {
+		t.Fatalf("Plugins were not run in priority order, got %v", calls)
+	}
+}
+
+func TestPluginsReject(t *testing.T) {
+	tx, err := getTransaction([]byte("simulation_result"))
+	if err != nil {
+		t.Fatalf("getTransaction failed, err %s", err)
+	}
+
+	var calls []string
+	rejection := errors.New("rejected")
+	v := &Validator{}
+	v.RegisterPlugin("", 30, &mockPlugin{name: "third", calls: &calls})
+	v.RegisterPlugin("", 20, &mockPlugin{name: "second", calls: &calls, err: rejection})
+	v.RegisterPlugin("", 10, &mockPlugin{name: "first", calls: &calls})
+
+	_, err = v.ValidateTransaction(tx)
+	if err != rejection {
+		t.Fatalf("ValidateTransaction should have been rejected by the plugin, got %v", err)
+	}
+
+	if len(calls) != 2 || calls[0] != "first" || calls[1] != "second" {
+		t.Fatalf("Plugins after the rejecting one should not run, got %v", calls)
+	}
+}
+
+func TestPluginsNotRunOnInvalidTx(t *testing.T) {
+	tx, err := getTransaction([]byte("simulation_result"))
+	if err != nil {
+		t.Fatalf("getTransaction failed, err %s", err)
+	}
+	corrupt(tx.Signature)
+
+	var calls []string
+	v := &Validator{}
+	v.RegisterPlugin("", 0, &mockPlugin{name: "first", calls: &calls})
+
+	_, err = v.ValidateTransaction(tx)
+	if err == nil {
+		t.Fatalf("ValidateTransaction should have failed")
+	}
+
+	if len(calls) != 0 {
+		t.Fatalf("Plugins should not run on invalid transactions, got %v", calls)
+	}
+}
+
+// mutatingPlugin scribbles over the result it is handed
+type mutatingPlugin struct{}
+
+func (p *mutatingPlugin) Validate(result *ValidationResult) error {
+	result.Envelope.Signature = nil
+	result.Payload.Header.ChannelHeader.ChannelId = "mutated"
+	return nil
+}
+
+func TestPluginsConcurrentMutation(t *testing.T) {
+	tx, err := getTransaction([]byte("simulation_result"))
+	if err != nil {
+		t.Fatalf("getTransaction failed, err %s", err)
+	}
+
+	v := &Validator{}
+	v.RegisterPlugin("", 0, &mutatingPlugin{})
+
+	var wg sync.WaitGroup
+	errs := make(chan error, 8)
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			result, err := v.Validate(tx)
+			if err != nil {
+				errs <- err
+				return
+			}
+			if result.Payload.Header.ChannelHeader.ChannelId != util.GetTestChainID() {
+				errs <- errors.New("result mutated by plugin")
+			}
+		}()
+	}
+	wg.Wait()
+	close(errs)
+
+	for err := range errs {
+		t.Fatalf("Concurrent validation failed, err %s", err)
+	}
+
+	if tx.Signature == nil {
+		t.Fatalf("Envelope mutated by plugin")
+	}
+}
+
+func TestValidationResultCopy(t *testing.T) {
+	tx, err := getTransaction([]byte("simulation_result"))
+	if err != nil {
+		t.Fatalf("getTransaction failed, err %s", err)
+	}
+
+	result, err := defaultValidator.Validate(tx)
+	if err != nil {
+		t.Fatalf("Validate failed, err %s", err)
+	}
+
+	c := result.Copy()
+	c.Payload.Header.SignatureHeader.Nonce[0]++
+	c.Envelope.Payload[0]++
+
+	if c.Payload.Header.SignatureHeader.Nonce[0] == result.Payload.Header.SignatureHeader.Nonce[0] ||
+		c.Envelope.Payload[0] == result.Envelope.Payload[0] {
+		t.Fatalf("Copy should not share data with the original result")
+	}
+
+	if (&ValidationResult{}).Copy() == nil {
+		t.Fatalf("Copy of an empty result should not be nil")
+	}
+}
@@ -0,0 +1,109 @@
+/*
+Copyright IBM Corp. 2017 All Rights Reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+		 http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package validation
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+
+	"github.com/hyperledger/fabric/bccsp"
+	"github.com/hyperledger/fabric/bccsp/factory"
+	"github.com/hyperledger/fabric/protos/common"
+	"github.com/hyperledger/fabric/protos/utils"
+	"golang.org/x/net/context"
+)
+
+// ErrPolicyDigestMismatch is returned when the endorsement policy applying
+// to a transaction is not the one expected
+var ErrPolicyDigestMismatch = errors.New("Endorsement policy digest mismatch")
+
+// EndorsementPolicyProvider provides the endorsement policies of chaincodes
+type EndorsementPolicyProvider interface {
+	// GetEndorsementPolicy returns the serialized endorsement policy of the
+	// chaincode on the given chain, as stored by LCCC
+	GetEndorsementPolicy(chainID, chaincodeName string) ([]byte, error)
+}
+
+// ValidateTransactionWithPolicyDigest checks that the transaction envelope
+// is properly formed and, if an expected policy digest is supplied, that the
+// SHA-256 digest of the endorsement policy of the invoked chaincode matches
+// it. Transactions do not carry their endorsement policy: the one applying
+// is the policy VSCC will fetch for the chaincode named in the header, as
+// supplied by the validator's EndorsementPolicyProvider. This allows callers
+// knowing the expected policy to detect a substituted one before VSCC runs
+func (v *Validator) ValidateTransactionWithPolicyDigest(e *common.Envelope, expectedPolicyDigest []byte) (*common.Payload, error) {
+	result, err := v.validateWith(context.Background(), e, func(result *ValidationResult) error {
+		return v.checkEndorsementPolicyDigest(result, expectedPolicyDigest)
+	})
+	return result.Payload, err
+}
+
+// checkEndorsementPolicyDigest checks the digest of the endorsement policy
+// applying to a valid transaction, if an expected digest is supplied
+func (v *Validator) checkEndorsementPolicyDigest(result *ValidationResult, expectedPolicyDigest []byte) error {
+	if len(expectedPolicyDigest) == 0 {
+		return nil
+	}
+
+	// the policy of the pinned chaincode version prevails over the latest
+	if result.ChaincodeDefinition != nil {
+		return checkPolicyDigest(result.ChaincodeDefinition.Name, result.ChaincodeDefinition.Policy, expectedPolicyDigest)
+	}
+
+	if v.EndorsementPolicyProvider == nil {
+		return fmt.Errorf("No endorsement policy provider to check the policy digest against")
+	}
+
+	payload := result.Payload
+	chdr := payload.Header.ChannelHeader
+	if common.HeaderType(chdr.Type) != common.HeaderType_ENDORSER_TRANSACTION {
+		return fmt.Errorf("Policy digest supplied for a transaction of type %d with no endorsement policy", chdr.Type)
+	}
+
+	hdrExt, err := utils.GetChaincodeHeaderExtension(payload.Header)
+	if err != nil {
+		return fmt.Errorf("Could not unmarshal the chaincode header extension, err %s", err)
+	}
+
+	if hdrExt.ChaincodeId == nil || hdrExt.ChaincodeId.Name == "" {
+		return fmt.Errorf("Missing chaincode ID in the chaincode header extension")
+	}
+
+	policy, err := v.EndorsementPolicyProvider.GetEndorsementPolicy(chdr.ChannelId, hdrExt.ChaincodeId.Name)
+	if err != nil {
+		return fmt.Errorf("Could not get the endorsement policy of chaincode %s, err %s", hdrExt.ChaincodeId.Name, err)
+	}
+
+	return checkPolicyDigest(hdrExt.ChaincodeId.Name, policy, expectedPolicyDigest)
+}
+
+// checkPolicyDigest checks that the SHA-256 digest of the endorsement
+// policy of a chaincode is the expected one
+func checkPolicyDigest(chaincodeName string, policy, expectedPolicyDigest []byte) error {
+	digest, err := factory.GetDefault().Hash(policy, &bccsp.SHA256Opts{})
+	if err != nil {
+		return fmt.Errorf("Failed computing the digest of the endorsement policy, err %s", err)
+	}
+
+	if !bytes.Equal(digest, expectedPolicyDigest) {
+		putilsLogger.Errorf("Endorsement policy of chaincode %s has digest %x, expected %x", chaincodeName, digest, expectedPolicyDigest)
+		return ErrPolicyDigestMismatch
+	}
+
+	return nil
+}
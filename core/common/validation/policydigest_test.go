@@ -0,0 +1,82 @@
+/*
+Copyright IBM Corp. 2017 All Rights Reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+		 http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package validation
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/hyperledger/fabric/bccsp"
+	"github.com/hyperledger/fabric/bccsp/factory"
+	"github.com/hyperledger/fabric/common/cauthdsl"
+	"github.com/hyperledger/fabric/protos/utils"
+)
+
+// mockPolicyProvider maps chaincode names to their endorsement policies
+type mockPolicyProvider map[string][]byte
+
+func (m mockPolicyProvider) GetEndorsementPolicy(chainID, chaincodeName string) ([]byte, error) {
+	policy, ok := m[chaincodeName]
+	if !ok {
+		return nil, errors.New("unknown chaincode")
+	}
+	return policy, nil
+}
+
+func TestPolicyDigest(t *testing.T) {
+	tx, err := getTransaction([]byte("simulation_result"))
+	if err != nil {
+		t.Fatalf("getTransaction failed, err %s", err)
+	}
+
+	policy := utils.MarshalOrPanic(cauthdsl.SignedByMspMember("DEFAULT"))
+	other := utils.MarshalOrPanic(cauthdsl.AcceptAllPolicy)
+
+	digest, err := factory.GetDefault().Hash(policy, &bccsp.SHA256Opts{})
+	if err != nil {
+		t.Fatalf("Hash failed, err %s", err)
+	}
+
+	tests := []struct {
+		name     string
+		provider EndorsementPolicyProvider
+		digest   []byte
+		err      bool
+	}{
+		{"Matching", mockPolicyProvider{"foo": policy}, digest, false},
+		{"NoDigest", mockPolicyProvider{"foo": other}, nil, false},
+		{"Substituted", mockPolicyProvider{"foo": other}, digest, true},
+		{"UnknownChaincode", mockPolicyProvider{"bar": policy}, digest, true},
+		{"NoProvider", nil, digest, true},
+	}
+
+	for _, test := range tests {
+		v := &Validator{EndorsementPolicyProvider: test.provider}
+		_, err := v.ValidateTransactionWithPolicyDigest(tx, test.digest)
+		if !test.err && err != nil {
+			t.Fatalf("%s: ValidateTransactionWithPolicyDigest failed, err %s", test.name, err)
+		}
+		if test.err && err == nil {
+			t.Fatalf("%s: ValidateTransactionWithPolicyDigest should have failed", test.name)
+		}
+	}
+
+	_, err = (&Validator{EndorsementPolicyProvider: mockPolicyProvider{"foo": other}}).ValidateTransactionWithPolicyDigest(tx, digest)
+	if err != ErrPolicyDigestMismatch {
+		t.Fatalf("ValidateTransactionWithPolicyDigest should have failed with ErrPolicyDigestMismatch, got %v", err)
+	}
+}
@@ -0,0 +1,66 @@
+/*
+Copyright IBM Corp. 2017 All Rights Reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+		 http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package validation
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/hyperledger/fabric/protos/common"
+	"golang.org/x/net/context"
+)
+
+// ErrUnknownPolicyName is returned for endorser transactions referencing an
+// endorsement policy that is not defined on their channel
+var ErrUnknownPolicyName = errors.New("The transaction references an unknown endorsement policy")
+
+// PolicyResolver resolves the names of the endorsement policies of the
+// channels
+type PolicyResolver interface {
+	// HasPolicy tells whether an endorsement policy with the given name is
+	// defined on the given chain
+	HasPolicy(chainID, policyName string) (bool, error)
+}
+
+// validatePolicyName ensures that the endorsement policy referenced by the
+// transaction in the PolicyName field of its chaincode header extension, if
+// any, is known to the PolicyResolver of the validator, so
+// that the transactions of misconfigured clients are rejected before VSCC
+// evaluates them
+func (v *Validator) validatePolicyName(ctx context.Context, chdr *common.ChannelHeader) error {
+	ext, err := getHeaderExtension(ctx, chdr)
+	if err != nil {
+		return err
+	}
+
+	name := ext.PolicyName
+	if name == "" {
+		return nil
+	}
+
+	known, err := v.PolicyResolver.HasPolicy(chdr.ChannelId, name)
+	if err != nil {
+		return fmt.Errorf("Could not resolve endorsement policy %s, err %s", name, err)
+	}
+
+	if !known {
+		putilsLogger.Errorf("Transaction [%s] references endorsement policy %s, unknown on chain [%s]", chdr.TxId, name, chdr.ChannelId)
+		return ErrUnknownPolicyName
+	}
+
+	return nil
+}
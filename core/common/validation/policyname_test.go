@@ -0,0 +1,84 @@
+/*
+Copyright IBM Corp. 2017 All Rights Reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+		 http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package validation
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/hyperledger/fabric/common/util"
+	"github.com/hyperledger/fabric/protos/common"
+	"github.com/hyperledger/fabric/protos/peer"
+	"github.com/hyperledger/fabric/protos/utils"
+)
+
+// mockPolicyResolver knows the policies in its map, by channel
+type mockPolicyResolver map[string]map[string]struct{}
+
+func (r mockPolicyResolver) HasPolicy(chainID, policyName string) (bool, error) {
+	if policyName == "broken" {
+		return false, errors.New("policies unavailable")
+	}
+
+	_, ok := r[chainID][policyName]
+	return ok, nil
+}
+
+// encodePolicyName encodes a policy name as a field of the chaincode header
+// extension
+func encodePolicyName(name string) []byte {
+	return utils.MarshalOrPanic(&peer.ChaincodeHeaderExtension{PolicyName: name})
+}
+
+func TestPolicyName(t *testing.T) {
+	resolver := mockPolicyResolver{
+		util.GetTestChainID(): {"majority": struct{}{}},
+		"otherchain":          {"any": struct{}{}},
+	}
+
+	knownTx, _ := getTransactionWithExtension(t, encodePolicyName("majority"))
+	unknownTx, _ := getTransactionWithExtension(t, encodePolicyName("unanimity"))
+	otherChainTx, _ := getTransactionWithExtension(t, encodePolicyName("any"))
+	noPolicyTx, _ := getTransactionWithExtension(t, nil)
+	brokenTx, _ := getTransactionWithExtension(t, encodePolicyName("broken"))
+
+	tests := []struct {
+		name     string
+		tx       *common.Envelope
+		resolver PolicyResolver
+		err      error
+	}{
+		{"Known", knownTx, resolver, nil},
+		{"Unknown", unknownTx, resolver, ErrUnknownPolicyName},
+		// the policies of other channels cannot be referenced
+		{"OtherChain", otherChainTx, resolver, ErrUnknownPolicyName},
+		{"NoReference", noPolicyTx, resolver, nil},
+		{"NotChecked", unknownTx, nil, nil},
+	}
+
+	for _, test := range tests {
+		_, err := (&Validator{PolicyResolver: test.resolver}).ValidateTransaction(test.tx)
+		if err != test.err {
+			t.Fatalf("%s: expected err %v, got %v", test.name, test.err, err)
+		}
+	}
+
+	_, err := (&Validator{PolicyResolver: resolver}).ValidateTransaction(brokenTx)
+	if err == nil || err == ErrUnknownPolicyName {
+		t.Fatalf("ValidateTransaction should have failed, got %v", err)
+	}
+}
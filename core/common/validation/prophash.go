@@ -0,0 +1,96 @@
+/*
+Copyright IBM Corp. 2017 All Rights Reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+		 http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package validation
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+
+	"github.com/hyperledger/fabric/protos/common"
+	pb "github.com/hyperledger/fabric/protos/peer"
+	"github.com/hyperledger/fabric/protos/utils"
+)
+
+// ErrProposalHashMismatch is returned for the actions of endorser
+// transactions whose proposal hash does not match the one recomputed from
+// the header of the transaction and the ChaincodeProposalPayload of the
+// action. The signature of the creator over the transaction has been
+// verified by then, so the transaction was not altered in transit: it was
+// assembled from a header, most likely a ChannelHeader whose fields were
+// changed after endorsement, other than the one the endorsers signed off on
+var ErrProposalHashMismatch = errors.New("proposal hash mismatch: channel header likely tampered")
+
+// VerifyProposalHashes recomputes the proposal hash of each action from the
+// supplied header bytes and the action's ChaincodeProposalPayload, and
+// checks it against the expected hash at the same index
+func VerifyProposalHashes(hdrBytes []byte, actions []*pb.ChaincodeActionPayload, expected [][]byte) error {
+	if len(actions) != len(expected) {
+		return fmt.Errorf("Mismatched number of actions and expected hashes, got %d and %d", len(actions), len(expected))
+	}
+
+	for i, cap := range actions {
+		if cap == nil {
+			return fmt.Errorf("Nil ChaincodeActionPayload at index %d", i)
+		}
+
+		err := verifyProposalHash(hdrBytes, cap.ChaincodeProposalPayload, expected[i])
+		if err != nil {
+			return fmt.Errorf("Invalid proposal hash at index %d, err %s", i, err)
+		}
+	}
+
+	return nil
+}
+
+// verifyProposalHash recomputes the proposal hash from the header bytes and
+// the ChaincodeProposalPayload bytes, and compares it with the expected one
+func verifyProposalHash(hdrBytes []byte, ccPropPayload []byte, expected []byte) error {
+	// the proposal hash is computed over some header and payload
+	if len(hdrBytes) == 0 || len(ccPropPayload) == 0 {
+		return fmt.Errorf("Nil or empty arguments")
+	}
+
+	// compute proposalHash
+	pHash, err := utils.GetProposalHash2(hdrBytes, ccPropPayload)
+	if err != nil {
+		return err
+	}
+
+	// ensure that the proposal hash is well formed before comparing it
+	if len(expected) == 0 {
+		return fmt.Errorf("Empty proposal hash in the proposal response payload")
+	}
+	if len(expected) != len(pHash) {
+		return fmt.Errorf("Invalid proposal hash length, expected %d, got %d", len(pHash), len(expected))
+	}
+
+	// ensure that the proposal hash matches
+	if bytes.Compare(pHash, expected) != 0 {
+		return ErrProposalHashMismatch
+	}
+
+	return nil
+}
+
+// logProposalHashMismatch logs the fields of the channel header the
+// proposal hash of an action was recomputed over, so that they can be
+// compared with those of the proposal the endorsers received
+func logProposalHashMismatch(index int, chdr *common.ChannelHeader, expected []byte) {
+	putilsLogger.Errorf("Proposal hash mismatch for action %d of transaction [%s] on chain [%s], expected %x", index, chdr.TxId, chdr.ChannelId, expected)
+	putilsLogger.Debugf("Channel header of action %d: type %d, version %d, timestamp %v, epoch %d, extension %x", index, chdr.Type, chdr.Version, chdr.Timestamp, chdr.Epoch, chdr.Extension)
+}
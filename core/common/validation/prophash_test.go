@@ -0,0 +1,241 @@
+/*
+Copyright IBM Corp. 2017 All Rights Reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+		 http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package validation
+
+import (
+	"testing"
+	"time"
+
+	"github.com/golang/protobuf/ptypes/timestamp"
+	"github.com/hyperledger/fabric/protos/common"
+	"github.com/hyperledger/fabric/protos/peer"
+	"github.com/hyperledger/fabric/protos/utils"
+)
+
+func TestProposalHashLength(t *testing.T) {
+	tx, err := getTransaction([]byte("simulation_result"))
+	if err != nil {
+		t.Fatalf("getTransaction failed, err %s", err)
+	}
+
+	tests := []struct {
+		name  string
+		hash  func(h []byte) []byte
+		valid bool
+	}{
+		{"Unchanged", func(h []byte) []byte { return h }, true},
+		{"Nil", func(h []byte) []byte { return nil }, false},
+		{"Empty", func(h []byte) []byte { return []byte{} }, false},
+		{"Truncated", func(h []byte) []byte { return h[:len(h)-1] }, false},
+		{"Extended", func(h []byte) []byte { return append(h, 0) }, false},
+	}
+
+	for _, test := range tests {
+		mtx, err := modifyTransaction(tx, func(sHdr *common.SignatureHeader, cap *peer.ChaincodeActionPayload, prp *peer.ProposalResponsePayload) {
+			prp.ProposalHash = test.hash(prp.ProposalHash)
+		})
+		if err != nil {
+			t.Fatalf("%s: modifyTransaction failed, err %s", test.name, err)
+		}
+
+		_, err = ValidateTransaction(mtx)
+		if test.valid && err != nil {
+			t.Fatalf("%s: ValidateTransaction failed, err %s", test.name, err)
+		}
+		if !test.valid && err == nil {
+			t.Fatalf("%s: ValidateTransaction should have failed", test.name)
+		}
+	}
+}
+
+func TestVerifyProposalHashes(t *testing.T) {
+	tx, err := getTransaction([]byte("simulation_result"))
+	if err != nil {
+		t.Fatalf("getTransaction failed, err %s", err)
+	}
+
+	payload, err := utils.GetPayload(tx)
+	if err != nil {
+		t.Fatalf("GetPayload failed, err %s", err)
+	}
+
+	transaction, err := utils.GetTransaction(payload.Data)
+	if err != nil {
+		t.Fatalf("GetTransaction failed, err %s", err)
+	}
+
+	sHdr, err := utils.GetSignatureHeader(transaction.Actions[0].Header)
+	if err != nil {
+		t.Fatalf("GetSignatureHeader failed, err %s", err)
+	}
+
+	hdrBytes, err := utils.GetBytesHeader(&common.Header{ChannelHeader: payload.Header.ChannelHeader, SignatureHeader: sHdr})
+	if err != nil {
+		t.Fatalf("GetBytesHeader failed, err %s", err)
+	}
+
+	cap, err := utils.GetChaincodeActionPayload(transaction.Actions[0].Payload)
+	if err != nil {
+		t.Fatalf("GetChaincodeActionPayload failed, err %s", err)
+	}
+
+	prp, err := utils.GetProposalResponsePayload(cap.Action.ProposalResponsePayload)
+	if err != nil {
+		t.Fatalf("GetProposalResponsePayload failed, err %s", err)
+	}
+
+	actions := []*peer.ChaincodeActionPayload{cap, cap}
+
+	err = VerifyProposalHashes(hdrBytes, actions, [][]byte{prp.ProposalHash, prp.ProposalHash})
+	if err != nil {
+		t.Fatalf("VerifyProposalHashes failed, err %s", err)
+	}
+
+	mismatched := append([]byte(nil), prp.ProposalHash...)
+	mismatched[0]++
+	err = VerifyProposalHashes(hdrBytes, actions, [][]byte{prp.ProposalHash, mismatched})
+	if err == nil {
+		t.Fatalf("VerifyProposalHashes should have failed for a mismatched hash")
+	}
+
+	err = VerifyProposalHashes([]byte("other header"), actions, [][]byte{prp.ProposalHash, prp.ProposalHash})
+	if err == nil {
+		t.Fatalf("VerifyProposalHashes should have failed for other header bytes")
+	}
+
+	err = VerifyProposalHashes(hdrBytes, actions, [][]byte{prp.ProposalHash})
+	if err == nil {
+		t.Fatalf("VerifyProposalHashes should have failed for a missing expected hash")
+	}
+}
+
+func TestChannelHeaderTampered(t *testing.T) {
+	tx, err := getTransaction([]byte("simulation_result"))
+	if err != nil {
+		t.Fatalf("getTransaction failed, err %s", err)
+	}
+
+	tests := []struct {
+		name   string
+		tamper func(chdr *common.ChannelHeader)
+	}{
+		{"Version", func(chdr *common.ChannelHeader) { chdr.Version++ }},
+		{"Timestamp", func(chdr *common.ChannelHeader) { chdr.Timestamp = &timestamp.Timestamp{Seconds: time.Now().Unix()} }},
+		{"Extension", func(chdr *common.ChannelHeader) { chdr.Extension = append(chdr.Extension, encodeTTL(time.Hour)...) }},
+	}
+
+	for _, test := range tests {
+		payload, err := utils.GetPayload(tx)
+		if err != nil {
+			t.Fatalf("%s: GetPayload failed, err %s", test.name, err)
+		}
+		test.tamper(payload.Header.ChannelHeader)
+
+		// the creator signs the altered transaction
+		mtx := &common.Envelope{Payload: utils.MarshalOrPanic(payload)}
+		mtx.Signature, err = signer.Sign(mtx.Payload)
+		if err != nil {
+			t.Fatalf("%s: Sign failed, err %s", test.name, err)
+		}
+
+		_, err = ValidateTransaction(mtx)
+		if err != ErrProposalHashMismatch {
+			t.Fatalf("%s: expected err %v, got %v", test.name, ErrProposalHashMismatch, err)
+		}
+
+		// altered without the creator's signature, the transaction is
+		// rejected as such
+		mtx.Signature = tx.Signature
+		_, err = ValidateTransaction(mtx)
+		if err == nil || err == ErrProposalHashMismatch {
+			t.Fatalf("%s: ValidateTransaction should have failed verifying the signature, got %v", test.name, err)
+		}
+	}
+}
+
+// appendActions returns a signed transaction with the actions of tx followed
+// by those of from
+func appendActions(t *testing.T, tx, from *common.Envelope) *common.Envelope {
+	var actions [][]*peer.TransactionAction
+	var payload *common.Payload
+	for _, e := range []*common.Envelope{tx, from} {
+		p, err := utils.GetPayload(e)
+		if err != nil {
+			t.Fatalf("GetPayload failed, err %s", err)
+		}
+		transaction, err := utils.GetTransaction(p.Data)
+		if err != nil {
+			t.Fatalf("GetTransaction failed, err %s", err)
+		}
+		if payload == nil {
+			payload = p
+		}
+		actions = append(actions, transaction.Actions)
+	}
+
+	payload.Data = utils.MarshalOrPanic(&peer.Transaction{Actions: append(actions[0], actions[1]...)})
+	stitched := &common.Envelope{Payload: utils.MarshalOrPanic(payload)}
+	var err error
+	stitched.Signature, err = signer.Sign(stitched.Payload)
+	if err != nil {
+		t.Fatalf("Sign failed, err %s", err)
+	}
+
+	return stitched
+}
+
+func TestStitchedActionTimestamps(t *testing.T) {
+	prop, err := getProposal()
+	if err != nil {
+		t.Fatalf("getProposal failed, err %s", err)
+	}
+
+	hdr, err := utils.GetHeader(prop.Header)
+	if err != nil {
+		t.Fatalf("GetHeader failed, err %s", err)
+	}
+
+	// transactions for the same proposal, differing only by the timestamp
+	// of their header
+	created := time.Now()
+	at := func(ts time.Time, result string) *common.Envelope {
+		hdr.ChannelHeader.Timestamp = &timestamp.Timestamp{Seconds: ts.Unix()}
+		prop.Header = utils.MarshalOrPanic(hdr)
+		tx, err := getTransactionForProposal(prop, []byte(result))
+		if err != nil {
+			t.Fatalf("getTransactionForProposal failed, err %s", err)
+		}
+		return tx
+	}
+	tx := at(created, "simulation_result")
+
+	tests := []struct {
+		name string
+		from *common.Envelope
+		err  error
+	}{
+		{"Consistent", at(created, "other_simulation_result"), nil},
+		{"Divergent", at(created.Add(time.Hour), "other_simulation_result"), ErrProposalHashMismatch},
+	}
+
+	for _, test := range tests {
+		_, err := ValidateTransaction(appendActions(t, tx, test.from))
+		if err != test.err {
+			t.Fatalf("%s: expected err %v, got %v", test.name, test.err, err)
+		}
+	}
+}
@@ -0,0 +1,142 @@
+/*
+Copyright IBM Corp. 2017 All Rights Reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+		 http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package validation
+
+import (
+	"fmt"
+
+	"github.com/hyperledger/fabric/msp"
+	"github.com/hyperledger/fabric/protos/common"
+	pb "github.com/hyperledger/fabric/protos/peer"
+	"golang.org/x/net/context"
+)
+
+// ProposalValidationResult holds the messages of a valid proposal, as
+// returned by ValidateProposalMessage
+type ProposalValidationResult struct {
+	// Proposal is the proposal decoded from the signed proposal
+	Proposal *pb.Proposal
+
+	// Header is the header of the proposal
+	Header *common.Header
+
+	// ChaincodeHeaderExtension is the chaincode header extension of the
+	// proposal
+	ChaincodeHeaderExtension *pb.ChaincodeHeaderExtension
+}
+
+// proposalBatch holds the lookups shared by the proposals of a batch
+type proposalBatch struct {
+	// deserializers holds the outcome of the lookup of the identity
+	// deserializer of each channel
+	deserializers map[string]batchDeserializer
+
+	// identities holds the identities deserialized, by channel and
+	// serialized identity
+	identities map[string]msp.Identity
+}
+
+// batchDeserializer is the outcome of the lookup of an identity deserializer
+type batchDeserializer struct {
+	mspObj msp.IdentityDeserializer
+	err    error
+}
+
+// batchKey is the key of the proposal batch in contexts
+type batchKey struct{}
+
+// ValidateProposalBatch validates the signed proposals with the default
+// validator, see Validator.ValidateProposalBatch
+func ValidateProposalBatch(props []*pb.SignedProposal) ([]*ProposalValidationResult, []error) {
+	return defaultValidator.ValidateProposalBatch(props)
+}
+
+// ValidateProposalBatch validates the signed proposals one after the other,
+// as ValidateProposalMessage does, and returns the result and the error of
+// each at the same index as the proposal; the result of an invalid proposal
+// is nil. The identity deserializer of each channel is looked up once for
+// the whole batch, and the creators common to several proposals are only
+// deserialized once, which pays off for the proposals of the same channel
+// received together by gateways
+func (v *Validator) ValidateProposalBatch(props []*pb.SignedProposal) ([]*ProposalValidationResult, []error) {
+	batch := &proposalBatch{
+		deserializers: make(map[string]batchDeserializer),
+		identities:    make(map[string]msp.Identity),
+	}
+	ctx := context.WithValue(context.Background(), batchKey{}, batch)
+
+	results := make([]*ProposalValidationResult, len(props))
+	errs := make([]error, len(props))
+	for i, signedProp := range props {
+		if signedProp == nil {
+			errs[i] = fmt.Errorf("Nil signed proposal")
+			continue
+		}
+
+		prop, hdr, ext, err := v.validateProposalMessage(ctx, signedProp)
+		if err != nil {
+			putilsLogger.Warningf("Invalid proposal with index %d, err %s", i, err)
+			errs[i] = err
+			continue
+		}
+
+		results[i] = &ProposalValidationResult{Proposal: prop, Header: hdr, ChaincodeHeaderExtension: ext}
+	}
+
+	return results, errs
+}
+
+// getBatchIdentityDeserializer returns the IdentityDeserializer for the
+// given chain, looked up once for the whole batch held by the context, if
+// any
+func (v *Validator) getBatchIdentityDeserializer(ctx context.Context, chainID string) (msp.IdentityDeserializer, error) {
+	batch, ok := ctx.Value(batchKey{}).(*proposalBatch)
+	if !ok {
+		return v.getIdentityDeserializer(ctx, chainID)
+	}
+
+	d, ok := batch.deserializers[chainID]
+	if !ok {
+		d.mspObj, d.err = v.getIdentityDeserializer(ctx, chainID)
+		batch.deserializers[chainID] = d
+	}
+
+	return d.mspObj, d.err
+}
+
+// deserializeBatchIdentity deserializes the identity with the deserializer
+// of the given chain, reusing the identity already deserialized for the
+// batch held by the context, if any
+func deserializeBatchIdentity(ctx context.Context, chainID string, mspObj msp.IdentityDeserializer, serializedIdentity []byte) (msp.Identity, error) {
+	batch, ok := ctx.Value(batchKey{}).(*proposalBatch)
+	if !ok {
+		return mspObj.DeserializeIdentity(serializedIdentity)
+	}
+
+	key := chainID + "\x00" + string(serializedIdentity)
+	if identity, ok := batch.identities[key]; ok {
+		return identity, nil
+	}
+
+	identity, err := mspObj.DeserializeIdentity(serializedIdentity)
+	if err != nil {
+		return nil, err
+	}
+	batch.identities[key] = identity
+
+	return identity, nil
+}
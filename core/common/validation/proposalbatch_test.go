@@ -0,0 +1,101 @@
+/*
+Copyright IBM Corp. 2017 All Rights Reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+		 http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package validation
+
+import (
+	"testing"
+
+	"github.com/hyperledger/fabric/common/util"
+	"github.com/hyperledger/fabric/msp"
+	mspmgmt "github.com/hyperledger/fabric/msp/mgmt"
+	"github.com/hyperledger/fabric/protos/peer"
+	"github.com/hyperledger/fabric/protos/utils"
+)
+
+// countingDeserializerProvider counts the lookups of the deserializers of
+// the MSP manager, and the identities they deserialize
+type countingDeserializerProvider struct {
+	lookups          int
+	deserializations int
+}
+
+func (p *countingDeserializerProvider) GetIdentityDeserializer(chainID string) msp.IdentityDeserializer {
+	p.lookups++
+	return countingDeserializer{p, mspmgmt.GetIdentityDeserializer(chainID)}
+}
+
+type countingDeserializer struct {
+	p *countingDeserializerProvider
+	msp.IdentityDeserializer
+}
+
+func (d countingDeserializer) DeserializeIdentity(serializedIdentity []byte) (msp.Identity, error) {
+	d.p.deserializations++
+	return d.IdentityDeserializer.DeserializeIdentity(serializedIdentity)
+}
+
+func TestValidateProposalBatch(t *testing.T) {
+	var props []*peer.SignedProposal
+	for i := 0; i < 3; i++ {
+		prop, err := getProposal()
+		if err != nil {
+			t.Fatalf("getProposal failed, err %s", err)
+		}
+		sProp, err := utils.GetSignedProposal(prop, signer)
+		if err != nil {
+			t.Fatalf("GetSignedProposal failed, err %s", err)
+		}
+		props = append(props, sProp)
+	}
+
+	badSig := &peer.SignedProposal{ProposalBytes: props[0].ProposalBytes, Signature: []byte("signature")}
+	undecodable := &peer.SignedProposal{ProposalBytes: []byte("garbage"), Signature: []byte("signature")}
+	batch := []*peer.SignedProposal{props[0], badSig, props[1], nil, undecodable, props[2]}
+	valid := []bool{true, false, true, false, false, true}
+
+	p := &countingDeserializerProvider{}
+	results, errs := (&Validator{DeserializerProvider: p}).ValidateProposalBatch(batch)
+	if len(results) != len(batch) || len(errs) != len(batch) {
+		t.Fatalf("Expected %d results and errors, got %d and %d", len(batch), len(results), len(errs))
+	}
+
+	for i := range batch {
+		if valid[i] != (errs[i] == nil) || valid[i] != (results[i] != nil) {
+			t.Fatalf("Proposal %d: expected valid %t, got result %v and err %v", i, valid[i], results[i], errs[i])
+		}
+		if valid[i] && results[i].Header.ChannelHeader.ChannelId != util.GetTestChainID() {
+			t.Fatalf("Proposal %d: unexpected header %s", i, results[i].Header)
+		}
+	}
+
+	// the deserializer and the identity of the creator are shared
+	if p.lookups != 1 || p.deserializations != 1 {
+		t.Fatalf("Expected a single lookup and deserialization, got %d and %d", p.lookups, p.deserializations)
+	}
+
+	// while they are not across calls of ValidateProposalMessage
+	p = &countingDeserializerProvider{}
+	for _, sProp := range props {
+		_, _, _, err := (&Validator{DeserializerProvider: p}).ValidateProposalMessage(sProp)
+		if err != nil {
+			t.Fatalf("ValidateProposalMessage failed, err %s", err)
+		}
+	}
+	if p.lookups != len(props) || p.deserializations != len(props) {
+		t.Fatalf("Expected %d lookups and deserializations, got %d and %d", len(props), p.lookups, p.deserializations)
+	}
+}
@@ -0,0 +1,80 @@
+/*
+Copyright IBM Corp. 2017 All Rights Reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+		 http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package validation
+
+import (
+	"testing"
+
+	"github.com/hyperledger/fabric/common/util"
+	"github.com/hyperledger/fabric/protos/common"
+	"github.com/hyperledger/fabric/protos/peer"
+	"github.com/hyperledger/fabric/protos/utils"
+)
+
+// getSignedProposalOfType returns a signed proposal of the given type
+// invoking the given chaincode, if any
+func getSignedProposalOfType(t *testing.T, typ common.HeaderType, chainID string, ccID *peer.ChaincodeID) *peer.SignedProposal {
+	cis := &peer.ChaincodeInvocationSpec{
+		ChaincodeSpec: &peer.ChaincodeSpec{
+			ChaincodeId: ccID,
+			Type:        peer.ChaincodeSpec_GOLANG}}
+
+	prop, _, err := utils.CreateProposalFromCIS(typ, chainID, cis, signerSerialized)
+	if err != nil {
+		t.Fatalf("CreateProposalFromCIS failed, err %s", err)
+	}
+
+	sProp, err := utils.GetSignedProposal(prop, signer)
+	if err != nil {
+		t.Fatalf("GetSignedProposal failed, err %s", err)
+	}
+
+	return sProp
+}
+
+func TestProposalTypeExtension(t *testing.T) {
+	tests := []struct {
+		name    string
+		typ     common.HeaderType
+		chainID string
+		ccID    *peer.ChaincodeID
+		valid   bool
+	}{
+		{"Endorser", common.HeaderType_ENDORSER_TRANSACTION, util.GetTestChainID(), &peer.ChaincodeID{Name: "foo"}, true},
+		{"EndorserSystemChaincode", common.HeaderType_ENDORSER_TRANSACTION, util.GetTestChainID(), &peer.ChaincodeID{Name: ConfigProposalChaincode}, true},
+		{"EndorserNoChaincode", common.HeaderType_ENDORSER_TRANSACTION, util.GetTestChainID(), nil, false},
+		{"EndorserNoChaincodeName", common.HeaderType_ENDORSER_TRANSACTION, util.GetTestChainID(), &peer.ChaincodeID{Version: "1.0"}, false},
+		{"Config", common.HeaderType_CONFIG, "", &peer.ChaincodeID{Name: ConfigProposalChaincode}, true},
+		{"ConfigUserChaincode", common.HeaderType_CONFIG, "", &peer.ChaincodeID{Name: "foo"}, false},
+		{"ConfigNoChaincode", common.HeaderType_CONFIG, "", nil, false},
+	}
+
+	for _, test := range tests {
+		_, _, hdrExt, err := ValidateProposalMessage(getSignedProposalOfType(t, test.typ, test.chainID, test.ccID))
+		if test.valid {
+			if err != nil {
+				t.Fatalf("%s: ValidateProposalMessage failed, err %s", test.name, err)
+			}
+			if hdrExt.ChaincodeId.Name != test.ccID.Name {
+				t.Fatalf("%s: expected chaincode %s, got %s", test.name, test.ccID.Name, hdrExt.ChaincodeId.Name)
+			}
+		}
+		if !test.valid && err == nil {
+			t.Fatalf("%s: ValidateProposalMessage should have failed", test.name)
+		}
+	}
+}
@@ -0,0 +1,67 @@
+/*
+Copyright IBM Corp. 2017 All Rights Reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+		 http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package validation
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/hyperledger/fabric/protos/common"
+	"golang.org/x/net/context"
+)
+
+// ErrStaleReadCommitment is returned in strict mode for transactions whose
+// creator committed to reading a ledger height that is implausibly stale
+var ErrStaleReadCommitment = errors.New("The transaction commits to a stale ledger height")
+
+// checkReadCommitment flags the transactions committing, in the ReadHeight
+// field of their chaincode header extension, to a ledger height more than
+// MaxReadStaleness blocks behind the current height of the ledger of their
+// channel, as configured by ReadCommitmentMode, which must not be off, with
+// the LedgerHeightProvider of the validator. Unlike the versions in the
+// read-write sets, checked by validateReadStaleness, the height is declared
+// by the client, so that clients reading from lagging peers can be detected
+// before the endorsements are even checked
+func (v *Validator) checkReadCommitment(ctx context.Context, chdr *common.ChannelHeader) error {
+	ext, err := getHeaderExtension(ctx, chdr)
+	if err != nil {
+		return err
+	}
+
+	committed := ext.ReadHeight
+	if committed == 0 {
+		return nil
+	}
+
+	height, err := v.LedgerHeightProvider.GetLedgerHeight(chdr.ChannelId)
+	if err != nil {
+		return fmt.Errorf("Could not get the height of the ledger of chain [%s], err %s", chdr.ChannelId, err)
+	}
+
+	// clients may have read from peers ahead of this one
+	if committed >= height || height-committed <= v.MaxReadStaleness {
+		return nil
+	}
+
+	if v.ReadCommitmentMode == HeuristicsStrict {
+		putilsLogger.Errorf("Transaction [%s] rejected: it commits to ledger height %d, %d blocks behind the ledger height %d", chdr.TxId, committed, height-committed, height)
+		return ErrStaleReadCommitment
+	}
+
+	addWarning(ctx, WarningFlagged, "Transaction [%s] flagged: it commits to ledger height %d, %d blocks behind the ledger height %d", chdr.TxId, committed, height-committed, height)
+	return nil
+}
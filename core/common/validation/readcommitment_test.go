@@ -0,0 +1,86 @@
+/*
+Copyright IBM Corp. 2017 All Rights Reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+		 http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package validation
+
+import (
+	"testing"
+
+	"github.com/hyperledger/fabric/common/util"
+	"github.com/hyperledger/fabric/protos/common"
+	"github.com/hyperledger/fabric/protos/peer"
+	"github.com/hyperledger/fabric/protos/utils"
+)
+
+// getTransactionReadingAt returns a signed transaction whose creator commits
+// to having read the ledger at the given height, if positive
+func getTransactionReadingAt(t *testing.T, height uint64) *common.Envelope {
+	prop, err := getProposal()
+	if err != nil {
+		t.Fatalf("getProposal failed, err %s", err)
+	}
+
+	if height > 0 {
+		hdr, err := utils.GetHeader(prop.Header)
+		if err != nil {
+			t.Fatalf("GetHeader failed, err %s", err)
+		}
+		ext := utils.MarshalOrPanic(&peer.ChaincodeHeaderExtension{ReadHeight: height})
+		hdr.ChannelHeader.Extension = append(hdr.ChannelHeader.Extension, ext...)
+		prop.Header = utils.MarshalOrPanic(hdr)
+	}
+
+	tx, err := getTransactionForProposal(prop, getRWSetBytes(t, "foo"))
+	if err != nil {
+		t.Fatalf("getTransactionForProposal failed, err %s", err)
+	}
+
+	return tx
+}
+
+func TestReadCommitment(t *testing.T) {
+	heights := mockLedgerHeightProvider{util.GetTestChainID(): 100}
+
+	tests := []struct {
+		name   string
+		height uint64
+		mode   HeuristicsMode
+		err    error
+	}{
+		{"Fresh", 95, HeuristicsStrict, nil},
+		{"AtLimit", 90, HeuristicsStrict, nil},
+		{"Stale", 89, HeuristicsStrict, ErrStaleReadCommitment},
+		{"StaleLenient", 89, HeuristicsLenient, nil},
+		{"StaleOff", 89, HeuristicsOff, nil},
+		{"Ahead", 120, HeuristicsStrict, nil},
+		{"NoCommitment", 0, HeuristicsStrict, nil},
+	}
+
+	for _, test := range tests {
+		v := &Validator{LedgerHeightProvider: heights, MaxReadStaleness: 10, ReadCommitmentMode: test.mode}
+		_, err := v.ValidateTransaction(getTransactionReadingAt(t, test.height))
+		if err != test.err {
+			t.Fatalf("%s: expected err %v, got %v", test.name, test.err, err)
+		}
+	}
+
+	// the commitment cannot be checked without ledger heights
+	v := &Validator{MaxReadStaleness: 10, ReadCommitmentMode: HeuristicsStrict}
+	_, err := v.ValidateTransaction(getTransactionReadingAt(t, 1))
+	if err != nil {
+		t.Fatalf("ValidateTransaction failed, err %s", err)
+	}
+}
@@ -0,0 +1,84 @@
+/*
+Copyright IBM Corp. 2017 All Rights Reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+		 http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package validation
+
+import (
+	"fmt"
+
+	"github.com/hyperledger/fabric/core/ledger/kvledger/txmgmt/rwset"
+	"golang.org/x/net/context"
+)
+
+// writeCounter counts the writes of the read-write sets extracted from the
+// actions of a transaction
+type writeCounter struct {
+	// actions is the number of actions whose read-write set was extracted
+	actions int
+
+	// writes is the number of keys written by those actions
+	writes int
+
+	// undecodable is set if the read-write set of an action could not be
+	// extracted, so that its writes are unknown
+	undecodable bool
+}
+
+// writeCounterKey is the key of the write counter in contexts
+type writeCounterKey struct{}
+
+// withWriteCounter returns a context holding a new write counter, along
+// with the counter
+func withWriteCounter(ctx context.Context) (context.Context, *writeCounter) {
+	counter := &writeCounter{}
+	return context.WithValue(ctx, writeCounterKey{}, counter), counter
+}
+
+// getRWSet extracts the read-write set from the simulation results of an
+// action; it is unmarshalled once and shared by all the checks on it
+func getRWSet(results []byte) (*rwset.TxReadWriteSet, error) {
+	txRWSet := &rwset.TxReadWriteSet{}
+	if err := txRWSet.Unmarshal(results); err != nil {
+		return nil, permanentDecodeError(fmt.Errorf("Could not unmarshal the read-write set, err %s", err))
+	}
+
+	return txRWSet, nil
+}
+
+// countWrites counts the writes of the read-write set of an action, or nil
+// if it could not be extracted, in the counter held by the context, if any
+func countWrites(ctx context.Context, txRWSet *rwset.TxReadWriteSet) {
+	counter, ok := ctx.Value(writeCounterKey{}).(*writeCounter)
+	if !ok {
+		return
+	}
+
+	if txRWSet == nil {
+		counter.undecodable = true
+		return
+	}
+
+	counter.actions++
+	for _, nsRWSet := range txRWSet.NsRWs {
+		counter.writes += len(nsRWSet.Writes)
+	}
+}
+
+// readOnly tells whether the read-write sets of all the actions were
+// extracted and none of them writes any key
+func (c *writeCounter) readOnly() bool {
+	return c.actions > 0 && !c.undecodable && c.writes == 0
+}
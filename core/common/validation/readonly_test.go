@@ -0,0 +1,78 @@
+/*
+Copyright IBM Corp. 2017 All Rights Reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+		 http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package validation
+
+import (
+	"testing"
+
+	"github.com/hyperledger/fabric/core/ledger/kvledger/txmgmt/rwset"
+	"github.com/hyperledger/fabric/core/ledger/kvledger/txmgmt/version"
+)
+
+// getRWSetWithWrites returns a read-write set reading a key and writing the
+// given number of keys
+func getRWSetWithWrites(t *testing.T, writes int) []byte {
+	nsRWSet := &rwset.NsReadWriteSet{
+		NameSpace: "foo",
+		Reads:     []*rwset.KVRead{rwset.NewKVRead("key", version.NewHeight(1, 0))},
+	}
+	for i := 0; i < writes; i++ {
+		nsRWSet.Writes = append(nsRWSet.Writes, rwset.NewKVWrite(string('a'+rune(i)), []byte("value")))
+	}
+
+	rwsetBytes, err := (&rwset.TxReadWriteSet{NsRWs: []*rwset.NsReadWriteSet{nsRWSet}}).Marshal()
+	if err != nil {
+		t.Fatalf("Marshal failed, err %s", err)
+	}
+
+	return rwsetBytes
+}
+
+func TestIsReadOnly(t *testing.T) {
+	readOnly := getRWSetWithWrites(t, 0)
+	readWrite := getRWSetWithWrites(t, 2)
+
+	tests := []struct {
+		name     string
+		results  [][]byte
+		v        *Validator
+		readOnly bool
+	}{
+		{"ReadOnly", [][]byte{readOnly}, &Validator{RWSetLimits: &RWSetLimits{}}, true},
+		{"ReadWrite", [][]byte{readWrite}, &Validator{RWSetLimits: &RWSetLimits{}}, false},
+		{"ReadOnlyActions", [][]byte{readOnly, readOnly}, &Validator{RWSetLimits: &RWSetLimits{}}, true},
+		{"WritingAction", [][]byte{readOnly, readWrite}, &Validator{RWSetLimits: &RWSetLimits{}}, false},
+		// the read-write sets are extracted without checks on them
+		{"ReadOnlyUnchecked", [][]byte{readOnly}, &Validator{}, true},
+		{"ReadWriteUnchecked", [][]byte{readWrite}, &Validator{}, false},
+		// the writes of undecodable read-write sets are unknown
+		{"Undecodable", [][]byte{readOnly, []byte("garbage")}, &Validator{}, false},
+	}
+
+	for _, test := range tests {
+		result, err := test.v.Validate(getTransactionWithResults(t, test.results...))
+		if err != nil {
+			t.Fatalf("%s: Validate failed, err %s", test.name, err)
+		}
+		if result.IsReadOnly != test.readOnly {
+			t.Fatalf("%s: expected IsReadOnly %t, got %t", test.name, test.readOnly, result.IsReadOnly)
+		}
+		if result.Copy().IsReadOnly != test.readOnly {
+			t.Fatalf("%s: IsReadOnly not copied", test.name)
+		}
+	}
+}
@@ -0,0 +1,85 @@
+/*
+Copyright IBM Corp. 2017 All Rights Reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+		 http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package validation
+
+import (
+	"sync"
+	"time"
+)
+
+// ReplayStore stores the state of the anti-replay trackers, so that it may
+// outlive the peer process when backed by a persistent store (e.g. a local
+// database or a remote key-value service)
+type ReplayStore interface {
+	// Get returns the value stored under the given key, or false if there
+	// is none or it has expired
+	Get(key string) ([]byte, bool, error)
+
+	// Put stores the value under the given key, replacing any previous
+	// value, until the TTL elapses; a zero TTL never expires
+	Put(key string, value []byte, ttl time.Duration) error
+}
+
+// memoryReplayStore is a ReplayStore held in memory, whose content is lost
+// when the process exits
+type memoryReplayStore struct {
+	sync.Mutex
+	entries map[string]memoryReplayEntry
+	now     func() time.Time
+}
+
+// memoryReplayEntry is a value of a memoryReplayStore along with its expiry;
+// the zero expiry never expires
+type memoryReplayEntry struct {
+	value  []byte
+	expiry time.Time
+}
+
+// NewMemoryReplayStore returns an empty ReplayStore held in memory
+func NewMemoryReplayStore() ReplayStore {
+	return &memoryReplayStore{entries: make(map[string]memoryReplayEntry), now: time.Now}
+}
+
+func (s *memoryReplayStore) Get(key string) ([]byte, bool, error) {
+	s.Lock()
+	defer s.Unlock()
+
+	entry, ok := s.entries[key]
+	if !ok {
+		return nil, false, nil
+	}
+
+	if !entry.expiry.IsZero() && !s.now().Before(entry.expiry) {
+		delete(s.entries, key)
+		return nil, false, nil
+	}
+
+	return entry.value, true, nil
+}
+
+func (s *memoryReplayStore) Put(key string, value []byte, ttl time.Duration) error {
+	entry := memoryReplayEntry{value: append([]byte(nil), value...)}
+	if ttl > 0 {
+		entry.expiry = s.now().Add(ttl)
+	}
+
+	s.Lock()
+	s.entries[key] = entry
+	s.Unlock()
+
+	return nil
+}
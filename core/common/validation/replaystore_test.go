@@ -0,0 +1,145 @@
+/*
+Copyright IBM Corp. 2017 All Rights Reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+		 http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package validation
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+// mockPersistentStore is a ReplayStore standing for a persistent backend:
+// its content outlives the trackers using it, as it would a restart
+type mockPersistentStore struct {
+	values map[string][]byte
+	ttls   map[string]time.Duration
+	err    error
+}
+
+func newMockPersistentStore() *mockPersistentStore {
+	return &mockPersistentStore{values: make(map[string][]byte), ttls: make(map[string]time.Duration)}
+}
+
+func (s *mockPersistentStore) Get(key string) ([]byte, bool, error) {
+	if s.err != nil {
+		return nil, false, s.err
+	}
+
+	value, ok := s.values[key]
+	return value, ok, nil
+}
+
+func (s *mockPersistentStore) Put(key string, value []byte, ttl time.Duration) error {
+	if s.err != nil {
+		return s.err
+	}
+
+	s.values[key] = value
+	s.ttls[key] = ttl
+	return nil
+}
+
+func TestMemoryReplayStore(t *testing.T) {
+	now := time.Unix(1496275200, 0)
+	store := NewMemoryReplayStore().(*memoryReplayStore)
+	store.now = func() time.Time { return now }
+
+	store.Put("forever", []byte("a"), 0)
+	store.Put("minute", []byte("b"), time.Minute)
+
+	tests := []struct {
+		name  string
+		key   string
+		after time.Duration
+		value string
+		found bool
+	}{
+		{"Missing", "missing", 0, "", false},
+		{"NoTTL", "forever", 0, "a", true},
+		{"NotExpired", "minute", 30 * time.Second, "b", true},
+		{"Expired", "minute", time.Minute, "", false},
+		{"NoTTLLater", "forever", 24 * time.Hour, "a", true},
+	}
+
+	for _, test := range tests {
+		now = time.Unix(1496275200, 0).Add(test.after)
+		value, found, err := store.Get(test.key)
+		if err != nil {
+			t.Fatalf("%s: Get failed, err %s", test.name, err)
+		}
+		if found != test.found || string(value) != test.value {
+			t.Fatalf("%s: expected %q, %v, got %q, %v", test.name, test.value, test.found, value, found)
+		}
+	}
+
+	// expired entries are dropped
+	if _, ok := store.entries["minute"]; ok {
+		t.Fatalf("The expired entry should have been dropped")
+	}
+}
+
+func TestSequenceTrackerRestart(t *testing.T) {
+	store := newMockPersistentStore()
+
+	v := &Validator{SequenceTracker: NewSequenceTrackerWithStore(store, time.Hour)}
+	for _, seq := range []uint64{1, 2, 3} {
+		_, err := v.ValidateTransaction(getSequencedTransaction(t, seq))
+		if err != nil {
+			t.Fatalf("ValidateTransaction failed for sequence number %d, err %s", seq, err)
+		}
+	}
+	for _, ttl := range store.ttls {
+		if ttl != time.Hour {
+			t.Fatalf("Expected the sequence numbers to be stored for an hour, got %s", ttl)
+		}
+	}
+
+	// a tracker held in memory forgets the sequence numbers on restart
+	v = &Validator{SequenceTracker: NewSequenceTracker()}
+	_, err := v.ValidateTransaction(getSequencedTransaction(t, 3))
+	if err != nil {
+		t.Fatalf("ValidateTransaction failed, err %s", err)
+	}
+
+	// while one backed by the persistent store still detects replays
+	v = &Validator{SequenceTracker: NewSequenceTrackerWithStore(store, time.Hour)}
+	_, err = v.ValidateTransaction(getSequencedTransaction(t, 3))
+	if err != ErrSequenceOutOfOrder {
+		t.Fatalf("Expected err %v, got %v", ErrSequenceOutOfOrder, err)
+	}
+	_, err = v.ValidateTransaction(getSequencedTransaction(t, 4))
+	if err != nil {
+		t.Fatalf("ValidateTransaction failed, err %s", err)
+	}
+
+	// the failures of the store fail the validation
+	store.err = errors.New("store unavailable")
+	_, err = v.ValidateTransaction(getSequencedTransaction(t, 5))
+	if err == nil || err == ErrSequenceOutOfOrder {
+		t.Fatalf("ValidateTransaction should have failed getting the sequence number, got %v", err)
+	}
+
+	// as do corrupted sequence numbers
+	store.err = nil
+	for key := range store.values {
+		store.values[key] = []byte("corrupted")
+	}
+	_, err = v.ValidateTransaction(getSequencedTransaction(t, 5))
+	if err == nil || err == ErrSequenceOutOfOrder {
+		t.Fatalf("ValidateTransaction should have failed decoding the sequence number, got %v", err)
+	}
+}
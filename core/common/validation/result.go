@@ -0,0 +1,91 @@
+/*
+Copyright IBM Corp. 2017 All Rights Reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+		 http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package validation
+
+import (
+	"github.com/golang/protobuf/proto"
+	"github.com/hyperledger/fabric/core/common/ccprovider"
+	"github.com/hyperledger/fabric/protos/common"
+)
+
+// ValidationResult holds the outcome of the validation of a transaction.
+// A result, and the structures it references, must be treated as read-only
+// as they may be shared with the caller and with concurrent validations
+type ValidationResult struct {
+	// Envelope is the validated envelope
+	Envelope *common.Envelope
+
+	// Payload is the payload decoded from the envelope, if any
+	Payload *common.Payload
+
+	// ChaincodeDefinition is the definition of the chaincode invoked by
+	// the transaction, in the version it was endorsed against, if the
+	// validator pins chaincode versions
+	ChaincodeDefinition *ccprovider.ChaincodeData
+
+	// IsReadOnly is set if the read-write sets of the actions of the
+	// endorser transaction, always extracted, are well formed and none of
+	// them writes any key, so that the transaction cannot mutate the world
+	// state
+	IsReadOnly bool
+
+	// Steps holds the steps of the validation, in the order they were
+	// performed, if the validator records them
+	Steps []ValidationStep
+
+	// Stats counts the work performed by the validation, if the validator
+	// records it
+	Stats *ValidationStats
+
+	// Warnings holds the borderline conditions met by the transaction,
+	// which do not fail its validation, see WarningCode
+	Warnings []ValidationWarning
+
+	// CacheOperations holds the operations on the caches of the validator
+	// performed by the validation, which were not actually performed on
+	// them, if the validator runs in dry-run mode
+	CacheOperations []CacheOperation
+}
+
+// Copy returns a deep copy of the result, which may be freely mutated
+func (r *ValidationResult) Copy() *ValidationResult {
+	c := &ValidationResult{IsReadOnly: r.IsReadOnly}
+	if r.Envelope != nil {
+		c.Envelope = proto.Clone(r.Envelope).(*common.Envelope)
+	}
+	if r.Payload != nil {
+		c.Payload = proto.Clone(r.Payload).(*common.Payload)
+	}
+	if r.ChaincodeDefinition != nil {
+		c.ChaincodeDefinition = proto.Clone(r.ChaincodeDefinition).(*ccprovider.ChaincodeData)
+	}
+	if r.Steps != nil {
+		c.Steps = append([]ValidationStep(nil), r.Steps...)
+	}
+	if r.Stats != nil {
+		stats := *r.Stats
+		c.Stats = &stats
+	}
+	if r.Warnings != nil {
+		c.Warnings = append([]ValidationWarning(nil), r.Warnings...)
+	}
+	if r.CacheOperations != nil {
+		c.CacheOperations = append([]CacheOperation(nil), r.CacheOperations...)
+	}
+
+	return c
+}
@@ -0,0 +1,169 @@
+/*
+Copyright IBM Corp. 2017 All Rights Reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+		 http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package validation
+
+import (
+	"errors"
+
+	"github.com/hyperledger/fabric/core/ledger/kvledger/txmgmt/rwset"
+)
+
+// ErrRWSetTooLarge is returned for actions whose read-write set exceeds
+// the limits of the validator
+var ErrRWSetTooLarge = errors.New("The read-write set exceeds the limits")
+
+// ErrMalformedRWSet is returned for actions whose read-write set is not
+// structurally valid
+var ErrMalformedRWSet = errors.New("The read-write set is malformed")
+
+const (
+	// DefaultMaxRWSetKeySize is the default maximum size of a key
+	DefaultMaxRWSetKeySize = 64 * 1024
+
+	// DefaultMaxRWSetValueSize is the default maximum size of a value
+	DefaultMaxRWSetValueSize = 64 * 1024 * 1024
+
+	// DefaultMaxRWSetKeys is the default maximum number of keys read or
+	// written by an action, across all its namespaces
+	DefaultMaxRWSetKeys = 100000
+
+	// DefaultMaxRWSetWrites is the default maximum number of keys written
+	// by an action, across all its namespaces
+	DefaultMaxRWSetWrites = 50000
+)
+
+// RWSetLimits bounds the read-write sets of the actions; zero fields use
+// the default limits
+type RWSetLimits struct {
+	// MaxKeySize is the maximum size of a key read or written
+	MaxKeySize int
+
+	// MaxValueSize is the maximum size of a value written
+	MaxValueSize int
+
+	// MaxKeys is the maximum number of reads, writes and range query
+	// results of an action
+	MaxKeys int
+
+	// MaxWrites is the maximum number of writes of an action; each key
+	// may be written once per namespace, see validateRWSetStructure
+	MaxWrites int
+}
+
+func (l *RWSetLimits) maxKeySize() int {
+	if l.MaxKeySize == 0 {
+		return DefaultMaxRWSetKeySize
+	}
+	return l.MaxKeySize
+}
+
+func (l *RWSetLimits) maxValueSize() int {
+	if l.MaxValueSize == 0 {
+		return DefaultMaxRWSetValueSize
+	}
+	return l.MaxValueSize
+}
+
+func (l *RWSetLimits) maxKeys() int {
+	if l.MaxKeys == 0 {
+		return DefaultMaxRWSetKeys
+	}
+	return l.MaxKeys
+}
+
+func (l *RWSetLimits) maxWrites() int {
+	if l.MaxWrites == 0 {
+		return DefaultMaxRWSetWrites
+	}
+	return l.MaxWrites
+}
+
+// validateRWSetStructure ensures that the read-write set of an action is
+// within the limits of the validator and well formed: each namespace
+// appears once, and within a namespace each key is read at most once and
+// written at most once
+func (v *Validator) validateRWSetStructure(txRWSet *rwset.TxReadWriteSet) error {
+	limits := v.RWSetLimits
+	keys := 0
+	writeCount := 0
+	namespaces := make(map[string]struct{}, len(txRWSet.NsRWs))
+	for _, nsRWSet := range txRWSet.NsRWs {
+		if _, ok := namespaces[nsRWSet.NameSpace]; ok {
+			putilsLogger.Errorf("validateRWSetStructure error: namespace %s appears more than once", nsRWSet.NameSpace)
+			return ErrMalformedRWSet
+		}
+		namespaces[nsRWSet.NameSpace] = struct{}{}
+
+		keys += len(nsRWSet.Reads) + len(nsRWSet.Writes)
+		for _, rqi := range nsRWSet.RangeQueriesInfo {
+			keys += len(rqi.Results)
+		}
+		if keys > limits.maxKeys() {
+			putilsLogger.Errorf("validateRWSetStructure error: more than %d keys", limits.maxKeys())
+			return ErrRWSetTooLarge
+		}
+
+		writeCount += len(nsRWSet.Writes)
+		if writeCount > limits.maxWrites() {
+			putilsLogger.Errorf("validateRWSetStructure error: more than %d writes", limits.maxWrites())
+			return ErrRWSetTooLarge
+		}
+
+		reads := make(map[string]struct{}, len(nsRWSet.Reads))
+		for _, read := range nsRWSet.Reads {
+			if err := checkRWSetKey(nsRWSet.NameSpace, read.Key, reads, limits); err != nil {
+				return err
+			}
+		}
+
+		writes := make(map[string]struct{}, len(nsRWSet.Writes))
+		for _, write := range nsRWSet.Writes {
+			if err := checkRWSetKey(nsRWSet.NameSpace, write.Key, writes, limits); err != nil {
+				return err
+			}
+
+			if len(write.Value) > limits.maxValueSize() {
+				putilsLogger.Errorf("validateRWSetStructure error: value of key %s of namespace %s is %d bytes long, more than %d", write.Key, nsRWSet.NameSpace, len(write.Value), limits.maxValueSize())
+				return ErrRWSetTooLarge
+			}
+		}
+	}
+
+	return nil
+}
+
+// checkRWSetKey ensures that a key is neither empty, too large nor already
+// in seen, and adds it to seen
+func checkRWSetKey(ns, key string, seen map[string]struct{}, limits *RWSetLimits) error {
+	if key == "" {
+		putilsLogger.Errorf("validateRWSetStructure error: empty key in namespace %s", ns)
+		return ErrMalformedRWSet
+	}
+
+	if len(key) > limits.maxKeySize() {
+		putilsLogger.Errorf("validateRWSetStructure error: key of namespace %s is %d bytes long, more than %d", ns, len(key), limits.maxKeySize())
+		return ErrRWSetTooLarge
+	}
+
+	if _, ok := seen[key]; ok {
+		putilsLogger.Errorf("validateRWSetStructure error: key %s of namespace %s appears more than once", key, ns)
+		return ErrMalformedRWSet
+	}
+	seen[key] = struct{}{}
+
+	return nil
+}
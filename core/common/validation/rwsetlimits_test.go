@@ -0,0 +1,107 @@
+/*
+Copyright IBM Corp. 2017 All Rights Reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+		 http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package validation
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/hyperledger/fabric/core/ledger/kvledger/txmgmt/rwset"
+	"github.com/hyperledger/fabric/core/ledger/kvledger/txmgmt/version"
+)
+
+func TestRWSetLimits(t *testing.T) {
+	read := rwset.NewKVRead("key", version.NewHeight(1, 0))
+	write := rwset.NewKVWrite("key", []byte("value"))
+
+	tests := []struct {
+		name   string
+		nsRWs  []*rwset.NsReadWriteSet
+		limits *RWSetLimits
+		err    error
+	}{
+		{"ReadModifyWrite", []*rwset.NsReadWriteSet{
+			{NameSpace: "foo", Reads: []*rwset.KVRead{read}, Writes: []*rwset.KVWrite{write}},
+		}, &RWSetLimits{}, nil},
+		{"SameKeyInTwoNamespaces", []*rwset.NsReadWriteSet{
+			{NameSpace: "foo", Writes: []*rwset.KVWrite{write}},
+			{NameSpace: "bar", Writes: []*rwset.KVWrite{write}},
+		}, &RWSetLimits{}, nil},
+		{"Delete", []*rwset.NsReadWriteSet{
+			{NameSpace: "foo", Writes: []*rwset.KVWrite{rwset.NewKVWrite("key", nil)}},
+		}, &RWSetLimits{}, nil},
+		{"TooManyKeys", []*rwset.NsReadWriteSet{
+			{NameSpace: "foo", Reads: []*rwset.KVRead{read}, Writes: []*rwset.KVWrite{write}},
+			{NameSpace: "bar", Reads: []*rwset.KVRead{read}},
+		}, &RWSetLimits{MaxKeys: 2}, ErrRWSetTooLarge},
+		{"TooManyRangeQueryResults", []*rwset.NsReadWriteSet{
+			{NameSpace: "foo", RangeQueriesInfo: []*rwset.RangeQueryInfo{
+				{StartKey: "a", EndKey: "z", ItrExhausted: true, Results: []*rwset.KVRead{read, read, read}},
+			}},
+		}, &RWSetLimits{MaxKeys: 2}, ErrRWSetTooLarge},
+		{"WritesAtLimit", []*rwset.NsReadWriteSet{
+			{NameSpace: "foo", Reads: []*rwset.KVRead{read}, Writes: []*rwset.KVWrite{write, rwset.NewKVWrite("key2", nil)}},
+			{NameSpace: "bar", Writes: []*rwset.KVWrite{write}},
+		}, &RWSetLimits{MaxWrites: 3}, nil},
+		{"TooManyWrites", []*rwset.NsReadWriteSet{
+			{NameSpace: "foo", Writes: []*rwset.KVWrite{write, rwset.NewKVWrite("key2", nil)}},
+			{NameSpace: "bar", Writes: []*rwset.KVWrite{write, rwset.NewKVWrite("key2", nil)}},
+		}, &RWSetLimits{MaxWrites: 3}, ErrRWSetTooLarge},
+		{"KeyTooLarge", []*rwset.NsReadWriteSet{
+			{NameSpace: "foo", Reads: []*rwset.KVRead{rwset.NewKVRead("large_key", nil)}},
+		}, &RWSetLimits{MaxKeySize: 4}, ErrRWSetTooLarge},
+		{"ValueTooLarge", []*rwset.NsReadWriteSet{
+			{NameSpace: "foo", Writes: []*rwset.KVWrite{rwset.NewKVWrite("key", bytes.Repeat([]byte{1}, 5))}},
+		}, &RWSetLimits{MaxValueSize: 4}, ErrRWSetTooLarge},
+		{"DuplicateNamespace", []*rwset.NsReadWriteSet{
+			{NameSpace: "foo", Reads: []*rwset.KVRead{read}},
+			{NameSpace: "foo", Writes: []*rwset.KVWrite{write}},
+		}, &RWSetLimits{}, ErrMalformedRWSet},
+		{"DuplicateRead", []*rwset.NsReadWriteSet{
+			{NameSpace: "foo", Reads: []*rwset.KVRead{read, rwset.NewKVRead("key", version.NewHeight(2, 0))}},
+		}, &RWSetLimits{}, ErrMalformedRWSet},
+		{"DuplicateWrite", []*rwset.NsReadWriteSet{
+			{NameSpace: "foo", Writes: []*rwset.KVWrite{write, rwset.NewKVWrite("key", nil)}},
+		}, &RWSetLimits{}, ErrMalformedRWSet},
+		{"EmptyKey", []*rwset.NsReadWriteSet{
+			{NameSpace: "foo", Writes: []*rwset.KVWrite{rwset.NewKVWrite("", []byte("value"))}},
+		}, &RWSetLimits{}, ErrMalformedRWSet},
+	}
+
+	for _, test := range tests {
+		rwsetBytes, err := (&rwset.TxReadWriteSet{NsRWs: test.nsRWs}).Marshal()
+		if err != nil {
+			t.Fatalf("%s: Marshal failed, err %s", test.name, err)
+		}
+
+		tx, err := getTransaction(rwsetBytes)
+		if err != nil {
+			t.Fatalf("%s: getTransaction failed, err %s", test.name, err)
+		}
+
+		_, err = (&Validator{RWSetLimits: test.limits}).ValidateTransaction(tx)
+		if err != test.err {
+			t.Fatalf("%s: expected err %v, got %v", test.name, test.err, err)
+		}
+
+		// the read-write set is not checked by default
+		_, err = (&Validator{}).ValidateTransaction(tx)
+		if err != nil {
+			t.Fatalf("%s: ValidateTransaction failed, err %s", test.name, err)
+		}
+	}
+}
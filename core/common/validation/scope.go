@@ -0,0 +1,147 @@
+/*
+Copyright IBM Corp. 2017 All Rights Reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+		 http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package validation
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/hyperledger/fabric/common/configtx"
+	"github.com/hyperledger/fabric/protos/common"
+	"golang.org/x/net/context"
+)
+
+var (
+	// ErrEndorserTxMissingSignature is returned for endorser transactions
+	// whose envelope is not signed
+	ErrEndorserTxMissingSignature = errors.New("endorser transaction missing outer signature")
+
+	// ErrEndorserTxMissingCreator is returned for endorser transactions
+	// whose header names no creator
+	ErrEndorserTxMissingCreator = errors.New("endorser transaction missing creator")
+
+	// ErrConfigTxMissingSignature is returned for config transactions
+	// naming a creator but whose envelope is not signed
+	ErrConfigTxMissingSignature = errors.New("config transaction missing outer signature")
+
+	// ErrConfigTxMissingCreator is returned for config transactions whose
+	// envelope is signed but whose header names no creator
+	ErrConfigTxMissingCreator = errors.New("config transaction missing creator")
+
+	// ErrUnsignedConfigTx is returned for config transactions with neither
+	// outer signature nor creator that do not carry a genesis config
+	ErrUnsignedConfigTx = errors.New("unsigned config transaction is not a genesis config")
+)
+
+// genesisKey is the key of the flag telling that the envelope being
+// validated is from the genesis block in contexts
+type genesisKey struct{}
+
+// withGenesis returns a context telling that the envelope being validated
+// is from the genesis block, block 0
+func withGenesis(ctx context.Context) context.Context {
+	return context.WithValue(ctx, genesisKey{}, true)
+}
+
+// isGenesis tells whether the context states that the envelope being
+// validated is from the genesis block
+func isGenesis(ctx context.Context) bool {
+	genesis, _ := ctx.Value(genesisKey{}).(bool)
+	return genesis
+}
+
+// isUnsignedGenesisConfig checks whether the outer signature of an envelope
+// is required by the type of its payload, and tells whether the envelope is
+// an unsigned genesis config: endorser transactions must always be signed by
+// their creator, while the genesis config, whose signatures are inside, may
+// have neither outer signature nor creator. The sender controls the content
+// of the envelope, so unsigned configs are only accepted when the caller
+// states that it validates the genesis block
+func isUnsignedGenesisConfig(ctx context.Context, e *common.Envelope, payload *common.Payload) (bool, error) {
+	// the header itself is validated later on
+	if payload.Header == nil || payload.Header.ChannelHeader == nil {
+		return false, nil
+	}
+
+	signed := len(e.Signature) != 0
+	hasCreator := payload.Header.SignatureHeader != nil && len(payload.Header.SignatureHeader.Creator) != 0
+
+	switch common.HeaderType(payload.Header.ChannelHeader.Type) {
+	case common.HeaderType_ENDORSER_TRANSACTION:
+		if !signed {
+			return false, ErrEndorserTxMissingSignature
+		}
+		if !hasCreator {
+			return false, ErrEndorserTxMissingCreator
+		}
+	case common.HeaderType_CONFIG:
+		if signed && !hasCreator {
+			return false, ErrConfigTxMissingCreator
+		}
+		if !signed && hasCreator {
+			return false, ErrConfigTxMissingSignature
+		}
+		if !signed {
+			if !isGenesis(ctx) {
+				return false, ErrUnsignedConfigTx
+			}
+
+			// the genesis config is the only one with no last update
+			configEnv, err := configtx.UnmarshalConfigEnvelope(payload.Data)
+			if err != nil {
+				return false, permanentDecodeError(fmt.Errorf("Could not decode the unsigned config envelope, err %s", err))
+			}
+			if configEnv.LastUpdate != nil {
+				return false, ErrUnsignedConfigTx
+			}
+			return true, nil
+		}
+	}
+
+	return false, nil
+}
+
+// validateEnvelopeHeader validates the header of a transaction envelope,
+// and tells whether the envelope is an unsigned genesis config, whose header
+// has no creator to validate
+func (v *Validator) validateEnvelopeHeader(ctx context.Context, e *common.Envelope, payload *common.Payload) (bool, error) {
+	unsigned, err := isUnsignedGenesisConfig(ctx, e, payload)
+	if err != nil {
+		return false, err
+	}
+
+	if unsigned {
+		return true, validateChannelHeader(payload.Header.ChannelHeader)
+	}
+
+	return false, v.validateCommonHeader(ctx, payload.Header)
+}
+
+// ValidateGenesisTransaction checks that the transaction envelope of the
+// genesis block is properly formed with the default validator, see
+// Validator.ValidateGenesisTransaction
+func ValidateGenesisTransaction(e *common.Envelope) (*common.Payload, error) {
+	return defaultValidator.ValidateGenesisTransaction(e)
+}
+
+// ValidateGenesisTransaction checks that the transaction envelope of the
+// genesis block, block 0, is properly formed as ValidateTransaction does,
+// except that it may be an unsigned config transaction carrying the genesis
+// config. Callers must only use it for the envelopes of block 0
+func (v *Validator) ValidateGenesisTransaction(e *common.Envelope) (*common.Payload, error) {
+	return v.ValidateTransactionWithContext(withGenesis(context.Background()), e)
+}
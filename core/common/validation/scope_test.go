@@ -0,0 +1,152 @@
+/*
+Copyright IBM Corp. 2017 All Rights Reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+		 http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package validation
+
+import (
+	"testing"
+
+	"github.com/hyperledger/fabric/common/configtx"
+	configtxtest "github.com/hyperledger/fabric/common/configtx/test"
+	"github.com/hyperledger/fabric/common/genesis"
+	"github.com/hyperledger/fabric/common/util"
+	cb "github.com/hyperledger/fabric/protos/common"
+	"github.com/hyperledger/fabric/protos/utils"
+)
+
+// getScopedConfigTransaction returns a config transaction carrying the given last
+// update, created by the given creator and signed if required
+func getScopedConfigTransaction(t *testing.T, lastUpdate *cb.Envelope, creator []byte, sign bool) *cb.Envelope {
+	payload := &cb.Payload{
+		Header: &cb.Header{
+			ChannelHeader: &cb.ChannelHeader{
+				Type:      int32(cb.HeaderType_CONFIG),
+				ChannelId: util.GetTestChainID(),
+			},
+			SignatureHeader: &cb.SignatureHeader{
+				Creator: creator,
+				Nonce:   utils.CreateNonceOrPanic(),
+			},
+		},
+		Data: utils.MarshalOrPanic(&cb.ConfigEnvelope{Config: &cb.Config{}, LastUpdate: lastUpdate}),
+	}
+
+	env := &cb.Envelope{Payload: utils.MarshalOrPanic(payload)}
+	if sign {
+		var err error
+		env.Signature, err = signer.Sign(env.Payload)
+		if err != nil {
+			t.Fatalf("Sign failed, err %s", err)
+		}
+	}
+
+	return env
+}
+
+func TestSignatureScope(t *testing.T) {
+	chainID := util.GetTestChainID()
+
+	tx, err := getTransaction([]byte("simulation_result"))
+	if err != nil {
+		t.Fatalf("getTransaction failed, err %s", err)
+	}
+	unsignedTx := &cb.Envelope{Payload: tx.Payload}
+
+	payload, err := utils.GetPayload(tx)
+	if err != nil {
+		t.Fatalf("GetPayload failed, err %s", err)
+	}
+	payload.Header.SignatureHeader.Creator = nil
+	noCreatorTx := &cb.Envelope{Payload: utils.MarshalOrPanic(payload)}
+	noCreatorTx.Signature, err = signer.Sign(noCreatorTx.Payload)
+	if err != nil {
+		t.Fatalf("Sign failed, err %s", err)
+	}
+
+	block, err := genesis.NewFactoryImpl(configtxtest.CompositeTemplate()).Block(chainID)
+	if err != nil {
+		t.Fatalf("Block failed, err %s", err)
+	}
+	genesisTx, err := utils.GetEnvelopeFromBlock(block.Data.Data[0])
+	if err != nil {
+		t.Fatalf("GetEnvelopeFromBlock failed, err %s", err)
+	}
+
+	lastUpdate, err := configtx.MakeChainCreationTransaction(configtxtest.AcceptAllPolicyKey, chainID, signer, configtxtest.CompositeTemplate())
+	if err != nil {
+		t.Fatalf("MakeChainCreationTransaction failed, err %s", err)
+	}
+
+	tests := []struct {
+		name    string
+		tx      *cb.Envelope
+		genesis bool
+		err     error
+	}{
+		{"EndorserTx", tx, false, nil},
+		{"EndorserTxGenesis", tx, true, nil},
+		{"EndorserTxUnsigned", unsignedTx, false, ErrEndorserTxMissingSignature},
+		{"EndorserTxNoCreator", noCreatorTx, false, ErrEndorserTxMissingCreator},
+		{"Genesis", genesisTx, true, nil},
+		{"GenesisNotInGenesisBlock", genesisTx, false, ErrUnsignedConfigTx},
+		{"GenesisUnsigned", getScopedConfigTransaction(t, nil, nil, false), true, nil},
+		{"UnsignedNoLastUpdate", getScopedConfigTransaction(t, nil, nil, false), false, ErrUnsignedConfigTx},
+		{"GenesisSigned", getScopedConfigTransaction(t, nil, signerSerialized, true), false, nil},
+		{"GenesisNoCreator", getScopedConfigTransaction(t, nil, nil, true), true, ErrConfigTxMissingCreator},
+		{"GenesisNoSignature", getScopedConfigTransaction(t, nil, signerSerialized, false), true, ErrConfigTxMissingSignature},
+		{"Update", getScopedConfigTransaction(t, lastUpdate, signerSerialized, true), false, nil},
+		{"UpdateUnsigned", getScopedConfigTransaction(t, lastUpdate, nil, false), false, ErrUnsignedConfigTx},
+		{"UpdateUnsignedInGenesisBlock", getScopedConfigTransaction(t, lastUpdate, nil, false), true, ErrUnsignedConfigTx},
+	}
+
+	for _, test := range tests {
+		validate := ValidateTransaction
+		if test.genesis {
+			validate = ValidateGenesisTransaction
+		}
+
+		_, err := validate(test.tx)
+		if err != test.err {
+			t.Fatalf("%s: expected err %v, got %v", test.name, test.err, err)
+		}
+	}
+
+	// the envelopes of block 0 are validated as genesis envelopes
+	result, err := (&Validator{}).ValidateBlock(block)
+	if err != nil {
+		t.Fatalf("ValidateBlock failed, err %s", err)
+	}
+	if result.Errors[0] != nil {
+		t.Fatalf("ValidateBlock rejected the genesis config, err %s", result.Errors[0])
+	}
+
+	block.Header.Number = 1
+	result, err = (&Validator{}).ValidateBlock(block)
+	if err != nil {
+		t.Fatalf("ValidateBlock failed, err %s", err)
+	}
+	if result.Errors[0] != ErrUnsignedConfigTx {
+		t.Fatalf("expected err %v for an unsigned config past the genesis block, got %v", ErrUnsignedConfigTx, result.Errors[0])
+	}
+
+	// an unsigned config that does not decode is malformed
+	payload.Header.ChannelHeader.Type = int32(cb.HeaderType_CONFIG)
+	payload.Data = []byte("garbage")
+	_, err = ValidateGenesisTransaction(&cb.Envelope{Payload: utils.MarshalOrPanic(payload)})
+	if GetErrorClass(err) != PermanentError {
+		t.Fatalf("expected a permanent error for an undecodable unsigned config, got %v", err)
+	}
+}
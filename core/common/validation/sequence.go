@@ -0,0 +1,130 @@
+/*
+Copyright IBM Corp. 2017 All Rights Reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+		 http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package validation
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/hyperledger/fabric/protos/common"
+	"golang.org/x/net/context"
+)
+
+// ErrSequenceOutOfOrder is returned when the sequence number of a
+// transaction is not greater than the last one accepted from its creator
+var ErrSequenceOutOfOrder = errors.New("Out of order or replayed sequence number")
+
+// SequenceNumberLength is the length of the sequence number prefixed to
+// the nonces of the clients submitting ordered streams of transactions
+const SequenceNumberLength = 8
+
+// GetSequenceNumber returns the sequence number carried by a nonce. Clients
+// submitting ordered streams of transactions prefix the random nonce in the
+// SignatureHeader of their transactions with a sequence number, encoded as
+// a big endian uint64; being part of the nonce, the sequence number is
+// covered by the signature of the creator and by the TxId
+func GetSequenceNumber(nonce []byte) (uint64, error) {
+	if len(nonce) < SequenceNumberLength {
+		return 0, fmt.Errorf("Nonce too short to carry a sequence number, got %d bytes", len(nonce))
+	}
+
+	return binary.BigEndian.Uint64(nonce[:SequenceNumberLength]), nil
+}
+
+// SequenceTracker records the last sequence number accepted from each
+// creator on each channel, and rejects the transactions whose sequence
+// number is not strictly greater
+type SequenceTracker struct {
+	sync.Mutex
+	store ReplayStore
+	ttl   time.Duration
+}
+
+// NewSequenceTracker returns a SequenceTracker with no recorded sequence,
+// held in memory
+func NewSequenceTracker() *SequenceTracker {
+	return NewSequenceTrackerWithStore(NewMemoryReplayStore(), 0)
+}
+
+// NewSequenceTrackerWithStore returns a SequenceTracker recording the last
+// sequence numbers in the given store, so that they survive restarts if it
+// is persistent. Each sequence number is kept for the given TTL after it is
+// accepted, or forever if the TTL is zero; once it expires, the creator may
+// start a new sequence
+func NewSequenceTrackerWithStore(store ReplayStore, ttl time.Duration) *SequenceTracker {
+	return &SequenceTracker{store: store, ttl: ttl}
+}
+
+// isSequenced tells whether the sequence number of a transaction is enforced
+// by the tracker, if any: only those of endorser transactions are
+func isSequenced(st *SequenceTracker, payload *common.Payload) bool {
+	if st == nil || payload == nil || payload.Header == nil || payload.Header.ChannelHeader == nil {
+		return false
+	}
+
+	return common.HeaderType(payload.Header.ChannelHeader.Type) == common.HeaderType_ENDORSER_TRANSACTION
+}
+
+// advance checks the sequence number of the transaction against the last
+// one accepted from its creator and, if it is greater, records it unless
+// in dry-run mode
+func (st *SequenceTracker) advance(ctx context.Context, hdr *common.Header, dryRun bool) error {
+	seq, err := GetSequenceNumber(hdr.SignatureHeader.Nonce)
+	if err != nil {
+		return err
+	}
+
+	key := "sequence/" + hdr.ChannelHeader.ChannelId + "/" + string(hdr.SignatureHeader.Creator)
+
+	st.Lock()
+	defer st.Unlock()
+
+	value, ok, err := st.store.Get(key)
+	if err != nil {
+		return fmt.Errorf("Failed to get the last sequence number of the creator, err %s", err)
+	}
+	if ok {
+		reportCacheOperation(ctx, SequenceCache, key, CacheHit)
+		if len(value) != SequenceNumberLength {
+			return fmt.Errorf("Invalid last sequence number of the creator, got %d bytes", len(value))
+		}
+
+		if last := binary.BigEndian.Uint64(value); seq <= last {
+			putilsLogger.Errorf("Transaction %s has sequence number %d, the last accepted from its creator is %d", hdr.ChannelHeader.TxId, seq, last)
+			return ErrSequenceOutOfOrder
+		}
+	} else {
+		reportCacheOperation(ctx, SequenceCache, key, CacheMiss)
+	}
+
+	reportCacheOperation(ctx, SequenceCache, key, CachePopulate)
+	if dryRun {
+		return nil
+	}
+
+	value = make([]byte, SequenceNumberLength)
+	binary.BigEndian.PutUint64(value, seq)
+	err = st.store.Put(key, value, st.ttl)
+	if err != nil {
+		return fmt.Errorf("Failed to record the sequence number of the creator, err %s", err)
+	}
+
+	return nil
+}
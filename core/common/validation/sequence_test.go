@@ -0,0 +1,142 @@
+/*
+Copyright IBM Corp. 2017 All Rights Reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+		 http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package validation
+
+import (
+	"encoding/binary"
+	"errors"
+	"testing"
+
+	"github.com/hyperledger/fabric/common/util"
+	"github.com/hyperledger/fabric/protos/common"
+	"github.com/hyperledger/fabric/protos/peer"
+	"github.com/hyperledger/fabric/protos/utils"
+	"golang.org/x/net/context"
+)
+
+// getSequencedTransaction returns a transaction whose nonce carries the
+// given sequence number
+func getSequencedTransaction(t *testing.T, seq uint64) *common.Envelope {
+	nonce := utils.CreateNonceOrPanic()
+	binary.BigEndian.PutUint64(nonce, seq)
+
+	txID, err := utils.ComputeProposalTxID(nonce, signerSerialized)
+	if err != nil {
+		t.Fatalf("ComputeProposalTxID failed, err %s", err)
+	}
+
+	cis := &peer.ChaincodeInvocationSpec{
+		ChaincodeSpec: &peer.ChaincodeSpec{
+			ChaincodeId: &peer.ChaincodeID{Name: "foo"},
+			Type:        peer.ChaincodeSpec_GOLANG}}
+
+	prop, _, err := utils.CreateChaincodeProposalWithTxIDNonceAndTransient(txID, common.HeaderType_ENDORSER_TRANSACTION, util.GetTestChainID(), cis, nonce, signerSerialized, nil)
+	if err != nil {
+		t.Fatalf("CreateChaincodeProposalWithTxIDNonceAndTransient failed, err %s", err)
+	}
+
+	tx, err := getTransactionForProposal(prop, []byte("simulation_result"))
+	if err != nil {
+		t.Fatalf("getTransactionForProposal failed, err %s", err)
+	}
+
+	return tx
+}
+
+func TestSequenceTracker(t *testing.T) {
+	tests := []struct {
+		name  string
+		seq   uint64
+		valid bool
+	}{
+		{"First", 1, true},
+		{"InOrder", 2, true},
+		{"Gap", 5, true},
+		{"Duplicate", 5, false},
+		{"OutOfOrder", 3, false},
+		{"Next", 6, true},
+	}
+
+	v := &Validator{SequenceTracker: NewSequenceTracker()}
+	for _, test := range tests {
+		_, err := v.ValidateTransaction(getSequencedTransaction(t, test.seq))
+		if test.valid && err != nil {
+			t.Fatalf("%s: ValidateTransaction failed, err %s", test.name, err)
+		}
+		if !test.valid && err != ErrSequenceOutOfOrder {
+			t.Fatalf("%s: ValidateTransaction should have failed with ErrSequenceOutOfOrder, got %v", test.name, err)
+		}
+	}
+}
+
+func TestSequenceTrackerFinalOutcome(t *testing.T) {
+	tx := getSequencedTransaction(t, 1)
+	v := &Validator{SequenceTracker: NewSequenceTracker()}
+
+	// a transaction rejected by the additional checks of an entry point
+	// after passing the built-in validation; no policy provider is set to
+	// check the digest against
+	_, err := v.ValidateTransactionWithPolicyDigest(tx, []byte("digest"))
+	if err == nil {
+		t.Fatalf("ValidateTransactionWithPolicyDigest should have failed")
+	}
+
+	// a transaction rejected by a middleware after the built-in validation
+	rejected := errors.New("rejected")
+	v.Use(func(next ValidationHandler) ValidationHandler {
+		return func(ctx context.Context, e *common.Envelope) (*ValidationResult, error) {
+			result, _ := next(ctx, e)
+			return result, rejected
+		}
+	})
+	_, err = v.ValidateTransaction(tx)
+	if err != rejected {
+		t.Fatalf("Expected err %v, got %v", rejected, err)
+	}
+
+	// neither consumed the sequence number
+	_, err = (&Validator{SequenceTracker: v.SequenceTracker}).ValidateTransaction(tx)
+	if err != nil {
+		t.Fatalf("ValidateTransaction failed, err %s", err)
+	}
+}
+
+func TestSequenceTrackerOptIn(t *testing.T) {
+	tx := getSequencedTransaction(t, 1)
+
+	for i := 0; i < 2; i++ {
+		_, err := (&Validator{}).ValidateTransaction(tx)
+		if err != nil {
+			t.Fatalf("ValidateTransaction failed, err %s", err)
+		}
+	}
+}
+
+func TestGetSequenceNumber(t *testing.T) {
+	seq, err := GetSequenceNumber([]byte{0, 0, 0, 0, 0, 0, 1, 2, 3})
+	if err != nil {
+		t.Fatalf("GetSequenceNumber failed, err %s", err)
+	}
+	if seq != 258 {
+		t.Fatalf("Unexpected sequence number %d", seq)
+	}
+
+	_, err = GetSequenceNumber([]byte{1, 2, 3})
+	if err == nil {
+		t.Fatalf("GetSequenceNumber should have failed for a short nonce")
+	}
+}
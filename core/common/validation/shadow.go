@@ -0,0 +1,149 @@
+/*
+Copyright IBM Corp. 2017 All Rights Reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+		 http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package validation
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+
+	"golang.org/x/net/context"
+)
+
+// CheckMode tells whether the failures of a check reject transactions
+type CheckMode int
+
+const (
+	// EnforceMode checks reject the transactions failing them
+	EnforceMode CheckMode = iota
+
+	// ShadowMode checks run and their failures are logged and counted, but
+	// do not reject the transactions, so that operators can measure the
+	// impact of a new check before enforcing it
+	ShadowMode
+)
+
+func (m CheckMode) String() string {
+	switch m {
+	case EnforceMode:
+		return "enforce"
+	case ShadowMode:
+		return "shadow"
+	default:
+		return fmt.Sprintf("unknown(%d)", int(m))
+	}
+}
+
+// ShadowableChecks are the names of the checks that may run in shadow mode:
+// the optional checks, named after the steps of the validation they perform
+// (without the "action-N-" prefix of the steps of actions), whose failures
+// do not prevent the rest of the validation from running
+var ShadowableChecks = []string{"ttl", "spamguard", "policyname", "endorsers", "chaincode", "plugins", "sequence"}
+
+// shadowCounters counts the failures of the checks run in shadow mode
+type shadowCounters struct {
+	sync.Mutex
+	failures map[string]uint64
+}
+
+// shadowKey is the key of the validator running checks in shadow mode in
+// contexts
+type shadowKey struct{}
+
+// CheckMode returns the mode the check with the given name runs in
+func (v *Validator) CheckMode(name string) CheckMode {
+	if _, ok := v.ShadowChecks[name]; !ok {
+		return EnforceMode
+	}
+
+	for _, check := range ShadowableChecks {
+		if check == name {
+			return ShadowMode
+		}
+	}
+
+	return EnforceMode
+}
+
+// ShadowFailures returns the number of failures of each check run in shadow
+// mode since the validator was created
+func (v *Validator) ShadowFailures() map[string]uint64 {
+	v.shadowCounters.Lock()
+	defer v.shadowCounters.Unlock()
+
+	failures := make(map[string]uint64, len(v.shadowCounters.failures))
+	for name, count := range v.shadowCounters.failures {
+		failures[name] = count
+	}
+
+	return failures
+}
+
+// withShadowChecks returns a context holding the validator, if it runs
+// checks in shadow mode
+func (v *Validator) withShadowChecks(ctx context.Context) context.Context {
+	if len(v.ShadowChecks) == 0 {
+		return ctx
+	}
+
+	return context.WithValue(ctx, shadowKey{}, v)
+}
+
+// checkName returns the name of the check performed by a step, that is the
+// name of the step without the "action-N-" prefix of the steps of actions
+func checkName(step string) string {
+	if !strings.HasPrefix(step, "action-") {
+		return step
+	}
+
+	rest := step[len("action-"):]
+	if i := strings.IndexByte(rest, '-'); i >= 0 {
+		return rest[i+1:]
+	}
+
+	return step
+}
+
+// shadowStep returns err, unless it is the failure of a step performing a
+// check run in shadow mode by the validator of the context, which is then
+// logged and counted
+func shadowStep(ctx context.Context, step string, err error) error {
+	if err == nil {
+		return nil
+	}
+
+	v, ok := ctx.Value(shadowKey{}).(*Validator)
+	if !ok {
+		return err
+	}
+
+	check := checkName(step)
+	if v.CheckMode(check) != ShadowMode {
+		return err
+	}
+
+	putilsLogger.Warningf("Check %s failed in shadow mode, not rejecting the transaction, err %s", check, err)
+
+	v.shadowCounters.Lock()
+	if v.shadowCounters.failures == nil {
+		v.shadowCounters.failures = make(map[string]uint64)
+	}
+	v.shadowCounters.failures[check]++
+	v.shadowCounters.Unlock()
+
+	return nil
+}
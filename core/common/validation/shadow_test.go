@@ -0,0 +1,137 @@
+/*
+Copyright IBM Corp. 2017 All Rights Reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+		 http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package validation
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/hyperledger/fabric/protos/common"
+)
+
+// rejectingPlugin rejects every transaction
+type rejectingPlugin struct{}
+
+func (rejectingPlugin) Validate(result *ValidationResult) error {
+	return errors.New("rejected by plugin")
+}
+
+func TestShadowMode(t *testing.T) {
+	expiredTx, created := getTransactionWithExtension(t, encodeTTL(time.Minute))
+	clock := func() time.Time { return created.Add(time.Hour) }
+
+	v := &Validator{
+		EnforceTTL:   true,
+		Clock:        clock,
+		ShadowChecks: map[string]struct{}{"ttl": {}, "plugins": {}, "signature": {}},
+		RecordSteps:  true,
+	}
+	v.RegisterPlugin("", 0, rejectingPlugin{})
+
+	// the failures of the checks in shadow mode do not reject the transaction
+	for i := 0; i < 2; i++ {
+		result, err := v.Validate(expiredTx)
+		if err != nil {
+			t.Fatalf("Validate failed, err %s", err)
+		}
+
+		// but are recorded
+		var failed []string
+		for _, step := range result.Steps {
+			if !step.Passed {
+				failed = append(failed, step.Name)
+			}
+		}
+		if !reflect.DeepEqual(failed, []string{"ttl", "plugins"}) {
+			t.Fatalf("Expected the ttl and plugins steps to have failed, got %v", result.Steps)
+		}
+	}
+
+	// and counted
+	expected := map[string]uint64{"ttl": 2, "plugins": 2}
+	if failures := v.ShadowFailures(); !reflect.DeepEqual(failures, expected) {
+		t.Fatalf("Expected failures %v, got %v", expected, failures)
+	}
+
+	// the established checks cannot be shadowed
+	badTx := &common.Envelope{Payload: expiredTx.Payload, Signature: append([]byte(nil), expiredTx.Signature...)}
+	corrupt(badTx.Signature)
+	_, err := v.Validate(badTx)
+	if err == nil {
+		t.Fatalf("Validate should have failed verifying the signature")
+	}
+
+	// the checks not in shadow mode are enforced
+	_, err = (&Validator{EnforceTTL: true, Clock: clock, ShadowChecks: map[string]struct{}{"plugins": {}}}).Validate(expiredTx)
+	if err != ErrTransactionExpired {
+		t.Fatalf("Expected err %v, got %v", ErrTransactionExpired, err)
+	}
+	_, err = (&Validator{EnforceTTL: true, Clock: clock}).Validate(expiredTx)
+	if err != ErrTransactionExpired {
+		t.Fatalf("Expected err %v, got %v", ErrTransactionExpired, err)
+	}
+}
+
+func TestShadowModeActionChecks(t *testing.T) {
+	tx, err := getTransaction([]byte("simulation_result"))
+	if err != nil {
+		t.Fatalf("getTransaction failed, err %s", err)
+	}
+
+	v := &Validator{AllowedEndorserMSPs: map[string]struct{}{"OtherMSP": {}}}
+	_, err = v.Validate(tx)
+	if err == nil {
+		t.Fatalf("Validate should have failed checking the endorsers")
+	}
+
+	v.ShadowChecks = map[string]struct{}{"endorsers": {}}
+	_, err = v.Validate(tx)
+	if err != nil {
+		t.Fatalf("Validate failed, err %s", err)
+	}
+	if failures := v.ShadowFailures(); failures["endorsers"] != 1 {
+		t.Fatalf("Expected a failure of the endorsers check, got %v", failures)
+	}
+}
+
+func TestCheckMode(t *testing.T) {
+	v := &Validator{ShadowChecks: map[string]struct{}{"ttl": {}, "signature": {}}}
+
+	tests := []struct {
+		check string
+		mode  CheckMode
+	}{
+		{"ttl", ShadowMode},
+		{"spamguard", EnforceMode},
+		// not shadowable
+		{"signature", EnforceMode},
+	}
+
+	for _, test := range tests {
+		if mode := v.CheckMode(test.check); mode != test.mode {
+			t.Fatalf("%s: expected mode %s, got %s", test.check, test.mode, mode)
+		}
+	}
+
+	for step, check := range map[string]string{"ttl": "ttl", "action-0-endorsers": "endorsers", "action-12-prophash": "prophash"} {
+		if name := checkName(step); name != check {
+			t.Fatalf("Expected check %s for step %s, got %s", check, step, name)
+		}
+	}
+}
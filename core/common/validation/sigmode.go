@@ -0,0 +1,84 @@
+/*
+Copyright IBM Corp. 2017 All Rights Reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+		 http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package validation
+
+import (
+	"fmt"
+
+	"github.com/hyperledger/fabric/bccsp"
+	"github.com/hyperledger/fabric/bccsp/factory"
+	"golang.org/x/net/context"
+)
+
+// SignatureMode tells what the signatures of creators are computed over
+type SignatureMode int
+
+const (
+	// RawMessageSignature signatures are over the signed message itself
+	RawMessageSignature SignatureMode = iota
+
+	// DigestSignature signatures are over the SHA-256 digest of the signed
+	// message, as produced by signing hardware that only signs digests
+	DigestSignature
+)
+
+func (m SignatureMode) String() string {
+	switch m {
+	case RawMessageSignature:
+		return "raw"
+	case DigestSignature:
+		return "digest"
+	default:
+		return fmt.Sprintf("unknown(%d)", int(m))
+	}
+}
+
+// SignatureModeProvider provides the signature mode of the channels, as
+// set in their crypto config
+type SignatureModeProvider interface {
+	// GetSignatureMode returns the signature mode of the given chain
+	GetSignatureMode(chainID string) SignatureMode
+}
+
+// getSignedMessage returns the message the creator's signature over msg is
+// expected to be computed over, according to the signature mode of the chain
+func (v *Validator) getSignedMessage(ctx context.Context, chainID string, msg []byte) ([]byte, error) {
+	config, err := v.getCryptoConfig(ctx, chainID)
+	if err != nil {
+		return nil, err
+	}
+
+	mode := RawMessageSignature
+	if config != nil {
+		mode = config.SignatureMode
+	} else if v.SignatureModeProvider != nil {
+		mode = v.SignatureModeProvider.GetSignatureMode(chainID)
+	}
+
+	switch mode {
+	case RawMessageSignature:
+		return msg, nil
+	case DigestSignature:
+		digest, err := factory.GetDefault().Hash(msg, &bccsp.SHA256Opts{})
+		if err != nil {
+			return nil, fmt.Errorf("Failed computing the digest of the signed message, err %s", err)
+		}
+		return digest, nil
+	default:
+		return nil, fmt.Errorf("Unsupported signature mode %s for chain [%s]", mode, chainID)
+	}
+}
@@ -0,0 +1,79 @@
+/*
+Copyright IBM Corp. 2017 All Rights Reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+		 http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package validation
+
+import (
+	"testing"
+
+	"github.com/hyperledger/fabric/bccsp"
+	"github.com/hyperledger/fabric/bccsp/factory"
+	"github.com/hyperledger/fabric/common/util"
+	"github.com/hyperledger/fabric/protos/common"
+)
+
+// mockSignatureModeProvider maps chains to their signature mode
+type mockSignatureModeProvider map[string]SignatureMode
+
+func (m mockSignatureModeProvider) GetSignatureMode(chainID string) SignatureMode {
+	return m[chainID]
+}
+
+func TestSignatureMode(t *testing.T) {
+	rawTx, err := getTransaction([]byte("simulation_result"))
+	if err != nil {
+		t.Fatalf("getTransaction failed, err %s", err)
+	}
+
+	digest, err := factory.GetDefault().Hash(rawTx.Payload, &bccsp.SHA256Opts{})
+	if err != nil {
+		t.Fatalf("Hash failed, err %s", err)
+	}
+
+	digestSig, err := signer.Sign(digest)
+	if err != nil {
+		t.Fatalf("Sign failed, err %s", err)
+	}
+	digestTx := &common.Envelope{Payload: rawTx.Payload, Signature: digestSig}
+
+	rawValidator := &Validator{SignatureModeProvider: mockSignatureModeProvider{util.GetTestChainID(): RawMessageSignature}}
+	digestValidator := &Validator{SignatureModeProvider: mockSignatureModeProvider{util.GetTestChainID(): DigestSignature}}
+
+	tests := []struct {
+		name  string
+		v     *Validator
+		tx    *common.Envelope
+		valid bool
+	}{
+		{"DefaultRaw", &Validator{}, rawTx, true},
+		{"DefaultDigest", &Validator{}, digestTx, false},
+		{"RawRaw", rawValidator, rawTx, true},
+		{"RawDigest", rawValidator, digestTx, false},
+		{"DigestDigest", digestValidator, digestTx, true},
+		{"DigestRaw", digestValidator, rawTx, false},
+		{"Unsupported", &Validator{SignatureModeProvider: mockSignatureModeProvider{util.GetTestChainID(): SignatureMode(42)}}, rawTx, false},
+	}
+
+	for _, test := range tests {
+		_, err = test.v.ValidateTransaction(test.tx)
+		if test.valid && err != nil {
+			t.Fatalf("%s: ValidateTransaction failed, err %s", test.name, err)
+		}
+		if !test.valid && err == nil {
+			t.Fatalf("%s: ValidateTransaction should have failed", test.name)
+		}
+	}
+}
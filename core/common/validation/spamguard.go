@@ -0,0 +1,97 @@
+/*
+Copyright IBM Corp. 2017 All Rights Reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+		 http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package validation
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/hyperledger/fabric/bccsp"
+	"github.com/hyperledger/fabric/bccsp/factory"
+	"github.com/hyperledger/fabric/protos/common"
+	"golang.org/x/net/context"
+)
+
+// ErrInsufficientWork is returned for transactions without an anti-spam
+// token meeting the difficulty required by the SpamGuard
+var ErrInsufficientWork = errors.New("The transaction does not carry sufficient proof of work")
+
+// MaxSpamTokenSize is the maximum size of anti-spam tokens
+const MaxSpamTokenSize = 64
+
+// SpamGuard requires the endorser transactions to carry an anti-spam token
+// in the SpamToken field of their chaincode header extension: it meets a
+// difficulty of d bits if the SHA-256 hash of the TxId followed by the
+// token starts with at least d zero bits, so that finding it takes 2^d
+// hashes on average
+type SpamGuard struct {
+	// Difficulty is the minimum number of leading zero bits of the hash of
+	// the TxId and the token; if zero, no token is required
+	Difficulty uint
+}
+
+// validate ensures that the channel header carries an anti-spam token
+// meeting the difficulty of the guard
+func (g *SpamGuard) validate(ctx context.Context, chdr *common.ChannelHeader) error {
+	if g.Difficulty == 0 {
+		return nil
+	}
+
+	ext, err := getHeaderExtension(ctx, chdr)
+	if err != nil {
+		return err
+	}
+
+	token := ext.SpamToken
+	if len(token) == 0 {
+		putilsLogger.Errorf("Transaction [%s] carries no anti-spam token", chdr.TxId)
+		return ErrInsufficientWork
+	}
+
+	if len(token) > MaxSpamTokenSize {
+		return fmt.Errorf("Anti-spam token too large, %d bytes, maximum %d", len(token), MaxSpamTokenSize)
+	}
+
+	digest, err := factory.GetDefault().Hash(append([]byte(chdr.TxId), token...), &bccsp.SHA256Opts{})
+	if err != nil {
+		return fmt.Errorf("Failed computing the hash of the anti-spam token, err %s", err)
+	}
+
+	if zeros := leadingZeroBits(digest); zeros < g.Difficulty {
+		putilsLogger.Errorf("Transaction [%s] carries an anti-spam token of difficulty %d, %d required", chdr.TxId, zeros, g.Difficulty)
+		return ErrInsufficientWork
+	}
+
+	return nil
+}
+
+// leadingZeroBits returns the number of leading zero bits of b
+func leadingZeroBits(b []byte) uint {
+	var zeros uint
+	for _, c := range b {
+		if c != 0 {
+			for c&0x80 == 0 {
+				zeros++
+				c <<= 1
+			}
+			return zeros
+		}
+		zeros += 8
+	}
+
+	return zeros
+}
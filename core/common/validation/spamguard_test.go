@@ -0,0 +1,126 @@
+/*
+Copyright IBM Corp. 2017 All Rights Reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+		 http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package validation
+
+import (
+	"crypto/sha256"
+	"encoding/binary"
+	"testing"
+
+	"github.com/hyperledger/fabric/protos/common"
+	"github.com/hyperledger/fabric/protos/peer"
+	"github.com/hyperledger/fabric/protos/utils"
+)
+
+// getTransactionWithToken returns a signed transaction for a toy proposal
+// carrying the anti-spam token returned by token for its TxId
+func getTransactionWithToken(t *testing.T, token func(txID string) []byte) *common.Envelope {
+	prop, err := getProposal()
+	if err != nil {
+		t.Fatalf("getProposal failed, err %s", err)
+	}
+
+	hdr, err := utils.GetHeader(prop.Header)
+	if err != nil {
+		t.Fatalf("GetHeader failed, err %s", err)
+	}
+
+	ext := utils.MarshalOrPanic(&peer.ChaincodeHeaderExtension{SpamToken: token(hdr.ChannelHeader.TxId)})
+	hdr.ChannelHeader.Extension = append(hdr.ChannelHeader.Extension, ext...)
+	prop.Header = utils.MarshalOrPanic(hdr)
+
+	tx, err := getTransactionForProposal(prop, []byte("simulation_result"))
+	if err != nil {
+		t.Fatalf("getTransactionForProposal failed, err %s", err)
+	}
+
+	return tx
+}
+
+// mineToken returns the first token, an 8 bytes counter, whose hash with
+// the TxId starts with exactly the given number of zero bits
+func mineToken(txID string, difficulty uint) []byte {
+	token := make([]byte, 8)
+	for i := uint64(0); ; i++ {
+		binary.BigEndian.PutUint64(token, i)
+		digest := sha256.Sum256(append([]byte(txID), token...))
+		if leadingZeroBits(digest[:]) == difficulty {
+			return token
+		}
+	}
+}
+
+func TestSpamGuard(t *testing.T) {
+	tx := getTransactionWithToken(t, func(txID string) []byte { return mineToken(txID, 8) })
+	noTokenTx, err := getTransaction([]byte("simulation_result"))
+	if err != nil {
+		t.Fatalf("getTransaction failed, err %s", err)
+	}
+	emptyTokenTx := getTransactionWithToken(t, func(string) []byte { return nil })
+	largeTokenTx := getTransactionWithToken(t, func(string) []byte { return make([]byte, MaxSpamTokenSize+1) })
+
+	tests := []struct {
+		name       string
+		tx         *common.Envelope
+		difficulty uint
+		err        error
+	}{
+		{"Sufficient", tx, 8, nil},
+		{"Easier", tx, 4, nil},
+		{"Insufficient", tx, 9, ErrInsufficientWork},
+		{"NoToken", noTokenTx, 8, ErrInsufficientWork},
+		{"EmptyToken", emptyTokenTx, 8, ErrInsufficientWork},
+		{"NoDifficulty", noTokenTx, 0, nil},
+	}
+
+	for _, test := range tests {
+		_, err := (&Validator{SpamGuard: &SpamGuard{Difficulty: test.difficulty}}).ValidateTransaction(test.tx)
+		if err != test.err {
+			t.Fatalf("%s: expected err %v, got %v", test.name, test.err, err)
+		}
+	}
+
+	_, err = (&Validator{SpamGuard: &SpamGuard{Difficulty: 8}}).ValidateTransaction(largeTokenTx)
+	if err == nil {
+		t.Fatalf("ValidateTransaction should have failed for a token too large")
+	}
+
+	// the guard is opt-in
+	_, err = (&Validator{}).ValidateTransaction(noTokenTx)
+	if err != nil {
+		t.Fatalf("ValidateTransaction failed without SpamGuard, err %s", err)
+	}
+}
+
+func TestLeadingZeroBits(t *testing.T) {
+	tests := []struct {
+		b     []byte
+		zeros uint
+	}{
+		{nil, 0},
+		{[]byte{0x80}, 0},
+		{[]byte{0x01}, 7},
+		{[]byte{0x00, 0x00}, 16},
+		{[]byte{0x00, 0x20, 0xff}, 10},
+	}
+
+	for _, test := range tests {
+		if zeros := leadingZeroBits(test.b); zeros != test.zeros {
+			t.Fatalf("Expected %d leading zero bits for %x, got %d", test.zeros, test.b, zeros)
+		}
+	}
+}
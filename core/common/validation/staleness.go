@@ -0,0 +1,85 @@
+/*
+Copyright IBM Corp. 2017 All Rights Reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+		 http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package validation
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/hyperledger/fabric/core/ledger/kvledger/txmgmt/rwset"
+)
+
+// ErrStaleReads is returned for transactions whose reads are too old to
+// have any chance of passing the MVCC checks
+var ErrStaleReads = errors.New("Transaction reads stale versions")
+
+// LedgerHeightProvider provides the current height of the ledgers
+type LedgerHeightProvider interface {
+	// GetLedgerHeight returns the height of the ledger of the given chain
+	GetLedgerHeight(chainID string) (uint64, error)
+}
+
+// getOldestReadBlock returns the lowest block number among the versions
+// read from the channel by the supplied simulation results, or false if
+// no existing key is read; the reads on other channels are ignored
+func getOldestReadBlock(chainID string, txRWSet *rwset.TxReadWriteSet) (uint64, bool) {
+	var oldest uint64
+	found := false
+	for _, nsRWSet := range txRWSet.NsRWs {
+		if i := strings.IndexByte(nsRWSet.NameSpace, '/'); i >= 0 && nsRWSet.NameSpace[i+1:] != chainID {
+			continue
+		}
+
+		for _, read := range nsRWSet.Reads {
+			// keys that did not exist have no version
+			if read.Version == nil {
+				continue
+			}
+
+			if !found || read.Version.BlockNum < oldest {
+				oldest = read.Version.BlockNum
+				found = true
+			}
+		}
+	}
+
+	return oldest, found
+}
+
+// validateReadStaleness ensures that the oldest version read by an action
+// is no more than MaxReadStaleness blocks older than the ledger height:
+// such transactions would most likely be invalidated by the MVCC checks
+func (v *Validator) validateReadStaleness(chainID string, txRWSet *rwset.TxReadWriteSet) error {
+	oldest, found := getOldestReadBlock(chainID, txRWSet)
+	if !found {
+		return nil
+	}
+
+	height, err := v.LedgerHeightProvider.GetLedgerHeight(chainID)
+	if err != nil {
+		return fmt.Errorf("Could not get the height of the ledger of chain [%s], err %s", chainID, err)
+	}
+
+	// the last block of the ledger is at height-1
+	if height > oldest && height-1-oldest > v.MaxReadStaleness {
+		putilsLogger.Errorf("Transaction reads a version from block %d, %d blocks behind the ledger height %d", oldest, height-1-oldest, height)
+		return ErrStaleReads
+	}
+
+	return nil
+}
@@ -0,0 +1,95 @@
+/*
+Copyright IBM Corp. 2017 All Rights Reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+		 http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package validation
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/hyperledger/fabric/common/util"
+	"github.com/hyperledger/fabric/core/ledger/kvledger/txmgmt/rwset"
+	"github.com/hyperledger/fabric/core/ledger/kvledger/txmgmt/version"
+)
+
+// mockLedgerHeightProvider maps chains to the height of their ledger
+type mockLedgerHeightProvider map[string]uint64
+
+func (m mockLedgerHeightProvider) GetLedgerHeight(chainID string) (uint64, error) {
+	height, ok := m[chainID]
+	if !ok {
+		return 0, errors.New("unknown chain")
+	}
+	return height, nil
+}
+
+// getVersionedRWSetBytes returns a read-write set reading a key at each of
+// the given block numbers from the namespace
+func getVersionedRWSetBytes(t *testing.T, ns string, blockNums ...uint64) []byte {
+	nsRWSet := &rwset.NsReadWriteSet{NameSpace: ns, Reads: []*rwset.KVRead{rwset.NewKVRead("new_key", nil)}}
+	for _, blockNum := range blockNums {
+		nsRWSet.Reads = append(nsRWSet.Reads, rwset.NewKVRead("key", version.NewHeight(blockNum, 0)))
+	}
+
+	rwsetBytes, err := (&rwset.TxReadWriteSet{NsRWs: []*rwset.NsReadWriteSet{nsRWSet}}).Marshal()
+	if err != nil {
+		t.Fatalf("Marshal failed, err %s", err)
+	}
+
+	return rwsetBytes
+}
+
+func TestReadStaleness(t *testing.T) {
+	v := &Validator{
+		LedgerHeightProvider: mockLedgerHeightProvider{util.GetTestChainID(): 100},
+		MaxReadStaleness:     10,
+	}
+
+	tests := []struct {
+		name      string
+		ns        string
+		blockNums []uint64
+		valid     bool
+	}{
+		{"NoVersionedRead", "foo", nil, true},
+		{"Fresh", "foo", []uint64{99, 95}, true},
+		{"AtThreshold", "foo", []uint64{99, 89}, true},
+		{"Stale", "foo", []uint64{99, 88}, false},
+		{"StaleOnOtherChannel", "foo/otherchannel", []uint64{1}, true},
+		{"StaleOnSameChannel", "foo/" + util.GetTestChainID(), []uint64{1}, false},
+	}
+
+	for _, test := range tests {
+		tx, err := getTransaction(getVersionedRWSetBytes(t, test.ns, test.blockNums...))
+		if err != nil {
+			t.Fatalf("%s: getTransaction failed, err %s", test.name, err)
+		}
+
+		_, err = v.ValidateTransaction(tx)
+		if test.valid && err != nil {
+			t.Fatalf("%s: ValidateTransaction failed, err %s", test.name, err)
+		}
+		if !test.valid && err != ErrStaleReads {
+			t.Fatalf("%s: ValidateTransaction should have failed with ErrStaleReads, got %v", test.name, err)
+		}
+
+		// the check is opt-in
+		_, err = (&Validator{}).ValidateTransaction(tx)
+		if err != nil {
+			t.Fatalf("%s: ValidateTransaction failed without height provider, err %s", test.name, err)
+		}
+	}
+}
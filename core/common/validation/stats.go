@@ -0,0 +1,63 @@
+/*
+Copyright IBM Corp. 2017 All Rights Reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+		 http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package validation
+
+import (
+	"golang.org/x/net/context"
+)
+
+// ValidationStats counts the work performed to validate a transaction, for
+// capacity planning. The work of a check is only counted once it passed
+type ValidationStats struct {
+	// BytesHashed is the total size of the messages hashed to verify the
+	// signatures and to recompute the TxId and the proposal hashes
+	BytesHashed int
+
+	// SignaturesVerified is the number of signatures verified: that of the
+	// creator and those of the aggregated endorsements and, if required,
+	// of the other endorsements, otherwise verified later on by VSCC
+	SignaturesVerified int
+
+	// IdentitiesDeserialized is the number of identities deserialized, of
+	// the creators and, if checked, of the endorsers
+	IdentitiesDeserialized int
+
+	// ActionsProcessed is the number of actions of an endorser transaction
+	// whose validation started
+	ActionsProcessed int
+}
+
+// statsKey is the key of the stats in contexts
+type statsKey struct{}
+
+// withStats returns a context holding new stats, if the validator records
+// them, along with the stats
+func (v *Validator) withStats(ctx context.Context) (context.Context, *ValidationStats) {
+	if !v.RecordStats {
+		return ctx, nil
+	}
+
+	stats := &ValidationStats{}
+	return context.WithValue(ctx, statsKey{}, stats), stats
+}
+
+// countStats updates the stats held by the context, if any
+func countStats(ctx context.Context, update func(stats *ValidationStats)) {
+	if stats, ok := ctx.Value(statsKey{}).(*ValidationStats); ok {
+		update(stats)
+	}
+}
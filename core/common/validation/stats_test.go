@@ -0,0 +1,95 @@
+/*
+Copyright IBM Corp. 2017 All Rights Reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+		 http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package validation
+
+import (
+	"testing"
+
+	"github.com/hyperledger/fabric/protos/common"
+	"github.com/hyperledger/fabric/protos/utils"
+)
+
+func TestRecordStats(t *testing.T) {
+	// a transaction with two actions
+	tx := getCostTransaction(t, 2, 1)
+	sig, err := signer.Sign(tx.Payload)
+	if err != nil {
+		t.Fatalf("Sign failed, err %s", err)
+	}
+	tx.Signature = sig
+
+	badTx := &common.Envelope{Payload: tx.Payload, Signature: append([]byte(nil), sig...)}
+	corrupt(badTx.Signature)
+
+	// the bytes hashed: the payload for the signature, the nonce and the
+	// creator for the TxId, and the header and the chaincode proposal
+	// payload of each action for its proposal hash
+	payload, err := utils.GetPayload(tx)
+	if err != nil {
+		t.Fatalf("GetPayload failed, err %s", err)
+	}
+	transaction, err := utils.GetTransaction(payload.Data)
+	if err != nil {
+		t.Fatalf("GetTransaction failed, err %s", err)
+	}
+	bytesHashed := len(tx.Payload) + len(payload.Header.SignatureHeader.Nonce) + len(payload.Header.SignatureHeader.Creator)
+	for _, act := range transaction.Actions {
+		cap, err := utils.GetChaincodeActionPayload(act.Payload)
+		if err != nil {
+			t.Fatalf("GetChaincodeActionPayload failed, err %s", err)
+		}
+		hdrBytes, err := utils.GetBytesHeader(payload.Header)
+		if err != nil {
+			t.Fatalf("GetBytesHeader failed, err %s", err)
+		}
+		bytesHashed += len(hdrBytes) + len(cap.ChaincodeProposalPayload)
+	}
+
+	// stats are not recorded by default
+	result, err := (&Validator{}).Validate(tx)
+	if err != nil {
+		t.Fatalf("Validate failed, err %s", err)
+	}
+	if result.Stats != nil {
+		t.Fatalf("Stats should not have been recorded, got %v", result.Stats)
+	}
+
+	tests := []struct {
+		name     string
+		v        *Validator
+		tx       *common.Envelope
+		expected ValidationStats
+	}{
+		{"Default", &Validator{RecordStats: true}, tx, ValidationStats{bytesHashed, 1, 1, 2}},
+		// the endorser of each action is deserialized to check its MSP
+		{"AllowedEndorserMSPs", &Validator{RecordStats: true, AllowedEndorserMSPs: map[string]struct{}{"DEFAULT": struct{}{}}}, tx, ValidationStats{bytesHashed, 1, 3, 2}},
+		// the action creators are the creator of the transaction
+		{"CheckActionCreators", &Validator{RecordStats: true, AllowDuplicateEndorsers: true, CheckActionCreators: true}, tx, ValidationStats{bytesHashed, 1, 1, 2}},
+		// the work of the failed signature check is not counted
+		{"BadSignature", &Validator{RecordStats: true}, badTx, ValidationStats{}},
+	}
+
+	for _, test := range tests {
+		result, _ := test.v.Validate(test.tx)
+		if result.Stats == nil {
+			t.Fatalf("%s: Stats should have been recorded", test.name)
+		}
+		if *result.Stats != test.expected {
+			t.Fatalf("%s: expected stats %+v, got %+v", test.name, test.expected, *result.Stats)
+		}
+	}
+}
@@ -0,0 +1,81 @@
+/*
+Copyright IBM Corp. 2017 All Rights Reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+		 http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package validation
+
+import (
+	"fmt"
+
+	"golang.org/x/net/context"
+)
+
+// ValidationStep is a step of the validation of a transaction
+type ValidationStep struct {
+	// Name is the name of the step: "payload", "header", "signature",
+	// "txid", "ttl", "spamguard", "policyname", "action-N-sighdr",
+	// "action-N-endorsers" and "action-N-prophash" for the N-th action of
+	// endorser transactions, "config" for config transactions, "chaincode",
+	// "plugins" and "sequence"
+	Name string
+
+	// Passed is true if the transaction passed the step; the validation
+	// goes on after the failed steps performing checks run in shadow mode
+	Passed bool
+}
+
+// stepRecorder records the steps of a validation
+type stepRecorder struct {
+	steps []ValidationStep
+}
+
+// stepsKey is the key of the step recorder in contexts
+type stepsKey struct{}
+
+// withStepRecorder returns a context holding a new step recorder, if the
+// validator records steps, along with the recorder
+func (v *Validator) withStepRecorder(ctx context.Context) (context.Context, *stepRecorder) {
+	if !v.RecordSteps {
+		return ctx, nil
+	}
+
+	recorder := &stepRecorder{}
+	return context.WithValue(ctx, stepsKey{}, recorder), recorder
+}
+
+// recordStep records the outcome of a step in the recorder of the context,
+// if any, and returns err, unless the step performs a check run in shadow
+// mode
+func recordStep(ctx context.Context, name string, err error) error {
+	if recorder, ok := ctx.Value(stepsKey{}).(*stepRecorder); ok {
+		recorder.steps = append(recorder.steps, ValidationStep{Name: name, Passed: err == nil})
+	}
+
+	return shadowStep(ctx, name, err)
+}
+
+// actionStep returns the name of a step of the validation of the i-th action
+func actionStep(i int, step string) string {
+	return fmt.Sprintf("action-%d-%s", i, step)
+}
+
+// getSteps returns the steps recorded by the recorder, if not nil
+func (r *stepRecorder) getSteps() []ValidationStep {
+	if r == nil {
+		return nil
+	}
+
+	return r.steps
+}
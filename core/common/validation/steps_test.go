@@ -0,0 +1,83 @@
+/*
+Copyright IBM Corp. 2017 All Rights Reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+		 http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package validation
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/hyperledger/fabric/protos/common"
+)
+
+func TestRecordSteps(t *testing.T) {
+	// a transaction with two actions
+	tx := getCostTransaction(t, 2, 1)
+	sig, err := signer.Sign(tx.Payload)
+	if err != nil {
+		t.Fatalf("Sign failed, err %s", err)
+	}
+	tx.Signature = sig
+
+	badTx := &common.Envelope{Payload: tx.Payload, Signature: append([]byte(nil), sig...)}
+	corrupt(badTx.Signature)
+
+	// steps are not recorded by default
+	result, err := (&Validator{}).Validate(tx)
+	if err != nil {
+		t.Fatalf("Validate failed, err %s", err)
+	}
+	if result.Steps != nil {
+		t.Fatalf("Steps should not have been recorded, got %v", result.Steps)
+	}
+
+	v := &Validator{RecordSteps: true}
+
+	result, err = v.Validate(tx)
+	if err != nil {
+		t.Fatalf("Validate failed, err %s", err)
+	}
+	expected := []ValidationStep{
+		{"payload", true},
+		{"header", true},
+		{"signature", true},
+		{"txid", true},
+		{"action-0-sighdr", true},
+		{"action-0-endorsers", true},
+		{"action-0-prophash", true},
+		{"action-1-sighdr", true},
+		{"action-1-endorsers", true},
+		{"action-1-prophash", true},
+		{"plugins", true},
+	}
+	if !reflect.DeepEqual(result.Steps, expected) {
+		t.Fatalf("Expected steps %v, got %v", expected, result.Steps)
+	}
+
+	// the validation stops at the first failed step
+	result, err = v.Validate(badTx)
+	if err == nil {
+		t.Fatalf("Validate should have failed")
+	}
+	expected = []ValidationStep{
+		{"payload", true},
+		{"header", true},
+		{"signature", false},
+	}
+	if !reflect.DeepEqual(result.Steps, expected) {
+		t.Fatalf("Expected steps %v, got %v", expected, result.Steps)
+	}
+}
@@ -0,0 +1,38 @@
+/*
+Copyright IBM Corp. 2017 All Rights Reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+		 http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package validation
+
+import "errors"
+
+// ErrMSPSuspended is returned for the proposals and transactions whose
+// creator is a member of a suspended MSP
+var ErrMSPSuspended = errors.New("The MSP of the creator is suspended")
+
+// checkSuspendedMSP ensures that the MSP that validated the creator is not
+// suspended; the error is classified as configured by SuspendedMSPErrorClass
+func (v *Validator) checkSuspendedMSP(mspID string) error {
+	if _, suspended := v.SuspendedMSPs[mspID]; !suspended {
+		return nil
+	}
+
+	putilsLogger.Errorf("checkSuspendedMSP error: MSP %s is suspended", mspID)
+	if v.SuspendedMSPErrorClass == UnclassifiedError {
+		return ErrMSPSuspended
+	}
+
+	return &ValidationError{Class: v.SuspendedMSPErrorClass, Err: ErrMSPSuspended}
+}
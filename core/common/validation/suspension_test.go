@@ -0,0 +1,77 @@
+/*
+Copyright IBM Corp. 2017 All Rights Reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+		 http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package validation
+
+import (
+	"testing"
+
+	"github.com/hyperledger/fabric/protos/utils"
+)
+
+func TestSuspendedMSPs(t *testing.T) {
+	tx, err := getTransaction([]byte("simulation_result"))
+	if err != nil {
+		t.Fatalf("getTransaction failed, err %s", err)
+	}
+
+	prop, err := getProposal()
+	if err != nil {
+		t.Fatalf("getProposal failed, err %s", err)
+	}
+
+	sProp, err := utils.GetSignedProposal(prop, signer)
+	if err != nil {
+		t.Fatalf("GetSignedProposal failed, err %s", err)
+	}
+
+	tests := []struct {
+		name      string
+		suspended map[string]struct{}
+		class     ErrorClass
+		err       bool
+	}{
+		{"NoneSuspended", nil, UnclassifiedError, false},
+		{"OtherSuspended", map[string]struct{}{"Org1MSP": {}}, PermanentError, false},
+		{"Suspended", map[string]struct{}{"DEFAULT": {}}, UnclassifiedError, true},
+		{"SuspendedRetryable", map[string]struct{}{"DEFAULT": {}}, TransientError, true},
+		{"SuspendedTerminal", map[string]struct{}{"DEFAULT": {}}, PermanentError, true},
+	}
+
+	for _, test := range tests {
+		v := &Validator{SuspendedMSPs: test.suspended, SuspendedMSPErrorClass: test.class}
+		_, txErr := v.ValidateTransaction(tx)
+		_, _, _, propErr := v.ValidateProposalMessage(sProp)
+		for _, err := range []error{txErr, propErr} {
+			if !test.err {
+				if err != nil {
+					t.Fatalf("%s: validation failed, err %s", test.name, err)
+				}
+				continue
+			}
+
+			if GetErrorClass(err) != test.class {
+				t.Fatalf("%s: expected an error of class %s, got %v of class %s", test.name, test.class, err, GetErrorClass(err))
+			}
+			if verr, ok := err.(*ValidationError); ok {
+				err = verr.Err
+			}
+			if err != ErrMSPSuspended {
+				t.Fatalf("%s: expected err %v, got %v", test.name, ErrMSPSuspended, err)
+			}
+		}
+	}
+}
@@ -0,0 +1,92 @@
+/*
+Copyright IBM Corp. 2017 All Rights Reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+		 http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package validation
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/hyperledger/fabric/bccsp"
+	"github.com/hyperledger/fabric/bccsp/factory"
+	"github.com/hyperledger/fabric/protos/common"
+	"golang.org/x/net/context"
+)
+
+// DefaultTimestampTokenTolerance is the largest difference allowed between
+// the timestamp in the header of a transaction and the time certified by
+// its time-stamp token when the Validator sets no TimestampTokenTolerance
+const DefaultTimestampTokenTolerance = 5 * time.Minute
+
+// TimestampAuthorityVerifier verifies the tokens issued by a trusted
+// time-stamping authority (e.g. RFC 3161 time-stamp tokens)
+type TimestampAuthorityVerifier interface {
+	// Verify checks that token is a valid time-stamp token issued over
+	// digest and returns the time it certifies
+	Verify(token []byte, digest []byte) (time.Time, error)
+}
+
+// ValidateTransactionWithTimestampToken checks that the transaction envelope
+// is properly formed and, if a token is supplied and the validator has a
+// TimestampAuthorityVerifier, that the token is a valid time-stamp token
+// over the SHA-256 digest of the envelope's payload. As the token proves
+// that the payload existed at the time it certifies, the timestamp in the
+// header of the transaction must be that time, give or take the
+// TimestampTokenTolerance: a header claiming a time much earlier than
+// the token is backdated, and one claiming a later time contradicts it
+func (v *Validator) ValidateTransactionWithTimestampToken(e *common.Envelope, token []byte) (*common.Payload, error) {
+	result, err := v.validateWith(context.Background(), e, func(result *ValidationResult) error {
+		return v.checkTimestampToken(e, result.Payload, token)
+	})
+	return result.Payload, err
+}
+
+// checkTimestampToken checks the time-stamp token of a valid transaction
+func (v *Validator) checkTimestampToken(e *common.Envelope, payload *common.Payload, token []byte) error {
+	if len(token) == 0 || v.TimestampAuthorityVerifier == nil {
+		return nil
+	}
+
+	digest, err := factory.GetDefault().Hash(e.Payload, &bccsp.SHA256Opts{})
+	if err != nil {
+		return fmt.Errorf("Failed computing the digest of the payload, err %s", err)
+	}
+
+	certified, err := v.TimestampAuthorityVerifier.Verify(token, digest)
+	if err != nil {
+		return fmt.Errorf("Invalid time-stamp token, err %s", err)
+	}
+
+	ts := payload.Header.ChannelHeader.Timestamp
+	if ts == nil {
+		return fmt.Errorf("Nil timestamp in the header")
+	}
+
+	tolerance := v.TimestampTokenTolerance
+	if tolerance == 0 {
+		tolerance = DefaultTimestampTokenTolerance
+	}
+
+	claimed := time.Unix(ts.Seconds, int64(ts.Nanos))
+	if claimed.After(certified.Add(tolerance)) {
+		return fmt.Errorf("Transaction timestamp %s is later than the time certified by the time-stamp token %s", claimed, certified)
+	}
+	if claimed.Before(certified.Add(-tolerance)) {
+		return fmt.Errorf("Transaction timestamp %s is earlier than the time certified by the time-stamp token %s", claimed, certified)
+	}
+
+	return nil
+}
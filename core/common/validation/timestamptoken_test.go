@@ -0,0 +1,132 @@
+/*
+Copyright IBM Corp. 2017 All Rights Reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+		 http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package validation
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/golang/protobuf/proto"
+	"github.com/golang/protobuf/ptypes/timestamp"
+	"github.com/hyperledger/fabric/bccsp"
+	"github.com/hyperledger/fabric/bccsp/factory"
+	"github.com/hyperledger/fabric/protos/common"
+	"github.com/hyperledger/fabric/protos/utils"
+)
+
+// mockTimestampAuthority accepts the tokens it has issued
+type mockTimestampAuthority struct {
+	tokens map[string]time.Time
+	digest []byte
+}
+
+func (m *mockTimestampAuthority) Verify(token []byte, digest []byte) (time.Time, error) {
+	t, ok := m.tokens[string(token)]
+	if !ok {
+		return time.Time{}, errors.New("unknown token")
+	}
+	if !bytes.Equal(digest, m.digest) {
+		return time.Time{}, errors.New("digest mismatch")
+	}
+	return t, nil
+}
+
+// getTransactionAt returns a signed transaction whose header carries the supplied timestamp
+func getTransactionAt(t *testing.T, at time.Time) *common.Envelope {
+	prop, err := getProposal()
+	if err != nil {
+		t.Fatalf("getProposal failed, err %s", err)
+	}
+
+	hdr, err := utils.GetHeader(prop.Header)
+	if err != nil {
+		t.Fatalf("GetHeader failed, err %s", err)
+	}
+	hdr.ChannelHeader.Timestamp = &timestamp.Timestamp{Seconds: at.Unix(), Nanos: int32(at.Nanosecond())}
+	prop.Header, err = proto.Marshal(hdr)
+	if err != nil {
+		t.Fatalf("Marshal failed, err %s", err)
+	}
+
+	tx, err := getTransactionForProposal(prop, []byte("simulation_result"))
+	if err != nil {
+		t.Fatalf("getTransactionForProposal failed, err %s", err)
+	}
+
+	return tx
+}
+
+func TestTimestampToken(t *testing.T) {
+	certified := time.Date(2017, 3, 1, 12, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name      string
+		claimed   time.Time
+		token     []byte
+		tolerance time.Duration
+		valid     bool
+	}{
+		{"NoToken", certified.Add(time.Hour), nil, 0, true},
+		{"Consistent", certified.Add(-time.Second), []byte("token"), 0, true},
+		{"SkewedWithinTolerance", certified.Add(time.Minute), []byte("token"), 0, true},
+		{"Contradictory", certified.Add(time.Hour), []byte("token"), 0, false},
+		{"Backdated", certified.Add(-time.Hour), []byte("token"), 0, false},
+		{"BackdatedWithinTolerance", certified.Add(-time.Hour), []byte("token"), 2 * time.Hour, true},
+		{"SkewedBeyondTolerance", certified.Add(-time.Minute), []byte("token"), time.Second, false},
+		{"InvalidToken", certified.Add(-time.Second), []byte("forged"), 0, false},
+	}
+
+	for _, test := range tests {
+		tx := getTransactionAt(t, test.claimed)
+		digest, err := factory.GetDefault().Hash(tx.Payload, &bccsp.SHA256Opts{})
+		if err != nil {
+			t.Fatalf("Hash failed, err %s", err)
+		}
+
+		v := &Validator{
+			TimestampAuthorityVerifier: &mockTimestampAuthority{
+				tokens: map[string]time.Time{"token": certified},
+				digest: digest,
+			},
+			TimestampTokenTolerance: test.tolerance,
+		}
+		_, err = v.ValidateTransactionWithTimestampToken(tx, test.token)
+		if test.valid && err != nil {
+			t.Fatalf("%s: ValidateTransactionWithTimestampToken failed, err %s", test.name, err)
+		}
+		if !test.valid && err == nil {
+			t.Fatalf("%s: ValidateTransactionWithTimestampToken should have failed", test.name)
+		}
+	}
+}
+
+func TestTimestampTokenOtherPayload(t *testing.T) {
+	certified := time.Date(2017, 3, 1, 12, 0, 0, 0, time.UTC)
+	tx := getTransactionAt(t, certified.Add(-time.Second))
+
+	// the token was issued for a different payload
+	v := &Validator{TimestampAuthorityVerifier: &mockTimestampAuthority{
+		tokens: map[string]time.Time{"token": certified},
+		digest: []byte("another digest"),
+	}}
+	_, err := v.ValidateTransactionWithTimestampToken(tx, []byte("token"))
+	if err == nil {
+		t.Fatalf("ValidateTransactionWithTimestampToken should have failed")
+	}
+}
@@ -0,0 +1,100 @@
+/*
+Copyright IBM Corp. 2017 All Rights Reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+		 http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package validation
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+
+	"github.com/hyperledger/fabric/bccsp"
+	"github.com/hyperledger/fabric/bccsp/factory"
+	"github.com/hyperledger/fabric/protos/common"
+	"golang.org/x/net/context"
+)
+
+// ErrTLSBindingMismatch is returned for endorser transactions bound to a TLS
+// client certificate other than that of the connection they were submitted on
+var ErrTLSBindingMismatch = errors.New("The transaction is bound to another TLS client certificate")
+
+// ErrMissingTLSBinding is returned, in strict mode, for endorser transactions
+// that are not bound to a TLS client certificate
+var ErrMissingTLSBinding = errors.New("The transaction is not bound to a TLS client certificate")
+
+// TLSBindingChecker checks the binding of endorser transactions to the TLS
+// client certificates of the connections they are submitted on: the creator
+// of a transaction may bind it to a certificate by setting the TlsCertHash
+// field of its chaincode header extension to the SHA-256 hash of the DER
+// encoding of the certificate, so that it cannot be submitted over another
+// connection
+type TLSBindingChecker struct {
+	// Strict, if set, rejects the transactions that are not bound; by
+	// default only the bound ones are checked
+	Strict bool
+}
+
+// check ensures that the TLS client certificate the channel header binds
+// the transaction to, if any, is clientCert
+func (c *TLSBindingChecker) check(ctx context.Context, chdr *common.ChannelHeader, clientCert []byte) error {
+	ext, err := getHeaderExtension(ctx, chdr)
+	if err != nil {
+		return err
+	}
+
+	certHash := ext.TlsCertHash
+	if len(certHash) == 0 {
+		if c.Strict {
+			putilsLogger.Errorf("Transaction [%s] is not bound to a TLS client certificate", chdr.TxId)
+			return ErrMissingTLSBinding
+		}
+		return nil
+	}
+
+	if len(clientCert) == 0 {
+		putilsLogger.Errorf("Transaction [%s] is bound to a TLS client certificate but was submitted without", chdr.TxId)
+		return ErrTLSBindingMismatch
+	}
+
+	digest, err := factory.GetDefault().Hash(clientCert, &bccsp.SHA256Opts{})
+	if err != nil {
+		return fmt.Errorf("Failed computing the hash of the TLS client certificate, err %s", err)
+	}
+
+	if !bytes.Equal(certHash, digest) {
+		putilsLogger.Errorf("Transaction [%s] is bound to TLS client certificate hash %x, submitted with %x", chdr.TxId, certHash, digest)
+		return ErrTLSBindingMismatch
+	}
+
+	return nil
+}
+
+// ValidateTransactionWithTLSBinding checks that the transaction envelope is
+// properly formed and, if the validator has a TLSBindingChecker and the
+// transaction is an endorser transaction, that it is bound to clientCert,
+// the DER encoded TLS client certificate of the connection it was submitted
+// on, or nil if the connection had none
+func (v *Validator) ValidateTransactionWithTLSBinding(e *common.Envelope, clientCert []byte) (*common.Payload, error) {
+	ctx := withHeaderExtension(context.Background())
+	result, err := v.validateWith(ctx, e, func(result *ValidationResult) error {
+		if v.TLSBindingChecker == nil || common.HeaderType(result.Payload.Header.ChannelHeader.Type) != common.HeaderType_ENDORSER_TRANSACTION {
+			return nil
+		}
+
+		return v.TLSBindingChecker.check(ctx, result.Payload.Header.ChannelHeader, clientCert)
+	})
+	return result.Payload, err
+}
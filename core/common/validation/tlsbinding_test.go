@@ -0,0 +1,71 @@
+/*
+Copyright IBM Corp. 2017 All Rights Reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+		 http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package validation
+
+import (
+	"crypto/sha256"
+	"testing"
+
+	"github.com/hyperledger/fabric/protos/common"
+	"github.com/hyperledger/fabric/protos/peer"
+	"github.com/hyperledger/fabric/protos/utils"
+)
+
+// encodeTLSCertHash encodes the hash of a TLS client certificate as a field
+// of the chaincode header extension
+func encodeTLSCertHash(clientCert []byte) []byte {
+	digest := sha256.Sum256(clientCert)
+	return utils.MarshalOrPanic(&peer.ChaincodeHeaderExtension{TlsCertHash: digest[:]})
+}
+
+func TestTLSBinding(t *testing.T) {
+	clientCert := []byte("client certificate")
+	otherCert := []byte("other certificate")
+
+	boundTx, _ := getTransactionWithExtension(t, encodeTLSCertHash(clientCert))
+	unboundTx, _ := getTransactionWithExtension(t, nil)
+
+	tests := []struct {
+		name       string
+		tx         *common.Envelope
+		clientCert []byte
+		checker    *TLSBindingChecker
+		err        error
+	}{
+		{"Matching", boundTx, clientCert, &TLSBindingChecker{}, nil},
+		{"MatchingStrict", boundTx, clientCert, &TLSBindingChecker{Strict: true}, nil},
+		{"Mismatched", boundTx, otherCert, &TLSBindingChecker{}, ErrTLSBindingMismatch},
+		{"NoClientCert", boundTx, nil, &TLSBindingChecker{}, ErrTLSBindingMismatch},
+		{"Absent", unboundTx, clientCert, &TLSBindingChecker{}, nil},
+		{"AbsentStrict", unboundTx, clientCert, &TLSBindingChecker{Strict: true}, ErrMissingTLSBinding},
+		{"NotChecked", boundTx, otherCert, nil, nil},
+	}
+
+	for _, test := range tests {
+		v := &Validator{TLSBindingChecker: test.checker}
+		_, err := v.ValidateTransactionWithTLSBinding(test.tx, test.clientCert)
+		if err != test.err {
+			t.Fatalf("%s: expected err %v, got %v", test.name, test.err, err)
+		}
+	}
+
+	// the binding is not checked by ValidateTransaction
+	_, err := (&Validator{TLSBindingChecker: &TLSBindingChecker{Strict: true}}).ValidateTransaction(unboundTx)
+	if err != nil {
+		t.Fatalf("ValidateTransaction failed, err %s", err)
+	}
+}
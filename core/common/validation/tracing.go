@@ -0,0 +1,136 @@
+/*
+Copyright IBM Corp. 2017 All Rights Reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+		 http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package validation
+
+import (
+	"github.com/golang/protobuf/proto"
+	"github.com/hyperledger/fabric/msp"
+	"github.com/hyperledger/fabric/protos/common"
+	"golang.org/x/net/context"
+)
+
+// Names of the spans tracing the validation of transactions
+const (
+	// ValidateTransactionSpan spans the validation of a transaction
+	ValidateTransactionSpan = "ValidateTransaction"
+
+	// VerifySignatureSpan spans the verification of the creator's signature
+	VerifySignatureSpan = "VerifySignature"
+
+	// VerifyProposalHashSpan spans the recomputation of the proposal hash
+	// of an action
+	VerifyProposalHashSpan = "VerifyProposalHash"
+)
+
+// Keys of the attributes of the spans tracing the validation of transactions
+const (
+	ChannelAttribute     = "channel"
+	TxIDAttribute        = "txid"
+	CreatorMSPAttribute  = "creator.msp"
+	ActionCountAttribute = "actions"
+	OutcomeAttribute     = "outcome"
+	ErrorAttribute       = "error"
+)
+
+// Tracer starts the spans tracing the validation of transactions; it can be
+// implemented by an adapter to a distributed tracing system, such as an
+// OpenTelemetry tracer
+type Tracer interface {
+	// Start starts a span with the given name, as a child of parent if
+	// parent is not nil
+	Start(parent Span, name string) Span
+}
+
+// Span is a span started by a Tracer
+type Span interface {
+	// SetAttribute sets an attribute of the span
+	SetAttribute(key string, value interface{})
+
+	// End ends the span
+	End()
+}
+
+// spanKey is the key of the current span in contexts
+type spanKey struct{}
+
+// startSpan starts a span as a child of the span of the context, if the
+// validator has a tracer, and returns it along with a context holding it.
+// Without tracer, the context is returned unchanged along with a nil span
+func (v *Validator) startSpan(ctx context.Context, name string) (context.Context, Span) {
+	if v.Tracer == nil {
+		return ctx, nil
+	}
+
+	parent, _ := ctx.Value(spanKey{}).(Span)
+	span := v.Tracer.Start(parent, name)
+
+	return context.WithValue(ctx, spanKey{}, span), span
+}
+
+// spanFromContext returns the span held by the context, if any
+func spanFromContext(ctx context.Context) Span {
+	span, _ := ctx.Value(spanKey{}).(Span)
+	return span
+}
+
+// setSpanAttribute sets an attribute of the span, if not nil
+func setSpanAttribute(span Span, key string, value interface{}) {
+	if span != nil {
+		span.SetAttribute(key, value)
+	}
+}
+
+// endSpan records the outcome of the operation on the span, if not nil,
+// and ends it
+func endSpan(span Span, err error) {
+	if span == nil {
+		return
+	}
+
+	if err != nil {
+		span.SetAttribute(OutcomeAttribute, "invalid")
+		span.SetAttribute(ErrorAttribute, err.Error())
+	} else {
+		span.SetAttribute(OutcomeAttribute, "valid")
+	}
+
+	span.End()
+}
+
+// endValidationSpan records the attributes of the transaction on the span
+// tracing its validation, if not nil, and ends it
+func endValidationSpan(span Span, payload *common.Payload, err error) {
+	if span == nil {
+		return
+	}
+
+	if payload != nil && payload.Header != nil {
+		if chdr := payload.Header.ChannelHeader; chdr != nil {
+			span.SetAttribute(ChannelAttribute, chdr.ChannelId)
+			span.SetAttribute(TxIDAttribute, chdr.TxId)
+		}
+
+		if shdr := payload.Header.SignatureHeader; shdr != nil {
+			sId := &msp.SerializedIdentity{}
+			if proto.Unmarshal(shdr.Creator, sId) == nil {
+				span.SetAttribute(CreatorMSPAttribute, sId.Mspid)
+			}
+		}
+	}
+
+	endSpan(span, err)
+}
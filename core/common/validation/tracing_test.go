@@ -0,0 +1,128 @@
+/*
+Copyright IBM Corp. 2017 All Rights Reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+		 http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package validation
+
+import (
+	"sync"
+	"testing"
+
+	"github.com/hyperledger/fabric/common/util"
+	"github.com/hyperledger/fabric/protos/common"
+)
+
+// mockSpan records its attributes and whether it ended
+type mockSpan struct {
+	name       string
+	parent     *mockSpan
+	attributes map[string]interface{}
+	ended      bool
+}
+
+func (s *mockSpan) SetAttribute(key string, value interface{}) {
+	s.attributes[key] = value
+}
+
+func (s *mockSpan) End() {
+	s.ended = true
+}
+
+// mockTracer records the spans it starts
+type mockTracer struct {
+	sync.Mutex
+	spans []*mockSpan
+}
+
+func (tr *mockTracer) Start(parent Span, name string) Span {
+	tr.Lock()
+	defer tr.Unlock()
+
+	span := &mockSpan{name: name, attributes: make(map[string]interface{})}
+	if parent != nil {
+		span.parent = parent.(*mockSpan)
+	}
+	tr.spans = append(tr.spans, span)
+
+	return span
+}
+
+func TestTracing(t *testing.T) {
+	// a transaction with two actions
+	tx := getCostTransaction(t, 2, 1)
+	sig, err := signer.Sign(tx.Payload)
+	if err != nil {
+		t.Fatalf("Sign failed, err %s", err)
+	}
+	tx.Signature = sig
+
+	badTx := &common.Envelope{Payload: tx.Payload, Signature: append([]byte(nil), sig...)}
+	corrupt(badTx.Signature)
+
+	tracer := &mockTracer{}
+	v := &Validator{Tracer: tracer}
+
+	payload, err := v.ValidateTransaction(tx)
+	if err != nil {
+		t.Fatalf("ValidateTransaction failed, err %s", err)
+	}
+
+	if len(tracer.spans) != 4 {
+		t.Fatalf("Expected 4 spans, got %d", len(tracer.spans))
+	}
+
+	root := tracer.spans[0]
+	if root.name != ValidateTransactionSpan || root.parent != nil || !root.ended {
+		t.Fatalf("Unexpected root span %+v", root)
+	}
+
+	expected := map[string]interface{}{
+		ChannelAttribute:     util.GetTestChainID(),
+		TxIDAttribute:        payload.Header.ChannelHeader.TxId,
+		CreatorMSPAttribute:  "DEFAULT",
+		ActionCountAttribute: 2,
+		OutcomeAttribute:     "valid",
+	}
+	for key, value := range expected {
+		if root.attributes[key] != value {
+			t.Fatalf("Expected attribute %s to be %v, got %v", key, value, root.attributes[key])
+		}
+	}
+
+	names := []string{VerifySignatureSpan, VerifyProposalHashSpan, VerifyProposalHashSpan}
+	for i, name := range names {
+		span := tracer.spans[i+1]
+		if span.name != name || span.parent != root || !span.ended || span.attributes[OutcomeAttribute] != "valid" {
+			t.Fatalf("Unexpected child span %d %+v", i, span)
+		}
+	}
+
+	// the outcome of invalid transactions is recorded
+	tracer.spans = nil
+	_, err = v.ValidateTransaction(badTx)
+	if err == nil {
+		t.Fatalf("ValidateTransaction should have failed")
+	}
+
+	if len(tracer.spans) != 2 {
+		t.Fatalf("Expected 2 spans, got %d", len(tracer.spans))
+	}
+
+	for _, span := range tracer.spans {
+		if span.attributes[OutcomeAttribute] != "invalid" || span.attributes[ErrorAttribute] == nil || !span.ended {
+			t.Fatalf("Unexpected span %+v", span)
+		}
+	}
+}
@@ -0,0 +1,41 @@
+/*
+Copyright IBM Corp. 2017 All Rights Reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+		 http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package validation
+
+import (
+	"errors"
+
+	pb "github.com/hyperledger/fabric/protos/peer"
+)
+
+// ErrTransientDataInTransaction is returned for transactions whose chaincode
+// proposal payload carries transient data
+var ErrTransientDataInTransaction = errors.New("The transaction carries transient data")
+
+// checkNoTransientData ensures that the chaincode proposal payload of an
+// action carries no transient data: the transient map of a proposal is only
+// meant for its endorsers, and is stripped off the proposal payload put in
+// the transaction, the proposal hash being computed without it. A non-empty
+// map would leak confidential data to the ledger
+func checkNoTransientData(cpp *pb.ChaincodeProposalPayload) error {
+	if len(cpp.TransientMap) != 0 {
+		putilsLogger.Errorf("checkNoTransientData error: transient map with %d entries in the transaction", len(cpp.TransientMap))
+		return ErrTransientDataInTransaction
+	}
+
+	return nil
+}
@@ -0,0 +1,83 @@
+/*
+Copyright IBM Corp. 2017 All Rights Reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+		 http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package validation
+
+import (
+	"testing"
+
+	"github.com/hyperledger/fabric/common/util"
+	"github.com/hyperledger/fabric/protos/common"
+	"github.com/hyperledger/fabric/protos/peer"
+	"github.com/hyperledger/fabric/protos/utils"
+)
+
+func TestTransientData(t *testing.T) {
+	cis := &peer.ChaincodeInvocationSpec{
+		ChaincodeSpec: &peer.ChaincodeSpec{
+			ChaincodeId: &peer.ChaincodeID{Name: "foo"},
+			Type:        peer.ChaincodeSpec_GOLANG}}
+	prop, _, err := utils.CreateChaincodeProposalWithTransient(common.HeaderType_ENDORSER_TRANSACTION, util.GetTestChainID(), cis, signerSerialized, map[string][]byte{"secret": []byte("value")})
+	if err != nil {
+		t.Fatalf("CreateChaincodeProposalWithTransient failed, err %s", err)
+	}
+
+	// the transient map of the proposal is stripped off the transaction
+	tx, err := getTransactionForProposal(prop, []byte("simulation_result"))
+	if err != nil {
+		t.Fatalf("getTransactionForProposal failed, err %s", err)
+	}
+
+	// the proposal payload, transient map included, is put in the
+	// transaction, along with a matching proposal hash
+	proposalHash, err := utils.GetProposalHash2(prop.Header, prop.Payload)
+	if err != nil {
+		t.Fatalf("GetProposalHash2 failed, err %s", err)
+	}
+	leakingTx, err := modifyTransaction(tx, func(sHdr *common.SignatureHeader, cap *peer.ChaincodeActionPayload, prp *peer.ProposalResponsePayload) {
+		cap.ChaincodeProposalPayload = prop.Payload
+		prp.ProposalHash = proposalHash
+	})
+	if err != nil {
+		t.Fatalf("modifyTransaction failed, err %s", err)
+	}
+
+	tests := []struct {
+		name string
+		tx   *common.Envelope
+		err  error
+	}{
+		{"Stripped", tx, nil},
+		{"Leaking", leakingTx, ErrTransientDataInTransaction},
+	}
+
+	for _, test := range tests {
+		_, err := ValidateTransaction(test.tx)
+		if err != test.err {
+			t.Fatalf("%s: expected err %v, got %v", test.name, test.err, err)
+		}
+	}
+
+	// the transient map is meant for the endorsers
+	sProp, err := utils.GetSignedProposal(prop, signer)
+	if err != nil {
+		t.Fatalf("GetSignedProposal failed, err %s", err)
+	}
+	_, _, _, err = ValidateProposalMessage(sProp)
+	if err != nil {
+		t.Fatalf("ValidateProposalMessage failed, err %s", err)
+	}
+}
@@ -0,0 +1,85 @@
+/*
+Copyright IBM Corp. 2017 All Rights Reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+		 http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package validation
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/hyperledger/fabric/protos/common"
+	"golang.org/x/net/context"
+)
+
+// ErrTransactionExpired is returned for transactions validated after the
+// expiry of their TTL
+var ErrTransactionExpired = errors.New("The transaction has expired")
+
+// getTTL returns the TTL declared in the Ttl field of the chaincode header
+// extension of a channel header, as a number of seconds, or false if none
+// is: the transaction must not be committed past its timestamp plus its TTL
+func getTTL(ctx context.Context, chdr *common.ChannelHeader) (time.Duration, bool, error) {
+	ext, err := getHeaderExtension(ctx, chdr)
+	if err != nil || ext.Ttl == 0 {
+		return 0, false, err
+	}
+
+	value := ext.Ttl
+	if value > uint64(time.Duration(1<<63-1)/time.Second) {
+		return 0, false, permanentDecodeError(fmt.Errorf("TTL of %d seconds out of range", value))
+	}
+
+	return time.Duration(value) * time.Second, true, nil
+}
+
+// now returns the current time according to the clock of the validator
+func (v *Validator) now() time.Time {
+	if v.Clock != nil {
+		return v.Clock()
+	}
+
+	return time.Now()
+}
+
+// validateTTL ensures that the TTL declared by the transaction, if any, has
+// not expired; the clock of the validator may be up to MaxClockSkew ahead
+// of that of the creator, the transactions only valid thanks to the skew
+// being warned about
+func (v *Validator) validateTTL(ctx context.Context, chdr *common.ChannelHeader) error {
+	ttl, found, err := getTTL(ctx, chdr)
+	if err != nil {
+		return err
+	}
+
+	if !found {
+		return nil
+	}
+
+	if chdr.Timestamp == nil {
+		return fmt.Errorf("Missing timestamp on a transaction with a TTL")
+	}
+
+	expiry := time.Unix(chdr.Timestamp.Seconds, int64(chdr.Timestamp.Nanos)).Add(ttl).Add(v.MaxClockSkew)
+	if now := v.now(); now.After(expiry) {
+		putilsLogger.Errorf("Transaction [%s] expired at %s, it is %s", chdr.TxId, expiry, now)
+		return ErrTransactionExpired
+	} else if now.After(expiry.Add(-v.MaxClockSkew)) {
+		addWarning(ctx, WarningClockSkew, "Transaction [%s] expired at %s, within the clock skew, it is %s", chdr.TxId, expiry.Add(-v.MaxClockSkew), now)
+	}
+
+	return nil
+}
@@ -0,0 +1,122 @@
+/*
+Copyright IBM Corp. 2017 All Rights Reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+		 http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package validation
+
+import (
+	"testing"
+	"time"
+
+	"github.com/golang/protobuf/proto"
+	"github.com/golang/protobuf/ptypes/timestamp"
+	"github.com/hyperledger/fabric/protos/common"
+	"github.com/hyperledger/fabric/protos/peer"
+	"github.com/hyperledger/fabric/protos/utils"
+	"golang.org/x/net/context"
+)
+
+// getTransactionWithExtension returns a signed transaction for a toy
+// proposal created now, whose chaincode header extension is followed by
+// the extra bytes, along with the time it was created
+func getTransactionWithExtension(t *testing.T, extra []byte) (*common.Envelope, time.Time) {
+	created := time.Unix(time.Now().Unix(), 0)
+
+	prop, err := getProposal()
+	if err != nil {
+		t.Fatalf("getProposal failed, err %s", err)
+	}
+
+	hdr, err := utils.GetHeader(prop.Header)
+	if err != nil {
+		t.Fatalf("GetHeader failed, err %s", err)
+	}
+	hdr.ChannelHeader.Timestamp = &timestamp.Timestamp{Seconds: created.Unix()}
+	hdr.ChannelHeader.Extension = append(hdr.ChannelHeader.Extension, extra...)
+	prop.Header = utils.MarshalOrPanic(hdr)
+
+	tx, err := getTransactionForProposal(prop, []byte("simulation_result"))
+	if err != nil {
+		t.Fatalf("getTransactionForProposal failed, err %s", err)
+	}
+
+	return tx, created
+}
+
+// encodeTTL encodes a TTL as a field of the chaincode header extension
+func encodeTTL(ttl time.Duration) []byte {
+	return utils.MarshalOrPanic(&peer.ChaincodeHeaderExtension{Ttl: uint64(ttl / time.Second)})
+}
+
+func TestTTL(t *testing.T) {
+	tx, created := getTransactionWithExtension(t, encodeTTL(time.Minute))
+	noTTLTx, noTTLCreated := getTransactionWithExtension(t, nil)
+
+	// a Ttl field claiming more bytes than the extension holds
+	truncated := proto.NewBuffer(nil)
+	truncated.EncodeVarint(100<<3 | proto.WireBytes)
+	truncated.EncodeVarint(16)
+	payload, err := utils.GetPayload(noTTLTx)
+	if err != nil {
+		t.Fatalf("GetPayload failed, err %s", err)
+	}
+	payload.Header.ChannelHeader.Extension = append(payload.Header.ChannelHeader.Extension, truncated.Bytes()...)
+	malformedTx := &common.Envelope{Payload: utils.MarshalOrPanic(payload)}
+	malformedTx.Signature, err = signer.Sign(malformedTx.Payload)
+	if err != nil {
+		t.Fatalf("Sign failed, err %s", err)
+	}
+
+	tests := []struct {
+		name    string
+		tx      *common.Envelope
+		now     time.Time
+		enforce bool
+		skew    time.Duration
+		err     error
+	}{
+		{"NotExpired", tx, created.Add(30 * time.Second), true, 0, nil},
+		{"Expired", tx, created.Add(90 * time.Second), true, 0, ErrTransactionExpired},
+		{"WithinSkew", tx, created.Add(90 * time.Second), true, time.Minute, nil},
+		{"NotEnforced", tx, created.Add(90 * time.Second), false, 0, nil},
+		{"NoTTL", noTTLTx, noTTLCreated.Add(24 * time.Hour), true, 0, nil},
+	}
+
+	for _, test := range tests {
+		now := test.now
+		v := &Validator{EnforceTTL: test.enforce, MaxClockSkew: test.skew, Clock: func() time.Time { return now }}
+		_, err := v.ValidateTransaction(test.tx)
+		if err != test.err {
+			t.Fatalf("%s: expected err %v, got %v", test.name, test.err, err)
+		}
+	}
+
+	// the TTL is enforced against the current time by default
+	_, err = (&Validator{EnforceTTL: true}).ValidateTransaction(tx)
+	if err != nil {
+		t.Fatalf("ValidateTransaction failed, err %s", err)
+	}
+
+	// a TTL is meaningless without a timestamp
+	err = (&Validator{EnforceTTL: true}).validateTTL(context.Background(), &common.ChannelHeader{Extension: encodeTTL(time.Minute)})
+	if err == nil || err == ErrTransactionExpired {
+		t.Fatalf("validateTTL should have failed for a missing timestamp, got %v", err)
+	}
+
+	_, err = (&Validator{EnforceTTL: true}).ValidateTransaction(malformedTx)
+	if GetErrorClass(err) != PermanentError {
+		t.Fatalf("ValidateTransaction should have failed with a permanent error, got %v", err)
+	}
+}
@@ -0,0 +1,103 @@
+/*
+Copyright IBM Corp. 2017 All Rights Reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+		 http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package validation
+
+import (
+	"encoding/hex"
+	"fmt"
+
+	"github.com/hyperledger/fabric/bccsp"
+	"github.com/hyperledger/fabric/bccsp/factory"
+	"golang.org/x/net/context"
+)
+
+// HashFunctionProvider provides the hash functions of the channels, as set
+// in their channel config
+type HashFunctionProvider interface {
+	// GetHashOpts returns the options selecting the BCCSP hash function of
+	// the given chain, e.g. bccsp.SHA256Opts or bccsp.SHA3_256Opts
+	GetHashOpts(chainID string) bccsp.HashOpts
+}
+
+// getHashOpts returns the options selecting the hash function of the chain,
+// SHA-256 unless its crypto config or the HashFunctionProvider of the
+// validator says otherwise
+func (v *Validator) getHashOpts(ctx context.Context, chainID string) (bccsp.HashOpts, error) {
+	config, err := v.getCryptoConfig(ctx, chainID)
+	if err != nil {
+		return nil, err
+	}
+
+	if config != nil {
+		if config.HashOpts != nil {
+			return config.HashOpts, nil
+		}
+	} else if v.HashFunctionProvider != nil {
+		if opts := v.HashFunctionProvider.GetHashOpts(chainID); opts != nil {
+			return opts, nil
+		}
+	}
+
+	return &bccsp.SHA256Opts{}, nil
+}
+
+// ComputeTxID returns the transaction ID of a message with the given nonce
+// and creator on the given channel, that is the hex encoded hash of their
+// concatenation; the default validator computes it with SHA-256
+func ComputeTxID(nonce, creator []byte, channelID string) (string, error) {
+	return defaultValidator.ComputeTxID(nonce, creator, channelID)
+}
+
+// ComputeTxID returns the transaction ID of a message with the given nonce
+// and creator on the given channel, that is the hex encoded hash of their
+// concatenation computed with the hash function of the channel
+func (v *Validator) ComputeTxID(nonce, creator []byte, channelID string) (string, error) {
+	return v.computeTxID(context.Background(), nonce, creator, channelID)
+}
+
+// computeTxID computes the transaction ID as ComputeTxID does, looking the
+// hash function of the channel up within the validation of the context
+func (v *Validator) computeTxID(ctx context.Context, nonce, creator []byte, channelID string) (string, error) {
+	opts, err := v.getHashOpts(ctx, channelID)
+	if err != nil {
+		return "", err
+	}
+
+	msg := make([]byte, 0, len(nonce)+len(creator))
+	msg = append(append(msg, nonce...), creator...)
+	digest, err := factory.GetDefault().Hash(msg, opts)
+	if err != nil {
+		return "", fmt.Errorf("Failed computing the TXID with %s [%s]", opts.Algorithm(), err)
+	}
+
+	return hex.EncodeToString(digest), nil
+}
+
+// checkTxID checks that the transaction ID is the one computed for the
+// nonce and creator with the hash function of the chain
+func (v *Validator) checkTxID(ctx context.Context, chainID, txid string, nonce, creator []byte) error {
+	computedTxID, err := v.computeTxID(ctx, nonce, creator, chainID)
+	if err != nil {
+		return fmt.Errorf("Failed computing target TXID for comparison [%s]", err)
+	}
+
+	if txid != computedTxID {
+		return fmt.Errorf("Transaction is not valid. Got [%s], expected [%s]", txid, computedTxID)
+	}
+
+	return nil
+}
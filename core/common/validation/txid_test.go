@@ -0,0 +1,165 @@
+/*
+Copyright IBM Corp. 2017 All Rights Reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+		 http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package validation
+
+import (
+	"encoding/hex"
+	"testing"
+
+	"github.com/hyperledger/fabric/bccsp"
+	"github.com/hyperledger/fabric/bccsp/factory"
+	"github.com/hyperledger/fabric/common/util"
+	"github.com/hyperledger/fabric/protos/common"
+	"github.com/hyperledger/fabric/protos/peer"
+	"github.com/hyperledger/fabric/protos/utils"
+)
+
+// mockHashProvider uses the same hash function for every channel
+type mockHashProvider struct {
+	opts bccsp.HashOpts
+}
+
+func (p *mockHashProvider) GetHashOpts(chainID string) bccsp.HashOpts {
+	return p.opts
+}
+
+// getProposalWithTxID returns a toy proposal whose transaction ID is
+// computed with the given hash function
+func getProposalWithTxID(t *testing.T, opts bccsp.HashOpts) *peer.Proposal {
+	prop, err := getProposal()
+	if err != nil {
+		t.Fatalf("getProposal failed, err %s", err)
+	}
+
+	hdr, err := utils.GetHeader(prop.Header)
+	if err != nil {
+		t.Fatalf("GetHeader failed, err %s", err)
+	}
+
+	msg := append(append([]byte{}, hdr.SignatureHeader.Nonce...), hdr.SignatureHeader.Creator...)
+	digest, err := factory.GetDefault().Hash(msg, opts)
+	if err != nil {
+		t.Fatalf("Hash failed, err %s", err)
+	}
+
+	hdr.ChannelHeader.TxId = hex.EncodeToString(digest)
+	prop.Header = utils.MarshalOrPanic(hdr)
+
+	return prop
+}
+
+func TestTxIDHashFunction(t *testing.T) {
+	sha256Prop := getProposalWithTxID(t, &bccsp.SHA256Opts{})
+	sha3Prop := getProposalWithTxID(t, &bccsp.SHA3_256Opts{})
+
+	sha256Tx, err := getTransactionForProposal(sha256Prop, []byte("simulation_result"))
+	if err != nil {
+		t.Fatalf("getTransactionForProposal failed, err %s", err)
+	}
+	sha3Tx, err := getTransactionForProposal(sha3Prop, []byte("simulation_result"))
+	if err != nil {
+		t.Fatalf("getTransactionForProposal failed, err %s", err)
+	}
+
+	tests := []struct {
+		name     string
+		prop     *peer.Proposal
+		tx       *common.Envelope
+		provider HashFunctionProvider
+		valid    bool
+	}{
+		{"SHA256Default", sha256Prop, sha256Tx, nil, true},
+		{"SHA256Channel", sha256Prop, sha256Tx, &mockHashProvider{&bccsp.SHA256Opts{}}, true},
+		{"SHA3Channel", sha3Prop, sha3Tx, &mockHashProvider{&bccsp.SHA3_256Opts{}}, true},
+		{"SHA3OnSHA256Channel", sha3Prop, sha3Tx, nil, false},
+		{"SHA256OnSHA3Channel", sha256Prop, sha256Tx, &mockHashProvider{&bccsp.SHA3_256Opts{}}, false},
+	}
+
+	for _, test := range tests {
+		v := &Validator{HashFunctionProvider: test.provider}
+
+		_, err := v.ValidateTransaction(test.tx)
+		if test.valid && err != nil {
+			t.Fatalf("%s: ValidateTransaction failed, err %s", test.name, err)
+		}
+		if !test.valid && err == nil {
+			t.Fatalf("%s: ValidateTransaction should have failed", test.name)
+		}
+
+		sProp, err := utils.GetSignedProposal(test.prop, signer)
+		if err != nil {
+			t.Fatalf("%s: GetSignedProposal failed, err %s", test.name, err)
+		}
+
+		_, _, _, err = v.ValidateProposalMessage(sProp)
+		if test.valid && err != nil {
+			t.Fatalf("%s: ValidateProposalMessage failed, err %s", test.name, err)
+		}
+		if !test.valid && err == nil {
+			t.Fatalf("%s: ValidateProposalMessage should have failed", test.name)
+		}
+	}
+}
+
+func TestComputeTxID(t *testing.T) {
+	nonce := []byte("nonce")
+	creator := []byte("creator")
+
+	// the default validator computes the canonical TxID
+	txID, err := ComputeTxID(nonce, creator, util.GetTestChainID())
+	if err != nil {
+		t.Fatalf("ComputeTxID failed, err %s", err)
+	}
+	if txID != "709184f9d24f6ade8fcd4d6521a6eef295fef6c2e67216c58b68ac15e8946492" {
+		t.Fatalf("Unexpected TxID %s", txID)
+	}
+
+	canonical, err := utils.ComputeProposalTxID(nonce, creator)
+	if err != nil {
+		t.Fatalf("ComputeProposalTxID failed, err %s", err)
+	}
+	if txID != canonical {
+		t.Fatalf("Expected TxID %s, got %s", canonical, txID)
+	}
+
+	// the TxIDs of a channel are computed with its hash function
+	v := &Validator{HashFunctionProvider: &mockHashProvider{&bccsp.SHA3_256Opts{}}}
+	txID, err = v.ComputeTxID(nonce, creator, util.GetTestChainID())
+	if err != nil {
+		t.Fatalf("ComputeTxID failed, err %s", err)
+	}
+	if txID != "e22a5d5356b97e811a49e5336e947f73ef49f0ed7fa462e8bade6b378d70679c" {
+		t.Fatalf("Unexpected TxID %s", txID)
+	}
+
+	// the TxID of a proposal created by fabric is the one computed
+	prop, err := getProposal()
+	if err != nil {
+		t.Fatalf("getProposal failed, err %s", err)
+	}
+	hdr, err := utils.GetHeader(prop.Header)
+	if err != nil {
+		t.Fatalf("GetHeader failed, err %s", err)
+	}
+	txID, err = ComputeTxID(hdr.SignatureHeader.Nonce, hdr.SignatureHeader.Creator, hdr.ChannelHeader.ChannelId)
+	if err != nil {
+		t.Fatalf("ComputeTxID failed, err %s", err)
+	}
+	if txID != hdr.ChannelHeader.TxId {
+		t.Fatalf("Expected TxID %s, got %s", hdr.ChannelHeader.TxId, txID)
+	}
+}
@@ -0,0 +1,219 @@
+/*
+Copyright IBM Corp. 2017 All Rights Reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+		 http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package validation
+
+import (
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/golang/protobuf/proto"
+	"golang.org/x/net/context"
+)
+
+// UnknownFieldError is returned, as a permanent error, for the transactions
+// with a field unknown to the proto definitions of the peer in one of
+// their security-relevant messages, if the validator rejects them
+type UnknownFieldError struct {
+	// Path locates the message holding the unknown field, e.g.
+	// "actions[0].ChaincodeActionPayload.action.endorsements[1]"
+	Path string
+
+	// Field is the number of the unknown field
+	Field uint64
+}
+
+func (e *UnknownFieldError) Error() string {
+	return fmt.Sprintf("Unknown field %d in %s", e.Field, e.Path)
+}
+
+// messageField is a field declared by a message type
+type messageField struct {
+	// name is the name of the field in the proto definition
+	name string
+
+	// message is the type of the field, if it is an embedded message
+	// (or a repeated one) to look into, or nil
+	message reflect.Type
+
+	// repeated is set for repeated fields
+	repeated bool
+}
+
+// messageFieldsCache caches the fields declared by the message types, or
+// nil for those whose fields cannot be told from their struct tags
+var messageFieldsCache = struct {
+	sync.RWMutex
+	fields map[reflect.Type]map[uint64]messageField
+}{fields: make(map[reflect.Type]map[uint64]messageField)}
+
+// getMessageFields returns the fields declared by a message struct type,
+// as told by the protobuf tags of its Go fields, or nil if it has oneof
+// fields, which are not tagged with their numbers
+func getMessageFields(t reflect.Type) map[uint64]messageField {
+	messageFieldsCache.RLock()
+	fields, ok := messageFieldsCache.fields[t]
+	messageFieldsCache.RUnlock()
+	if ok {
+		return fields
+	}
+
+	messageType := reflect.TypeOf((*proto.Message)(nil)).Elem()
+	fields = make(map[uint64]messageField)
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		if f.Tag.Get("protobuf_oneof") != "" {
+			fields = nil
+			break
+		}
+
+		tag := strings.Split(f.Tag.Get("protobuf"), ",")
+		if len(tag) < 4 {
+			continue
+		}
+		number, err := strconv.ParseUint(tag[1], 10, 64)
+		if err != nil {
+			continue
+		}
+
+		field := messageField{name: strings.TrimPrefix(tag[3], "name=")}
+		elem := f.Type
+		if elem.Kind() == reflect.Slice {
+			field.repeated = true
+			elem = elem.Elem()
+		}
+		if elem.Kind() == reflect.Ptr && elem.Implements(messageType) && elem.Elem().Kind() == reflect.Struct {
+			field.message = elem.Elem()
+		}
+		fields[number] = field
+	}
+
+	messageFieldsCache.Lock()
+	messageFieldsCache.fields[t] = fields
+	messageFieldsCache.Unlock()
+
+	return fields
+}
+
+// walkWireFields calls f with the number, wire type and value, if it is a
+// varint, or bytes, if it is length-delimited, of each field of the wire
+// encoding of a message, in order, until f returns false
+func walkWireFields(b []byte, f func(number, wireType, value uint64, data []byte) bool) error {
+	for len(b) > 0 {
+		tag, n := proto.DecodeVarint(b)
+		if n == 0 {
+			return fmt.Errorf("Could not decode the tag of a field")
+		}
+		b = b[n:]
+
+		var value uint64
+		var data []byte
+		switch tag & 7 {
+		case proto.WireVarint:
+			value, n = proto.DecodeVarint(b)
+		case proto.WireFixed64:
+			n = 8
+		case proto.WireFixed32:
+			n = 4
+		case proto.WireBytes:
+			var length uint64
+			length, n = proto.DecodeVarint(b)
+			if n != 0 && length > uint64(len(b)-n) {
+				n = 0
+			} else if n != 0 {
+				data = b[n : n+int(length)]
+				n += int(length)
+			}
+		default:
+			n = 0
+		}
+		if n == 0 || n > len(b) {
+			return fmt.Errorf("Could not decode field %d", tag>>3)
+		}
+		b = b[n:]
+
+		if !f(tag>>3, tag&7, value, data) {
+			return nil
+		}
+	}
+
+	return nil
+}
+
+// findUnknownField scans the wire encoding of a message of the given struct
+// type, and of the messages embedded in it, for a field its type does not
+// declare, which unmarshalling silently drops, and returns the first one
+// found, or nil
+func findUnknownField(raw []byte, t reflect.Type, path string) (*UnknownFieldError, error) {
+	fields := getMessageFields(t)
+	if fields == nil {
+		return nil, nil
+	}
+
+	var unknown *UnknownFieldError
+	var embeddedErr error
+	counts := make(map[uint64]int)
+	err := walkWireFields(raw, func(number, wireType, value uint64, data []byte) bool {
+		field, ok := fields[number]
+		if !ok {
+			unknown = &UnknownFieldError{Path: path, Field: number}
+			return false
+		}
+
+		if field.message == nil || wireType != proto.WireBytes {
+			return true
+		}
+
+		embeddedPath := path + "." + field.name
+		if field.repeated {
+			embeddedPath = fmt.Sprintf("%s[%d]", embeddedPath, counts[number])
+			counts[number]++
+		}
+		unknown, embeddedErr = findUnknownField(data, field.message, embeddedPath)
+		return unknown == nil && embeddedErr == nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return unknown, embeddedErr
+}
+
+// checkUnknownFields looks for fields unknown to the proto definitions of
+// the peer in a decoded message, which newer peers or orderers may produce
+// and which this peer would silently ignore, and rejects the message if
+// the validator is required to; otherwise they are warned about
+func (v *Validator) checkUnknownFields(ctx context.Context, path string, raw []byte, msg proto.Message) error {
+	unknown, err := findUnknownField(raw, reflect.TypeOf(msg).Elem(), path)
+	if err != nil {
+		return permanentDecodeError(fmt.Errorf("Could not decode %s, err %s", path, err))
+	}
+
+	if unknown == nil {
+		return nil
+	}
+
+	if v.RejectUnknownFields {
+		putilsLogger.Errorf("checkUnknownFields error: %s", unknown)
+		return permanentDecodeError(unknown)
+	}
+
+	addWarning(ctx, WarningUnknownFields, "%s", unknown)
+	return nil
+}
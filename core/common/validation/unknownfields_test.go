@@ -0,0 +1,150 @@
+/*
+Copyright IBM Corp. 2017 All Rights Reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+		 http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package validation
+
+import (
+	"testing"
+
+	"github.com/golang/protobuf/proto"
+	"github.com/hyperledger/fabric/protos/common"
+	"github.com/hyperledger/fabric/protos/peer"
+	"github.com/hyperledger/fabric/protos/utils"
+)
+
+// withUnknownField appends to the wire encoding of a message a field that
+// none of the messages declares
+func withUnknownField(b []byte) []byte {
+	buf := proto.NewBuffer(append([]byte(nil), b...))
+	buf.EncodeVarint(99<<3 | proto.WireVarint)
+	buf.EncodeVarint(1)
+	return buf.Bytes()
+}
+
+// withData returns a signed copy of the envelope with the given payload data
+func withData(t *testing.T, env *common.Envelope, data []byte) *common.Envelope {
+	payload, err := utils.GetPayload(env)
+	if err != nil {
+		t.Fatalf("GetPayload failed, err %s", err)
+	}
+	payload.Data = data
+
+	res := &common.Envelope{Payload: utils.MarshalOrPanic(payload)}
+	res.Signature, err = signer.Sign(res.Payload)
+	if err != nil {
+		t.Fatalf("Sign failed, err %s", err)
+	}
+	return res
+}
+
+// withActionPayload returns a signed copy of the envelope whose first action
+// has the given payload
+func withActionPayload(t *testing.T, env *common.Envelope, actionPayload []byte) *common.Envelope {
+	payload, err := utils.GetPayload(env)
+	if err != nil {
+		t.Fatalf("GetPayload failed, err %s", err)
+	}
+	tx, err := utils.GetTransaction(payload.Data)
+	if err != nil {
+		t.Fatalf("GetTransaction failed, err %s", err)
+	}
+	tx.Actions[0].Payload = actionPayload
+
+	return withData(t, env, utils.MarshalOrPanic(tx))
+}
+
+func TestUnknownFields(t *testing.T) {
+	tx, err := getTransaction([]byte("simulation_result"))
+	if err != nil {
+		t.Fatalf("getTransaction failed, err %s", err)
+	}
+	payload, err := utils.GetPayload(tx)
+	if err != nil {
+		t.Fatalf("GetPayload failed, err %s", err)
+	}
+	transaction, err := utils.GetTransaction(payload.Data)
+	if err != nil {
+		t.Fatalf("GetTransaction failed, err %s", err)
+	}
+	cap, err := utils.GetChaincodeActionPayload(transaction.Actions[0].Payload)
+	if err != nil {
+		t.Fatalf("GetChaincodeActionPayload failed, err %s", err)
+	}
+
+	// an unknown field in the endorsement embedded in the action payload
+	action := proto.NewBuffer(nil)
+	action.EncodeVarint(1<<3 | proto.WireBytes)
+	action.EncodeRawBytes(cap.Action.ProposalResponsePayload)
+	action.EncodeVarint(2<<3 | proto.WireBytes)
+	action.EncodeRawBytes(withUnknownField(utils.MarshalOrPanic(cap.Action.Endorsements[0])))
+	nested := proto.NewBuffer(nil)
+	nested.EncodeVarint(1<<3 | proto.WireBytes)
+	nested.EncodeRawBytes(cap.ChaincodeProposalPayload)
+	nested.EncodeVarint(2<<3 | proto.WireBytes)
+	nested.EncodeRawBytes(action.Bytes())
+
+	// an unknown field in the proposal response payload
+	prpCap := &peer.ChaincodeActionPayload{
+		ChaincodeProposalPayload: cap.ChaincodeProposalPayload,
+		Action: &peer.ChaincodeEndorsedAction{
+			ProposalResponsePayload: withUnknownField(cap.Action.ProposalResponsePayload),
+			Endorsements:            cap.Action.Endorsements,
+		},
+	}
+
+	tests := []struct {
+		name string
+		tx   *common.Envelope
+		path string
+	}{
+		{"Known", tx, ""},
+		{"Transaction", withData(t, tx, withUnknownField(payload.Data)), "Transaction"},
+		{"ChaincodeActionPayload", withActionPayload(t, tx, withUnknownField(transaction.Actions[0].Payload)), "actions[0].ChaincodeActionPayload"},
+		{"Endorsement", withActionPayload(t, tx, nested.Bytes()), "actions[0].ChaincodeActionPayload.action.endorsements[0]"},
+		{"ProposalResponsePayload", withActionPayload(t, tx, utils.MarshalOrPanic(prpCap)), "actions[0].ProposalResponsePayload"},
+	}
+
+	for _, test := range tests {
+		// the unknown fields are warned about by default
+		result, err := (&Validator{}).Validate(test.tx)
+		if err != nil {
+			t.Fatalf("%s: Validate failed, err %s", test.name, err)
+		}
+		codes := warningCodes(result)
+		if (test.path != "") != (len(codes) == 1 && codes[0] == WarningUnknownFields) {
+			t.Fatalf("%s: unexpected warnings %v", test.name, result.Warnings)
+		}
+
+		// and rejected if required
+		_, err = (&Validator{RejectUnknownFields: true}).Validate(test.tx)
+		if test.path == "" {
+			if err != nil {
+				t.Fatalf("%s: Validate failed, err %s", test.name, err)
+			}
+			continue
+		}
+		if GetErrorClass(err) != PermanentError {
+			t.Fatalf("%s: expected a permanent error, got %v", test.name, err)
+		}
+		unknown, ok := err.(*ValidationError).Err.(*UnknownFieldError)
+		if !ok {
+			t.Fatalf("%s: expected an UnknownFieldError, got %v", test.name, err)
+		}
+		if unknown.Path != test.path || unknown.Field != 99 {
+			t.Fatalf("%s: expected unknown field 99 in %s, got %s", test.name, test.path, unknown)
+		}
+	}
+}
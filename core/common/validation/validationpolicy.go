@@ -0,0 +1,175 @@
+/*
+Copyright IBM Corp. 2017 All Rights Reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+		 http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package validation
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"sync/atomic"
+
+	"github.com/hyperledger/fabric/protos/common"
+)
+
+// ErrTooManyActions is returned for endorser transactions with more actions
+// than the validation policy allows
+var ErrTooManyActions = errors.New("The transaction has too many actions")
+
+// ErrPayloadTooLarge is returned for transactions whose payload is larger
+// than the validation policy allows
+var ErrPayloadTooLarge = errors.New("The transaction payload is too large")
+
+// ErrHeaderTypeNotAllowed is returned for the messages whose header type is
+// not allowed by the validation policy
+var ErrHeaderTypeNotAllowed = errors.New("The header type is not allowed")
+
+// ValidationPolicy holds the limits of the validation that can be tuned
+// while the peer runs, see LoadValidationPolicy. It is read from a JSON
+// document such as
+//
+//	{
+//		"max_actions": 4,
+//		"max_payload_size": 1048576,
+//		"allowed_types": ["ENDORSER_TRANSACTION", "CONFIG"]
+//	}
+//
+// where omitted or zero limits do not restrict the messages
+type ValidationPolicy struct {
+	// MaxActions is the maximum number of actions of endorser transactions
+	MaxActions int `json:"max_actions"`
+
+	// MaxPayloadSize is the maximum size of the payloads of transaction
+	// envelopes, as transmitted, i.e. before any decompression
+	MaxPayloadSize int `json:"max_payload_size"`
+
+	// AllowedTypes holds the names of the header types, as defined by
+	// HeaderType, of the proposals and transactions accepted; if empty,
+	// all the supported types are
+	AllowedTypes []string `json:"allowed_types"`
+
+	// allowedTypes holds the values of AllowedTypes
+	allowedTypes map[common.HeaderType]struct{}
+}
+
+// validationPolicy holds the active validation policy of a validator
+type validationPolicy struct {
+	value atomic.Value
+}
+
+// ParseValidationPolicy reads and validates a validation policy document
+func ParseValidationPolicy(r io.Reader) (*ValidationPolicy, error) {
+	policy := &ValidationPolicy{}
+	err := json.NewDecoder(r).Decode(policy)
+	if err != nil {
+		return nil, fmt.Errorf("Could not decode the validation policy, err %s", err)
+	}
+
+	if policy.MaxActions < 0 {
+		return nil, fmt.Errorf("Invalid validation policy, negative max_actions %d", policy.MaxActions)
+	}
+
+	if policy.MaxPayloadSize < 0 {
+		return nil, fmt.Errorf("Invalid validation policy, negative max_payload_size %d", policy.MaxPayloadSize)
+	}
+
+	if len(policy.AllowedTypes) != 0 {
+		policy.allowedTypes = make(map[common.HeaderType]struct{}, len(policy.AllowedTypes))
+		for _, name := range policy.AllowedTypes {
+			headerType, ok := common.HeaderType_value[name]
+			if !ok {
+				return nil, fmt.Errorf("Invalid validation policy, unknown header type %s", name)
+			}
+			policy.allowedTypes[common.HeaderType(headerType)] = struct{}{}
+		}
+	}
+
+	return policy, nil
+}
+
+// LoadValidationPolicy reads a validation policy document and, if it is
+// valid, atomically makes it the active policy of the validator, which
+// each check reads when it runs. If the document is invalid, the active
+// policy is left unchanged
+func (v *Validator) LoadValidationPolicy(r io.Reader) error {
+	policy, err := ParseValidationPolicy(r)
+	if err != nil {
+		return err
+	}
+
+	v.validationPolicy.value.Store(policy)
+	putilsLogger.Infof("Validation policy loaded, max actions %d, max payload size %d, allowed types %v", policy.MaxActions, policy.MaxPayloadSize, policy.AllowedTypes)
+
+	return nil
+}
+
+// LoadValidationPolicyFile loads the validation policy document of the
+// given file, see LoadValidationPolicy; it is meant to be called whenever
+// a file watcher reports that the file changed
+func (v *Validator) LoadValidationPolicyFile(path string) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return fmt.Errorf("Could not open the validation policy, err %s", err)
+	}
+	defer f.Close()
+
+	return v.LoadValidationPolicy(f)
+}
+
+// getValidationPolicy returns the active validation policy, or nil if none
+// was loaded
+func (v *Validator) getValidationPolicy() *ValidationPolicy {
+	policy, _ := v.validationPolicy.value.Load().(*ValidationPolicy)
+	return policy
+}
+
+// checkHeaderType ensures that the header type is allowed by the policy
+func (p *ValidationPolicy) checkHeaderType(cHdr *common.ChannelHeader) error {
+	if p == nil || p.allowedTypes == nil {
+		return nil
+	}
+
+	if _, ok := p.allowedTypes[common.HeaderType(cHdr.Type)]; !ok {
+		putilsLogger.Errorf("Header type %s of [%s] is not allowed", common.HeaderType(cHdr.Type), cHdr.TxId)
+		return ErrHeaderTypeNotAllowed
+	}
+
+	return nil
+}
+
+// checkPayloadSize ensures that the payload of the envelope is not larger
+// than allowed by the policy
+func (p *ValidationPolicy) checkPayloadSize(e *common.Envelope) error {
+	if p == nil || p.MaxPayloadSize == 0 || len(e.Payload) <= p.MaxPayloadSize {
+		return nil
+	}
+
+	putilsLogger.Errorf("Payload of %d bytes, at most %d allowed", len(e.Payload), p.MaxPayloadSize)
+	return ErrPayloadTooLarge
+}
+
+// checkActions ensures that the number of actions of a transaction is
+// allowed by the policy
+func (p *ValidationPolicy) checkActions(actions int) error {
+	if p == nil || p.MaxActions == 0 || actions <= p.MaxActions {
+		return nil
+	}
+
+	putilsLogger.Errorf("Transaction with %d actions, at most %d allowed", actions, p.MaxActions)
+	return ErrTooManyActions
+}
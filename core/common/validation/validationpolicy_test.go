@@ -0,0 +1,130 @@
+/*
+Copyright IBM Corp. 2017 All Rights Reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+		 http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package validation
+
+import (
+	"io/ioutil"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/hyperledger/fabric/protos/utils"
+)
+
+func TestValidationPolicy(t *testing.T) {
+	tx := getTransactionWithResults(t, []byte("simulation_result"), []byte("simulation_result"))
+
+	prop, err := getProposal()
+	if err != nil {
+		t.Fatalf("getProposal failed, err %s", err)
+	}
+	sProp, err := utils.GetSignedProposal(prop, signer)
+	if err != nil {
+		t.Fatalf("GetSignedProposal failed, err %s", err)
+	}
+
+	tests := []struct {
+		name    string
+		policy  string
+		err     error
+		propErr error
+	}{
+		{"Empty", `{}`, nil, nil},
+		{"EnoughActions", `{"max_actions": 2}`, nil, nil},
+		{"TooManyActions", `{"max_actions": 1}`, ErrTooManyActions, nil},
+		{"SmallEnough", `{"max_payload_size": 1048576}`, nil, nil},
+		{"TooLarge", `{"max_payload_size": 16}`, ErrPayloadTooLarge, nil},
+		{"AllowedType", `{"allowed_types": ["ENDORSER_TRANSACTION"]}`, nil, nil},
+		{"TypeNotAllowed", `{"allowed_types": ["CONFIG"]}`, ErrHeaderTypeNotAllowed, ErrHeaderTypeNotAllowed},
+	}
+
+	// the same validator is reconfigured by each policy
+	v := &Validator{}
+	for _, test := range tests {
+		err := v.LoadValidationPolicy(strings.NewReader(test.policy))
+		if err != nil {
+			t.Fatalf("%s: LoadValidationPolicy failed, err %s", test.name, err)
+		}
+
+		_, err = v.ValidateTransaction(tx)
+		if err != test.err {
+			t.Fatalf("%s: expected err %v, got %v", test.name, test.err, err)
+		}
+
+		_, _, _, err = v.ValidateProposalMessage(sProp)
+		if err != test.propErr {
+			t.Fatalf("%s: expected proposal err %v, got %v", test.name, test.propErr, err)
+		}
+	}
+}
+
+func TestInvalidValidationPolicy(t *testing.T) {
+	tx := getTransactionWithResults(t, []byte("simulation_result"), []byte("simulation_result"))
+
+	v := &Validator{}
+	err := v.LoadValidationPolicy(strings.NewReader(`{"max_actions": 1}`))
+	if err != nil {
+		t.Fatalf("LoadValidationPolicy failed, err %s", err)
+	}
+
+	for _, policy := range []string{
+		`{"max_actions": 1`,
+		`{"max_actions": "one"}`,
+		`{"max_actions": -1}`,
+		`{"max_payload_size": -1}`,
+		`{"allowed_types": ["UNKNOWN"]}`,
+	} {
+		err = v.LoadValidationPolicy(strings.NewReader(policy))
+		if err == nil {
+			t.Fatalf("LoadValidationPolicy should have failed for %s", policy)
+		}
+
+		// the active policy is left unchanged
+		_, err = v.ValidateTransaction(tx)
+		if err != ErrTooManyActions {
+			t.Fatalf("Expected err %v after loading %s, got %v", ErrTooManyActions, policy, err)
+		}
+	}
+}
+
+func TestValidationPolicyFile(t *testing.T) {
+	tx := getTransactionWithResults(t, []byte("simulation_result"), []byte("simulation_result"))
+
+	f, err := ioutil.TempFile("", "validationpolicy")
+	if err != nil {
+		t.Fatalf("TempFile failed, err %s", err)
+	}
+	defer os.Remove(f.Name())
+	f.WriteString(`{"max_actions": 1}`)
+	f.Close()
+
+	v := &Validator{}
+	err = v.LoadValidationPolicyFile(f.Name())
+	if err != nil {
+		t.Fatalf("LoadValidationPolicyFile failed, err %s", err)
+	}
+
+	_, err = v.ValidateTransaction(tx)
+	if err != ErrTooManyActions {
+		t.Fatalf("Expected err %v, got %v", ErrTooManyActions, err)
+	}
+
+	err = v.LoadValidationPolicyFile(f.Name() + ".missing")
+	if err == nil {
+		t.Fatalf("LoadValidationPolicyFile should have failed for a missing file")
+	}
+}
@@ -0,0 +1,397 @@
+/*
+Copyright IBM Corp. 2017 All Rights Reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+		 http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package validation
+
+import (
+	"crypto/x509"
+	"time"
+
+	"github.com/hyperledger/fabric/msp"
+	"github.com/hyperledger/fabric/protos/common"
+)
+
+// IdentityDeserializerProvider provides the IdentityDeserializer of a channel
+type IdentityDeserializerProvider interface {
+	// GetIdentityDeserializer returns the IdentityDeserializer for the given chain
+	GetIdentityDeserializer(chainID string) msp.IdentityDeserializer
+}
+
+// Validator checks the validity of proposals and transactions. The zero
+// value only performs the built-in checks; the optional fields below enable
+// additional checks for the deployments that need them
+type Validator struct {
+	// DeserializerProvider supplies the identity deserializers used to
+	// validate creators; if nil, those of the MSP manager are used
+	DeserializerProvider IdentityDeserializerProvider
+
+	// MSPReadyTimeout bounds the time spent waiting for the MSP config of
+	// a channel to be ready, if the DeserializerProvider implements
+	// MSPReadinessNotifier; if zero, DefaultMSPReadyTimeout is used
+	MSPReadyTimeout time.Duration
+
+	// ChannelUnavailableRetries is the number of times the lookup of the
+	// IdentityDeserializer of a channel is retried before failing with
+	// ErrChannelUnavailable, or until the context of the validation is
+	// done; by default it is not retried
+	ChannelUnavailableRetries int
+
+	// ChannelUnavailableBackoff is the time waited before the first retry
+	// of the lookup, doubled at each retry; if zero,
+	// DefaultChannelUnavailableBackoff is used
+	ChannelUnavailableBackoff time.Duration
+
+	// ChannelMembership, if set, enables the validation of cross-channel
+	// reads: every channel referenced by a transaction must be a valid
+	// channel ID the peer is joined to
+	ChannelMembership ChannelMembership
+
+	// MaxReferencedChannels is the maximum number of distinct channels,
+	// other than its own, a transaction may read from, checked along with
+	// the ChannelMembership; if zero, DefaultMaxReferencedChannels is used
+	MaxReferencedChannels int
+
+	// ChannelMembershipChecker, if set, restricts the creators of the
+	// proposals and transactions of each channel to the members of its
+	// organizations; by default valid creators of any MSP are accepted
+	ChannelMembershipChecker ChannelMembershipChecker
+
+	// SuspendedMSPs, if not empty, rejects the proposals and transactions
+	// whose creator is a member of one of the listed MSPs, e.g. during an
+	// incident response, without removing the MSPs from the channels
+	SuspendedMSPs map[string]struct{}
+
+	// SuspendedMSPErrorClass is the class of the ErrMSPSuspended errors:
+	// TransientError if the transactions may be validated again once the
+	// MSP is reinstated, PermanentError if they are to be dropped; if
+	// UnclassifiedError, ErrMSPSuspended is returned as is
+	SuspendedMSPErrorClass ErrorClass
+
+	// ChannelConfigVerifier, if set, rejects the endorser transactions of
+	// the channels whose config is not verified; by default the config of
+	// every channel is trusted
+	ChannelConfigVerifier ChannelConfigVerifier
+
+	// SubmissionWindow, if set, restricts the times of the day at which
+	// transactions may be submitted; by default they always are
+	SubmissionWindow *SubmissionWindow
+
+	// EnforceTTL, if set, rejects the endorser transactions validated
+	// after the expiry of the TTL they declare, see getTTL
+	EnforceTTL bool
+
+	// EnforceActionOrdering, if set, rejects the endorser transactions
+	// declaring ordered actions whose actions are not in order, see
+	// checkActionOrdering
+	EnforceActionOrdering bool
+
+	// MaxClockSkew is the time the clock of the validator may be ahead of
+	// those of the creators when checking for expiry, or behind those of
+	// the issuers of their certificates when checking for validity
+	MaxClockSkew time.Duration
+
+	// Clock, if set, returns the time against which expiry is checked,
+	// e.g. the time of the block being validated; if nil, the current
+	// time is used
+	Clock func() time.Time
+
+	// SpamGuard, if set, rejects the endorser transactions without an
+	// anti-spam token meeting its difficulty
+	SpamGuard *SpamGuard
+
+	// TimestampAuthorityVerifier, if set, is used to verify the trusted
+	// time-stamp tokens supplied along with transactions
+	TimestampAuthorityVerifier TimestampAuthorityVerifier
+
+	// TimestampTokenTolerance is the largest difference allowed, in either
+	// direction, between the timestamp in the header of a transaction and
+	// the time certified by its time-stamp token; if zero,
+	// DefaultTimestampTokenTolerance is used
+	TimestampTokenTolerance time.Duration
+
+	// ChannelTypeProvider, if set, is used to reject config transactions
+	// meant for a different type of channel than the one they target
+	ChannelTypeProvider ChannelTypeProvider
+
+	// ConfigSequenceProvider, if set, is used to reject config transactions
+	// that do not bump the config sequence of their channel by exactly one
+	ConfigSequenceProvider ConfigSequenceProvider
+
+	// MinNonceLength is the minimum length of the nonces in the headers,
+	// used when the NonceLengthProvider does not know the length required
+	// on a channel; if zero, any non-empty nonce is accepted
+	MinNonceLength int
+
+	// NonceLengthProvider, if set, provides the minimum nonce length
+	// required by the crypto suite of each channel
+	NonceLengthProvider NonceLengthProvider
+
+	// NonceValidators, if not empty, maps channel IDs to the validators of
+	// the nonces of those channels, checked in addition to their length;
+	// the nonces of the other channels only need to be long enough
+	NonceValidators map[string]NonceValidator
+
+	// HashFunctionProvider, if set, supplies the hash functions the
+	// transaction IDs of each channel are computed with; by default they
+	// are computed with SHA-256
+	HashFunctionProvider HashFunctionProvider
+
+	// SignatureModeProvider, if set, provides the signature mode of each
+	// channel; by default signatures are over the raw messages
+	SignatureModeProvider SignatureModeProvider
+
+	// ChannelCryptoConfigProvider, if set, provides the crypto config of
+	// each channel, fetched the first time it is needed and cached until
+	// RefreshChannelCryptoConfig is called for the channel
+	ChannelCryptoConfigProvider ChannelCryptoConfigProvider
+
+	// EndorsementHeuristics controls the heuristic check flagging the
+	// transactions whose endorsements follow a suspicious pattern; it is
+	// off by default
+	EndorsementHeuristics HeuristicsMode
+
+	// EndorsementOrdering controls the check of the canonical order of the
+	// endorsements of each action, by endorser MSP ID then identity; it is
+	// off by default
+	EndorsementOrdering HeuristicsMode
+
+	// EndorsementPolicyProvider, if set, supplies the endorsement policies
+	// checked by ValidateTransactionWithPolicyDigest
+	EndorsementPolicyProvider EndorsementPolicyProvider
+
+	// PolicyResolver, if set, rejects the endorser transactions referencing
+	// an endorsement policy it does not know in the PolicyName field of
+	// their chaincode header extension
+	PolicyResolver PolicyResolver
+
+	// ChaincodeDefinitionProvider, if set, pins the definition of the
+	// chaincode invoked by endorser transactions to the version they were
+	// endorsed against, which must still be known; the definition is
+	// returned in the ValidationResult
+	ChaincodeDefinitionProvider ChaincodeDefinitionProvider
+
+	// StrictPayloadEncoding, if set, rejects the envelopes whose payload
+	// does not re-marshal to the exact bytes that were signed
+	StrictPayloadEncoding bool
+
+	// StrictArgsEncoding, if set, rejects the proposals and endorser
+	// transactions whose chaincode invocation spec does not re-marshal to
+	// the exact bytes that were proposed
+	StrictArgsEncoding bool
+
+	// SequenceTracker, if set, enforces strictly increasing sequence
+	// numbers, carried by the nonces, on the endorser transactions of
+	// each creator; a sequence number is only recorded once the final
+	// outcome of the validation of its transaction is known to be valid
+	SequenceTracker *SequenceTracker
+
+	// IdentityResolver, if set, resolves the creators referencing their
+	// identity, see IdentityReferenceMarker; by default creators must
+	// carry their identity in full
+	IdentityResolver IdentityResolver
+
+	// TLSBindingChecker, if set, checks the binding of the endorser
+	// transactions validated by ValidateTransactionWithTLSBinding to the TLS
+	// client certificates of the connections they were submitted on
+	TLSBindingChecker *TLSBindingChecker
+
+	// PinnedCreatorFingerprints, if not empty, is the set of fingerprints,
+	// as computed by CertificateFingerprint, of the only certificates
+	// allowed to create proposals and transactions
+	PinnedCreatorFingerprints map[string]struct{}
+
+	// CreatorRoots, if set, requires the certificate chains of the creators
+	// to terminate at one of these roots, in addition to being valid for
+	// their MSP; creators may present the intermediate certificates of
+	// their chain inline, as PEM blocks following their own certificate
+	CreatorRoots *x509.CertPool
+
+	// MinCreatorKeySizes, if set, rejects the creators whose certificate
+	// has a public key smaller than the minimum size for its algorithm; by
+	// default keys of any size are accepted
+	MinCreatorKeySizes *KeySizes
+
+	// RejectNotYetValidCertificates, if set, rejects the creators whose
+	// certificate is not valid yet, according to the Clock of the
+	// validator, whether their MSP checks it or not
+	RejectNotYetValidCertificates bool
+
+	// CertificateExpiryWarning, if set, warns about the creators whose
+	// certificate expires within that time, or is signed with a deprecated
+	// algorithm, see ValidationWarning
+	CertificateExpiryWarning time.Duration
+
+	// RejectUnknownFields, if set, rejects the endorser transactions with a
+	// field unknown to the proto definitions of the peer in their
+	// Transaction, ChaincodeActionPayload or ProposalResponsePayload
+	// messages, or the messages embedded in them, see UnknownFieldError;
+	// by default such fields are warned about
+	RejectUnknownFields bool
+
+	// DeniedTxIDs, if not empty, maps channel IDs to the sets of the
+	// transaction IDs rejected on those channels, e.g. to block known
+	// malicious transactions during an incident
+	DeniedTxIDs map[string]map[string]struct{}
+
+	// AggregateVerifier, if set, verifies the aggregated endorsements of
+	// endorser transactions, which are rejected if it is not set
+	AggregateVerifier AggregateVerifier
+
+	// AllowDuplicateEndorsers, if set, disables the rejection of the actions
+	// of endorser transactions endorsed more than once by the same identity
+	AllowDuplicateEndorsers bool
+
+	// AllowedEndorserMSPs, if not empty, restricts the endorsements of
+	// endorser transactions to members of the listed MSPs, so that the
+	// endorsements of revoked or untrusted organizations can be blocked
+	// independently of the endorsement policies; aggregated endorsements
+	// cannot be attributed to MSPs and are then rejected
+	AllowedEndorserMSPs map[string]struct{}
+
+	// CreatorChaincodeACL, if not empty, maps MSP IDs to the names of the
+	// only chaincodes the members of the MSP may invoke through proposals
+	// and endorser transactions; the MSPs it does not list are unrestricted
+	CreatorChaincodeACL map[string]map[string]struct{}
+
+	// VerifyEndorsementSignatures, if set, rejects the actions of endorser
+	// transactions with an endorsement whose signature does not verify
+	// against its endorser, before VSCC evaluates the endorsement policy
+	VerifyEndorsementSignatures bool
+
+	// MaxArgsSize is the maximum total size of the arguments of the
+	// chaincode invocations of proposals and endorser transactions; if
+	// zero, DefaultMaxArgsSize is used
+	MaxArgsSize int
+
+	// MaxDecompressedPayloadSize, if positive, enables the decompression of
+	// gzip compressed payloads and bounds their decompressed size; if zero,
+	// compressed payloads are rejected with ErrCompressedPayload. Only the
+	// validated payload is decompressed, not the envelope, so callers must
+	// use the payload returned rather than pass the envelope on: the block
+	// entry points, whose envelopes are committed as they are, and the
+	// helpers decoding envelopes directly always reject compressed payloads
+	MaxDecompressedPayloadSize int
+
+	// BlockSizeBudgets holds the maximum total size of the entries of the
+	// blocks validated by ValidateBlock, by channel; the channels not in
+	// the map have a budget of DefaultBlockSizeBudget
+	BlockSizeBudgets map[string]int
+
+	// RWSetLimits, if set, enables the structural validation of the
+	// read-write sets of endorser transactions, rejecting those that are
+	// malformed or exceed the limits
+	RWSetLimits *RWSetLimits
+
+	// LedgerHeightProvider, if set, enables the rejection of transactions
+	// reading versions more than MaxReadStaleness blocks older than the
+	// current height of the ledger
+	LedgerHeightProvider LedgerHeightProvider
+
+	// MaxReadStaleness is the maximum number of blocks between the height
+	// of the ledger and the oldest version read by a transaction, or the
+	// ledger height it commits to, see checkReadCommitment
+	MaxReadStaleness uint64
+
+	// ReadCommitmentMode controls the check, along with the
+	// LedgerHeightProvider, of the ledger heights the transactions commit
+	// to having read; it is off by default
+	ReadCommitmentMode HeuristicsMode
+
+	// CheckFraming, if set, rejects the envelopes read by
+	// ValidateFramedTransactions whose length prefix disagrees with their
+	// content
+	CheckFraming bool
+
+	// TokenVerifier, if set, verifies the delegation tokens presented by
+	// the creators of endorser transactions in the DelegationToken field of
+	// their chaincode header extension; the transactions without a token are
+	// validated as usual
+	TokenVerifier TokenVerifier
+
+	// Tracer, if set, traces the validation of transactions; without
+	// tracer, no span is created
+	Tracer Tracer
+
+	// MinCoSignatures, if positive, enables the validation of co-signed
+	// envelopes, see CoSignatureMarker, and is the minimum number of their
+	// co-signatures; if zero, co-signed envelopes are rejected
+	MinCoSignatures int
+
+	// CheckActionCreators, if set, rejects the transactions with an action
+	// whose creator is not the same identity as the creator of the
+	// transaction
+	CheckActionCreators bool
+
+	// CheckActionNonce, if set, rejects the transactions with a single
+	// action whose nonce is not the same as that of the transaction
+	CheckActionNonce bool
+
+	// AuditSink, if set, records the final outcome of the validation of
+	// each transaction, including the rejections by the middlewares and by
+	// the additional checks of the entry point it was validated through,
+	// in a tamper-evident audit log
+	AuditSink *AuditSink
+
+	// OnValid, if set, is called once with the result of each transaction
+	// that passed validation, including the middlewares and the additional
+	// checks of the entry point it was validated through, e.g. those of
+	// the ValidateTransactionWith variants, after the validation completed.
+	// It is not called if the validation panicked
+	OnValid func(result *ValidationResult)
+
+	// OnInvalid, if set, is called once with each transaction that failed
+	// validation and the error it failed with, under the same conditions
+	// as OnValid
+	OnInvalid func(e *common.Envelope, err error)
+
+	// RecordSteps, if set, records the steps of the validation of each
+	// transaction in its ValidationResult
+	RecordSteps bool
+
+	// ShadowChecks holds the names of the checks, among ShadowableChecks,
+	// run in shadow mode: their failures are logged and counted, see
+	// ShadowFailures, but do not reject the transactions
+	ShadowChecks map[string]struct{}
+
+	// RecordStats, if set, counts the work performed to validate each
+	// transaction in its ValidationResult
+	RecordStats bool
+
+	// DryRun, if set, leaves the caches of the validator, namely the
+	// crypto configs of the channels and the sequence numbers of the
+	// SequenceTracker, untouched, and reports the operations the
+	// validations would have performed on them in their ValidationResult
+	DryRun bool
+
+	// pluginRegistry holds the plugins registered with RegisterPlugin
+	pluginRegistry pluginRegistry
+
+	// middlewareChain holds the middlewares used with Use
+	middlewareChain middlewareChain
+
+	// cryptoConfigCache caches the crypto configs of the channels
+	cryptoConfigCache cryptoConfigCache
+
+	// shadowCounters counts the failures of the checks run in shadow mode
+	shadowCounters shadowCounters
+
+	// validationPolicy holds the validation policy loaded with
+	// LoadValidationPolicy
+	validationPolicy validationPolicy
+}
+
+// defaultValidator backs the package-level validation functions
+var defaultValidator = &Validator{}
@@ -0,0 +1,105 @@
+/*
+Copyright IBM Corp. 2017 All Rights Reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+		 http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package validation
+
+import (
+	"fmt"
+
+	"golang.org/x/net/context"
+)
+
+// WarningCode identifies the kind of a validation warning
+type WarningCode int
+
+const (
+	// WarningCertificateNearExpiry is raised for creators whose certificate
+	// expires within the CertificateExpiryWarning of the validator
+	WarningCertificateNearExpiry WarningCode = iota + 1
+
+	// WarningDeprecatedAlgorithm is raised for creators whose certificate
+	// is signed with a deprecated algorithm, such as SHA-1 based ones,
+	// which the MSPs still accept
+	WarningDeprecatedAlgorithm
+
+	// WarningClockSkew is raised for transactions whose timestamp, TTL or
+	// creator certificate only passed a time check thanks to the
+	// MaxClockSkew of the validator
+	WarningClockSkew
+
+	// WarningFlagged is raised for transactions flagged by a heuristic
+	// check in lenient mode, such as the EndorsementHeuristics or the
+	// ReadCommitmentMode
+	WarningFlagged
+
+	// WarningUnknownFields is raised for transactions with a field unknown
+	// to the proto definitions of the peer in one of their security-relevant
+	// messages, unless the validator rejects them, see RejectUnknownFields
+	WarningUnknownFields
+)
+
+func (c WarningCode) String() string {
+	switch c {
+	case WarningCertificateNearExpiry:
+		return "certificate-near-expiry"
+	case WarningDeprecatedAlgorithm:
+		return "deprecated-algorithm"
+	case WarningClockSkew:
+		return "clock-skew"
+	case WarningFlagged:
+		return "flagged"
+	case WarningUnknownFields:
+		return "unknown-fields"
+	default:
+		return fmt.Sprintf("unknown(%d)", int(c))
+	}
+}
+
+// ValidationWarning is a borderline condition met by a transaction, which
+// does not fail its validation but is worth logging or alerting on
+type ValidationWarning struct {
+	// Code is the kind of the warning
+	Code WarningCode
+
+	// Message describes the warning
+	Message string
+}
+
+// warningRecorder records the warnings of a validation
+type warningRecorder struct {
+	warnings []ValidationWarning
+}
+
+// warningsKey is the key of the warning recorder in contexts
+type warningsKey struct{}
+
+// withWarningRecorder returns a context holding a new warning recorder,
+// along with the recorder
+func withWarningRecorder(ctx context.Context) (context.Context, *warningRecorder) {
+	recorder := &warningRecorder{}
+	return context.WithValue(ctx, warningsKey{}, recorder), recorder
+}
+
+// addWarning records a warning in the recorder held by the context, if any,
+// and logs it
+func addWarning(ctx context.Context, code WarningCode, format string, args ...interface{}) {
+	message := fmt.Sprintf(format, args...)
+	putilsLogger.Warningf("Validation warning %s: %s", code, message)
+
+	if recorder, ok := ctx.Value(warningsKey{}).(*warningRecorder); ok {
+		recorder.warnings = append(recorder.warnings, ValidationWarning{Code: code, Message: message})
+	}
+}
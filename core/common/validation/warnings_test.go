@@ -0,0 +1,85 @@
+/*
+Copyright IBM Corp. 2017 All Rights Reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+		 http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package validation
+
+import (
+	"testing"
+	"time"
+
+	"github.com/golang/protobuf/proto"
+	"github.com/hyperledger/fabric/common/util"
+	"github.com/hyperledger/fabric/msp"
+	"github.com/hyperledger/fabric/protos/common"
+)
+
+// warningCodes returns the codes of the warnings of a result
+func warningCodes(result *ValidationResult) []WarningCode {
+	var codes []WarningCode
+	for _, warning := range result.Warnings {
+		codes = append(codes, warning.Code)
+	}
+	return codes
+}
+
+func TestWarnings(t *testing.T) {
+	sId := &msp.SerializedIdentity{}
+	err := proto.Unmarshal(signerSerialized, sId)
+	if err != nil {
+		t.Fatalf("Unmarshal failed, err %s", err)
+	}
+	certs, err := parseCertificateChain(sId.IdBytes)
+	if err != nil {
+		t.Fatalf("parseCertificateChain failed, err %s", err)
+	}
+	notAfter := certs[0].NotAfter
+
+	tx, created := getTransactionWithExtension(t, encodeTTL(time.Minute))
+	staleTx := getTransactionReadingAt(t, 50)
+
+	tests := []struct {
+		name  string
+		tx    *common.Envelope
+		v     *Validator
+		codes []WarningCode
+	}{
+		{"None", tx, &Validator{}, nil},
+		{"NearExpiry", tx, &Validator{CertificateExpiryWarning: 24 * time.Hour, Clock: func() time.Time { return notAfter.Add(-time.Hour) }}, []WarningCode{WarningCertificateNearExpiry}},
+		{"FarFromExpiry", tx, &Validator{CertificateExpiryWarning: time.Hour, Clock: func() time.Time { return notAfter.Add(-24 * time.Hour) }}, nil},
+		{"TTLWithinSkew", tx, &Validator{EnforceTTL: true, MaxClockSkew: time.Minute, Clock: func() time.Time { return created.Add(90 * time.Second) }}, []WarningCode{WarningClockSkew}},
+		{"TTLNotExpired", tx, &Validator{EnforceTTL: true, MaxClockSkew: time.Minute, Clock: func() time.Time { return created.Add(30 * time.Second) }}, nil},
+		{"Flagged", staleTx, &Validator{LedgerHeightProvider: mockLedgerHeightProvider{util.GetTestChainID(): 100}, MaxReadStaleness: 10, ReadCommitmentMode: HeuristicsLenient}, []WarningCode{WarningFlagged}},
+	}
+
+	for _, test := range tests {
+		result, err := test.v.Validate(test.tx)
+		if err != nil {
+			t.Fatalf("%s: Validate failed, err %s", test.name, err)
+		}
+		if codes := warningCodes(result); len(codes) != len(test.codes) || (len(codes) != 0 && codes[0] != test.codes[0]) {
+			t.Fatalf("%s: expected warnings %v, got %v", test.name, test.codes, codes)
+		}
+		for _, warning := range result.Warnings {
+			if warning.Message == "" {
+				t.Fatalf("%s: warning %s without a message", test.name, warning.Code)
+			}
+		}
+	}
+
+	if WarningCertificateNearExpiry.String() != "certificate-near-expiry" || WarningCode(0).String() != "unknown(0)" {
+		t.Fatalf("Unexpected warning code names")
+	}
+}
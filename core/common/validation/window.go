@@ -0,0 +1,98 @@
+/*
+Copyright IBM Corp. 2017 All Rights Reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+		 http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package validation
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/golang/protobuf/ptypes/timestamp"
+)
+
+// ErrOutsideSubmissionWindow is returned when the timestamp of a
+// transaction falls outside of the allowed submission window
+var ErrOutsideSubmissionWindow = errors.New("Transaction timestamp is outside of the allowed submission window")
+
+// TimeRange is a range of the day, expressed as the clock times of its
+// start (inclusive) and end (exclusive), as durations since 00:00; on the
+// days of daylight saving time transitions, 09:00 is still 9 hours even
+// though 8 or 10 hours elapsed since midnight. A range whose end precedes
+// its start wraps around midnight
+type TimeRange struct {
+	Start time.Duration
+	End   time.Duration
+}
+
+// contains returns true if the time of the day t falls within the range
+func (r TimeRange) contains(t time.Duration) bool {
+	if r.Start <= r.End {
+		return r.Start <= t && t < r.End
+	}
+
+	return r.Start <= t || t < r.End
+}
+
+// SubmissionWindow is a set of time ranges during which transactions
+// may be submitted
+type SubmissionWindow struct {
+	// Location is the time zone in which the ranges are expressed;
+	// if nil, UTC is assumed
+	Location *time.Location
+
+	// Ranges are the allowed time ranges
+	Ranges []TimeRange
+}
+
+// Allows returns true if t falls within one of the ranges of the window
+func (w *SubmissionWindow) Allows(t time.Time) bool {
+	loc := w.Location
+	if loc == nil {
+		loc = time.UTC
+	}
+
+	// the clock time, not the time elapsed since midnight, which differs
+	// on the days of daylight saving time transitions
+	t = t.In(loc)
+	timeOfDay := time.Duration(t.Hour())*time.Hour +
+		time.Duration(t.Minute())*time.Minute +
+		time.Duration(t.Second())*time.Second +
+		time.Duration(t.Nanosecond())
+
+	for _, r := range w.Ranges {
+		if r.contains(timeOfDay) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// validate checks the timestamp of a header against the window
+func (w *SubmissionWindow) validate(ts *timestamp.Timestamp) error {
+	if ts == nil {
+		return fmt.Errorf("Nil timestamp in the header")
+	}
+
+	t := time.Unix(ts.Seconds, int64(ts.Nanos))
+	if !w.Allows(t) {
+		putilsLogger.Errorf("Transaction timestamp %s is outside of the submission window", t)
+		return ErrOutsideSubmissionWindow
+	}
+
+	return nil
+}
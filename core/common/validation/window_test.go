@@ -0,0 +1,123 @@
+/*
+Copyright IBM Corp. 2017 All Rights Reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+		 http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package validation
+
+import (
+	"testing"
+	"time"
+
+	"github.com/golang/protobuf/ptypes/timestamp"
+	"github.com/hyperledger/fabric/common/util"
+	"github.com/hyperledger/fabric/protos/common"
+	"github.com/hyperledger/fabric/protos/utils"
+	"golang.org/x/net/context"
+)
+
+func getHeaderAt(t time.Time) *common.Header {
+	chdr := utils.MakeChannelHeader(common.HeaderType_ENDORSER_TRANSACTION, 0, util.GetTestChainID(), 0)
+	chdr.Timestamp = &timestamp.Timestamp{Seconds: t.Unix(), Nanos: int32(t.Nanosecond())}
+	return &common.Header{
+		ChannelHeader:   chdr,
+		SignatureHeader: &common.SignatureHeader{Creator: signerSerialized, Nonce: utils.CreateNonceOrPanic()},
+	}
+}
+
+func TestSubmissionWindow(t *testing.T) {
+	// business hours on the US east coast (UTC-5)
+	eastCoast := &SubmissionWindow{
+		Location: time.FixedZone("EST", -5*60*60),
+		Ranges:   []TimeRange{{Start: 9 * time.Hour, End: 17 * time.Hour}},
+	}
+	// a night shift in Tokyo (UTC+9), wrapping around midnight
+	tokyoNights := &SubmissionWindow{
+		Location: time.FixedZone("JST", 9*60*60),
+		Ranges:   []TimeRange{{Start: 22 * time.Hour, End: 2 * time.Hour}},
+	}
+
+	tests := []struct {
+		name   string
+		window *SubmissionWindow
+		time   time.Time
+		valid  bool
+	}{
+		{"NoWindow", nil, time.Date(2017, 3, 1, 3, 0, 0, 0, time.UTC), true},
+		{"EastCoastMorning", eastCoast, time.Date(2017, 3, 1, 15, 30, 0, 0, time.UTC), true},
+		{"EastCoastOpening", eastCoast, time.Date(2017, 3, 1, 9, 0, 0, 0, eastCoast.Location), true},
+		{"EastCoastBeforeOpening", eastCoast, time.Date(2017, 3, 1, 13, 59, 59, 0, time.UTC), false},
+		{"EastCoastClosing", eastCoast, time.Date(2017, 3, 1, 22, 0, 0, 0, time.UTC), false},
+		{"EastCoastPreviousDayInUTC", eastCoast, time.Date(2017, 3, 2, 3, 0, 0, 0, time.UTC), false},
+		{"TokyoBeforeMidnight", tokyoNights, time.Date(2017, 3, 1, 14, 30, 0, 0, time.UTC), true},
+		{"TokyoAfterMidnight", tokyoNights, time.Date(2017, 3, 1, 16, 30, 0, 0, time.UTC), true},
+		{"TokyoDaytime", tokyoNights, time.Date(2017, 3, 1, 3, 0, 0, 0, time.UTC), false},
+	}
+
+	for _, test := range tests {
+		v := &Validator{SubmissionWindow: test.window}
+		err := v.validateCommonHeader(context.Background(), getHeaderAt(test.time))
+		if test.valid && err != nil {
+			t.Fatalf("%s: validateCommonHeader failed, err %s", test.name, err)
+		}
+		if !test.valid && err != ErrOutsideSubmissionWindow {
+			t.Fatalf("%s: validateCommonHeader should have failed with ErrOutsideSubmissionWindow, got %v", test.name, err)
+		}
+	}
+}
+
+func TestSubmissionWindowNoTimestamp(t *testing.T) {
+	v := &Validator{SubmissionWindow: &SubmissionWindow{Ranges: []TimeRange{{Start: 0, End: 24 * time.Hour}}}}
+	hdr := getHeaderAt(time.Now())
+	hdr.ChannelHeader.Timestamp = nil
+
+	err := v.validateCommonHeader(context.Background(), hdr)
+	if err == nil {
+		t.Fatalf("validateCommonHeader should have failed")
+	}
+}
+
+func TestSubmissionWindowDST(t *testing.T) {
+	newYork, err := time.LoadLocation("America/New_York")
+	if err != nil {
+		t.Fatalf("LoadLocation failed, err %s", err)
+	}
+
+	window := &SubmissionWindow{
+		Location: newYork,
+		Ranges:   []TimeRange{{Start: 9 * time.Hour, End: 17 * time.Hour}},
+	}
+
+	tests := []struct {
+		name  string
+		time  time.Time
+		valid bool
+	}{
+		// clocks went forward at 02:00 on 2017-03-12, and back at 02:00
+		// on 2017-11-05
+		{"SpringForwardOpening", time.Date(2017, 3, 12, 9, 0, 0, 0, newYork), true},
+		{"SpringForwardMorning", time.Date(2017, 3, 12, 9, 30, 0, 0, newYork), true},
+		{"SpringForwardBeforeOpening", time.Date(2017, 3, 12, 8, 59, 59, 0, newYork), false},
+		{"SpringForwardClosing", time.Date(2017, 3, 12, 17, 0, 0, 0, newYork), false},
+		{"FallBackBeforeOpening", time.Date(2017, 11, 5, 8, 30, 0, 0, newYork), false},
+		{"FallBackAfternoon", time.Date(2017, 11, 5, 16, 30, 0, 0, newYork), true},
+		{"FallBackClosing", time.Date(2017, 11, 5, 17, 0, 0, 0, newYork), false},
+	}
+
+	for _, test := range tests {
+		if window.Allows(test.time) != test.valid {
+			t.Fatalf("%s: Allows(%s) should have returned %t", test.name, test.time, test.valid)
+		}
+	}
+}
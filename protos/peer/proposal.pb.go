@@ -105,6 +105,27 @@ type ChaincodeHeaderExtension struct {
 	PayloadVisibility []byte `protobuf:"bytes,1,opt,name=payload_visibility,json=payloadVisibility,proto3" json:"payload_visibility,omitempty"`
 	// The ID of the chaincode to target.
 	ChaincodeId *ChaincodeID `protobuf:"bytes,2,opt,name=chaincode_id,json=chaincodeId" json:"chaincode_id,omitempty"`
+	// The Ttl field, if set, is the number of seconds after the timestamp of the
+	// transaction past which it must not be committed.
+	Ttl uint64 `protobuf:"varint,100,opt,name=ttl" json:"ttl,omitempty"`
+	// The SpamToken field is an anti-spam token computed over the TxId of the
+	// transaction, required by the peers enforcing a proof of work.
+	SpamToken []byte `protobuf:"bytes,101,opt,name=spam_token,json=spamToken,proto3" json:"spam_token,omitempty"`
+	// The PolicyName field, if set, is the name of the endorsement policy the
+	// transaction is meant to be evaluated against.
+	PolicyName string `protobuf:"bytes,102,opt,name=policy_name,json=policyName" json:"policy_name,omitempty"`
+	// The TlsCertHash field, if set, is the SHA-256 hash of the DER encoding of
+	// the TLS client certificate the transaction must be submitted with.
+	TlsCertHash []byte `protobuf:"bytes,103,opt,name=tls_cert_hash,json=tlsCertHash,proto3" json:"tls_cert_hash,omitempty"`
+	// The ReadHeight field, if set, is the height of the ledger the proposal
+	// was simulated against.
+	ReadHeight uint64 `protobuf:"varint,104,opt,name=read_height,json=readHeight" json:"read_height,omitempty"`
+	// The DelegationToken field, if set, is a token issued by a trusted
+	// authority authorizing the creator to submit on behalf of another identity.
+	DelegationToken []byte `protobuf:"bytes,105,opt,name=delegation_token,json=delegationToken,proto3" json:"delegation_token,omitempty"`
+	// The OrderedActions field, if set, requires the actions of the transaction
+	// to be applied in the order of the ordering index prefixed to their nonces.
+	OrderedActions bool `protobuf:"varint,106,opt,name=ordered_actions,json=orderedActions" json:"ordered_actions,omitempty"`
 }
 
 func (m *ChaincodeHeaderExtension) Reset()                    { *m = ChaincodeHeaderExtension{} }
@@ -181,32 +202,40 @@ func init() {
 func init() { proto.RegisterFile("peer/proposal.proto", fileDescriptor7) }
 
 var fileDescriptor7 = []byte{
-	// 419 bytes of a gzipped FileDescriptorProto
-	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x09, 0x6e, 0x88, 0x02, 0xff, 0x64, 0x52, 0xdf, 0x6b, 0xd4, 0x40,
-	0x10, 0xe6, 0xee, 0xb0, 0x3f, 0x26, 0x67, 0x6d, 0xb7, 0x45, 0xc2, 0xd1, 0x87, 0x12, 0x10, 0x2a,
-	0x6a, 0x02, 0x11, 0x44, 0x7c, 0x11, 0xab, 0x05, 0xfb, 0x20, 0x94, 0xa8, 0x7d, 0xe8, 0xcb, 0xb1,
-	0x49, 0xc6, 0x64, 0x31, 0xee, 0xae, 0xbb, 0x9b, 0xc3, 0x3c, 0xfa, 0xe7, 0xf9, 0x5f, 0x49, 0xb2,
-	0x3f, 0x6c, 0xbd, 0xa7, 0xe4, 0x9b, 0xef, 0x9b, 0x6f, 0x66, 0x76, 0x06, 0x8e, 0x25, 0xa2, 0xca,
-	0xa4, 0x12, 0x52, 0x68, 0xda, 0xa5, 0x52, 0x09, 0x23, 0xc8, 0xce, 0xf4, 0xd1, 0xab, 0x93, 0x89,
-	0xac, 0x5a, 0xca, 0x78, 0x25, 0x6a, 0xb4, 0xec, 0xea, 0xf4, 0x5e, 0xca, 0x5a, 0xa1, 0x96, 0x82,
-	0x6b, 0xc7, 0x26, 0x5f, 0xe1, 0xe0, 0x33, 0x6b, 0x38, 0xd6, 0xd7, 0x4e, 0x40, 0x9e, 0xc0, 0x41,
-	0x10, 0x97, 0x83, 0x41, 0x1d, 0xcf, 0xce, 0x66, 0xe7, 0xcb, 0xe2, 0xa1, 0x8f, 0x5e, 0x8c, 0x41,
-	0x72, 0x0a, 0xfb, 0x9a, 0x35, 0x9c, 0x9a, 0x5e, 0x61, 0x3c, 0x9f, 0x14, 0xff, 0x02, 0xc9, 0x2d,
-	0xec, 0x05, 0xc3, 0xc7, 0xb0, 0xd3, 0x22, 0xad, 0x51, 0x39, 0x23, 0x87, 0x48, 0x0c, 0xbb, 0x92,
-	0x0e, 0x9d, 0xa0, 0xb5, 0xcb, 0xf7, 0x70, 0xf4, 0xc6, 0x5f, 0x06, 0xb9, 0x66, 0x82, 0xc7, 0x0b,
-	0xeb, 0x1d, 0x02, 0xc9, 0xef, 0x19, 0xc4, 0xef, 0xfd, 0x90, 0x1f, 0x27, 0xaf, 0x4b, 0x4f, 0x92,
-	0x17, 0x40, 0x9c, 0xcb, 0x7a, 0xc3, 0x34, 0x2b, 0x59, 0xc7, 0xcc, 0xe0, 0x0a, 0x1f, 0x39, 0xe6,
-	0x26, 0x10, 0xe4, 0x15, 0x2c, 0xc3, 0x7b, 0xad, 0x99, 0x6d, 0x24, 0xca, 0x8f, 0xed, 0xe3, 0xe8,
-	0x34, 0x94, 0xb9, 0xfa, 0x50, 0x44, 0x41, 0x78, 0x55, 0x27, 0x7f, 0xee, 0xf6, 0xe0, 0x27, 0xbd,
-	0x76, 0xed, 0x9f, 0xc0, 0x03, 0xc6, 0x65, 0x6f, 0x5c, 0x59, 0x0b, 0xc8, 0x0d, 0x2c, 0xbf, 0x28,
-	0xca, 0x35, 0x43, 0x6e, 0x3e, 0x51, 0x19, 0xcf, 0xcf, 0x16, 0xe7, 0x51, 0x9e, 0x6f, 0x95, 0xfa,
-	0xcf, 0x2d, 0xbd, 0x9b, 0x74, 0xc9, 0x8d, 0x1a, 0x8a, 0x7b, 0x3e, 0xab, 0xb7, 0x70, 0xb4, 0x25,
-	0x21, 0x87, 0xb0, 0xf8, 0x8e, 0x76, 0xee, 0xfd, 0x62, 0xfc, 0x1d, 0x9b, 0xda, 0xd0, 0xae, 0xf7,
-	0xbb, 0xb2, 0xe0, 0xcd, 0xfc, 0xf5, 0x2c, 0xf9, 0x09, 0x8f, 0x42, 0xf1, 0x77, 0x95, 0x19, 0x5f,
-	0x31, 0x86, 0x5d, 0x85, 0xba, 0xef, 0x8c, 0x5f, 0xbe, 0x87, 0xe3, 0x32, 0x71, 0x83, 0xdc, 0x68,
-	0xe7, 0xe3, 0x10, 0x79, 0x0e, 0x7b, 0xfe, 0xb2, 0xa6, 0x8d, 0x45, 0xf9, 0xa1, 0x9f, 0xac, 0x70,
-	0xf1, 0x22, 0x28, 0x2e, 0x9e, 0xdd, 0x3e, 0x6d, 0x98, 0x69, 0xfb, 0x32, 0xad, 0xc4, 0x8f, 0xac,
-	0x1d, 0x24, 0xaa, 0x0e, 0xeb, 0x06, 0x55, 0xf6, 0x8d, 0x96, 0x8a, 0x55, 0x99, 0x4d, 0xcd, 0xc6,
-	0xd3, 0x2d, 0xed, 0x79, 0xbf, 0xfc, 0x1b, 0x00, 0x00, 0xff, 0xff, 0x28, 0x14, 0xac, 0x8e, 0xfc,
-	0x02, 0x00, 0x00,
+	// 553 bytes of a gzipped FileDescriptorProto
+	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x09, 0x6e, 0x88, 0x02, 0xff, 0x64, 0x93, 0xcf, 0x6e, 0xd4, 0x3e,
+	0x10, 0xc7, 0x95, 0xed, 0xef, 0x57, 0xda, 0x49, 0xff, 0xba, 0x15, 0xb2, 0xaa, 0x22, 0x56, 0x91,
+	0x10, 0x5b, 0x01, 0xbb, 0xd2, 0x22, 0x21, 0xc4, 0x05, 0xd1, 0x52, 0xa9, 0x3d, 0x80, 0xaa, 0x50,
+	0x7a, 0xe8, 0x25, 0xf2, 0x26, 0xd3, 0xc4, 0x34, 0x6b, 0x1b, 0xdb, 0xbb, 0x22, 0x8f, 0xc8, 0xbb,
+	0xf0, 0x10, 0xc8, 0xb1, 0x93, 0xb6, 0xf4, 0x94, 0xcc, 0x67, 0xc6, 0xdf, 0x19, 0xcf, 0x8c, 0x61,
+	0x4f, 0x21, 0xea, 0x89, 0xd2, 0x52, 0x49, 0xc3, 0xea, 0xb1, 0xd2, 0xd2, 0x4a, 0xb2, 0xda, 0x7e,
+	0xcc, 0xc1, 0x7e, 0xeb, 0xcc, 0x2b, 0xc6, 0x45, 0x2e, 0x0b, 0xf4, 0xde, 0x83, 0xc3, 0x07, 0x47,
+	0x32, 0x8d, 0x46, 0x49, 0x61, 0x82, 0x37, 0xf9, 0x0e, 0x5b, 0xdf, 0x78, 0x29, 0xb0, 0xb8, 0x08,
+	0x01, 0xe4, 0x05, 0x6c, 0xf5, 0xc1, 0xb3, 0xc6, 0xa2, 0xa1, 0xd1, 0x30, 0x1a, 0x6d, 0xa4, 0x9b,
+	0x1d, 0x3d, 0x76, 0x90, 0x1c, 0xc2, 0xba, 0xe1, 0xa5, 0x60, 0x76, 0xa1, 0x91, 0x0e, 0xda, 0x88,
+	0x3b, 0x90, 0x5c, 0xc3, 0x5a, 0x2f, 0xf8, 0x14, 0x56, 0x2b, 0x64, 0x05, 0xea, 0x20, 0x14, 0x2c,
+	0x42, 0xe1, 0x89, 0x62, 0x4d, 0x2d, 0x59, 0x11, 0xce, 0x77, 0xa6, 0xd3, 0xc6, 0x5f, 0x16, 0x85,
+	0xe1, 0x52, 0xd0, 0x15, 0xaf, 0xdd, 0x83, 0xe4, 0xcf, 0x00, 0xe8, 0x49, 0x77, 0xc9, 0xb3, 0x56,
+	0xeb, 0xb4, 0x73, 0x92, 0x37, 0x40, 0x82, 0x4a, 0xb6, 0xe4, 0x86, 0xcf, 0x78, 0xcd, 0x6d, 0x13,
+	0x12, 0xef, 0x06, 0xcf, 0x55, 0xef, 0x20, 0xef, 0x60, 0xa3, 0xef, 0x57, 0xc6, 0x7d, 0x21, 0xf1,
+	0x74, 0xcf, 0x37, 0xc7, 0x8c, 0xfb, 0x34, 0xe7, 0x9f, 0xd3, 0xb8, 0x0f, 0x3c, 0x2f, 0xc8, 0x0e,
+	0xac, 0x58, 0x5b, 0xd3, 0x62, 0x18, 0x8d, 0xfe, 0x4b, 0xdd, 0x2f, 0x79, 0x06, 0x60, 0x14, 0x9b,
+	0x67, 0x56, 0xde, 0xa2, 0xa0, 0x18, 0x1a, 0xa2, 0xd8, 0xfc, 0xd2, 0x01, 0xf2, 0x1c, 0x62, 0x25,
+	0x6b, 0x9e, 0x37, 0x99, 0x60, 0x73, 0xa4, 0x37, 0xc3, 0x68, 0xb4, 0x9e, 0x82, 0x47, 0x5f, 0xd9,
+	0x1c, 0x49, 0x02, 0x9b, 0xb6, 0x36, 0x59, 0x8e, 0xda, 0x66, 0x15, 0x33, 0x15, 0x2d, 0x5b, 0x89,
+	0xd8, 0xd6, 0xe6, 0x04, 0xb5, 0x3d, 0x63, 0xa6, 0x72, 0x22, 0x1a, 0x59, 0x91, 0x55, 0xc8, 0xcb,
+	0xca, 0xd2, 0xaa, 0xcd, 0x0e, 0x0e, 0x9d, 0xb5, 0x84, 0x1c, 0xc1, 0x4e, 0x81, 0x35, 0x96, 0xcc,
+	0x72, 0x29, 0x42, 0x29, 0xbc, 0xd5, 0xd9, 0xbe, 0xe3, 0xbe, 0xa0, 0x97, 0xb0, 0x2d, 0x75, 0x81,
+	0x1a, 0x8b, 0x8c, 0xe5, 0x0e, 0x1b, 0xfa, 0x63, 0x18, 0x8d, 0xd6, 0xd2, 0xad, 0x80, 0x3f, 0x79,
+	0x9a, 0xfc, 0x8e, 0xee, 0xb5, 0xbb, 0x1b, 0xea, 0x45, 0x98, 0xd4, 0x3e, 0xfc, 0xcf, 0x85, 0x5a,
+	0xd8, 0xd0, 0x61, 0x6f, 0x90, 0x2b, 0xd8, 0xb8, 0xd4, 0x4c, 0x18, 0x8e, 0xc2, 0x7e, 0x61, 0x8a,
+	0x0e, 0x86, 0x2b, 0xa3, 0x78, 0x3a, 0x7d, 0xd4, 0xd5, 0x7f, 0xd4, 0xc6, 0xf7, 0x0f, 0x9d, 0x0a,
+	0xab, 0x9b, 0xf4, 0x81, 0xce, 0xc1, 0x47, 0xd8, 0x7d, 0x14, 0xe2, 0x46, 0x71, 0x8b, 0x7e, 0xc4,
+	0xeb, 0xa9, 0xfb, 0x75, 0x45, 0x2d, 0x59, 0xbd, 0xe8, 0xd6, 0xd2, 0x1b, 0x1f, 0x06, 0xef, 0xa3,
+	0xe4, 0x27, 0x6c, 0xf7, 0xc9, 0xfd, 0xfd, 0xdc, 0x16, 0x6a, 0x34, 0x8b, 0xda, 0x76, 0x7b, 0xde,
+	0x99, 0x6e, 0x6f, 0x71, 0x89, 0xc2, 0x9a, 0xa0, 0x13, 0x2c, 0xf2, 0x1a, 0xd6, 0xba, 0x47, 0xd4,
+	0x2e, 0x67, 0x3c, 0xdd, 0xe9, 0x6e, 0x96, 0x06, 0x9e, 0xf6, 0x11, 0xc7, 0xaf, 0xae, 0x8f, 0x4a,
+	0x6e, 0xab, 0xc5, 0x6c, 0x9c, 0xcb, 0xf9, 0xa4, 0x6a, 0x14, 0xea, 0x1a, 0x8b, 0x12, 0xf5, 0xe4,
+	0x86, 0xcd, 0x34, 0xcf, 0x27, 0xfe, 0xe8, 0xc4, 0xbd, 0xd2, 0x99, 0x7f, 0xc9, 0x6f, 0xff, 0x0e,
+	0x00, 0x72, 0x11, 0x5a, 0xeb, 0xe7, 0x03, 0x00, 0x00,
 }